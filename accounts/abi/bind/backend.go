@@ -39,6 +39,10 @@ var (
 	// This error is returned by WaitDeployed if contract creation leaves an
 	// empty contract behind.
 	ErrNoCodeAfterDeploy = errors.New("no contract code after deployment")
+
+	// This error is raised when attempting to stream logs from a filterer that
+	// doesn't implement ChainHeadReader.
+	ErrNoChainHead = errors.New("backend does not support retrieving the chain head")
 )
 
 // ContractCaller defines the methods needed to allow operating with a contract on a read
@@ -98,6 +102,23 @@ type ContractFilterer interface {
 	SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
 }
 
+// BatchContractCaller defines the methods needed to execute many read only contract
+// calls in a single round trip.
+type BatchContractCaller interface {
+	// BatchCallContract executes the given contract calls at the given block. The
+	// returned slices hold the output and the failure of each individual call,
+	// the error is only set if the batch as a whole failed.
+	BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error)
+}
+
+// ChainHeadReader defines the methods needed by a ContractFilterer to stream logs
+// from the chain history before following the chain head.
+type ChainHeadReader interface {
+	// HeaderByNumber returns a block header from the current canonical chain. If
+	// number is nil, the latest known header is returned.
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
 // DeployBackend wraps the operations needed by WaitMined and WaitDeployed.
 type DeployBackend interface {
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
@@ -0,0 +1,269 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errNotImpersonated is returned if an unsigned transaction is sent on behalf
+// of an account which is not impersonated.
+var errNotImpersonated = errors.New("account not impersonated")
+
+// modify applies a direct state modification to the pending block. Like the
+// effects of transactions, the modification becomes part of the chain with the
+// next committed block.
+func (b *SimulatedBackend) modify(fn func()) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fn()
+	b.pendingModified = true
+	b.assemble(b.pendingBlock.Transactions())
+	return forkError(b.pendingState)
+}
+
+// SetBalance sets the balance of an account in the pending block.
+func (b *SimulatedBackend) SetBalance(account common.Address, balance *big.Int) error {
+	return b.modify(func() { b.pendingState.SetBalance(account, balance) })
+}
+
+// SetNonce sets the nonce of an account in the pending block.
+func (b *SimulatedBackend) SetNonce(account common.Address, nonce uint64) error {
+	return b.modify(func() { b.pendingState.SetNonce(account, nonce) })
+}
+
+// SetCode sets the code of an account in the pending block.
+func (b *SimulatedBackend) SetCode(account common.Address, code []byte) error {
+	return b.modify(func() { b.pendingState.SetCode(account, code) })
+}
+
+// SetStorageAt sets a storage slot of an account in the pending block.
+func (b *SimulatedBackend) SetStorageAt(account common.Address, key, value common.Hash) error {
+	return b.modify(func() { b.pendingState.SetState(account, key, value) })
+}
+
+// Impersonate allows sending transactions on behalf of an account without
+// knowing its key. The returned transactor leaves transactions unsigned and
+// marks them for acceptance by SendTransaction.
+func (b *SimulatedBackend) Impersonate(account common.Address) *bind.TransactOpts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.impersonated[account] = true
+	return &bind.TransactOpts{
+		From: account,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != account {
+				return nil, bind.ErrNotAuthorized
+			}
+			return tx, b.markImpersonated(account, tx)
+		},
+		Context: context.Background(),
+	}
+}
+
+// StopImpersonating revokes the impersonation of an account.
+func (b *SimulatedBackend) StopImpersonating(account common.Address) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.impersonated, account)
+}
+
+// markImpersonated records the sender of an unsigned transaction. Unsigned
+// transactions of different senders may share a hash, so the sender is tied to
+// the transaction instance passed on to SendTransaction. Marks not followed by
+// sending the transaction are dropped with the pending block.
+func (b *SimulatedBackend) markImpersonated(account common.Address, tx *types.Transaction) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.impersonated[account] {
+		return errNotImpersonated
+	}
+	b.impersonatedTxs[tx] = account
+	return nil
+}
+
+// WarpBlockNumber commits the pending block and empty blocks on top of it
+// until the head of the chain reaches the given number.
+func (b *SimulatedBackend) WarpBlockNumber(number uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if head := b.blockchain.CurrentBlock().NumberU64(); number <= head {
+		return fmt.Errorf("block %d not after head block %d", number, head)
+	}
+	for b.blockchain.CurrentBlock().NumberU64() < number {
+		b.commit()
+	}
+	return nil
+}
+
+// WarpTime sets the timestamp of the pending block. Like AdjustTime, it can
+// only be called on empty blocks, but unlike it the timestamp is kept when
+// transactions are added to the block afterwards.
+func (b *SimulatedBackend) WarpTime(timestamp uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.pendingBlock.Transactions()) != 0 {
+		return errors.New("Could not adjust time on non-empty block")
+	}
+	if err := b.setTime(timestamp); err != nil {
+		return err
+	}
+	b.pendingWarped = true
+	return nil
+}
+
+// APIs returns the RPC services exposing the state manipulation helpers of the
+// backend, to be registered on an rpc.Server. The "sim" namespace allows anyone
+// reaching it to rewrite the state, so it is not public and must only be served
+// for simulated backends used in testing.
+func (b *SimulatedBackend) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "sim",
+		Version:   "1.0",
+		Service:   &simulatedAPI{b},
+	}}
+}
+
+// simulatedAPI provides the state manipulation helpers of a simulated backend
+// in the "sim" RPC namespace.
+type simulatedAPI struct {
+	b *SimulatedBackend
+}
+
+// SetBalance sets the balance of an account in the pending block.
+func (api *simulatedAPI) SetBalance(account common.Address, balance hexutil.Big) error {
+	return api.b.SetBalance(account, balance.ToInt())
+}
+
+// SetNonce sets the nonce of an account in the pending block.
+func (api *simulatedAPI) SetNonce(account common.Address, nonce hexutil.Uint64) error {
+	return api.b.SetNonce(account, uint64(nonce))
+}
+
+// SetCode sets the code of an account in the pending block.
+func (api *simulatedAPI) SetCode(account common.Address, code hexutil.Bytes) error {
+	return api.b.SetCode(account, code)
+}
+
+// SetStorageAt sets a storage slot of an account in the pending block.
+func (api *simulatedAPI) SetStorageAt(account common.Address, key, value common.Hash) error {
+	return api.b.SetStorageAt(account, key, value)
+}
+
+// ImpersonateAccount allows sending transactions on behalf of an account with
+// SendTransaction.
+func (api *simulatedAPI) ImpersonateAccount(account common.Address) {
+	api.b.Impersonate(account)
+}
+
+// StopImpersonatingAccount revokes the impersonation of an account.
+func (api *simulatedAPI) StopImpersonatingAccount(account common.Address) {
+	api.b.StopImpersonating(account)
+}
+
+// sendTxArgs represents the arguments to send an unsigned transaction from an
+// impersonated account.
+type sendTxArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Uint64 `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Nonce    *hexutil.Uint64 `json:"nonce"`
+	Data     hexutil.Bytes   `json:"data"`
+}
+
+// SendTransaction adds an unsigned transaction from an impersonated account to
+// the pending block. Missing fields are filled in like bind does.
+func (api *simulatedAPI) SendTransaction(ctx context.Context, args sendTxArgs) (common.Hash, error) {
+	var (
+		gas      uint64
+		gasPrice = big.NewInt(1)
+		value    = new(big.Int)
+	)
+	pending, err := api.b.PendingNonceAt(ctx, args.From)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if args.Nonce != nil && uint64(*args.Nonce) != pending {
+		return common.Hash{}, fmt.Errorf("invalid transaction nonce: got %d, want %d", *args.Nonce, pending)
+	}
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	}
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+	if args.Gas != nil {
+		gas = uint64(*args.Gas)
+	} else {
+		msg := ethereum.CallMsg{From: args.From, To: args.To, GasPrice: gasPrice, Value: value, Data: args.Data}
+		estimate, err := api.b.EstimateGas(ctx, msg)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		gas = estimate
+	}
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    pending,
+		GasPrice: gasPrice,
+		Gas:      gas,
+		To:       args.To,
+		Value:    value,
+		Data:     args.Data,
+	})
+	if err := api.b.markImpersonated(args.From, tx); err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), api.b.SendTransaction(ctx, tx)
+}
+
+// Commit imports all pending transactions and modifications as a single block.
+func (api *simulatedAPI) Commit() {
+	api.b.Commit()
+}
+
+// Rollback discards all pending transactions and modifications.
+func (api *simulatedAPI) Rollback() {
+	api.b.Rollback()
+}
+
+// WarpBlockNumber commits blocks until the head reaches the given number.
+func (api *simulatedAPI) WarpBlockNumber(number hexutil.Uint64) error {
+	return api.b.WarpBlockNumber(uint64(number))
+}
+
+// WarpTime sets the timestamp of the empty pending block.
+func (api *simulatedAPI) WarpTime(timestamp hexutil.Uint64) error {
+	return api.b.WarpTime(uint64(timestamp))
+}
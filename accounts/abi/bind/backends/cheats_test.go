@@ -0,0 +1,294 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestSimulatedBackend_SetState(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	var (
+		ctx     = context.Background()
+		account = common.HexToAddress("0x1000")
+		slot    = common.HexToHash("0x2a")
+	)
+	if err := sim.SetBalance(account, big.NewInt(1000)); err != nil {
+		t.Fatalf("failed to set balance: %v", err)
+	}
+	if err := sim.SetNonce(account, 7); err != nil {
+		t.Fatalf("failed to set nonce: %v", err)
+	}
+	if err := sim.SetCode(account, forkGetterCode); err != nil {
+		t.Fatalf("failed to set code: %v", err)
+	}
+	if err := sim.SetStorageAt(account, common.Hash{}, slot); err != nil {
+		t.Fatalf("failed to set storage: %v", err)
+	}
+	// The modifications are pending until committed.
+	if nonce, _ := sim.PendingNonceAt(ctx, account); nonce != 7 {
+		t.Fatalf("wrong pending nonce: have %d, want 7", nonce)
+	}
+	if balance, _ := sim.BalanceAt(ctx, account, nil); balance.Sign() != 0 {
+		t.Fatalf("balance modified before commit: %v", balance)
+	}
+	sim.Commit()
+
+	if balance, _ := sim.BalanceAt(ctx, account, nil); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong balance: have %v, want 1000", balance)
+	}
+	if nonce, _ := sim.NonceAt(ctx, account, nil); nonce != 7 {
+		t.Errorf("wrong nonce: have %d, want 7", nonce)
+	}
+	if code, _ := sim.CodeAt(ctx, account, nil); !bytes.Equal(code, forkGetterCode) {
+		t.Errorf("wrong code: have %x, want %x", code, forkGetterCode)
+	}
+	res, err := sim.CallContract(ctx, ethereum.CallMsg{To: &account}, nil)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if common.BytesToHash(res) != slot {
+		t.Errorf("wrong storage slot: have %x, want %x", res, slot)
+	}
+	// Regular blocks must still be importable on top of modified ones.
+	sim.Commit()
+	if head, _ := sim.HeaderByNumber(ctx, nil); head.Number.Uint64() != 2 {
+		t.Errorf("wrong head number: have %d, want 2", head.Number)
+	}
+}
+
+func TestSimulatedBackend_Impersonate(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	var (
+		ctx       = context.Background()
+		whale     = common.HexToAddress("0x1000")
+		recipient = common.HexToAddress("0x2000")
+	)
+	sim.SetBalance(whale, big.NewInt(params.Ether))
+	opts := sim.Impersonate(whale)
+
+	// Send a plain transfer and deploy a contract on behalf of the account.
+	tx, err := opts.Signer(whale, types.NewTransaction(0, recipient, big.NewInt(1000), params.TxGas, big.NewInt(1), nil))
+	if err != nil {
+		t.Fatalf("failed to sign transfer: %v", err)
+	}
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transfer: %v", err)
+	}
+	parsed, _ := abi.JSON(strings.NewReader(abiJSON))
+	address, deploy, _, err := bind.DeployContract(opts, parsed, common.FromHex(abiBin), sim)
+	if err != nil {
+		t.Fatalf("failed to deploy contract: %v", err)
+	}
+	sim.Commit()
+
+	if balance, _ := sim.BalanceAt(ctx, recipient, nil); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong recipient balance: have %v, want 1000", balance)
+	}
+	receipt, _ := sim.TransactionReceipt(ctx, deploy.Hash())
+	if receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("deployment failed: %v", receipt)
+	}
+	if receipt.ContractAddress != address {
+		t.Errorf("wrong contract address: have %x, want %x", receipt.ContractAddress, address)
+	}
+	if code, _ := sim.CodeAt(ctx, address, nil); len(code) == 0 {
+		t.Error("contract not deployed")
+	}
+	// Unsigned transactions must be rejected once the impersonation stopped.
+	sim.StopImpersonating(whale)
+	if _, err := opts.Signer(whale, types.NewTransaction(2, recipient, big.NewInt(1000), params.TxGas, big.NewInt(1), nil)); err != errNotImpersonated {
+		t.Errorf("wrong signer error: have %v, want %v", err, errNotImpersonated)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("unsigned transaction accepted")
+		}
+	}()
+	sim.SendTransaction(ctx, tx)
+}
+
+// Tests that identical unsigned transactions of different impersonated senders
+// are executed on behalf of their own sender, and that unsent ones are dropped
+// with the pending block.
+func TestSimulatedBackend_ImpersonateSharedHash(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	var (
+		ctx       = context.Background()
+		whales    = []common.Address{common.HexToAddress("0x1000"), common.HexToAddress("0x1001")}
+		recipient = common.HexToAddress("0x2000")
+	)
+	for _, whale := range whales {
+		sim.SetBalance(whale, big.NewInt(params.Ether))
+
+		tx, err := sim.Impersonate(whale).Signer(whale, types.NewTransaction(0, recipient, big.NewInt(1000), params.TxGas, big.NewInt(1), nil))
+		if err != nil {
+			t.Fatalf("failed to sign transfer: %v", err)
+		}
+		if err := sim.SendTransaction(ctx, tx); err != nil {
+			t.Fatalf("failed to send transfer: %v", err)
+		}
+	}
+	sim.Commit()
+
+	if balance, _ := sim.BalanceAt(ctx, recipient, nil); balance.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("wrong recipient balance: have %v, want 2000", balance)
+	}
+	for _, whale := range whales {
+		if nonce, _ := sim.NonceAt(ctx, whale, nil); nonce != 1 {
+			t.Errorf("wrong nonce of %x: have %d, want 1", whale, nonce)
+		}
+	}
+	// Signed but unsent transactions are forgotten on rollback.
+	if _, err := sim.Impersonate(whales[0]).Signer(whales[0], types.NewTransaction(1, recipient, big.NewInt(1000), params.TxGas, big.NewInt(1), nil)); err != nil {
+		t.Fatalf("failed to sign transfer: %v", err)
+	}
+	sim.Rollback()
+	if len(sim.impersonatedTxs) != 0 {
+		t.Errorf("unsent transactions retained: %d", len(sim.impersonatedTxs))
+	}
+}
+
+func TestSimulatedBackend_Warp(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	ctx := context.Background()
+	if err := sim.WarpBlockNumber(5); err != nil {
+		t.Fatalf("failed to warp block number: %v", err)
+	}
+	if head, _ := sim.HeaderByNumber(ctx, nil); head.Number.Uint64() != 5 {
+		t.Errorf("wrong head number: have %d, want 5", head.Number)
+	}
+	if err := sim.WarpBlockNumber(5); err == nil {
+		t.Error("warped to current block number")
+	}
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if err := sim.WarpTime(head.Time); err == nil {
+		t.Error("warped to parent timestamp")
+	}
+	if err := sim.WarpTime(head.Time + 1000); err != nil {
+		t.Fatalf("failed to warp time: %v", err)
+	}
+	sim.Commit()
+	if warped, _ := sim.HeaderByNumber(ctx, nil); warped.Time != head.Time+1000 {
+		t.Errorf("wrong head timestamp: have %d, want %d", warped.Time, head.Time+1000)
+	}
+}
+
+func TestSimulatedBackend_WarpTimeWithTransaction(t *testing.T) {
+	testAddr := crypto.PubkeyToAddress(testKey.PublicKey)
+	sim := simTestBackend(testAddr)
+	defer sim.Close()
+
+	ctx := context.Background()
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if err := sim.WarpTime(head.Time + 100000); err != nil {
+		t.Fatalf("failed to warp time: %v", err)
+	}
+	tx, err := types.SignTx(types.NewTransaction(0, testAddr, big.NewInt(1000), params.TxGas, big.NewInt(1), nil), types.HomesteadSigner{}, testKey)
+	if err != nil {
+		t.Fatalf("could not sign tx: %v", err)
+	}
+	if err := sim.SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	sim.Commit()
+
+	block, _ := sim.BlockByNumber(ctx, nil)
+	if block.Time() != head.Time+100000 {
+		t.Errorf("wrong head timestamp: have %d, want %d", block.Time(), head.Time+100000)
+	}
+	if len(block.Transactions()) != 1 {
+		t.Errorf("wrong transaction count: have %d, want 1", len(block.Transactions()))
+	}
+	// The warp only applies to a single block.
+	if pending := sim.pendingBlock.Time(); pending != block.Time()+10 {
+		t.Errorf("wrong pending timestamp: have %d, want %d", pending, block.Time()+10)
+	}
+}
+
+func TestSimulatedBackend_API(t *testing.T) {
+	sim := NewSimulatedBackend(core.GenesisAlloc{}, 10000000)
+	defer sim.Close()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	for _, api := range sim.APIs() {
+		if err := server.RegisterName(api.Namespace, api.Service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	var (
+		ctx       = context.Background()
+		whale     = common.HexToAddress("0x1000")
+		recipient = common.HexToAddress("0x2000")
+		hash      common.Hash
+	)
+	if err := client.Call(nil, "sim_setBalance", whale, (*hexutil.Big)(big.NewInt(params.Ether))); err != nil {
+		t.Fatalf("failed to set balance: %v", err)
+	}
+	args := map[string]interface{}{"from": whale, "to": recipient, "value": (*hexutil.Big)(big.NewInt(1000))}
+	if err := client.Call(&hash, "sim_sendTransaction", args); err == nil {
+		t.Fatal("transaction from account which is not impersonated accepted")
+	}
+	if err := client.Call(nil, "sim_impersonateAccount", whale); err != nil {
+		t.Fatalf("failed to impersonate: %v", err)
+	}
+	if err := client.Call(&hash, "sim_sendTransaction", args); err != nil {
+		t.Fatalf("failed to send transaction: %v", err)
+	}
+	if err := client.Call(nil, "sim_commit"); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if balance, _ := sim.BalanceAt(ctx, recipient, nil); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("wrong recipient balance: have %v, want 1000", balance)
+	}
+	if receipt, _ := sim.TransactionReceipt(ctx, hash); receipt == nil {
+		t.Error("transaction not included")
+	}
+	if err := client.Call(nil, "sim_warpBlockNumber", hexutil.Uint64(3)); err != nil {
+		t.Fatalf("failed to warp block number: %v", err)
+	}
+	if head, _ := sim.HeaderByNumber(ctx, nil); head.Number.Uint64() != 3 {
+		t.Errorf("wrong head number: have %d, want 3", head.Number)
+	}
+}
@@ -0,0 +1,455 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	emptyRoot     = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+	emptyCodeHash = crypto.Keccak256Hash(nil)
+
+	// forkTombstone marks a value which was deleted in the simulated chain and
+	// must not be retrieved from the fork source again. It's the RLP encoding
+	// of an empty string, which is neither a valid account nor a valid storage
+	// value in a state trie.
+	forkTombstone = []byte{0x80}
+)
+
+// ForkAccount is the state of an account in the chain a simulated backend was
+// forked off.
+type ForkAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+}
+
+// ForkSource retrieves the state of a live chain at the block a simulated
+// backend was forked off. The state must not change between calls.
+type ForkSource interface {
+	// Header returns the header of the block the source is pinned to.
+	Header() *types.Header
+
+	// ChainID returns the chain ID of the forked chain.
+	ChainID() *big.Int
+
+	// Account retrieves an account. Non-existent accounts are returned as nil.
+	Account(ctx context.Context, address common.Address) (*ForkAccount, error)
+
+	// Storage retrieves a storage slot of an account.
+	Storage(ctx context.Context, address common.Address, key common.Hash) (common.Hash, error)
+}
+
+// RPCForkSource is a ForkSource retrieving the state of a block through the
+// JSON-RPC API of a node.
+type RPCForkSource struct {
+	client  *rpc.Client
+	number  *big.Int
+	header  *types.Header
+	chainID *big.Int
+}
+
+// NewRPCForkSource creates a fork source pinned to the given block of the node.
+// If number is nil, the source is pinned to the current head block.
+func NewRPCForkSource(ctx context.Context, client *rpc.Client, number *big.Int) (*RPCForkSource, error) {
+	block := "latest"
+	if number != nil {
+		block = hexutil.EncodeBig(number)
+	}
+	var (
+		header  *types.Header
+		chainID hexutil.Big
+	)
+	if err := client.CallContext(ctx, &header, "eth_getBlockByNumber", block, false); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("block %s not found", block)
+	}
+	if err := client.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return nil, err
+	}
+	return &RPCForkSource{client: client, number: new(big.Int).Set(header.Number), header: header, chainID: chainID.ToInt()}, nil
+}
+
+// Number returns the number of the block the source is pinned to.
+func (s *RPCForkSource) Number() *big.Int {
+	return new(big.Int).Set(s.number)
+}
+
+// Header implements ForkSource.
+func (s *RPCForkSource) Header() *types.Header {
+	return types.CopyHeader(s.header)
+}
+
+// ChainID implements ForkSource.
+func (s *RPCForkSource) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
+// Account implements ForkSource, retrieving the balance, nonce and code of the
+// account in a single batch request.
+func (s *RPCForkSource) Account(ctx context.Context, address common.Address) (*ForkAccount, error) {
+	var (
+		balance hexutil.Big
+		nonce   hexutil.Uint64
+		code    hexutil.Bytes
+		block   = hexutil.EncodeBig(s.number)
+	)
+	batch := []rpc.BatchElem{
+		{Method: "eth_getBalance", Args: []interface{}{address, block}, Result: &balance},
+		{Method: "eth_getTransactionCount", Args: []interface{}{address, block}, Result: &nonce},
+		{Method: "eth_getCode", Args: []interface{}{address, block}, Result: &code},
+	}
+	if err := s.client.BatchCallContext(ctx, batch); err != nil {
+		return nil, err
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("%s failed: %v", elem.Method, elem.Error)
+		}
+	}
+	if balance.ToInt().Sign() == 0 && nonce == 0 && len(code) == 0 {
+		return nil, nil
+	}
+	return &ForkAccount{Balance: balance.ToInt(), Nonce: uint64(nonce), Code: code}, nil
+}
+
+// Storage implements ForkSource.
+func (s *RPCForkSource) Storage(ctx context.Context, address common.Address, key common.Hash) (common.Hash, error) {
+	var value hexutil.Bytes
+	if err := s.client.CallContext(ctx, &value, "eth_getStorageAt", address, key, hexutil.EncodeBig(s.number)); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// forkState caches the state retrieved from a fork source. It is shared by
+// all state databases of a forked simulated backend.
+type forkState struct {
+	source ForkSource
+
+	lock      sync.Mutex
+	accounts  map[common.Address]*ForkAccount
+	storage   map[common.Address]map[common.Hash]common.Hash
+	addresses map[common.Hash]common.Address // preimages of accessed account keys
+}
+
+func newForkState(source ForkSource) *forkState {
+	return &forkState{
+		source:    source,
+		accounts:  make(map[common.Address]*ForkAccount),
+		storage:   make(map[common.Address]map[common.Hash]common.Hash),
+		addresses: make(map[common.Hash]common.Address),
+	}
+}
+
+// account returns an account of the fork source, fetching it if it's not
+// cached yet.
+func (f *forkState) account(address common.Address) (*ForkAccount, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.addresses[crypto.Keccak256Hash(address[:])] = address
+	if account, ok := f.accounts[address]; ok {
+		return account, nil
+	}
+	account, err := f.source.Account(context.Background(), address)
+	if err != nil {
+		return nil, fmt.Errorf("fork: can't retrieve account %x: %v", address, err)
+	}
+	f.accounts[address] = account
+	return account, nil
+}
+
+// slot returns a storage slot of the fork source, fetching it if it's not
+// cached yet.
+func (f *forkState) slot(address common.Address, key common.Hash) (common.Hash, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if value, ok := f.storage[address][key]; ok {
+		return value, nil
+	}
+	value, err := f.source.Storage(context.Background(), address, key)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("fork: can't retrieve storage slot %x of %x: %v", key, address, err)
+	}
+	if f.storage[address] == nil {
+		f.storage[address] = make(map[common.Hash]common.Hash)
+	}
+	f.storage[address][key] = value
+	return value, nil
+}
+
+// address returns the address of a previously accessed account.
+func (f *forkState) address(addrHash common.Hash) (common.Address, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	address, ok := f.addresses[addrHash]
+	return address, ok
+}
+
+// forkDatabase is a state.Database layering a local state on top of the state
+// of a fork source. The local tries only contain the accounts and storage
+// slots modified in the simulated chain, all other values are retrieved from
+// the fork source on demand. Since the source is pinned to a single block,
+// the roots of the local tries are deterministic and blocks can be imported
+// normally.
+type forkDatabase struct {
+	state.Database
+	fork *forkState
+	disk ethdb.KeyValueWriter
+
+	lock sync.Mutex
+	err  error // first retrieval error of the tries opened through this database
+}
+
+func newForkDatabase(db state.Database, fork *forkState) *forkDatabase {
+	return &forkDatabase{Database: db, fork: fork, disk: db.TrieDB().DiskDB()}
+}
+
+// view returns a database sharing all caches with db, but recording the
+// retrieval errors of its tries separately. The states opened by a single
+// call of the backend use their own view, so errors aren't mixed up between
+// calls.
+func (db *forkDatabase) view() *forkDatabase {
+	return newForkDatabase(db.Database, db.fork)
+}
+
+// setError records err if no error occurred since the last reset. The state
+// objects don't pass on errors of storage reads, so the backend has to check
+// them separately.
+func (db *forkDatabase) setError(err error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.err == nil {
+		db.err = err
+	}
+}
+
+// resetError returns the first retrieval error since the last reset and
+// clears it.
+func (db *forkDatabase) resetError() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	err := db.err
+	db.err = nil
+	return err
+}
+
+// OpenTrie implements state.Database.
+func (db *forkDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenTrie(root)
+	if err != nil {
+		return nil, err
+	}
+	return &forkTrie{Trie: tr, db: db}, nil
+}
+
+// OpenStorageTrie implements state.Database. Storage of accounts which don't
+// hold code in the fork source is never retrieved remotely.
+func (db *forkDatabase) OpenStorageTrie(addrHash, root common.Hash) (state.Trie, error) {
+	tr, err := db.Database.OpenStorageTrie(addrHash, root)
+	if err != nil {
+		return nil, err
+	}
+	storage := &forkTrie{Trie: tr, db: db, storage: true}
+	if address, ok := db.fork.address(addrHash); ok {
+		account, err := db.fork.account(address)
+		if err != nil {
+			db.setError(err)
+		} else if account != nil && len(account.Code) > 0 {
+			storage.owner = &address
+		}
+	}
+	return storage, nil
+}
+
+// CopyTrie implements state.Database.
+func (db *forkDatabase) CopyTrie(t state.Trie) state.Trie {
+	if t, ok := t.(*forkTrie); ok {
+		return &forkTrie{Trie: db.Database.CopyTrie(t.Trie), db: t.db, storage: t.storage, owner: t.owner}
+	}
+	return db.Database.CopyTrie(t)
+}
+
+// forkTrie is a local state trie falling back to the fork source for keys it
+// doesn't contain. Deleted keys are stored as tombstones to prevent them from
+// being retrieved again.
+type forkTrie struct {
+	state.Trie
+	db      *forkDatabase
+	storage bool            // whether the trie is a storage trie
+	owner   *common.Address // owner of a storage trie backed by the fork source
+}
+
+// TryGet implements state.Trie.
+func (t *forkTrie) TryGet(key []byte) ([]byte, error) {
+	enc, err := t.Trie.TryGet(key)
+	switch {
+	case err != nil:
+		return nil, err
+	case bytes.Equal(enc, forkTombstone):
+		return nil, nil
+	case len(enc) > 0:
+		return enc, nil
+	case !t.storage:
+		return t.getAccount(common.BytesToAddress(key))
+	case t.owner != nil:
+		return t.getStorage(common.BytesToHash(key))
+	default:
+		return nil, nil
+	}
+}
+
+// getAccount retrieves an account from the fork source and encodes it like
+// the state does. The code of the account is written to the database, its
+// storage root is the empty root as slots are retrieved separately.
+func (t *forkTrie) getAccount(address common.Address) ([]byte, error) {
+	account, err := t.db.fork.account(address)
+	if err != nil {
+		t.db.setError(err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+	codeHash := emptyCodeHash
+	if len(account.Code) > 0 {
+		codeHash = crypto.Keccak256Hash(account.Code)
+		rawdb.WriteCode(t.db.disk, codeHash, account.Code)
+	}
+	balance := account.Balance
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return rlp.EncodeToBytes(&state.Account{
+		Nonce:    account.Nonce,
+		Balance:  balance,
+		Root:     emptyRoot,
+		CodeHash: codeHash[:],
+	})
+}
+
+// getStorage retrieves a storage slot from the fork source and encodes it
+// like the state does.
+func (t *forkTrie) getStorage(key common.Hash) ([]byte, error) {
+	value, err := t.db.fork.slot(*t.owner, key)
+	if err != nil {
+		t.db.setError(err)
+		return nil, err
+	}
+	if value == (common.Hash{}) {
+		return nil, nil
+	}
+	return rlp.EncodeToBytes(common.TrimLeftZeroes(value[:]))
+}
+
+// TryDelete implements state.Trie.
+func (t *forkTrie) TryDelete(key []byte) error {
+	return t.Trie.TryUpdate(key, forkTombstone)
+}
+
+// TryUpdate implements state.Trie.
+func (t *forkTrie) TryUpdate(key, value []byte) error {
+	if len(value) == 0 {
+		return t.TryDelete(key)
+	}
+	return t.Trie.TryUpdate(key, value)
+}
+
+// writeForkAnchor writes a block standing in for the pinned block of the fork
+// source on top of the genesis block, so the simulated chain continues with the
+// number and timestamp of the pinned block. The blocks in between are missing,
+// the anchor only links to its real parent by hash.
+func writeForkAnchor(db ethdb.Database, config *params.ChainConfig, genesis *types.Block, pinned *types.Header) {
+	if pinned.Number.Sign() == 0 {
+		return
+	}
+	header := &types.Header{
+		ParentHash: pinned.ParentHash,
+		Coinbase:   pinned.Coinbase,
+		Root:       genesis.Root(),
+		Difficulty: new(big.Int),
+		Number:     new(big.Int).Set(pinned.Number),
+		GasLimit:   genesis.GasLimit(),
+		Time:       pinned.Time,
+	}
+	if pinned.Difficulty != nil {
+		header.Difficulty.Set(pinned.Difficulty)
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = pinned.BaseFee
+		if header.BaseFee == nil {
+			header.BaseFee = new(big.Int).SetUint64(params.InitialBaseFee)
+		}
+	}
+	anchor := types.NewBlock(header, nil, nil, nil, trie.NewStackTrie(nil))
+
+	rawdb.WriteTd(db, anchor.Hash(), anchor.NumberU64(), new(big.Int).Add(genesis.Difficulty(), header.Difficulty))
+	rawdb.WriteBlock(db, anchor)
+	rawdb.WriteReceipts(db, anchor.Hash(), anchor.NumberU64(), nil)
+	rawdb.WriteCanonicalHash(db, anchor.Hash(), anchor.NumberU64())
+	rawdb.WriteHeadBlockHash(db, anchor.Hash())
+	rawdb.WriteHeadFastBlockHash(db, anchor.Hash())
+	rawdb.WriteHeadHeaderHash(db, anchor.Hash())
+}
+
+// NewForkedSimulatedBackend creates a new binding backend whose simulated chain
+// starts from the state of a live chain. Accounts, code and storage are pulled
+// lazily from the fork source and cached, all changes made by transactions of
+// the simulated chain are kept locally.
+//
+// The simulated chain continues with the number, timestamp and chain ID of the
+// pinned block, but the block history of the forked chain isn't available.
+func NewForkedSimulatedBackend(source ForkSource, gasLimit uint64) *SimulatedBackend {
+	fork := newForkState(source)
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit:      256,
+		TrieCleanNoPrefetch: true,
+		TrieDirtyLimit:      256,
+		TrieDirtyDisabled:   true, // garbage collection looks up blocks missing before the anchor
+		TrieTimeLimit:       5 * time.Minute,
+		StateDatabase: func(db ethdb.Database, config *trie.Config) state.Database {
+			return newForkDatabase(state.NewDatabaseWithConfig(db, config), fork)
+		},
+	}
+	return newSimulatedBackend(rawdb.NewMemoryDatabase(), nil, gasLimit, cacheConfig, fork)
+}
@@ -0,0 +1,312 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	// forkGetterCode returns storage slot 0.
+	forkGetterCode = common.FromHex("60005460005260206000f3")
+	// forkSetterCode stores the first word of the call data in slot 0.
+	forkSetterCode = common.FromHex("60003560005500")
+	// forkEnvCode returns the block number, timestamp and chain ID.
+	forkEnvCode = common.FromHex("436000524260205246604052" + "60606000f3")
+)
+
+// fixtureForkSource is a ForkSource serving a fixed state and counting the
+// retrievals.
+type fixtureForkSource struct {
+	header   *types.Header
+	accounts map[common.Address]*ForkAccount
+	storage  map[common.Address]map[common.Hash]common.Hash
+	fail     bool
+
+	mu    sync.Mutex
+	reads map[common.Address]int
+}
+
+func (s *fixtureForkSource) Header() *types.Header {
+	return types.CopyHeader(s.header)
+}
+
+func (s *fixtureForkSource) ChainID() *big.Int {
+	return big.NewInt(5)
+}
+
+func (s *fixtureForkSource) Account(ctx context.Context, address common.Address) (*ForkAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail {
+		return nil, errors.New("source failure")
+	}
+	s.reads[address]++
+	return s.accounts[address], nil
+}
+
+func (s *fixtureForkSource) Storage(ctx context.Context, address common.Address, key common.Hash) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail {
+		return common.Hash{}, errors.New("source failure")
+	}
+	return s.storage[address][key], nil
+}
+
+func newFixtureForkSource(sender common.Address) *fixtureForkSource {
+	return &fixtureForkSource{
+		header: &types.Header{
+			ParentHash: common.HexToHash("0x01"),
+			Number:     big.NewInt(12345),
+			Time:       1600000000,
+			Difficulty: big.NewInt(131072),
+		},
+		accounts: map[common.Address]*ForkAccount{
+			sender:                        {Balance: big.NewInt(1000000000000), Nonce: 5},
+			common.HexToAddress("0x1000"): {Balance: big.NewInt(0), Code: forkGetterCode},
+			common.HexToAddress("0x2000"): {Balance: big.NewInt(0), Code: forkSetterCode},
+			common.HexToAddress("0x3000"): {Balance: big.NewInt(0), Code: forkEnvCode},
+		},
+		storage: map[common.Address]map[common.Hash]common.Hash{
+			common.HexToAddress("0x1000"): {common.Hash{}: common.HexToHash("0x2a")},
+			common.HexToAddress("0x2000"): {common.Hash{}: common.HexToHash("0x05")},
+		},
+		reads: make(map[common.Address]int),
+	}
+}
+
+func TestForkedSimulatedBackend(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		getter = common.HexToAddress("0x1000")
+		setter = common.HexToAddress("0x2000")
+		source = newFixtureForkSource(sender)
+	)
+	sim := NewForkedSimulatedBackend(source, 10000000)
+	defer sim.Close()
+
+	// Check that the state of the source is visible.
+	if balance, err := sim.BalanceAt(ctx, sender, nil); err != nil || balance.Cmp(big.NewInt(1000000000000)) != 0 {
+		t.Fatalf("wrong balance: %v, %v", balance, err)
+	}
+	if nonce, err := sim.PendingNonceAt(ctx, sender); err != nil || nonce != 5 {
+		t.Fatalf("wrong pending nonce: %d, %v", nonce, err)
+	}
+	if code, err := sim.CodeAt(ctx, getter, nil); err != nil || !bytes.Equal(code, forkGetterCode) {
+		t.Fatalf("wrong code: %x, %v", code, err)
+	}
+	res, err := sim.CallContract(ctx, ethereum.CallMsg{To: &getter}, nil)
+	if err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if common.BytesToHash(res) != common.HexToHash("0x2a") {
+		t.Fatalf("wrong call result: %x", res)
+	}
+	// Check that the chain continues from the pinned block.
+	env := common.HexToAddress("0x3000")
+	if res, err = sim.CallContract(ctx, ethereum.CallMsg{To: &env}, nil); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := new(big.Int).SetBytes(res[:32]); have.Uint64() != 12345 {
+		t.Errorf("wrong block number: have %v, want 12345", have)
+	}
+	if have := new(big.Int).SetBytes(res[32:64]); have.Uint64() != 1600000000 {
+		t.Errorf("wrong timestamp: have %v, want 1600000000", have)
+	}
+	if have := new(big.Int).SetBytes(res[64:]); have.Uint64() != 5 {
+		t.Errorf("wrong chain ID: have %v, want 5", have)
+	}
+	// Commit a transfer and a storage deletion on top of the forked state.
+	signer := types.HomesteadSigner{}
+	transfer, _ := types.SignTx(types.NewTransaction(5, getter, big.NewInt(1000), 50000, big.NewInt(1), nil), signer, key)
+	if err := sim.SendTransaction(ctx, transfer); err != nil {
+		t.Fatalf("failed to send transfer: %v", err)
+	}
+	clear, _ := types.SignTx(types.NewTransaction(6, setter, new(big.Int), 100000, big.NewInt(1), make([]byte, 32)), signer, key)
+	if err := sim.SendTransaction(ctx, clear); err != nil {
+		t.Fatalf("failed to send storage update: %v", err)
+	}
+	sim.Commit()
+
+	if receipt, _ := sim.TransactionReceipt(ctx, clear.Hash()); receipt == nil || receipt.Status != types.ReceiptStatusSuccessful {
+		t.Fatalf("storage update failed: %v", receipt)
+	}
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if head.Number.Uint64() != 12346 || head.Time != 1600000010 {
+		t.Fatalf("wrong head: number %d, time %d", head.Number, head.Time)
+	}
+	if logs, err := sim.FilterLogs(ctx, ethereum.FilterQuery{}); err != nil || len(logs) != 0 {
+		t.Fatalf("wrong logs: %v, %v", logs, err)
+	}
+	if balance, _ := sim.BalanceAt(ctx, getter, nil); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Fatalf("wrong recipient balance: %v", balance)
+	}
+	if nonce, _ := sim.NonceAt(ctx, sender, nil); nonce != 7 {
+		t.Fatalf("wrong nonce after commit: %d", nonce)
+	}
+	// The cleared slot must not be retrieved from the source again.
+	if value, err := sim.StorageAt(ctx, setter, common.Hash{}, nil); err != nil || common.BytesToHash(value) != (common.Hash{}) {
+		t.Fatalf("wrong cleared slot: %x, %v", value, err)
+	}
+	// The untouched state must still be served, each account retrieved once.
+	if value, err := sim.StorageAt(ctx, getter, common.Hash{}, nil); err != nil || common.BytesToHash(value) != common.HexToHash("0x2a") {
+		t.Fatalf("wrong untouched slot: %x, %v", value, err)
+	}
+	for address, reads := range source.reads {
+		if reads != 1 {
+			t.Errorf("account %x retrieved %d times", address, reads)
+		}
+	}
+}
+
+func TestForkedSimulatedBackendError(t *testing.T) {
+	source := newFixtureForkSource(common.Address{})
+	sim := NewForkedSimulatedBackend(source, 10000000)
+	defer sim.Close()
+
+	source.mu.Lock()
+	source.fail = true
+	source.mu.Unlock()
+
+	if _, err := sim.BalanceAt(context.Background(), common.HexToAddress("0x1000"), nil); err == nil {
+		t.Fatal("expected error from failing source")
+	}
+	getter := common.HexToAddress("0x1000")
+	if _, err := sim.CallContract(context.Background(), ethereum.CallMsg{To: &getter}, nil); err == nil {
+		t.Fatal("expected error from failing source")
+	}
+	// Errors are reported to the call which caused them, not to later ones.
+	key, _ := crypto.GenerateKey()
+	tx, _ := types.SignTx(types.NewTransaction(0, getter, new(big.Int), 50000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := sim.SendTransaction(context.Background(), tx); err == nil {
+		t.Fatal("expected error from failing source")
+	}
+	source.mu.Lock()
+	source.fail = false
+	source.mu.Unlock()
+
+	if _, err := sim.BalanceAt(context.Background(), getter, nil); err != nil {
+		t.Fatalf("error of earlier call reported: %v", err)
+	}
+	if _, err := sim.PendingNonceAt(context.Background(), getter); err != nil {
+		t.Fatalf("error of earlier call reported: %v", err)
+	}
+}
+
+// forkTestAPI serves the state of a simulated backend through the eth
+// namespace, like a node would.
+type forkTestAPI struct {
+	sim *SimulatedBackend
+}
+
+func (api *forkTestAPI) GetBlockByNumber(number rpc.BlockNumber, full bool) (*types.Header, error) {
+	if number == rpc.LatestBlockNumber {
+		return api.sim.HeaderByNumber(context.Background(), nil)
+	}
+	return api.sim.HeaderByNumber(context.Background(), big.NewInt(number.Int64()))
+}
+
+func (api *forkTestAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(api.sim.Blockchain().Config().ChainID)
+}
+
+func (api *forkTestAPI) GetBalance(address common.Address, number rpc.BlockNumber) (*hexutil.Big, error) {
+	balance, err := api.sim.BalanceAt(context.Background(), address, big.NewInt(number.Int64()))
+	return (*hexutil.Big)(balance), err
+}
+
+func (api *forkTestAPI) GetTransactionCount(address common.Address, number rpc.BlockNumber) (hexutil.Uint64, error) {
+	nonce, err := api.sim.NonceAt(context.Background(), address, big.NewInt(number.Int64()))
+	return hexutil.Uint64(nonce), err
+}
+
+func (api *forkTestAPI) GetCode(address common.Address, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	return api.sim.CodeAt(context.Background(), address, big.NewInt(number.Int64()))
+}
+
+func (api *forkTestAPI) GetStorageAt(address common.Address, key common.Hash, number rpc.BlockNumber) (hexutil.Bytes, error) {
+	return api.sim.StorageAt(context.Background(), address, key, big.NewInt(number.Int64()))
+}
+
+func TestRPCForkSource(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		key, _ = crypto.GenerateKey()
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		getter = common.HexToAddress("0x1000")
+	)
+	remote := NewSimulatedBackend(core.GenesisAlloc{
+		sender: {Balance: big.NewInt(1000000000000)},
+		getter: {Balance: new(big.Int), Code: forkGetterCode, Storage: map[common.Hash]common.Hash{{}: common.HexToHash("0x2a")}},
+	}, 10000000)
+	defer remote.Close()
+
+	server := rpc.NewServer()
+	defer server.Stop()
+	if err := server.RegisterName("eth", &forkTestAPI{remote}); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	source, err := NewRPCForkSource(ctx, client, nil)
+	if err != nil {
+		t.Fatalf("failed to create source: %v", err)
+	}
+	// Move the remote chain past the pinned block.
+	tx, _ := types.SignTx(types.NewTransaction(0, getter, big.NewInt(1000), 50000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	remote.SendTransaction(ctx, tx)
+	remote.Commit()
+
+	if source.Number().Sign() != 0 {
+		t.Fatalf("wrong pinned block: %v", source.Number())
+	}
+	if source.ChainID().Uint64() != 1337 {
+		t.Fatalf("wrong chain ID: %v", source.ChainID())
+	}
+	sim := NewForkedSimulatedBackend(source, 10000000)
+	defer sim.Close()
+
+	if balance, err := sim.BalanceAt(ctx, getter, nil); err != nil || balance.Sign() != 0 {
+		t.Fatalf("wrong balance at pinned block: %v, %v", balance, err)
+	}
+	res, err := sim.CallContract(ctx, ethereum.CallMsg{To: &getter}, nil)
+	if err != nil || common.BytesToHash(res) != common.HexToHash("0x2a") {
+		t.Fatalf("wrong call result: %x, %v", res, err)
+	}
+	if account, err := source.Account(ctx, common.HexToAddress("0xff")); err != nil || account != nil {
+		t.Fatalf("wrong missing account: %v, %v", account, err)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/common/math"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
@@ -63,11 +64,21 @@ type SimulatedBackend struct {
 	database   ethdb.Database   // In memory database to store our testing data
 	blockchain *core.BlockChain // Ethereum blockchain to handle the consensus
 
-	mu           sync.Mutex
-	pendingBlock *types.Block   // Currently pending block that will be imported on request
-	pendingState *state.StateDB // Currently pending state that will be the active on request
+	mu              sync.Mutex
+	pendingBlock    *types.Block   // Currently pending block that will be imported on request
+	pendingState    *state.StateDB // Currently pending state that will be the active on request
+	pendingHeader   *types.Header  // Header of the pending block before finalization
+	pendingReceipts types.Receipts // Receipts of the transactions in the pending block
+	pendingModified bool           // Whether the pending block can't be verified by re-execution
+	pendingWarped   bool           // Whether the pending timestamp was set by WarpTime
+
+	impersonated     map[common.Address]bool               // Accounts which may send unsigned transactions
+	impersonatedTxs  map[*types.Transaction]common.Address // Senders of unsigned transactions not yet sent
+	pendingCreations map[common.Hash]common.Address        // Contracts deployed by unsigned transactions in the pending block
+	creations        map[common.Hash]common.Address        // Contracts deployed by committed unsigned transactions
 
 	events *filters.EventSystem // Event system for filtering log events live
+	fork   *forkState           // Source of the initial state if forked off a live chain
 
 	config *params.ChainConfig
 }
@@ -76,15 +87,31 @@ type SimulatedBackend struct {
 // and uses a simulated blockchain for testing purposes.
 // A simulated backend always uses chainID 1337.
 func NewSimulatedBackendWithDatabase(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64) *SimulatedBackend {
+	return newSimulatedBackend(database, alloc, gasLimit, nil, nil)
+}
+
+func newSimulatedBackend(database ethdb.Database, alloc core.GenesisAlloc, gasLimit uint64, cacheConfig *core.CacheConfig, fork *forkState) *SimulatedBackend {
 	genesis := core.Genesis{Config: params.AllEthashProtocolChanges, GasLimit: gasLimit, Alloc: alloc}
-	genesis.MustCommit(database)
-	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if fork != nil {
+		config := *genesis.Config
+		config.ChainID = fork.source.ChainID()
+		genesis.Config = &config
+	}
+	block := genesis.MustCommit(database)
+	if fork != nil {
+		writeForkAnchor(database, genesis.Config, block, fork.source.Header())
+	}
+	blockchain, _ := core.NewBlockChain(database, cacheConfig, genesis.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
 
 	backend := &SimulatedBackend{
 		database:   database,
 		blockchain: blockchain,
 		config:     genesis.Config,
 		events:     filters.NewEventSystem(&filterBackend{database, blockchain}, false),
+		fork:       fork,
+
+		impersonated: make(map[common.Address]bool),
+		creations:    make(map[common.Hash]common.Address),
 	}
 	backend.rollback()
 	return backend
@@ -109,9 +136,39 @@ func (b *SimulatedBackend) Commit() {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
+	b.commit()
+}
+
+// commit imports the pending block into the chain and starts a new one.
+func (b *SimulatedBackend) commit() {
+	if !b.pendingModified {
+		if _, err := b.blockchain.InsertChain([]*types.Block{b.pendingBlock}); err != nil {
+			panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+		}
+		b.rollback()
+		return
+	}
+	// Blocks with direct state modifications or impersonated transactions can't
+	// be verified by re-executing them, write them along with the pending state
+	// the same way a miner does.
+	block, err := b.blockchain.Engine().FinalizeAndAssemble(b.blockchain, b.pendingHeader, b.pendingState, b.pendingBlock.Transactions(), nil, b.pendingReceipts)
+	if err != nil {
 		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
 	}
+	var logs []*types.Log
+	for _, receipt := range b.pendingReceipts {
+		receipt.BlockHash = block.Hash()
+		for _, log := range receipt.Logs {
+			log.BlockHash = block.Hash()
+		}
+		logs = append(logs, receipt.Logs...)
+	}
+	if _, err := b.blockchain.WriteBlockWithState(block, b.pendingReceipts, logs, b.pendingState, true); err != nil {
+		panic(err) // This cannot happen unless the simulator is wrong, fail in that case
+	}
+	for hash, address := range b.pendingCreations {
+		b.creations[hash] = address
+	}
 	b.rollback()
 }
 
@@ -124,22 +181,63 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(int, *core.BlockGen) {})
-
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), b.blockchain.StateCache(), nil)
+	parent := b.blockchain.CurrentBlock()
+	b.pendingHeader = &types.Header{
+		ParentHash: parent.Hash(),
+		Coinbase:   parent.Coinbase(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   parent.GasLimit(),
+		Time:       parent.Time() + 10, // block time is fixed at 10 seconds
+	}
+	b.pendingHeader.Difficulty = b.blockchain.Engine().CalcDifficulty(b.blockchain, b.pendingHeader.Time, parent.Header())
+	if b.config.IsLondon(b.pendingHeader.Number) {
+		b.pendingHeader.BaseFee = misc.CalcBaseFee(b.config, parent.Header())
+	}
+	b.pendingReceipts = nil
+	b.pendingModified = false
+	b.pendingWarped = false
+	b.pendingCreations = make(map[common.Hash]common.Address)
+	b.impersonatedTxs = make(map[*types.Transaction]common.Address)
+	b.pendingState, _ = b.stateAt(parent.Root())
+	b.assemble(nil)
+}
+
+// assemble recreates the pending block from the pending header, state and the
+// given transactions.
+func (b *SimulatedBackend) assemble(txs []*types.Transaction) {
+	b.pendingBlock, _ = b.blockchain.Engine().FinalizeAndAssemble(b.blockchain, types.CopyHeader(b.pendingHeader), b.pendingState.Copy(), txs, nil, b.pendingReceipts)
+}
+
+// stateAt opens the state with the given root. States of a forked backend are
+// opened through their own view of the state database, which keeps track of
+// the errors retrieving state from the fork source.
+func (b *SimulatedBackend) stateAt(root common.Hash) (*state.StateDB, error) {
+	if db, ok := b.blockchain.StateCache().(*forkDatabase); ok {
+		return state.New(root, db.view(), nil)
+	}
+	return b.blockchain.StateAt(root)
+}
+
+// forkError returns and clears the first error which occurred while retrieving
+// state from the fork source for the given state. Reads of the state don't
+// fail on errors, so they are checked separately.
+func forkError(stateDB *state.StateDB) error {
+	if db, ok := stateDB.Database().(*forkDatabase); ok {
+		return db.resetError()
+	}
+	return nil
 }
 
 // stateByBlockNumber retrieves a state by a given blocknumber.
 func (b *SimulatedBackend) stateByBlockNumber(ctx context.Context, blockNumber *big.Int) (*state.StateDB, error) {
 	if blockNumber == nil || blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) == 0 {
-		return b.blockchain.State()
+		return b.stateAt(b.blockchain.CurrentBlock().Root())
 	}
 	block, err := b.blockByNumberNoLock(ctx, blockNumber)
 	if err != nil {
 		return nil, err
 	}
-	return b.blockchain.StateAt(block.Root())
+	return b.stateAt(block.Root())
 }
 
 // CodeAt returns the code associated with a certain account in the blockchain.
@@ -152,7 +250,7 @@ func (b *SimulatedBackend) CodeAt(ctx context.Context, contract common.Address,
 		return nil, err
 	}
 
-	return stateDB.GetCode(contract), nil
+	return stateDB.GetCode(contract), forkError(stateDB)
 }
 
 // BalanceAt returns the wei balance of a certain account in the blockchain.
@@ -165,7 +263,7 @@ func (b *SimulatedBackend) BalanceAt(ctx context.Context, contract common.Addres
 		return nil, err
 	}
 
-	return stateDB.GetBalance(contract), nil
+	return stateDB.GetBalance(contract), forkError(stateDB)
 }
 
 // NonceAt returns the nonce of a certain account in the blockchain.
@@ -178,7 +276,7 @@ func (b *SimulatedBackend) NonceAt(ctx context.Context, contract common.Address,
 		return 0, err
 	}
 
-	return stateDB.GetNonce(contract), nil
+	return stateDB.GetNonce(contract), forkError(stateDB)
 }
 
 // StorageAt returns the value of key in the storage of an account in the blockchain.
@@ -192,6 +290,9 @@ func (b *SimulatedBackend) StorageAt(ctx context.Context, contract common.Addres
 	}
 
 	val := stateDB.GetState(contract, key)
+	if err := forkError(stateDB); err != nil {
+		return nil, err
+	}
 	return val[:], nil
 }
 
@@ -201,6 +302,11 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common
 	defer b.mu.Unlock()
 
 	receipt, _, _, _ := rawdb.ReadReceipt(b.database, txHash, b.config)
+	// The creator of a contract is derived from the signature, which is missing
+	// if the deployment was sent from an impersonated account.
+	if address, ok := b.creations[txHash]; ok && receipt != nil {
+		receipt.ContractAddress = address
+	}
 	return receipt, nil
 }
 
@@ -343,7 +449,7 @@ func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Ad
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	return b.pendingState.GetCode(contract), nil
+	return b.pendingState.GetCode(contract), forkError(b.pendingState)
 }
 
 func newRevertError(result *core.ExecutionResult) *revertError {
@@ -384,7 +490,7 @@ func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallM
 	if blockNumber != nil && blockNumber.Cmp(b.blockchain.CurrentBlock().Number()) != 0 {
 		return nil, errBlockNumberUnsupported
 	}
-	stateDB, err := b.blockchain.State()
+	stateDB, err := b.stateAt(b.blockchain.CurrentBlock().Root())
 	if err != nil {
 		return nil, err
 	}
@@ -422,7 +528,7 @@ func (b *SimulatedBackend) PendingNonceAt(ctx context.Context, account common.Ad
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	return b.pendingState.GetOrNewStateObject(account).Nonce(), nil
+	return b.pendingState.GetOrNewStateObject(account).Nonce(), forkError(b.pendingState)
 }
 
 // SuggestGasPrice implements ContractTransactor.SuggestGasPrice. Since the simulated
@@ -550,11 +656,16 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 	vmEnv := vm.NewEVM(evmContext, txContext, stateDB, b.config, vm.Config{})
 	gasPool := new(core.GasPool).AddGas(math.MaxUint64)
 
-	return core.NewStateTransition(vmEnv, msg, gasPool).TransitionDb()
+	res, err := core.NewStateTransition(vmEnv, msg, gasPool).TransitionDb()
+	if ferr := forkError(stateDB); ferr != nil {
+		return nil, ferr
+	}
+	return res, err
 }
 
 // SendTransaction updates the pending block to include the given transaction.
-// It panics if the transaction is invalid.
+// It panics if the transaction is invalid. Unsigned transactions are accepted
+// from impersonated accounts.
 func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -563,25 +674,46 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	block := b.blockchain.CurrentBlock()
 	signer := types.MakeSigner(b.blockchain.Config(), block.Number())
 	sender, err := types.Sender(signer, tx)
+	impersonated := false
 	if err != nil {
-		panic(fmt.Errorf("invalid transaction: %v", err))
+		from, ok := b.impersonatedTxs[tx]
+		if !ok || !b.impersonated[from] {
+			panic(fmt.Errorf("invalid transaction: %v", err))
+		}
+		delete(b.impersonatedTxs, tx)
+		sender, impersonated = from, true
 	}
 	nonce := b.pendingState.GetNonce(sender)
 	if tx.Nonce() != nonce {
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
 
-	// Include tx in chain.
-	blocks, _ := core.GenerateChain(b.config, block, ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTxWithChain(b.blockchain, tx)
-		}
-		block.AddTxWithChain(b.blockchain, tx)
-	})
-	stateDB, _ := b.blockchain.State()
-
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), stateDB.Database(), nil)
+	// Include tx in the pending block. Time adjustments only apply to empty
+	// blocks, so the first transaction resets the timestamp unless it was
+	// explicitly warped.
+	if len(b.pendingBlock.Transactions()) == 0 && !b.pendingWarped {
+		b.setTime(block.Time() + 10)
+	}
+	var (
+		txs      = b.pendingBlock.Transactions()
+		gasPool  = new(core.GasPool).AddGas(b.pendingHeader.GasLimit - b.pendingHeader.GasUsed)
+		snapshot = b.pendingState.Snapshot()
+	)
+	b.pendingState.Prepare(tx.Hash(), common.Hash{}, len(txs))
+	receipt, err := core.ApplyTransactionFrom(b.config, b.blockchain, nil, gasPool, b.pendingState, b.pendingHeader, tx, sender, &b.pendingHeader.GasUsed, vm.Config{})
+	if ferr := forkError(b.pendingState); ferr != nil {
+		err = ferr
+	}
+	if err != nil {
+		b.pendingState.RevertToSnapshot(snapshot)
+		return err
+	}
+	b.pendingReceipts = append(b.pendingReceipts, receipt)
+	if impersonated && tx.To() == nil {
+		b.pendingCreations[tx.Hash()] = receipt.ContractAddress
+	}
+	b.pendingModified = b.pendingModified || impersonated
+	b.assemble(append(txs, tx))
 	return nil
 }
 
@@ -600,6 +732,12 @@ func (b *SimulatedBackend) FilterLogs(ctx context.Context, query ethereum.Filter
 		if query.FromBlock != nil {
 			from = query.FromBlock.Int64()
 		}
+		// Forked chains are missing the blocks before the pinned one
+		if b.fork != nil {
+			if pinned := b.fork.source.Header().Number.Int64(); from >= 0 && from < pinned {
+				from = pinned
+			}
+		}
 		to := int64(-1)
 		if query.ToBlock != nil {
 			to = query.ToBlock.Int64()
@@ -689,15 +827,18 @@ func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	if len(b.pendingBlock.Transactions()) != 0 {
 		return errors.New("Could not adjust time on non-empty block")
 	}
+	return b.setTime(b.pendingHeader.Time + uint64(adjustment.Seconds()))
+}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), ethash.NewFaker(), b.database, 1, func(number int, block *core.BlockGen) {
-		block.OffsetTime(int64(adjustment.Seconds()))
-	})
-	stateDB, _ := b.blockchain.State()
-
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), stateDB.Database(), nil)
-
+// setTime changes the timestamp of the empty pending block.
+func (b *SimulatedBackend) setTime(timestamp uint64) error {
+	parent := b.blockchain.CurrentBlock()
+	if timestamp <= parent.Time() {
+		return fmt.Errorf("timestamp %d not after parent timestamp %d", timestamp, parent.Time())
+	}
+	b.pendingHeader.Time = timestamp
+	b.pendingHeader.Difficulty = b.blockchain.Engine().CalcDifficulty(b.blockchain, timestamp, parent.Header())
+	b.assemble(nil)
 	return nil
 }
 
@@ -790,6 +931,8 @@ func (fb *filterBackend) SubscribePendingLogsEvent(ch chan<- []*types.Log) event
 
 func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
 
+func (fb *filterBackend) LogIndex() *core.LogIndex { return nil }
+
 func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
 	panic("not supported")
 }
@@ -22,7 +22,6 @@ package bind
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"go/format"
 	"regexp"
@@ -165,9 +164,22 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 		if evmABI.HasReceive() {
 			receive = &tmplMethod{Original: evmABI.Receive}
 		}
-		// There is no easy way to pass arbitrary java objects to the Go side.
-		if len(structs) > 0 && lang == LangJava {
-			return "", errors.New("java binding for tuple arguments is not supported yet")
+		// Tuples cross over to the Go side as lists of their fields, which works for
+		// plain tuples and single dimensional lists of them only.
+		if lang != LangGo {
+			for _, method := range evmABI.Methods {
+				if err := checkMobileTuples(method.Inputs, method.Outputs); err != nil {
+					return "", fmt.Errorf("method %s: %v", method.Name, err)
+				}
+			}
+			if err := checkMobileTuples(evmABI.Constructor.Inputs); err != nil {
+				return "", fmt.Errorf("constructor: %v", err)
+			}
+			for _, event := range evmABI.Events {
+				if err := checkMobileTuples(event.Inputs); err != nil {
+					return "", fmt.Errorf("event %s: %v", event.Name, err)
+				}
+			}
 		}
 
 		contracts[types[i]] = &tmplContract{
@@ -217,11 +229,17 @@ func Bind(types []string, abis []string, bytecodes []string, fsigs []map[string]
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype":      bindType[lang],
-		"bindtopictype": bindTopicType[lang],
-		"namedtype":     namedType[lang],
-		"capitalise":    capitalise,
-		"decapitalise":  decapitalise,
+		"bindtype":       bindType[lang],
+		"bindtopictype":  bindTopicType[lang],
+		"bindtopictypes": bindTopicTypes[lang],
+		"namedtype":      namedType[lang],
+		"ifaceset":       ifaceSetter[lang],
+		"ifaceget":       ifaceGetter[lang],
+		"ifacedefault":   ifaceDefault[lang],
+		"hashedtopic":    hashedTopic,
+		"indexed":        indexed,
+		"capitalise":     capitalise,
+		"decapitalise":   decapitalise,
 	}
 	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
@@ -341,8 +359,11 @@ func pluralizeJavaType(typ string) string {
 		return "Addresses"
 	case "byte[]":
 		return "Binaries"
-	case "BigInt":
+	case "BigInt", "byte", "short", "int", "long":
+		// Small integer lists are passed as big integers, converted on the Go side
 		return "BigInts"
+	case "Hash":
+		return "Hashes"
 	}
 	return typ + "[]"
 }
@@ -386,20 +407,38 @@ func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
 }
 
 // bindTopicTypeJava converts a Solidity topic type to a Java one. It is almost the same
-// functionality as for simple types, but dynamic types get converted to hashes.
+// functionality as for simple types, but types not stored directly in a topic
+// (dynamic ones, arrays and structs) and fixed bytes get converted to hashes.
 func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
-	bound := bindTypeJava(kind, structs)
+	if hashedTopic(kind) {
+		return "Hash"
+	}
+	return bindTypeJava(kind, structs)
+}
 
-	// todo(rjl493456442) according solidity documentation, indexed event
-	// parameters that are not value types i.e. arrays and structs are not
-	// stored directly but instead a keccak256-hash of an encoding is stored.
-	//
-	// We only convert strings and bytes to hash, still need to deal with
-	// array(both fixed-size and dynamic-size) and struct.
-	if bound == "String" || bound == "byte[]" {
-		bound = "Hash"
+// hashedTopic reports whether an indexed event argument of the given type is
+// exposed as its raw topic hash by the mobile bindings.
+func hashedTopic(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.FixedBytesTy, abi.TupleTy, abi.ArrayTy, abi.SliceTy:
+		return true
 	}
-	return bound
+	return false
+}
+
+// bindTopicTypes is a set of type binders that convert Solidity types to some
+// supported programming language list types, used to filter for any of
+// multiple topic values.
+var bindTopicTypes = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   func(abi.Type, map[string]*tmplStruct) string { panic("this shouldn't be needed") },
+	LangJava: bindTopicTypesJava,
+}
+
+// bindTopicTypesJava converts a Solidity topic type to the Java list type used
+// to filter for it. All integers are filtered as big integers, since there are
+// no native list types for them.
+func bindTopicTypesJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	return pluralizeJavaType(bindTopicTypeJava(kind, structs))
 }
 
 // bindStructType is a set of type binders that convert Solidity tuple types to some supported
@@ -518,6 +557,95 @@ func namedTypeJava(javaKind string, solKind abi.Type) string {
 	}
 }
 
+// ifaceSetter is a set of functions that generate the invocation of the mobile
+// Interface setter storing a value of a Solidity type.
+var ifaceSetter = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct, value string) string{
+	LangGo:   func(abi.Type, map[string]*tmplStruct, string) string { panic("this shouldn't be needed") },
+	LangJava: ifaceSetterJava,
+}
+
+// ifaceSetterJava generates the Java invocation of the Interface setter storing
+// the given value. Structs are converted into tuples first.
+func ifaceSetterJava(kind abi.Type, structs map[string]*tmplStruct, value string) string {
+	switch {
+	case kind.T == abi.TupleTy:
+		return fmt.Sprintf("setTuple(%s.toTuple())", value)
+	case hasStruct(kind):
+		return fmt.Sprintf("setTuples(%s.toTuples(%s))", bindTypeJava(*kind.Elem, structs), value)
+	default:
+		return fmt.Sprintf("set%s(%s)", namedTypeJava(bindTypeJava(kind, structs), kind), value)
+	}
+}
+
+// ifaceGetter is a set of functions that generate the retrieval of a value of a
+// Solidity type from a mobile Interface.
+var ifaceGetter = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct, iface string) string{
+	LangGo:   func(abi.Type, map[string]*tmplStruct, string) string { panic("this shouldn't be needed") },
+	LangJava: ifaceGetterJava,
+}
+
+// ifaceGetterJava generates the Java expression retrieving a value from the given
+// Interface, converting tuples back into structs.
+func ifaceGetterJava(kind abi.Type, structs map[string]*tmplStruct, iface string) string {
+	switch {
+	case kind.T == abi.TupleTy:
+		return fmt.Sprintf("%s.fromTuple(%s.getTuple())", bindTypeJava(kind, structs), iface)
+	case hasStruct(kind):
+		return fmt.Sprintf("%s.fromTuples(%s.getTuples())", bindTypeJava(*kind.Elem, structs), iface)
+	default:
+		return fmt.Sprintf("%s.get%s()", iface, namedTypeJava(bindTypeJava(kind, structs), kind))
+	}
+}
+
+// ifaceDefault is a set of functions that generate the invocation of the mobile
+// Interface setter preparing it to receive a value of a Solidity type.
+var ifaceDefault = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   func(abi.Type, map[string]*tmplStruct) string { panic("this shouldn't be needed") },
+	LangJava: ifaceDefaultJava,
+}
+
+// ifaceDefaultJava generates the Java invocation of the Interface default setter.
+func ifaceDefaultJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch {
+	case kind.T == abi.TupleTy:
+		return "setDefaultTuple()"
+	case hasStruct(kind):
+		return "setDefaultTuples()"
+	default:
+		return fmt.Sprintf("setDefault%s()", namedTypeJava(bindTypeJava(kind, structs), kind))
+	}
+}
+
+// checkMobileTuples ensures that all tuples among the arguments can be passed to
+// the mobile bindings, which support single dimensional lists of tuples only.
+func checkMobileTuples(args ...abi.Arguments) error {
+	var check func(kind abi.Type) error
+	check = func(kind abi.Type) error {
+		switch kind.T {
+		case abi.TupleTy:
+			for _, elem := range kind.TupleElems {
+				if err := check(*elem); err != nil {
+					return err
+				}
+			}
+		case abi.ArrayTy, abi.SliceTy:
+			if (kind.Elem.T == abi.ArrayTy || kind.Elem.T == abi.SliceTy) && hasStruct(*kind.Elem) {
+				return fmt.Errorf("multi dimensional tuple list %s is not supported", kind)
+			}
+			return check(*kind.Elem)
+		}
+		return nil
+	}
+	for _, list := range args {
+		for _, arg := range list {
+			if err := check(arg.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // alias returns an alias of the given string based on the aliasing rules
 // or returns itself if no rule is matched.
 func alias(aliases map[string]string, n string) string {
@@ -570,6 +698,17 @@ func structured(args abi.Arguments) bool {
 	return true
 }
 
+// indexed returns the indexed arguments of an event.
+func indexed(args abi.Arguments) abi.Arguments {
+	var indexed abi.Arguments
+	for _, arg := range args {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	return indexed
+}
+
 // hasStruct returns an indicator whether the given type is struct, struct slice
 // or struct array.
 func hasStruct(t abi.Type) bool {
@@ -1078,6 +1078,36 @@ var bindTests = []struct {
 				t.Fatalf("unsubscribed simple event arrived: %v", event)
 			case <-time.After(250 * time.Millisecond):
 			}
+			// Test streaming the past events in small batches, followed by new ones
+			stream, err := eventer.StreamSimpleEvent(&bind.StreamOpts{BatchSize: 1}, ch, []common.Address{{1}}, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to stream simple events: %v", err)
+			}
+			defer stream.Unsubscribe()
+
+			for _, want := range []uint64{11, 21, 31} {
+				select {
+				case event := <-ch:
+					if event.Value.Uint64() != want {
+						t.Errorf("streamed log content mismatch: have %v, want %d", event, want)
+					}
+				case <-time.After(250 * time.Millisecond):
+					t.Fatalf("streamed simple event %d didn't arrive", want)
+				}
+			}
+			if _, err := eventer.RaiseSimpleEvent(auth, common.Address{1}, [32]byte{1}, true, big.NewInt(41)); err != nil {
+				t.Fatalf("failed to raise streamed simple event: %v", err)
+			}
+			sim.Commit()
+
+			select {
+			case event := <-ch:
+				if event.Value.Uint64() != 41 {
+					t.Errorf("streamed log content mismatch: have %v, want 41", event)
+				}
+			case <-time.After(250 * time.Millisecond):
+				t.Fatalf("streamed simple event didn't arrive")
+			}
 		`,
 		nil,
 		nil,
@@ -2268,3 +2298,114 @@ public class Test {
 		}
 	}
 }
+
+// mobileFeatureABI is the interface of a contract exercising the mobile binding
+// features beyond plain calls: structs, overloaded methods and events.
+const mobileFeatureABI = `[
+	{"type":"function","name":"get","stateMutability":"view","inputs":[{"name":"id","type":"uint256"}],"outputs":[{"name":"","type":"tuple","internalType":"struct Features.Point","components":[{"name":"x","type":"uint256"},{"name":"y","type":"int8"}]}]},
+	{"type":"function","name":"get","stateMutability":"view","inputs":[{"name":"ids","type":"uint256[]"}],"outputs":[{"name":"points","type":"tuple[]","internalType":"struct Features.Point[]","components":[{"name":"x","type":"uint256"},{"name":"y","type":"int8"}]},{"name":"ok","type":"bool"}]},
+	{"type":"function","name":"put","stateMutability":"nonpayable","inputs":[{"name":"p","type":"tuple","internalType":"struct Features.Point","components":[{"name":"x","type":"uint256"},{"name":"y","type":"int8"}]}],"outputs":[]},
+	{"type":"event","name":"Moved","inputs":[{"name":"who","type":"address","indexed":true},{"name":"tag","type":"string","indexed":true},{"name":"p","type":"tuple","internalType":"struct Features.Point","indexed":false,"components":[{"name":"x","type":"uint256"},{"name":"y","type":"int8"}]}]},
+	{"type":"event","name":"Moved","inputs":[{"name":"who","type":"address","indexed":true}]}
+]`
+
+// Tests that the mobile bindings expose structs, overloaded methods and events.
+func TestMobileBindingFeatures(t *testing.T) {
+	var cases = []struct {
+		lang     Lang
+		expected []string
+	}{
+		{
+			LangJava,
+			[]string{
+				"public static class FeaturesPoint {",
+				"public static FeaturesPoint fromTuple(Interfaces fields) throws Exception {",
+				"public FeaturesPoint get(CallOpts opts, BigInt id) throws Exception {",
+				"public Get0Results get0(CallOpts opts, BigInts ids) throws Exception {",
+				"result.Points = FeaturesPoint.fromTuples(results.get(0).getTuples());",
+				"public Transaction put(TransactOpts opts, FeaturesPoint p) throws Exception {",
+				"arg0.setTuple(p.toTuple());",
+				"public List<Moved> filterMoved(FilterOpts opts, Addresses who, Hashes tag) throws Exception {",
+				"public Subscription watchMoved(WatchOpts opts, final MovedHandler handler, Addresses who, Hashes tag) throws Exception {",
+				"public Moved parseMoved(Log log) throws Exception {",
+				"event.tag = fields.get(1).getHash();",
+				"public List<Moved0> filterMoved0(FilterOpts opts, Addresses who) throws Exception {",
+			},
+		},
+	}
+	for i, c := range cases {
+		binding, err := Bind([]string{"Features"}, []string{mobileFeatureABI}, []string{""}, nil, "bindtest", c.lang, nil, nil)
+		if err != nil {
+			t.Fatalf("test %d: failed to generate binding: %v", i, err)
+		}
+		for _, want := range c.expected {
+			if !strings.Contains(binding, want) {
+				t.Errorf("test %d: binding missing %q", i, want)
+			}
+		}
+	}
+}
+
+// Tests that the java bindings generated for the test contracts compile against
+// the gomobile generated Java sources of the mobile package. The test is skipped
+// if the Java or gomobile toolchains are missing, unless TEST_JAVA_BINDINGS is
+// set as done by the CI builder providing them.
+func TestJavaBindingsCompile(t *testing.T) {
+	skip := t.Skip
+	if os.Getenv("TEST_JAVA_BINDINGS") != "" {
+		skip = t.Fatal
+	}
+	javac, err := exec.LookPath("javac")
+	if err != nil {
+		skip("javac not found for testing")
+	}
+	gobind, err := exec.LookPath("gobind")
+	if err != nil {
+		skip("gobind not found for testing")
+	}
+	ws, err := ioutil.TempDir("", "java-binding-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary workspace: %v", err)
+	}
+	defer os.RemoveAll(ws)
+
+	// Generate the Java sources of the mobile package the bindings depend on
+	cmd := exec.Command(gobind, "-lang=java", "-javapkg=org.ethereum", "-outdir", ws, "github.com/ethereum/go-ethereum/mobile")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to generate mobile sources: %v\n%s", err, out)
+	}
+	// Generate the bindings of every test contract expressible in Java
+	pkg := filepath.Join(ws, "java", "bindtest")
+	if err := os.MkdirAll(pkg, 0700); err != nil {
+		t.Fatalf("failed to create package: %v", err)
+	}
+	write := func(name, abi, bytecode string) {
+		binding, err := Bind([]string{name}, []string{abi}, []string{bytecode}, nil, "bindtest", LangJava, nil, nil)
+		if err != nil {
+			t.Logf("skipping %s: %v", name, err)
+			return
+		}
+		if err := ioutil.WriteFile(filepath.Join(pkg, name+".java"), []byte(binding), 0600); err != nil {
+			t.Fatalf("failed to write binding %s: %v", name, err)
+		}
+	}
+	for _, tt := range bindTests {
+		if len(tt.abi) == 1 && tt.types == nil {
+			write(capitalise(tt.name), tt.abi[0], tt.bytecode[0])
+		}
+	}
+	write("Features", mobileFeatureABI, "")
+
+	// Compile all the sources together
+	var sources []string
+	filepath.Walk(filepath.Join(ws, "java"), func(path string, info os.FileInfo, err error) error {
+		if err == nil && strings.HasSuffix(path, ".java") {
+			sources = append(sources, path)
+		}
+		return nil
+	})
+	cmd = exec.Command(javac, append([]string{"-d", filepath.Join(ws, "classes")}, sources...)...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to compile java bindings: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// multicallABI is the interface of the tryAggregate method of the widely deployed
+// Multicall2 and Multicall3 aggregator contracts.
+const multicallABI = `[{"inputs":[{"internalType":"bool","name":"requireSuccess","type":"bool"},{"components":[{"internalType":"address","name":"target","type":"address"},{"internalType":"bytes","name":"callData","type":"bytes"}],"internalType":"struct Multicall3.Call[]","name":"calls","type":"tuple[]"}],"name":"tryAggregate","outputs":[{"components":[{"internalType":"bool","name":"success","type":"bool"},{"internalType":"bytes","name":"returnData","type":"bytes"}],"internalType":"struct Multicall3.Result[]","name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+// Multicall3Address is the address of the Multicall3 aggregator contract, which
+// is deployed at the same address on most networks.
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// ErrCallReverted is returned for a call executed through an aggregator contract
+// which reverted.
+var ErrCallReverted = errors.New("execution reverted")
+
+// multicallCall is an input of the tryAggregate method.
+type multicallCall struct {
+	Target   common.Address
+	CallData []byte
+}
+
+// multicallResult is an output of the tryAggregate method.
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall is a BatchContractCaller executing batches of calls as a single call
+// to an on-chain aggregator contract. As the calls are made by the aggregator,
+// their gas limit is not honoured. Calls setting a sender, value or gas price
+// are executed individually instead, the aggregator would silently drop them.
+type Multicall struct {
+	caller  ContractCaller
+	address common.Address
+	abi     abi.ABI
+}
+
+// NewMulticall creates a batch caller aggregating calls through the Multicall2
+// compatible contract deployed at the given address.
+func NewMulticall(caller ContractCaller, address common.Address) *Multicall {
+	parsed, err := abi.JSON(strings.NewReader(multicallABI))
+	if err != nil {
+		panic(err)
+	}
+	return &Multicall{caller: caller, address: address, abi: parsed}
+}
+
+// BatchCallContract implements BatchContractCaller, executing all plain calls
+// within a single call to the aggregator contract.
+func (m *Multicall) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	var (
+		outputs = make([][]byte, len(calls))
+		errs    = make([]error, len(calls))
+
+		inputs  []multicallCall
+		indices []int
+	)
+	for i, call := range calls {
+		if call.To == nil {
+			return nil, nil, errors.New("multicall: contract creation not supported")
+		}
+		if call.From != (common.Address{}) || isNonZero(call.Value) || isNonZero(call.GasPrice) {
+			outputs[i], errs[i] = m.caller.CallContract(ctx, call, blockNumber)
+			continue
+		}
+		inputs = append(inputs, multicallCall{Target: *call.To, CallData: call.Data})
+		indices = append(indices, i)
+	}
+	if len(inputs) == 0 {
+		return outputs, errs, nil
+	}
+	input, err := m.abi.Pack("tryAggregate", false, inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	output, err := m.caller.CallContract(ctx, ethereum.CallMsg{To: &m.address, Data: input}, blockNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(output) == 0 {
+		return nil, nil, ErrNoCode
+	}
+	unpacked, err := m.abi.Unpack("tryAggregate", output)
+	if err != nil {
+		return nil, nil, err
+	}
+	results := *abi.ConvertType(unpacked[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(inputs) {
+		return nil, nil, errors.New("multicall: result count mismatch")
+	}
+	for i, result := range results {
+		if result.Success {
+			outputs[indices[i]] = result.ReturnData
+		} else {
+			errs[indices[i]] = ErrCallReverted
+		}
+	}
+	return outputs, errs, nil
+}
+
+// isNonZero reports whether a call parameter is set to a non-zero value.
+func isNonZero(x *big.Int) bool {
+	return x != nil && x.Sign() != 0
+}
+
+// BatchCallerConfig is the collection of options to fine tune call batching.
+type BatchCallerConfig struct {
+	MaxBatch int           // Maximum number of calls in a single batch (0 = 100)
+	Wait     time.Duration // Time to wait for more calls before dispatching a batch (0 = 10ms)
+}
+
+// BatchCaller is a ContractCaller which collects the calls made concurrently at
+// the same block into batches, dispatching each batch in a single round trip.
+// It can be used to construct any bound contract in place of a regular caller,
+// so that reading from many goroutines batches the calls without changes to the
+// call sites.
+//
+// Batches are executed detached from the contexts of their calls, so that one
+// caller giving up doesn't fail the calls batched with it. A cancelled call only
+// stops waiting for its result.
+type BatchCaller struct {
+	caller  ContractCaller      // Caller to retrieve contract code with
+	batcher BatchContractCaller // Caller to execute batches of calls with
+	config  BatchCallerConfig
+
+	pending map[string]*callBatch // Batches collecting calls, keyed by block
+	lock    sync.Mutex
+}
+
+// callBatch is a set of calls to be dispatched together.
+type callBatch struct {
+	blockNumber *big.Int
+	calls       []ethereum.CallMsg
+	outputs     [][]byte
+	errs        []error
+	err         error
+	done        chan struct{}
+}
+
+// NewBatchCaller creates a contract caller batching the calls made through it
+// using batcher, falling back to caller for everything else.
+func NewBatchCaller(caller ContractCaller, batcher BatchContractCaller, config BatchCallerConfig) *BatchCaller {
+	if config.MaxBatch <= 0 {
+		config.MaxBatch = 100
+	}
+	if config.Wait <= 0 {
+		config.Wait = 10 * time.Millisecond
+	}
+	return &BatchCaller{
+		caller:  caller,
+		batcher: batcher,
+		config:  config,
+		pending: make(map[string]*callBatch),
+	}
+}
+
+// CodeAt implements ContractCaller, retrieving the code without batching.
+func (bc *BatchCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return bc.caller.CodeAt(ctx, contract, blockNumber)
+}
+
+// CallContract implements ContractCaller, adding the call to the batch of the
+// requested block and waiting for the batch to be executed.
+func (bc *BatchCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	key := "latest"
+	if blockNumber != nil {
+		key = blockNumber.String()
+	}
+	bc.lock.Lock()
+	batch, ok := bc.pending[key]
+	if !ok {
+		batch = &callBatch{blockNumber: blockNumber, done: make(chan struct{})}
+		bc.pending[key] = batch
+		time.AfterFunc(bc.config.Wait, func() { bc.dispatch(key, batch) })
+	}
+	index := len(batch.calls)
+	batch.calls = append(batch.calls, call)
+	full := len(batch.calls) >= bc.config.MaxBatch
+	bc.lock.Unlock()
+
+	if full {
+		bc.dispatch(key, batch)
+	}
+	select {
+	case <-batch.done:
+		if batch.err != nil {
+			return nil, batch.err
+		}
+		return batch.outputs[index], batch.errs[index]
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// dispatch executes a batch unless it was already dispatched.
+func (bc *BatchCaller) dispatch(key string, batch *callBatch) {
+	bc.lock.Lock()
+	if bc.pending[key] != batch {
+		bc.lock.Unlock()
+		return
+	}
+	delete(bc.pending, key)
+	bc.lock.Unlock()
+
+	batch.outputs, batch.errs, batch.err = bc.batcher.BatchCallContract(context.Background(), batch.calls, batch.blockNumber)
+	if batch.err == nil && (len(batch.outputs) != len(batch.calls) || len(batch.errs) != len(batch.calls)) {
+		batch.err = errors.New("batch result count mismatch")
+	}
+	close(batch.done)
+}
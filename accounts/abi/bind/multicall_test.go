@@ -0,0 +1,242 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const doublerABI = `[{"inputs":[{"name":"x","type":"uint256"}],"name":"double","outputs":[{"name":"","type":"uint256"}],"stateMutability":"view","type":"function"}]`
+
+const tryAggregateABI = `[{"inputs":[{"name":"requireSuccess","type":"bool"},{"components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}],"name":"calls","type":"tuple[]"}],"name":"tryAggregate","outputs":[{"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}],"name":"returnData","type":"tuple[]"}],"stateMutability":"payable","type":"function"}]`
+
+var errOddInput = errors.New("odd input")
+
+// doubleCall executes a call to the double method, failing for odd inputs.
+func doubleCall(parsed abi.ABI, input []byte) ([]byte, error) {
+	args, err := parsed.Methods["double"].Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, err
+	}
+	x := args[0].(*big.Int)
+	if x.Bit(0) == 1 {
+		return nil, errOddInput
+	}
+	return parsed.Methods["double"].Outputs.Pack(new(big.Int).Lsh(x, 1))
+}
+
+// mockBatcher is a BatchContractCaller executing calls to the double method.
+type mockBatcher struct {
+	abi     abi.ABI
+	lock    sync.Mutex
+	batches [][]ethereum.CallMsg
+}
+
+func (mb *mockBatcher) BatchCallContract(ctx context.Context, calls []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	mb.lock.Lock()
+	mb.batches = append(mb.batches, calls)
+	mb.lock.Unlock()
+
+	outputs, errs := make([][]byte, len(calls)), make([]error, len(calls))
+	for i, call := range calls {
+		outputs[i], errs[i] = doubleCall(mb.abi, call.Data)
+	}
+	return outputs, errs, nil
+}
+
+func TestBatchCaller(t *testing.T) {
+	parsed, _ := abi.JSON(strings.NewReader(doublerABI))
+	batcher := &mockBatcher{abi: parsed}
+	caller := bind.NewBatchCaller(&mockCaller{}, batcher, bind.BatchCallerConfig{MaxBatch: 8, Wait: 100 * time.Millisecond})
+
+	contract := bind.NewBoundContract(common.Address{1}, parsed, caller, nil, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var out []interface{}
+			err := contract.Call(nil, &out, "double", big.NewInt(int64(i)))
+			if i%2 == 1 {
+				if err != errOddInput {
+					t.Errorf("call %d: error mismatch: have %v, want %v", i, err, errOddInput)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("call %d: failed: %v", i, err)
+				return
+			}
+			if have := out[0].(*big.Int); have.Int64() != int64(2*i) {
+				t.Errorf("call %d: result mismatch: have %v, want %d", i, have, 2*i)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(batcher.batches) != 2 {
+		t.Fatalf("batch count mismatch: have %d, want 2", len(batcher.batches))
+	}
+	for i, batch := range batcher.batches {
+		if len(batch) != 8 {
+			t.Errorf("batch %d: size mismatch: have %d, want 8", i, len(batch))
+		}
+	}
+}
+
+// Tests that a batch is executed even if the call it was started with gave up.
+func TestBatchCallerCancel(t *testing.T) {
+	parsed, _ := abi.JSON(strings.NewReader(doublerABI))
+	batcher := &mockBatcher{abi: parsed}
+	caller := bind.NewBatchCaller(&mockCaller{}, batcher, bind.BatchCallerConfig{Wait: 100 * time.Millisecond})
+
+	contract := bind.NewBoundContract(common.Address{1}, parsed, caller, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out []interface{}
+	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "double", big.NewInt(1)); err != context.Canceled {
+		t.Fatalf("error mismatch: have %v, want %v", err, context.Canceled)
+	}
+	if err := contract.Call(nil, &out, "double", big.NewInt(2)); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if have := out[0].(*big.Int); have.Int64() != 4 {
+		t.Errorf("result mismatch: have %v, want 4", have)
+	}
+	if len(batcher.batches) != 1 || len(batcher.batches[0]) != 2 {
+		t.Errorf("calls not batched together: %v", batcher.batches)
+	}
+}
+
+// mockAggregator is a ContractCaller executing tryAggregate calls to the double
+// method, along with direct calls to the doubler contract.
+type mockAggregator struct {
+	mockCaller
+	abi        abi.ABI
+	doubler    abi.ABI
+	aggregator common.Address
+	direct     []ethereum.CallMsg
+}
+
+func (ma *mockAggregator) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if *call.To == (common.Address{1}) {
+		ma.direct = append(ma.direct, call)
+		return doubleCall(ma.doubler, call.Data)
+	}
+	if *call.To != ma.aggregator {
+		return nil, nil
+	}
+	args, err := ma.abi.Methods["tryAggregate"].Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[1], new([]struct {
+		Target   common.Address
+		CallData []byte
+	})).(*[]struct {
+		Target   common.Address
+		CallData []byte
+	})
+
+	type result struct {
+		Success    bool
+		ReturnData []byte
+	}
+	results := make([]result, len(calls))
+	for i, call := range calls {
+		output, err := doubleCall(ma.doubler, call.CallData)
+		results[i] = result{Success: err == nil, ReturnData: output}
+	}
+	return ma.abi.Methods["tryAggregate"].Outputs.Pack(results)
+}
+
+func TestMulticall(t *testing.T) {
+	doubler, _ := abi.JSON(strings.NewReader(doublerABI))
+	aggregator, _ := abi.JSON(strings.NewReader(tryAggregateABI))
+
+	backend := &mockAggregator{abi: aggregator, doubler: doubler, aggregator: bind.Multicall3Address}
+	multicall := bind.NewMulticall(backend, bind.Multicall3Address)
+
+	var calls []ethereum.CallMsg
+	for i := 0; i < 4; i++ {
+		input, _ := doubler.Pack("double", big.NewInt(int64(i)))
+		calls = append(calls, ethereum.CallMsg{To: &common.Address{1}, Data: input})
+	}
+	outputs, errs, err := multicall.BatchCallContract(context.Background(), calls, nil)
+	if err != nil {
+		t.Fatalf("failed to execute batch: %v", err)
+	}
+	for i := range calls {
+		if i%2 == 1 {
+			if errs[i] != bind.ErrCallReverted {
+				t.Errorf("call %d: error mismatch: have %v, want %v", i, errs[i], bind.ErrCallReverted)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("call %d: failed: %v", i, errs[i])
+			continue
+		}
+		if have := new(big.Int).SetBytes(outputs[i]); have.Int64() != int64(2*i) {
+			t.Errorf("call %d: result mismatch: have %v, want %d", i, have, 2*i)
+		}
+	}
+	if len(backend.direct) != 0 {
+		t.Fatalf("plain calls executed outside the aggregator: %d", len(backend.direct))
+	}
+	// Ensure calls the aggregator can't honour are executed individually
+	calls[0].From = common.Address{3}
+	calls[1].Value = big.NewInt(1)
+	outputs, errs, err = multicall.BatchCallContract(context.Background(), calls, nil)
+	if err != nil {
+		t.Fatalf("failed to execute batch: %v", err)
+	}
+	if len(backend.direct) != 2 || backend.direct[0].From != calls[0].From || backend.direct[1].Value != calls[1].Value {
+		t.Fatalf("calls not executed individually: %v", backend.direct)
+	}
+	if have := new(big.Int).SetBytes(outputs[0]); errs[0] != nil || have.Sign() != 0 {
+		t.Errorf("call 0: result mismatch: have %v (%v), want 0", have, errs[0])
+	}
+	if errs[1] != errOddInput {
+		t.Errorf("call 1: error mismatch: have %v, want %v", errs[1], errOddInput)
+	}
+	if have := new(big.Int).SetBytes(outputs[2]); errs[2] != nil || have.Int64() != 4 {
+		t.Errorf("call 2: result mismatch: have %v (%v), want 4", have, errs[2])
+	}
+	// Ensure a missing aggregator contract is reported
+	multicall = bind.NewMulticall(backend, common.Address{2})
+	if _, _, err := multicall.BatchCallContract(context.Background(), calls, nil); err != bind.ErrNoCode {
+		t.Fatalf("error mismatch: have %v, want %v", err, bind.ErrNoCode)
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// defaultStreamBatch is the number of blocks filtered per request while a log
+// stream is catching up with the chain history.
+const defaultStreamBatch = 2000
+
+// streamReorgDepth is the number of blocks before the chain head at subscription
+// time for which history logs are tracked, to detect their removal and their
+// duplicate delivery by the live subscription.
+const streamReorgDepth = 64
+
+// StreamOpts is the collection of options to fine tune streaming the events of a
+// bound contract, first from the chain history and then live.
+type StreamOpts struct {
+	Start     uint64          // Block to start streaming from
+	BatchSize uint64          // Number of blocks to filter per history request (0 = 2000)
+	Context   context.Context // Network context to support cancellation and timeouts (nil = no timeout)
+}
+
+// logKey identifies a log across reorgs.
+type logKey struct {
+	block common.Hash
+	index uint
+}
+
+// StreamLogs streams the logs of an event, starting at opts.Start. The chain
+// history is filtered in ranges of at most opts.BatchSize blocks until the
+// stream caught up with the chain head, after which new logs are delivered as
+// they arrive. Logs undone by reorgs are delivered again with Removed set.
+//
+// The live subscription is set up before reading the history, so no logs are
+// missed in between. A stream can be resumed after a failure by starting a new
+// one at the block following the last fully processed one.
+func (c *BoundContract) StreamLogs(opts *StreamOpts, name string, query ...[]interface{}) (chan types.Log, event.Subscription, error) {
+	// Don't crash on a lazy user
+	if opts == nil {
+		opts = new(StreamOpts)
+	}
+	reader, ok := c.filterer.(ChainHeadReader)
+	if !ok {
+		return nil, nil, ErrNoChainHead
+	}
+	// Append the event selector to the query parameters and construct the topic set
+	query = append([][]interface{}{{c.abi.Events[name].ID}}, query...)
+
+	topics, err := abi.MakeTopics(query...)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Subscribe to new logs before looking at the chain head
+	ctx := ensureContext(opts.Context)
+	live := make(chan types.Log, 128)
+	liveSub, err := c.filterer.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{c.address},
+		Topics:    topics,
+	}, live)
+	if err != nil {
+		return nil, nil, err
+	}
+	head, err := reader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		liveSub.Unsubscribe()
+		return nil, nil, err
+	}
+	stream := &logStream{
+		ctx:      ctx,
+		filterer: c.filterer,
+		reader:   reader,
+		query:    ethereum.FilterQuery{Addresses: []common.Address{c.address}, Topics: topics},
+		start:    opts.Start,
+		batch:    opts.BatchSize,
+		synced:   head.Number.Uint64(),
+		live:     live,
+		liveSub:  liveSub,
+		logs:     make(chan types.Log, 128),
+		seen:     make(map[logKey]struct{}),
+	}
+	if stream.batch == 0 {
+		stream.batch = defaultStreamBatch
+	}
+	sub := event.NewSubscription(func(quit <-chan struct{}) error {
+		defer liveSub.Unsubscribe()
+		return stream.run(quit)
+	})
+	return stream.logs, sub, nil
+}
+
+// logStream is the state of a running StreamLogs operation.
+type logStream struct {
+	ctx      context.Context
+	filterer ContractFilterer
+	reader   ChainHeadReader
+	query    ethereum.FilterQuery
+
+	start  uint64 // First block of the stream
+	next   uint64 // First block not yet read from the history
+	batch  uint64 // Maximum number of blocks per history request
+	synced uint64 // Chain head when the live subscription was created
+
+	live    chan types.Log
+	liveSub ethereum.Subscription
+	queued  []types.Log // Live logs received while delivering earlier ones
+	logs    chan types.Log
+
+	// seen tracks the delivered logs of the recent blocks read from the history,
+	// which the live subscription may deliver again or report as removed.
+	seen map[logKey]struct{}
+}
+
+// run reads the history until it caught up with the chain head and then
+// forwards the live logs.
+func (s *logStream) run(quit <-chan struct{}) error {
+	s.next = s.start
+	for {
+		head, err := s.reader.HeaderByNumber(s.ctx, nil)
+		if err != nil {
+			return err
+		}
+		if s.next > head.Number.Uint64() {
+			break
+		}
+		for s.next <= head.Number.Uint64() {
+			end := s.next + s.batch - 1
+			if end > head.Number.Uint64() {
+				end = head.Number.Uint64()
+			}
+			s.query.FromBlock, s.query.ToBlock = new(big.Int).SetUint64(s.next), new(big.Int).SetUint64(end)
+			logs, err := s.filterer.FilterLogs(s.ctx, s.query)
+			if err != nil {
+				return err
+			}
+			for _, log := range logs {
+				if log.BlockNumber+streamReorgDepth > s.synced {
+					s.seen[logKey{log.BlockHash, log.Index}] = struct{}{}
+				}
+				if !s.deliver(log, quit) {
+					return nil
+				}
+			}
+			s.next = end + 1
+		}
+	}
+	// Caught up with the chain, switch over to the live logs, starting with the
+	// ones queued up while delivering earlier logs
+	for {
+		if len(s.queued) > 0 {
+			log := s.queued[0]
+			s.queued = s.queued[1:]
+			if s.forward(log) && !s.deliver(log, quit) {
+				return nil
+			}
+			continue
+		}
+		select {
+		case log := <-s.live:
+			if s.forward(log) && !s.deliver(log, quit) {
+				return nil
+			}
+		case err := <-s.liveSub.Err():
+			return err
+		case <-quit:
+			return nil
+		}
+	}
+}
+
+// deliver sends a log to the stream, queueing live logs in the meantime so the
+// subscription never blocks. It returns false if the stream was closed.
+func (s *logStream) deliver(log types.Log, quit <-chan struct{}) bool {
+	for {
+		select {
+		case s.logs <- log:
+			return true
+		case live := <-s.live:
+			s.queued = append(s.queued, live)
+		case <-quit:
+			return false
+		}
+	}
+}
+
+// forward reports whether a live log has to be delivered, filtering out the
+// ones already read from the history.
+func (s *logStream) forward(log types.Log) bool {
+	if log.BlockNumber >= s.next {
+		return true
+	}
+	key := logKey{log.BlockHash, log.Index}
+	_, seen := s.seen[key]
+	if log.Removed {
+		delete(s.seen, key)
+		return seen
+	}
+	s.seen[key] = struct{}{}
+	return !seen
+}
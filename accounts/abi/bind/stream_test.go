@@ -0,0 +1,131 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// mockStreamFilterer is a log filterer with a fixed history and a live feed
+// driven by the test.
+type mockStreamFilterer struct {
+	head    uint64
+	history []types.Log
+	ranges  [][2]uint64
+	live    event.Feed
+}
+
+func (mf *mockStreamFilterer) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := query.FromBlock.Uint64(), query.ToBlock.Uint64()
+	mf.ranges = append(mf.ranges, [2]uint64{from, to})
+
+	var logs []types.Log
+	for _, log := range mf.history {
+		if log.BlockNumber >= from && log.BlockNumber <= to {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (mf *mockStreamFilterer) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return mf.live.Subscribe(ch), nil
+}
+
+func (mf *mockStreamFilterer) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(mf.head)}, nil
+}
+
+func TestStreamLogs(t *testing.T) {
+	parsed, _ := abi.JSON(strings.NewReader(`[{"anonymous":false,"inputs":[],"name":"ping","type":"event"}]`))
+
+	newLog := func(number uint64, hash common.Hash, index uint, removed bool) types.Log {
+		return types.Log{BlockNumber: number, BlockHash: hash, Index: index, Removed: removed}
+	}
+	var (
+		hashA = common.Hash{0xa}
+		hashB = common.Hash{0xb}
+		hashC = common.Hash{0xc}
+	)
+	mf := &mockStreamFilterer{
+		head: 10,
+		history: []types.Log{
+			newLog(2, common.Hash{2}, 0, false),
+			newLog(5, common.Hash{5}, 0, false),
+			newLog(9, hashA, 0, false),
+		},
+	}
+	bc := bind.NewBoundContract(common.Address{}, parsed, nil, nil, mf)
+
+	logs, sub, err := bc.StreamLogs(&bind.StreamOpts{Start: 3, BatchSize: 3}, "ping")
+	if err != nil {
+		t.Fatalf("failed to stream logs: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Feed a duplicate of a history log, a reorg and a new block to the stream
+	mf.live.Send(newLog(9, hashA, 0, false))
+	mf.live.Send(newLog(9, hashA, 0, true))
+	mf.live.Send(newLog(9, hashB, 0, false))
+	mf.live.Send(newLog(11, hashC, 0, false))
+
+	want := []types.Log{
+		newLog(5, common.Hash{5}, 0, false),
+		newLog(9, hashA, 0, false),
+		newLog(9, hashA, 0, true),
+		newLog(9, hashB, 0, false),
+		newLog(11, hashC, 0, false),
+	}
+	for i, wantLog := range want {
+		select {
+		case log := <-logs:
+			if !reflect.DeepEqual(log, wantLog) {
+				t.Errorf("log %d mismatch: have %+v, want %+v", i, log, wantLog)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("log %d didn't arrive", i)
+		}
+	}
+	select {
+	case log := <-logs:
+		t.Errorf("unexpected log: %+v", log)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if wantRanges := [][2]uint64{{3, 5}, {6, 8}, {9, 10}}; !reflect.DeepEqual(mf.ranges, wantRanges) {
+		t.Errorf("filtered ranges mismatch: have %v, want %v", mf.ranges, wantRanges)
+	}
+}
+
+func TestStreamLogsNoChainHead(t *testing.T) {
+	filterer := struct{ bind.ContractFilterer }{} // no HeaderByNumber
+	bc := bind.NewBoundContract(common.Address{}, abi.ABI{}, nil, nil, filterer)
+	if _, _, err := bc.StreamLogs(nil, "ping"); err != bind.ErrNoChainHead {
+		t.Fatalf("error mismatch: have %v, want %v", err, bind.ErrNoChainHead)
+	}
+}
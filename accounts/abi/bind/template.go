@@ -536,6 +536,49 @@ var (
 			}), nil
 		}
 
+		// Stream{{.Normalized.Name}} is a resumable log subscription operation binding the contract event 0x{{printf "%x" .Original.ID}},
+		// delivering the historical events from opts.Start before following the chain head.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}Filterer) Stream{{.Normalized.Name}}(opts *bind.StreamOpts, sink chan<- *{{$contract.Type}}{{.Normalized.Name}}{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}} []{{bindtype .Type $structs}}{{end}}{{end}}) (event.Subscription, error) {
+			{{range .Normalized.Inputs}}
+			{{if .Indexed}}var {{.Name}}Rule []interface{}
+			for _, {{.Name}}Item := range {{.Name}} {
+				{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+			}{{end}}{{end}}
+
+			logs, sub, err := _{{$contract.Type}}.contract.StreamLogs(opts, "{{.Original.Name}}"{{range .Normalized.Inputs}}{{if .Indexed}}, {{.Name}}Rule{{end}}{{end}})
+			if err != nil {
+				return nil, err
+			}
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				defer sub.Unsubscribe()
+				for {
+					select {
+					case log := <-logs:
+						// New log arrived, parse the event and forward to the user
+						event := new({{$contract.Type}}{{.Normalized.Name}})
+						if err := _{{$contract.Type}}.contract.UnpackLog(event, "{{.Original.Name}}", log); err != nil {
+							return err
+						}
+						event.Raw = log
+
+						select {
+						case sink <- event:
+						case err := <-sub.Err():
+							return err
+						case <-quit:
+							return nil
+						}
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			}), nil
+		}
+
 		// Parse{{.Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.ID}}.
 		//
 		// Solidity: {{.Original.String}}
@@ -594,7 +637,7 @@ import java.util.*;
 		bytecode = bytecode.replace("__${{$pattern}}$__", {{decapitalise $name}}Inst.Address.getHex().substring(2));
 		{{end}}
 		{{end}}
-		{{range $index, $element := .Constructor.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
+		{{range $index, $element := .Constructor.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.{{ifaceset .Type $structs .Name}};args.set({{$index}},arg{{$index}});
 		{{end}}
 		return new {{.Type}}(Geth.deployContract(auth, ABI, Geth.decodeFromHex(bytecode), client, args));
 	}
@@ -621,6 +664,48 @@ import java.util.*;
 		this(Geth.bindContract(address, ABI, client));
 	}
 
+	{{range $structs}}
+	// {{.Name}} is an auto generated low-level Java binding around an user-defined struct.
+	public static class {{.Name}} {
+		{{range .Fields}}public {{.Type}} {{.Name}};
+		{{end}}
+
+		// toTuple converts the struct into the list of its fields.
+		public Interfaces toTuple() throws Exception {
+			Interfaces fields = Geth.newInterfaces({{len .Fields}});
+			{{range $index, $field := .Fields}}Interface field{{$index}} = Geth.newInterface();field{{$index}}.{{ifaceset .SolKind $structs (printf "this.%s" .Name)}};fields.set({{$index}},field{{$index}});
+			{{end}}
+			return fields;
+		}
+
+		// fromTuple converts the list of fields of a tuple into the struct.
+		public static {{.Name}} fromTuple(Interfaces fields) throws Exception {
+			{{.Name}} result = new {{.Name}}();
+			{{range $index, $field := .Fields}}result.{{.Name}} = {{ifaceget .SolKind $structs (printf "fields.get(%d)" $index)}};
+			{{end}}
+			return result;
+		}
+
+		// toTuples converts a list of structs into a list of tuples.
+		public static Interfaces toTuples({{.Name}}[] items) throws Exception {
+			Interfaces tuples = Geth.newInterfaces(items.length);
+			for (int i = 0; i < items.length; i++) {
+				Interface item = Geth.newInterface();item.setTuple(items[i].toTuple());tuples.set(i,item);
+			}
+			return tuples;
+		}
+
+		// fromTuples converts a list of tuples into a list of structs.
+		public static {{.Name}}[] fromTuples(Interfaces tuples) throws Exception {
+			{{.Name}}[] items = new {{.Name}}[(int)tuples.size()];
+			for (int i = 0; i < items.length; i++) {
+				items[i] = fromTuple(tuples.get(i).getTuple());
+			}
+			return items;
+		}
+	}
+	{{end}}
+
 	{{range .Calls}}
 	{{if gt (len .Normalized.Outputs) 1}}
 	// {{capitalise .Normalized.Name}}Results is the output of a call to {{.Normalized.Name}}.
@@ -635,11 +720,11 @@ import java.util.*;
 	// Solidity: {{.Original.String}}
 	public {{if gt (len .Normalized.Outputs) 1}}{{capitalise .Normalized.Name}}Results{{else if eq (len .Normalized.Outputs) 0}}void{{else}}{{range .Normalized.Outputs}}{{bindtype .Type $structs}}{{end}}{{end}} {{.Normalized.Name}}(CallOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) throws Exception {
 		Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
+		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.{{ifaceset .Type $structs .Name}};args.set({{$index}},arg{{$index}});
 		{{end}}
 
 		Interfaces results = Geth.newInterfaces({{(len .Normalized.Outputs)}});
-		{{range $index, $item := .Normalized.Outputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.setDefault{{namedtype (bindtype .Type $structs) .Type}}(); results.set({{$index}}, result{{$index}});
+		{{range $index, $item := .Normalized.Outputs}}Interface result{{$index}} = Geth.newInterface(); result{{$index}}.{{ifacedefault .Type $structs}}; results.set({{$index}}, result{{$index}});
 		{{end}}
 
 		if (opts == null) {
@@ -648,10 +733,10 @@ import java.util.*;
 		this.Contract.call(opts, results, "{{.Original.Name}}", args);
 		{{if gt (len .Normalized.Outputs) 1}}
 			{{capitalise .Normalized.Name}}Results result = new {{capitalise .Normalized.Name}}Results();
-			{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = results.get({{$index}}).get{{namedtype (bindtype .Type $structs) .Type}}();
+			{{range $index, $item := .Normalized.Outputs}}result.{{if ne .Name ""}}{{.Name}}{{else}}Return{{$index}}{{end}} = {{ifaceget .Type $structs (printf "results.get(%d)" $index)}};
 			{{end}}
 			return result;
-		{{else}}{{range .Normalized.Outputs}}return results.get(0).get{{namedtype (bindtype .Type $structs) .Type}}();{{end}}
+		{{else}}{{range .Normalized.Outputs}}return {{ifaceget .Type $structs "results.get(0)"}};{{end}}
 		{{end}}
 	}
 	{{end}}
@@ -662,7 +747,7 @@ import java.util.*;
 	// Solidity: {{.Original.String}}
 	public Transaction {{.Normalized.Name}}(TransactOpts opts{{range .Normalized.Inputs}}, {{bindtype .Type $structs}} {{.Name}}{{end}}) throws Exception {
 		Interfaces args = Geth.newInterfaces({{(len .Normalized.Inputs)}});
-		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.set{{namedtype (bindtype .Type $structs) .Type}}({{.Name}});args.set({{$index}},arg{{$index}});
+		{{range $index, $item := .Normalized.Inputs}}Interface arg{{$index}} = Geth.newInterface();arg{{$index}}.{{ifaceset .Type $structs .Name}};args.set({{$index}},arg{{$index}});
 		{{end}}
 		return this.Contract.transact(opts, "{{.Original.Name}}"	, args);
 	}
@@ -685,6 +770,78 @@ import java.util.*;
 		return this.Contract.rawTransact(opts, null);
 	}
     {{end}}
+
+	{{range .Events}}
+	// {{capitalise .Normalized.Name}} represents a {{.Normalized.Name}} event raised by the {{$contract.Type}} contract.
+	public static class {{capitalise .Normalized.Name}} {
+		{{range .Normalized.Inputs}}public {{if .Indexed}}{{bindtopictype .Type $structs}}{{else}}{{bindtype .Type $structs}}{{end}} {{.Name}};
+		{{end}}public Log raw; // Blockchain specific contextual infos
+	}
+
+	// {{capitalise .Normalized.Name}}Handler is the callback of a {{.Normalized.Name}} event subscription.
+	public interface {{capitalise .Normalized.Name}}Handler {
+		void on{{capitalise .Normalized.Name}}({{capitalise .Normalized.Name}} event);
+		void onError(String failure);
+	}
+
+	// filter{{capitalise .Normalized.Name}} is a free log retrieval operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+	//
+	// Solidity: {{.Original.String}}
+	public List<{{capitalise .Normalized.Name}}> filter{{capitalise .Normalized.Name}}(FilterOpts opts{{range .Normalized.Inputs}}{{if .Indexed}}, {{bindtopictypes .Type $structs}} {{.Name}}{{end}}{{end}}) throws Exception {
+		{{$indexed := indexed .Normalized.Inputs}}Interfaces query = Geth.newInterfaces({{len $indexed}});
+		{{range $index, $input := $indexed}}Interface rule{{$index}} = Geth.newInterface();if ({{.Name}} != null) {rule{{$index}}.set{{bindtopictypes .Type $structs}}({{.Name}});}query.set({{$index}},rule{{$index}});
+		{{end}}
+
+		if (opts == null) {
+			opts = Geth.newFilterOpts();
+		}
+		Logs logs = this.Contract.filterLogs(opts, "{{.Original.Name}}", query);
+
+		List<{{capitalise .Normalized.Name}}> events = new ArrayList<{{capitalise .Normalized.Name}}>();
+		for (int i = 0; i < logs.size(); i++) {
+			events.add(parse{{capitalise .Normalized.Name}}(logs.get(i)));
+		}
+		return events;
+	}
+
+	// watch{{capitalise .Normalized.Name}} is a free log subscription operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+	//
+	// Solidity: {{.Original.String}}
+	public Subscription watch{{capitalise .Normalized.Name}}(WatchOpts opts, final {{capitalise .Normalized.Name}}Handler handler{{range .Normalized.Inputs}}{{if .Indexed}}, {{bindtopictypes .Type $structs}} {{.Name}}{{end}}{{end}}) throws Exception {
+		{{$indexed := indexed .Normalized.Inputs}}Interfaces query = Geth.newInterfaces({{len $indexed}});
+		{{range $index, $input := $indexed}}Interface rule{{$index}} = Geth.newInterface();if ({{.Name}} != null) {rule{{$index}}.set{{bindtopictypes .Type $structs}}({{.Name}});}query.set({{$index}},rule{{$index}});
+		{{end}}
+
+		if (opts == null) {
+			opts = Geth.newWatchOpts();
+		}
+		return this.Contract.watchLogs(opts, "{{.Original.Name}}", query, new FilterLogsHandler() {
+			@Override public void onFilterLogs(Log log) {
+				try {
+					handler.on{{capitalise .Normalized.Name}}(parse{{capitalise .Normalized.Name}}(log));
+				} catch (Exception e) {
+					handler.onError(e.getMessage());
+				}
+			}
+			@Override public void onError(String failure) {
+				handler.onError(failure);
+			}
+		});
+	}
+
+	// parse{{capitalise .Normalized.Name}} is a log parse operation binding the contract event 0x{{printf "%x" .Original.ID}}.
+	//
+	// Solidity: {{.Original.String}}
+	public {{capitalise .Normalized.Name}} parse{{capitalise .Normalized.Name}}(Log log) throws Exception {
+		Interfaces fields = Geth.newInterfaces({{len .Normalized.Inputs}});
+		this.Contract.unpackLog(fields, "{{.Original.Name}}", log);
+
+		{{capitalise .Normalized.Name}} event = new {{capitalise .Normalized.Name}}();
+		{{range $index, $input := .Normalized.Inputs}}event.{{.Name}} = {{if and .Indexed (hashedtopic .Type)}}fields.get({{$index}}).getHash(){{else}}{{ifaceget .Type $structs (printf "fields.get(%d)" $index)}}{{end}};
+		{{end}}event.raw = log;
+		return event;
+	}
+	{{end}}
 }
 {{end}}
 `
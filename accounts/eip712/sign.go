@@ -0,0 +1,84 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eip712
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// SigningPayload returns the bytes signed over by the typed data, namely
+// `"\x19\x01" ‖ domainSeparator ‖ hashStruct(message)`.
+func (typedData *TypedData) SigningPayload() ([]byte, error) {
+	domainSeparator, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	if err != nil {
+		return nil, err
+	}
+	typedDataHash, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("\x19\x01%s%s", string(domainSeparator), string(typedDataHash))), nil
+}
+
+// Hash returns the keccak256 hash of the signing payload, which is the digest
+// signed over by the typed data signatures.
+func (typedData *TypedData) Hash() (common.Hash, error) {
+	payload, err := typedData.SigningPayload()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(payload), nil
+}
+
+// Sign calculates an ECDSA signature of the typed data with the given key.
+//
+// The produced signature is in the [R || S || V] format where V is 0 or 1, the
+// same as produced by the account backends. Callers conforming to the legacy
+// eth_signTypedData format should add 27 to V.
+func Sign(typedData *TypedData, prv *ecdsa.PrivateKey) ([]byte, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sign(hash[:], prv)
+}
+
+// Recover returns the address of the account that signed the typed data. The
+// signature V value may be either 0/1 or the legacy 27/28.
+func Recover(typedData *TypedData, sig []byte) (common.Address, error) {
+	if len(sig) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("signature must be %d bytes long", crypto.SignatureLength)
+	}
+	hash, err := typedData.Hash()
+	if err != nil {
+		return common.Address{}, err
+	}
+	// Transform the legacy V from 27/28 to 0/1 without touching the caller's copy
+	sig = common.CopyBytes(sig)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
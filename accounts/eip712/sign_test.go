@@ -0,0 +1,68 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eip712_test
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/eip712"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestSigningHash(t *testing.T) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Signing hash of the example in the EIP-712 specification
+	if want := common.HexToHash("0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"); hash != want {
+		t.Errorf("signing hash mismatch: have %x, want %x", hash, want)
+	}
+}
+
+func TestSignRecover(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	sig, err := eip712.Sign(&typedData, key)
+	if err != nil {
+		t.Fatalf("failed to sign typed data: %v", err)
+	}
+	if have, err := eip712.Recover(&typedData, sig); err != nil || have != addr {
+		t.Errorf("recovered signer mismatch: have %x (%v), want %x", have, err, addr)
+	}
+	// Legacy signatures with V of 27/28 should also be accepted
+	legacy := common.CopyBytes(sig)
+	legacy[crypto.RecoveryIDOffset] += 27
+	if have, err := eip712.Recover(&typedData, legacy); err != nil || have != addr {
+		t.Errorf("recovered legacy signer mismatch: have %x (%v), want %x", have, err, addr)
+	}
+	if legacy[crypto.RecoveryIDOffset] != sig[crypto.RecoveryIDOffset]+27 {
+		t.Errorf("recovery modified the signature")
+	}
+	// Tampering with the message should change the signer
+	tampered := typedData
+	tampered.Message = map[string]interface{}{
+		"from":     typedData.Message["from"],
+		"to":       typedData.Message["to"],
+		"contents": "Hello, Alice!",
+	}
+	if have, err := eip712.Recover(&tampered, sig); err == nil && have == addr {
+		t.Errorf("tampered message recovered to the signer")
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// seedVersion is the version of the seed file format.
+const seedVersion = 1
+
+// seedFile is the on-disk representation of an HD wallet. The mnemonic is held
+// encrypted, whereas the pinned accounts are kept in plain text so they can be
+// listed without unlocking the wallet, the same as keystore addresses.
+type seedFile struct {
+	ID       string              `json:"id"`
+	Version  int                 `json:"version"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`
+	Accounts []pinnedAccount     `json:"accounts"`
+}
+
+// pinnedAccount is an account derived from the seed and tracked permanently.
+type pinnedAccount struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+// newSeedFile encrypts the mnemonic with the passphrase into a fresh seed file.
+func newSeedFile(mnemonic, passphrase string, scryptN, scryptP int) (*seedFile, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, ErrInvalidMnemonic
+	}
+	crypto, err := keystore.EncryptDataV3([]byte(mnemonic), []byte(passphrase), scryptN, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	return &seedFile{
+		ID:       uuid.New().String(),
+		Version:  seedVersion,
+		Crypto:   crypto,
+		Accounts: []pinnedAccount{},
+	}, nil
+}
+
+// masterKey decrypts the mnemonic and derives the root of the BIP-32 tree.
+func (f *seedFile) masterKey(passphrase string) (*extendedKey, error) {
+	mnemonic, err := keystore.DecryptDataV3(f.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := bip39.NewSeedWithErrorChecking(string(mnemonic), "")
+	if err != nil {
+		return nil, err
+	}
+	return newMasterKey(seed)
+}
+
+// paths parses the derivation paths of the pinned accounts.
+func (f *seedFile) paths() (map[common.Address]accounts.DerivationPath, error) {
+	paths := make(map[common.Address]accounts.DerivationPath, len(f.Accounts))
+	for _, account := range f.Accounts {
+		path, err := accounts.ParseDerivationPath(account.Path)
+		if err != nil {
+			return nil, fmt.Errorf("account %x: %v", account.Address, err)
+		}
+		paths[account.Address] = path
+	}
+	return paths, nil
+}
+
+// seedFileName returns the file name of a seed file, ordering them by creation
+// time the same way as keystore files.
+func seedFileName(id string) string {
+	return fmt.Sprintf("UTC--%s--%s.json", time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), id)
+}
+
+// loadSeedFile reads and parses a seed file from disk.
+func loadSeedFile(path string) (*seedFile, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := new(seedFile)
+	if err := json.Unmarshal(blob, file); err != nil {
+		return nil, err
+	}
+	if file.Version != seedVersion {
+		return nil, fmt.Errorf("unsupported seed file version %d", file.Version)
+	}
+	return file, nil
+}
+
+// store atomically writes the seed file to disk.
+func (f *seedFile) store(path string) error {
+	blob, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(blob); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	tmp.Close()
+	return os.Rename(tmp.Name(), path)
+}
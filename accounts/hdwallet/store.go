@@ -0,0 +1,245 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package hdwallet implements a software hierarchical deterministic wallet.
+//
+// The wallets are derived from a BIP-39 mnemonic, held encrypted in the keystore
+// directory, and their accounts are derived along BIP-32 paths the same way as
+// on hardware wallets.
+package hdwallet
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// Scheme is the URL scheme of the HD wallets.
+const Scheme = "hd"
+
+// StoreType is the reflect type of an HD wallet store backend.
+var StoreType = reflect.TypeOf(&Store{})
+
+// ErrInvalidMnemonic is returned if a mnemonic fails the BIP-39 checksum.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// refreshThrottling is the minimum time between seed directory scans to avoid
+// hitting the disk if the wallets are listed in a loop.
+const refreshThrottling = time.Second
+
+// refreshCycle is the time between seed directory scans while anyone is
+// subscribed to wallet events.
+const refreshCycle = 3 * time.Second
+
+// Store is an accounts.Backend managing the HD wallets held in the hd folder of
+// a keystore directory.
+type Store struct {
+	dir              string // Folder containing the encrypted seed files
+	scryptN, scryptP int    // Key derivation parameters of new seed files
+
+	wallets   []*wallet // List of HD wallets currently tracked
+	refreshed time.Time // Time instance when the list of wallets was last refreshed
+
+	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
+	updateScope event.SubscriptionScope // Subscription scope tracking current live listeners
+	updating    bool                    // Whether the event notification loop is running
+
+	lock sync.RWMutex
+}
+
+// NewStore creates a backend for the HD wallets stored within the given keystore
+// directory, encrypting new seeds with the given scrypt parameters.
+func NewStore(keydir string, scryptN, scryptP int) *Store {
+	store := &Store{
+		dir:     filepath.Join(keydir, "hd"),
+		scryptN: scryptN,
+		scryptP: scryptP,
+	}
+	store.refreshWallets(true)
+	return store
+}
+
+// NewWallet generates a fresh mnemonic and stores it as a new HD wallet encrypted
+// with the passphrase. The mnemonic is returned for backup purposes, it is not
+// possible to retrieve it later.
+func (s *Store) NewWallet(passphrase string) (accounts.Wallet, string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return nil, "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, "", err
+	}
+	wallet, err := s.Import(mnemonic, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return wallet, mnemonic, nil
+}
+
+// Import stores an existing mnemonic as a new HD wallet encrypted with the
+// passphrase.
+func (s *Store) Import(mnemonic, passphrase string) (accounts.Wallet, error) {
+	file, err := newSeedFile(strings.Join(strings.Fields(mnemonic), " "), passphrase, s.scryptN, s.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(s.dir, seedFileName(file.ID))
+	if err := file.store(path); err != nil {
+		return nil, err
+	}
+	s.refreshWallets(true)
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, wallet := range s.wallets {
+		if wallet.path == path {
+			return wallet, nil
+		}
+	}
+	return nil, accounts.ErrUnknownWallet
+}
+
+// Wallets implements accounts.Backend, returning all the currently tracked HD
+// wallets sorted by URL.
+func (s *Store) Wallets() []accounts.Wallet {
+	// Make sure the list of wallets is up to date
+	s.refreshWallets(false)
+
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	cpy := make([]accounts.Wallet, len(s.wallets))
+	for i, wallet := range s.wallets {
+		cpy[i] = wallet
+	}
+	return cpy
+}
+
+// refreshWallets scans the seed directory and updates the list of wallets based
+// on the found seed files.
+func (s *Store) refreshWallets(force bool) {
+	// Don't scan the disk like crazy it the user fetches wallets in a loop
+	s.lock.RLock()
+	elapsed := time.Since(s.refreshed)
+	s.lock.RUnlock()
+
+	if !force && elapsed < refreshThrottling {
+		return
+	}
+	// Retrieve the current list of seed files, a missing folder being empty
+	var paths []string
+	if files, err := ioutil.ReadDir(s.dir); err == nil {
+		for _, fi := range files {
+			if fi.IsDir() || strings.HasPrefix(fi.Name(), ".") || !strings.HasSuffix(fi.Name(), ".json") {
+				continue
+			}
+			paths = append(paths, filepath.Join(s.dir, fi.Name()))
+		}
+	}
+	sort.Strings(paths)
+
+	// Transform the current list of wallets into the new one
+	s.lock.Lock()
+
+	var (
+		wallets = make([]*wallet, 0, len(paths))
+		events  []accounts.WalletEvent
+	)
+	for _, path := range paths {
+		// Drop wallets in front of the next seed file
+		for len(s.wallets) > 0 && s.wallets[0].path < path {
+			events = append(events, accounts.WalletEvent{Wallet: s.wallets[0], Kind: accounts.WalletDropped})
+			s.wallets = s.wallets[1:]
+		}
+		// If the seed file is the same as the first wallet, keep it
+		if len(s.wallets) > 0 && s.wallets[0].path == path {
+			wallets = append(wallets, s.wallets[0])
+			s.wallets = s.wallets[1:]
+			continue
+		}
+		// Otherwise wrap a new wallet around the seed file
+		wallet, err := newWallet(s, path)
+		if err != nil {
+			log.Warn("Failed to load HD wallet", "path", path, "err", err)
+			continue
+		}
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletArrived})
+		wallets = append(wallets, wallet)
+	}
+	// Drop any leftover wallets and set the new batch
+	for _, wallet := range s.wallets {
+		events = append(events, accounts.WalletEvent{Wallet: wallet, Kind: accounts.WalletDropped})
+	}
+	s.refreshed = time.Now()
+	s.wallets = wallets
+	s.lock.Unlock()
+
+	// Fire all wallet events and return
+	for _, event := range events {
+		s.updateFeed.Send(event)
+	}
+}
+
+// Subscribe implements accounts.Backend, creating an async subscription to
+// receive notifications on the addition or removal of HD wallets.
+func (s *Store) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	// We need the mutex to reliably start/stop the update loop
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Subscribe the caller and track the subscriber count
+	sub := s.updateScope.Track(s.updateFeed.Subscribe(sink))
+
+	// Subscribers require an active notification loop, start it
+	if !s.updating {
+		s.updating = true
+		go s.updater()
+	}
+	return sub
+}
+
+// updater is responsible for maintaining an up-to-date list of wallets managed
+// by the store, and for firing wallet addition/removal events.
+func (s *Store) updater() {
+	for {
+		time.Sleep(refreshCycle)
+
+		// Run the wallet refresher
+		s.refreshWallets(false)
+
+		// If all our subscribers left, stop the updater
+		s.lock.Lock()
+		if s.updateScope.Count() == 0 {
+			s.updating = false
+			s.lock.Unlock()
+			return
+		}
+		s.lock.Unlock()
+	}
+}
@@ -0,0 +1,458 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// selfDeriveThrottling is the minimum time between account self-derivations to
+// avoid hammering the chain if the accounts are listed in a loop.
+const selfDeriveThrottling = time.Second
+
+// wallet implements accounts.Wallet for a single encrypted seed file.
+type wallet struct {
+	store *Store       // Store where the wallet originates from
+	path  string       // Path of the seed file backing the wallet
+	url   accounts.URL // Textual URL uniquely identifying this wallet
+	log   log.Logger   // Contextual logger to tag the wallet with its URL
+
+	file     *seedFile                                  // Seed file contents as last written to disk
+	master   *extendedKey                               // Root of the derivation tree, nil if locked
+	accounts []accounts.Account                         // List of derived accounts pinned or discovered
+	paths    map[common.Address]accounts.DerivationPath // Derivation paths of the tracked accounts
+
+	deriveNextPaths []accounts.DerivationPath // Next derivation paths for account auto-discovery
+	deriveChain     ethereum.ChainStateReader // Blockchain state reader to discover used account with
+	derived         time.Time                 // Time instance of the last account self-derivation
+
+	deriveLock sync.Mutex   // Serializes self-derivations, held before the state lock
+	stateLock  sync.RWMutex // Protects read and write access to the wallet struct fields
+}
+
+// newWallet loads the seed file at the given path and wraps a locked wallet
+// around it, tracking its pinned accounts.
+func newWallet(store *Store, path string) (*wallet, error) {
+	file, err := loadSeedFile(path)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := file.paths()
+	if err != nil {
+		return nil, err
+	}
+	w := &wallet{
+		store: store,
+		path:  path,
+		url:   accounts.URL{Scheme: Scheme, Path: path},
+		file:  file,
+		paths: paths,
+	}
+	w.log = log.New("url", w.url)
+	for _, account := range file.Accounts {
+		w.accounts = append(w.accounts, w.account(account.Address, paths[account.Address]))
+	}
+	return w, nil
+}
+
+// account assembles the account at the given derivation path.
+func (w *wallet) account(address common.Address, path accounts.DerivationPath) accounts.Account {
+	return accounts.Account{
+		Address: address,
+		URL:     accounts.URL{Scheme: w.url.Scheme, Path: fmt.Sprintf("%s/%s", w.url.Path, path)},
+	}
+}
+
+// URL implements accounts.Wallet, returning the URL of the seed file.
+func (w *wallet) URL() accounts.URL {
+	return w.url
+}
+
+// Status implements accounts.Wallet, returning whether the seed of the wallet
+// is decrypted or not.
+func (w *wallet) Status() (string, error) {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master != nil {
+		return "Unlocked", nil
+	}
+	return "Locked", nil
+}
+
+// Open implements accounts.Wallet, decrypting the seed of the wallet with the
+// passphrase and keeping it in memory until the wallet is closed.
+func (w *wallet) Open(passphrase string) error {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master != nil {
+		return accounts.ErrWalletAlreadyOpen
+	}
+	master, err := w.file.masterKey(passphrase)
+	if err != nil {
+		return err
+	}
+	w.master = master
+	return nil
+}
+
+// Close implements accounts.Wallet, wiping the decrypted seed from memory and
+// dropping any self-derived accounts that were not pinned.
+func (w *wallet) Close() error {
+	w.deriveLock.Lock()
+	defer w.deriveLock.Unlock()
+
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master == nil {
+		return nil
+	}
+	w.master.zero()
+	w.master = nil
+
+	paths, err := w.file.paths()
+	if err != nil {
+		return err
+	}
+	w.paths, w.accounts = paths, w.accounts[:0]
+	for _, account := range w.file.Accounts {
+		w.accounts = append(w.accounts, w.account(account.Address, paths[account.Address]))
+	}
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the list of accounts pinned to
+// the wallet. If self-derivation was enabled and the wallet is open, the list is
+// extended with the accounts found in use on the chain.
+func (w *wallet) Accounts() []accounts.Account {
+	w.selfDerive()
+
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	cpy := make([]accounts.Account, len(w.accounts))
+	copy(cpy, w.accounts)
+	return cpy
+}
+
+// selfDerive derives the accounts along the self-derivation paths until the
+// first empty one is found, adding all the used ones to the tracked accounts.
+// The first empty account of each path is tracked too, but derivation doesn't
+// progress past it until it's used.
+func (w *wallet) selfDerive() {
+	w.deriveLock.Lock()
+	defer w.deriveLock.Unlock()
+
+	// Derivation needs a chain and a decrypted seed, skip if either unavailable
+	w.stateLock.RLock()
+	if w.master == nil || w.deriveChain == nil || time.Since(w.derived) < selfDeriveThrottling {
+		w.stateLock.RUnlock()
+		return
+	}
+	var (
+		master = w.master
+		chain  = w.deriveChain
+
+		accs      []accounts.Account
+		paths     []accounts.DerivationPath
+		nextPaths = make([]accounts.DerivationPath, len(w.deriveNextPaths))
+	)
+	for i, path := range w.deriveNextPaths {
+		nextPaths[i] = append(accounts.DerivationPath{}, path...)
+	}
+	w.stateLock.RUnlock()
+
+	// The master key can't be wiped while the derivation lock is held
+	ctx := context.Background()
+	for i := range nextPaths {
+		for empty := false; !empty; {
+			key, err := master.derive(nextPaths[i])
+			if err != nil {
+				w.log.Warn("HD wallet account derivation failed", "err", err)
+				break
+			}
+			address := crypto.PubkeyToAddress(key.PublicKey)
+			zeroKey(key)
+
+			// Check the account's status against the current chain state
+			balance, err := chain.BalanceAt(ctx, address, nil)
+			if err != nil {
+				w.log.Warn("HD wallet balance retrieval failed", "err", err)
+				break
+			}
+			nonce, err := chain.NonceAt(ctx, address, nil)
+			if err != nil {
+				w.log.Warn("HD wallet nonce retrieval failed", "err", err)
+				break
+			}
+			empty = balance.Sign() == 0 && nonce == 0
+
+			path := append(accounts.DerivationPath{}, nextPaths[i]...)
+			paths = append(paths, path)
+			accs = append(accs, w.account(address, path))
+
+			// Fetch the next potential account
+			if !empty {
+				nextPaths[i][len(nextPaths[i])-1]++
+			}
+		}
+	}
+	// Insert any accounts successfully derived
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	for i, account := range accs {
+		if _, ok := w.paths[account.Address]; !ok {
+			w.log.Info("HD wallet discovered new account", "address", account.Address, "path", paths[i])
+			w.accounts = append(w.accounts, account)
+			w.paths[account.Address] = paths[i]
+		}
+	}
+	w.deriveNextPaths = nextPaths
+	w.derived = time.Now()
+}
+
+// Contains implements accounts.Wallet, returning whether a particular account is
+// or is not tracked by this wallet instance.
+func (w *wallet) Contains(account accounts.Account) bool {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	return w.contains(account)
+}
+
+// contains is the lock free version of Contains, the caller must hold the state
+// lock.
+func (w *wallet) contains(account accounts.Account) bool {
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return false
+	}
+	return account.URL == (accounts.URL{}) || account.URL == w.account(account.Address, path).URL
+}
+
+// Derive implements accounts.Wallet, deriving a new account at the specific
+// derivation path. If pin is set to true, the account will be added to the list
+// of tracked accounts and persisted into the seed file.
+func (w *wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, accounts.ErrWalletClosed
+	}
+	key, err := w.master.derive(path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	path = append(accounts.DerivationPath{}, path...)
+	account := w.account(address, path)
+	if !pin {
+		return account, nil
+	}
+	for _, pinned := range w.file.Accounts {
+		if pinned.Address == address {
+			return account, nil
+		}
+	}
+	file := *w.file
+	file.Accounts = append(append([]pinnedAccount{}, w.file.Accounts...), pinnedAccount{Address: address, Path: path.String()})
+	if err := file.store(w.path); err != nil {
+		return accounts.Account{}, err
+	}
+	w.file = &file
+
+	if _, ok := w.paths[address]; !ok {
+		w.accounts = append(w.accounts, account)
+		w.paths[address] = path
+	}
+	return account, nil
+}
+
+// SelfDerive implements accounts.Wallet, setting a base account derivation paths
+// from which the wallet attempts to discover non zero accounts and automatically
+// add them to the list of tracked accounts.
+func (w *wallet) SelfDerive(bases []accounts.DerivationPath, chain ethereum.ChainStateReader) {
+	w.deriveLock.Lock()
+	defer w.deriveLock.Unlock()
+
+	w.stateLock.Lock()
+	defer w.stateLock.Unlock()
+
+	w.deriveNextPaths = make([]accounts.DerivationPath, len(bases))
+	for i, base := range bases {
+		w.deriveNextPaths[i] = append(accounts.DerivationPath{}, base...)
+	}
+	w.deriveChain = chain
+	w.derived = time.Time{}
+}
+
+// signHash signs the given hash with the key of the account, derived from the
+// given master key.
+func (w *wallet) signHash(master *extendedKey, account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.accountKey(master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+// signTx signs the transaction with the key of the account, derived from the
+// given master key.
+func (w *wallet) signTx(master *extendedKey, account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.accountKey(master, account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+
+	// Depending on the presence of the chain ID, sign with 2718 or homestead
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
+}
+
+// accountKey derives the private key of a tracked account. The caller must hold
+// the state lock.
+func (w *wallet) accountKey(master *extendedKey, account accounts.Account) (*ecdsa.PrivateKey, error) {
+	// Make sure the requested account is contained within
+	if !w.contains(account) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return master.derive(w.paths[account.Address])
+}
+
+// unlocked runs the given signing operation with the master key of the open
+// wallet, holding the state lock.
+func (w *wallet) unlocked(fn func(master *extendedKey) error) error {
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	if w.master == nil {
+		return accounts.ErrWalletClosed
+	}
+	return fn(w.master)
+}
+
+// withPassphrase runs the given signing operation with the master key decrypted
+// using the passphrase, holding the state lock and wiping the key afterwards.
+func (w *wallet) withPassphrase(passphrase string, fn func(master *extendedKey) error) error {
+	w.stateLock.RLock()
+	file := w.file
+	w.stateLock.RUnlock()
+
+	master, err := file.masterKey(passphrase)
+	if err != nil {
+		return err
+	}
+	defer master.zero()
+
+	w.stateLock.RLock()
+	defer w.stateLock.RUnlock()
+
+	return fn(master)
+}
+
+// SignData implements accounts.Wallet, signing keccak256(data) with the given
+// account of the open wallet.
+func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte) ([]byte, error) {
+	var sig []byte
+	err := w.unlocked(func(master *extendedKey) (err error) {
+		sig, err = w.signHash(master, account, crypto.Keccak256(data))
+		return err
+	})
+	return sig, err
+}
+
+// SignDataWithPassphrase implements accounts.Wallet, signing keccak256(data)
+// with the given account, decrypting the seed with the passphrase.
+func (w *wallet) SignDataWithPassphrase(account accounts.Account, passphrase, mimeType string, data []byte) ([]byte, error) {
+	var sig []byte
+	err := w.withPassphrase(passphrase, func(master *extendedKey) (err error) {
+		sig, err = w.signHash(master, account, crypto.Keccak256(data))
+		return err
+	})
+	return sig, err
+}
+
+// SignText implements accounts.Wallet, signing the hash of the given text with
+// the given account of the open wallet.
+func (w *wallet) SignText(account accounts.Account, text []byte) ([]byte, error) {
+	var sig []byte
+	err := w.unlocked(func(master *extendedKey) (err error) {
+		sig, err = w.signHash(master, account, accounts.TextHash(text))
+		return err
+	})
+	return sig, err
+}
+
+// SignTextWithPassphrase implements accounts.Wallet, signing the hash of the
+// given text with the given account, decrypting the seed with the passphrase.
+func (w *wallet) SignTextWithPassphrase(account accounts.Account, passphrase string, text []byte) ([]byte, error) {
+	var sig []byte
+	err := w.withPassphrase(passphrase, func(master *extendedKey) (err error) {
+		sig, err = w.signHash(master, account, accounts.TextHash(text))
+		return err
+	})
+	return sig, err
+}
+
+// SignTx implements accounts.Wallet, signing the given transaction with the
+// given account of the open wallet.
+func (w *wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var signed *types.Transaction
+	err := w.unlocked(func(master *extendedKey) (err error) {
+		signed, err = w.signTx(master, account, tx, chainID)
+		return err
+	})
+	return signed, err
+}
+
+// SignTxWithPassphrase implements accounts.Wallet, signing the given transaction
+// with the given account, decrypting the seed with the passphrase.
+func (w *wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	var signed *types.Transaction
+	err := w.withPassphrase(passphrase, func(master *extendedKey) (err error) {
+		signed, err = w.signTx(master, account, tx, chainID)
+		return err
+	})
+	return signed, err
+}
+
+// zeroKey zeroes a private key in memory.
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hdwallet
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// testChain is a chain state reader with a fixed set of used accounts.
+type testChain map[common.Address]uint64
+
+func (c testChain) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return new(big.Int), nil
+}
+
+func (c testChain) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return nil, nil
+}
+
+func (c testChain) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	return c[account], nil
+}
+
+func tmpStore(t *testing.T) (string, *Store) {
+	dir, err := ioutil.TempDir("", "hdwallet-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, NewStore(dir, keystore.LightScryptN, keystore.LightScryptP)
+}
+
+// deriveAddress derives the address at the given path of the test mnemonic.
+func deriveAddress(t *testing.T, path string) common.Address {
+	file, err := newSeedFile(testMnemonic, "", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := file.masterKey("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.derive(derivationPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestStoreWallets(t *testing.T) {
+	dir, store := tmpStore(t)
+	defer os.RemoveAll(dir)
+
+	if wallets := store.Wallets(); len(wallets) != 0 {
+		t.Fatalf("empty store has %d wallets", len(wallets))
+	}
+	if _, err := store.Import("abandon abandon abandon", "pass"); err != ErrInvalidMnemonic {
+		t.Fatalf("invalid mnemonic import error mismatch: have %v, want %v", err, ErrInvalidMnemonic)
+	}
+	events := make(chan accounts.WalletEvent, 2)
+	sub := store.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	created, mnemonic, err := store.NewWallet("pass")
+	if err != nil {
+		t.Fatalf("failed to create wallet: %v", err)
+	}
+	if len(mnemonic) == 0 {
+		t.Fatalf("no mnemonic returned")
+	}
+	imported, err := store.Import(testMnemonic, "pass")
+	if err != nil {
+		t.Fatalf("failed to import wallet: %v", err)
+	}
+	for _, wallet := range []accounts.Wallet{created, imported} {
+		if event := <-events; event.Kind != accounts.WalletArrived || event.Wallet != wallet {
+			t.Errorf("wallet event mismatch: have %v %v, want arrival of %v", event.Kind, event.Wallet.URL(), wallet.URL())
+		}
+	}
+	wallets := store.Wallets()
+	if len(wallets) != 2 || wallets[0] != created || wallets[1] != imported {
+		t.Fatalf("wallet list mismatch: %v", wallets)
+	}
+	// Wallets should be reloaded from disk by a fresh store
+	reloaded := NewStore(dir, keystore.LightScryptN, keystore.LightScryptP).Wallets()
+	if len(reloaded) != 2 || reloaded[0].URL() != created.URL() || reloaded[1].URL() != imported.URL() {
+		t.Fatalf("reloaded wallet list mismatch: %v", reloaded)
+	}
+	// Removing the seed file should drop the wallet
+	if err := os.Remove(created.URL().Path); err != nil {
+		t.Fatal(err)
+	}
+	store.refreshWallets(true)
+	if event := <-events; event.Kind != accounts.WalletDropped || event.Wallet != created {
+		t.Errorf("wallet event mismatch: have %v %v, want drop of %v", event.Kind, event.Wallet.URL(), created.URL())
+	}
+}
+
+func TestWalletDeriveAndSign(t *testing.T) {
+	dir, store := tmpStore(t)
+	defer os.RemoveAll(dir)
+
+	wallet, err := store.Import(testMnemonic, "pass")
+	if err != nil {
+		t.Fatalf("failed to import wallet: %v", err)
+	}
+	path := accounts.DefaultBaseDerivationPath
+	if _, err := wallet.Derive(path, true); err != accounts.ErrWalletClosed {
+		t.Fatalf("derivation on locked wallet error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if err := wallet.Open("wrong"); err != keystore.ErrDecrypt {
+		t.Fatalf("open with wrong passphrase error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	if err := wallet.Open("pass"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	if status, _ := wallet.Status(); status != "Unlocked" {
+		t.Fatalf("status mismatch: have %s, want Unlocked", status)
+	}
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		t.Fatalf("failed to derive account: %v", err)
+	}
+	if want := deriveAddress(t, "m/44'/60'/0'/0/0"); account.Address != want {
+		t.Fatalf("derived address mismatch: have %x, want %x", account.Address, want)
+	}
+	if !wallet.Contains(account) {
+		t.Fatalf("pinned account not contained")
+	}
+	// Sign a transaction with the open wallet and with the passphrase
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	chainID := big.NewInt(1337)
+	for i, sign := range []func() (*types.Transaction, error){
+		func() (*types.Transaction, error) { return wallet.SignTx(account, tx, chainID) },
+		func() (*types.Transaction, error) { return wallet.SignTxWithPassphrase(account, "pass", tx, chainID) },
+	} {
+		signed, err := sign()
+		if err != nil {
+			t.Fatalf("signer %d: failed to sign transaction: %v", i, err)
+		}
+		sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+		if err != nil || sender != account.Address {
+			t.Fatalf("signer %d: sender mismatch: have %x (%v), want %x", i, sender, err, account.Address)
+		}
+	}
+	sig, err := wallet.SignText(account, []byte("hello"))
+	if err != nil {
+		t.Fatalf("failed to sign text: %v", err)
+	}
+	if pubkey, err := crypto.SigToPub(accounts.TextHash([]byte("hello")), sig); err != nil || crypto.PubkeyToAddress(*pubkey) != account.Address {
+		t.Fatalf("text signer mismatch: %v", err)
+	}
+	// Unknown accounts must be rejected, locked wallets only sign with passphrase
+	if _, err := wallet.SignText(accounts.Account{Address: common.Address{0x01}}, []byte("hello")); err != accounts.ErrUnknownAccount {
+		t.Fatalf("unknown account error mismatch: have %v, want %v", err, accounts.ErrUnknownAccount)
+	}
+	if err := wallet.Close(); err != nil {
+		t.Fatalf("failed to close wallet: %v", err)
+	}
+	if _, err := wallet.SignText(account, []byte("hello")); err != accounts.ErrWalletClosed {
+		t.Fatalf("closed wallet error mismatch: have %v, want %v", err, accounts.ErrWalletClosed)
+	}
+	if _, err := wallet.SignTextWithPassphrase(account, "wrong", []byte("hello")); err != keystore.ErrDecrypt {
+		t.Fatalf("wrong passphrase error mismatch: have %v, want %v", err, keystore.ErrDecrypt)
+	}
+	// Pinned accounts must be listed by a reloaded wallet without unlocking
+	reloaded := NewStore(dir, keystore.LightScryptN, keystore.LightScryptP).Wallets()[0]
+	if accs := reloaded.Accounts(); len(accs) != 1 || accs[0] != account {
+		t.Fatalf("reloaded accounts mismatch: have %v, want %v", accs, account)
+	}
+}
+
+func TestWalletSelfDerive(t *testing.T) {
+	dir, store := tmpStore(t)
+	defer os.RemoveAll(dir)
+
+	wallet, err := store.Import(testMnemonic, "pass")
+	if err != nil {
+		t.Fatalf("failed to import wallet: %v", err)
+	}
+	used := []common.Address{
+		deriveAddress(t, "m/44'/60'/0'/0/0"),
+		deriveAddress(t, "m/44'/60'/0'/0/1"),
+	}
+	fresh := deriveAddress(t, "m/44'/60'/0'/0/2")
+
+	wallet.SelfDerive([]accounts.DerivationPath{accounts.DefaultBaseDerivationPath}, testChain{used[0]: 1, used[1]: 3})
+	if accs := wallet.Accounts(); len(accs) != 0 {
+		t.Fatalf("locked wallet self-derived %d accounts", len(accs))
+	}
+	if err := wallet.Open("pass"); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	accs := wallet.Accounts()
+	if len(accs) != 3 {
+		t.Fatalf("self-derived account count mismatch: have %d, want 3", len(accs))
+	}
+	for i, want := range append(used, fresh) {
+		if accs[i].Address != want {
+			t.Errorf("account %d mismatch: have %x, want %x", i, accs[i].Address, want)
+		}
+	}
+	// Self-derived accounts are not pinned, closing should drop them
+	if err := wallet.Close(); err != nil {
+		t.Fatalf("failed to close wallet: %v", err)
+	}
+	if accs := wallet.Accounts(); len(accs) != 0 {
+		t.Fatalf("closed wallet retained %d accounts", len(accs))
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/eip712"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -288,6 +289,16 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, chainID *b
 	return types.SignTx(tx, signer, unlockedKey.PrivateKey)
 }
 
+// SignTypedData signs the EIP-712 typed data with the requested account. The
+// produced signature is in the [R || S || V] format where V is 0 or 1.
+func (ks *KeyStore) SignTypedData(a accounts.Account, typedData *eip712.TypedData) ([]byte, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return ks.SignHash(a, hash[:])
+}
+
 // SignHashWithPassphrase signs hash if the private key matching the given address
 // can be decrypted with the given passphrase. The produced signature is in the
 // [R || S || V] format where V is 0 or 1.
@@ -313,6 +324,16 @@ func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string,
 	return types.SignTx(tx, signer, key.PrivateKey)
 }
 
+// SignTypedDataWithPassphrase signs the EIP-712 typed data if the private key
+// matching the given address can be decrypted with the given passphrase.
+func (ks *KeyStore) SignTypedDataWithPassphrase(a accounts.Account, passphrase string, typedData *eip712.TypedData) ([]byte, error) {
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return ks.SignHashWithPassphrase(a, passphrase, hash[:])
+}
+
 // Unlock unlocks the given account indefinitely.
 func (ks *KeyStore) Unlock(a accounts.Account, passphrase string) error {
 	return ks.TimedUnlock(a, passphrase, 0)
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/eip712"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/event"
@@ -116,6 +117,46 @@ func TestSignWithPassphrase(t *testing.T) {
 	}
 }
 
+func TestSignTypedData(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	pass := "passwd"
+	acc, err := ks.NewAccount(pass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	typedData := &eip712.TypedData{
+		Types: eip712.Types{
+			"EIP712Domain": {{Name: "name", Type: "string"}},
+			"Greeting":     {{Name: "text", Type: "string"}},
+		},
+		PrimaryType: "Greeting",
+		Domain:      eip712.TypedDataDomain{Name: "test"},
+		Message:     eip712.TypedDataMessage{"text": "hello"},
+	}
+	if _, err := ks.SignTypedData(acc, typedData); err != ErrLocked {
+		t.Fatalf("signing with locked account: have %v, want %v", err, ErrLocked)
+	}
+	sig, err := ks.SignTypedDataWithPassphrase(acc, pass, typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := eip712.Recover(typedData, sig); err != nil || signer != acc.Address {
+		t.Fatalf("signer mismatch: have %x (%v), want %x", signer, err, acc.Address)
+	}
+	if err := ks.Unlock(acc, pass); err != nil {
+		t.Fatal(err)
+	}
+	unlockedSig, err := ks.SignTypedData(acc, typedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer, err := eip712.Recover(typedData, unlockedSig); err != nil || signer != acc.Address {
+		t.Fatalf("unlocked signer mismatch: have %x (%v), want %x", signer, err, acc.Address)
+	}
+}
+
 func TestTimedUnlock(t *testing.T) {
 	dir, ks := tmpKeyStore(t, true)
 	defer os.RemoveAll(dir)
@@ -168,6 +168,15 @@ func (w *ledgerDriver) SignTx(path accounts.DerivationPath, tx *types.Transactio
 		//lint:ignore ST1005 brand name displayed on the console
 		return common.Address{}, nil, fmt.Errorf("Ledger v%d.%d.%d doesn't support signing this transaction, please update to v1.0.3 at least", w.version[0], w.version[1], w.version[2])
 	}
+	if tx.Type() != types.LegacyTxType {
+		if chainID == nil {
+			return common.Address{}, nil, errors.New("typed transactions require a chain ID")
+		}
+		if !w.versionAtLeast(1, 9, 0) {
+			//lint:ignore ST1005 brand name displayed on the console
+			return common.Address{}, nil, fmt.Errorf("Ledger v%d.%d.%d doesn't support signing typed transactions, please update to v1.9.0 at least", w.version[0], w.version[1], w.version[2])
+		}
+	}
 	// All infos gathered and metadata checks out, request signing
 	return w.ledgerSign(path, tx, chainID)
 }
@@ -182,7 +191,7 @@ func (w *ledgerDriver) SignTypedMessage(path accounts.DerivationPath, domainHash
 		return nil, accounts.ErrWalletClosed
 	}
 	// Ensure the wallet is capable of signing the given transaction
-	if w.version[0] < 1 && w.version[1] < 5 {
+	if !w.versionAtLeast(1, 5, 0) {
 		//lint:ignore ST1005 brand name displayed on the console
 		return nil, fmt.Errorf("Ledger version >= 1.5.0 required for EIP-712 signing (found version v%d.%d.%d)", w.version[0], w.version[1], w.version[2])
 	}
@@ -190,6 +199,18 @@ func (w *ledgerDriver) SignTypedMessage(path accounts.DerivationPath, domainHash
 	return w.ledgerSignTypedMessage(path, domainHash, messageHash)
 }
 
+// versionAtLeast reports whether the Ethereum app running on the Ledger is of
+// the given version or newer.
+func (w *ledgerDriver) versionAtLeast(major, minor, patch byte) bool {
+	if w.version[0] != major {
+		return w.version[0] > major
+	}
+	if w.version[1] != minor {
+		return w.version[1] > minor
+	}
+	return w.version[2] >= patch
+}
+
 // ledgerVersion retrieves the current version of the Ethereum wallet app running
 // on the Ledger wallet.
 //
@@ -306,6 +327,9 @@ func (w *ledgerDriver) ledgerDerive(derivationPath []uint32) (common.Address, er
 //   Last derivation index (big endian)               | 4 bytes
 //   RLP transaction chunk                            | arbitrary
 //
+// Typed transactions (EIP-2718) are sent as the transaction type byte followed
+// by the RLP encoding of the unsigned transaction payload.
+//
 // And the input for subsequent transaction blocks (first 255 bytes) are:
 //
 //   Description           | Length
@@ -326,19 +350,33 @@ func (w *ledgerDriver) ledgerSign(derivationPath []uint32, tx *types.Transaction
 	for i, component := range derivationPath {
 		binary.BigEndian.PutUint32(path[1+4*i:], component)
 	}
-	// Create the transaction RLP based on whether legacy or EIP155 signing was requested
+	// Create the transaction RLP based on whether legacy, EIP155 or typed
+	// transaction signing was requested
 	var (
 		txrlp []byte
 		err   error
 	)
-	if chainID == nil {
+	switch {
+	case chainID == nil:
 		if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data()}); err != nil {
 			return common.Address{}, nil, err
 		}
-	} else {
+	case tx.Type() == types.LegacyTxType:
 		if txrlp, err = rlp.EncodeToBytes([]interface{}{tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, big.NewInt(0), big.NewInt(0)}); err != nil {
 			return common.Address{}, nil, err
 		}
+	case tx.Type() == types.AccessListTxType:
+		if txrlp, err = rlp.EncodeToBytes([]interface{}{chainID, tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList()}); err != nil {
+			return common.Address{}, nil, err
+		}
+		txrlp = append([]byte{tx.Type()}, txrlp...)
+	case tx.Type() == types.DynamicFeeTxType:
+		if txrlp, err = rlp.EncodeToBytes([]interface{}{chainID, tx.Nonce(), tx.Tip(), tx.FeeCap(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), tx.AccessList()}); err != nil {
+			return common.Address{}, nil, err
+		}
+		txrlp = append([]byte{tx.Type()}, txrlp...)
+	default:
+		return common.Address{}, nil, types.ErrTxTypeNotSupported
 	}
 	payload := append(path, txrlp...)
 
@@ -373,8 +411,13 @@ func (w *ledgerDriver) ledgerSign(derivationPath []uint32, tx *types.Transaction
 	if chainID == nil {
 		signer = new(types.HomesteadSigner)
 	} else {
-		signer = types.NewEIP155Signer(chainID)
-		signature[64] -= byte(chainID.Uint64()*2 + 35)
+		signer = types.LatestSignerForChainID(chainID)
+
+		// Typed transactions use 0 and 1 as their recovery id, only the
+		// legacy ones have the EIP155 chain ID folded in
+		if tx.Type() == types.LegacyTxType {
+			signature[64] -= byte(chainID.Uint64()*2 + 35)
+		}
 	}
 	signed, err := tx.WithSignature(signer, signature)
 	if err != nil {
@@ -0,0 +1,275 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// mockLedger emulates the Ethereum app of a Ledger device on the USB transport
+// level. It reassembles the APDUs written by the driver, signs with a fixed
+// key and streams the framed replies back.
+type mockLedger struct {
+	key     *ecdsa.PrivateKey
+	version [3]byte
+
+	lock    sync.Mutex
+	request []byte       // APDU being received
+	size    int          // Total length of the APDU being received
+	reply   bytes.Buffer // Reply chunks not yet read
+	txdata  []byte       // Transaction payload being received
+	apdus   [][]byte     // All APDUs received so far
+}
+
+func newMockLedger(key *ecdsa.PrivateKey, version [3]byte) *mockLedger {
+	return &mockLedger{key: key, version: version}
+}
+
+func (d *mockLedger) Close() error { return nil }
+
+func (d *mockLedger) Read(b []byte) (int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.reply.Len() == 0 {
+		return 0, io.EOF
+	}
+	return d.reply.Read(b)
+}
+
+func (d *mockLedger) Write(chunk []byte) (int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(chunk) < 7 || chunk[0] != 0x01 || chunk[1] != 0x01 || chunk[2] != 0x05 {
+		return 0, errors.New("invalid transport header")
+	}
+	if seq := binary.BigEndian.Uint16(chunk[3:5]); seq == 0 {
+		d.size = int(binary.BigEndian.Uint16(chunk[5:7]))
+		d.request = append([]byte{}, chunk[7:]...)
+	} else {
+		d.request = append(d.request, chunk[5:]...)
+	}
+	if len(d.request) >= d.size {
+		apdu := d.request[:d.size]
+		d.apdus = append(d.apdus, apdu)
+		d.respond(d.handle(apdu))
+	}
+	return len(chunk), nil
+}
+
+// respond frames the reply data along with a status word into 64 byte chunks.
+func (d *mockLedger) respond(data []byte, status uint16) {
+	payload := make([]byte, 2, 4+len(data))
+	binary.BigEndian.PutUint16(payload, uint16(len(data)+2))
+	payload = append(payload, data...)
+	payload = append(payload, byte(status>>8), byte(status))
+
+	for i := 0; len(payload) > 0; i++ {
+		chunk := make([]byte, 64)
+		copy(chunk, []byte{0x01, 0x01, 0x05})
+		binary.BigEndian.PutUint16(chunk[3:], uint16(i))
+		n := copy(chunk[5:], payload)
+		payload = payload[n:]
+		d.reply.Write(chunk)
+	}
+}
+
+// handle executes a single APDU and returns the reply data and status word.
+func (d *mockLedger) handle(apdu []byte) ([]byte, uint16) {
+	if len(apdu) < 5 || apdu[0] != 0xe0 || int(apdu[4]) != len(apdu)-5 {
+		return nil, 0x6700
+	}
+	op, p1, data := ledgerOpcode(apdu[1]), ledgerParam1(apdu[2]), apdu[5:]
+
+	switch op {
+	case ledgerOpGetConfiguration:
+		return append([]byte{0x01}, d.version[:]...), 0x9000
+
+	case ledgerOpRetrieveAddress:
+		pubkey := crypto.FromECDSAPub(&d.key.PublicKey)
+		address := hex.EncodeToString(crypto.PubkeyToAddress(d.key.PublicKey).Bytes())
+
+		reply := append([]byte{byte(len(pubkey))}, pubkey...)
+		reply = append(reply, byte(len(address)))
+		return append(reply, address...), 0x9000
+
+	case ledgerOpSignTransaction:
+		if p1 == ledgerP1InitTransactionData {
+			d.txdata = append([]byte{}, data[1+4*int(data[0]):]...)
+		} else {
+			d.txdata = append(d.txdata, data...)
+		}
+		// The device knows the transaction is complete once its RLP is
+		payload := d.txdata
+		if len(payload) > 0 && payload[0] < 0x80 {
+			payload = payload[1:]
+		}
+		if _, _, _, err := rlp.Split(payload); err != nil {
+			return nil, 0x9000
+		}
+		sig, err := crypto.Sign(crypto.Keccak256(d.txdata), d.key)
+		if err != nil {
+			return nil, 0x6a80
+		}
+		v := sig[64]
+		if d.txdata[0] >= 0x80 {
+			// Legacy transaction, fold in the chain ID if there is one
+			var fields []interface{}
+			if err := rlp.DecodeBytes(d.txdata, &fields); err != nil {
+				return nil, 0x6a80
+			}
+			if len(fields) == 9 {
+				chainID := new(big.Int).SetBytes(fields[6].([]byte))
+				v += byte(chainID.Uint64()*2 + 35)
+			}
+		}
+		return append([]byte{v}, sig[:64]...), 0x9000
+
+	case ledgerOpSignTypedMessage:
+		data = data[1+4*int(data[0]):]
+		if len(data) != 64 {
+			return nil, 0x6a80
+		}
+		hash := crypto.Keccak256([]byte{0x19, 0x01}, data)
+		sig, err := crypto.Sign(hash, d.key)
+		if err != nil {
+			return nil, 0x6a80
+		}
+		return append([]byte{27 + sig[64]}, sig[:64]...), 0x9000
+	}
+	return nil, 0x6d00
+}
+
+func newTestLedger(t *testing.T, version [3]byte) (*ledgerDriver, *mockLedger, common.Address) {
+	key, _ := crypto.GenerateKey()
+	device := newMockLedger(key, version)
+	driver := newLedgerDriver(log.Root()).(*ledgerDriver)
+	if err := driver.Open(device, ""); err != nil {
+		t.Fatalf("failed to open ledger: %v", err)
+	}
+	return driver, device, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestLedgerOpen(t *testing.T) {
+	driver, _, address := newTestLedger(t, [3]byte{1, 9, 17})
+	if status, err := driver.Status(); err != nil || status != "Ethereum app v1.9.17 online" {
+		t.Fatalf("status mismatch: have %q (%v)", status, err)
+	}
+	have, err := driver.Derive(accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive address: %v", err)
+	}
+	if have != address {
+		t.Fatalf("address mismatch: have %x, want %x", have, address)
+	}
+}
+
+func TestLedgerSignTx(t *testing.T) {
+	to := common.HexToAddress("0x1234")
+	chainID := big.NewInt(1337)
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}, {0x02}}}}
+
+	tests := []struct {
+		name    string
+		chainID *big.Int
+		tx      types.TxData
+	}{
+		{"homestead", nil, &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(3)}},
+		{"eip155", chainID, &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(3)}},
+		{"eip2930", chainID, &types.AccessListTx{ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(2), Gas: 30000, To: &to, AccessList: accessList}},
+		{"eip1559", chainID, &types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 30000, To: &to, AccessList: accessList}},
+		{"eip1559-create", chainID, &types.DynamicFeeTx{ChainID: chainID, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 1000000, Data: make([]byte, 1000)}},
+	}
+	for _, test := range tests {
+		driver, _, address := newTestLedger(t, [3]byte{1, 9, 0})
+		tx := types.NewTx(test.tx)
+
+		sender, signed, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, test.chainID)
+		if err != nil {
+			t.Errorf("%s: failed to sign transaction: %v", test.name, err)
+			continue
+		}
+		if sender != address {
+			t.Errorf("%s: sender mismatch: have %x, want %x", test.name, sender, address)
+		}
+		if signed.Type() != tx.Type() || signed.Hash() == tx.Hash() {
+			t.Errorf("%s: transaction not signed correctly", test.name)
+		}
+		signer := types.LatestSignerForChainID(test.chainID)
+		if from, err := types.Sender(signer, signed); err != nil || from != address {
+			t.Errorf("%s: signature recovery failed: have %x (%v), want %x", test.name, from, err, address)
+		}
+	}
+}
+
+func TestLedgerSignTxOldVersion(t *testing.T) {
+	driver, _, _ := newTestLedger(t, [3]byte{1, 8, 9})
+
+	chainID := big.NewInt(1)
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 21000})
+	if _, _, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, chainID); err == nil {
+		t.Fatal("typed transaction signed by old firmware")
+	}
+	tx = types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1), Gas: 21000})
+	if _, _, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, chainID); err != nil {
+		t.Fatalf("failed to sign legacy transaction: %v", err)
+	}
+}
+
+func TestLedgerSignTypedMessage(t *testing.T) {
+	driver, device, address := newTestLedger(t, [3]byte{1, 5, 0})
+
+	domain, message := crypto.Keccak256([]byte("domain")), crypto.Keccak256([]byte("message"))
+	sig, err := driver.SignTypedMessage(accounts.DefaultBaseDerivationPath, domain, message)
+	if err != nil {
+		t.Fatalf("failed to sign typed message: %v", err)
+	}
+	// Check the wire format of the request
+	apdu := device.apdus[len(device.apdus)-1]
+	if want := []byte{0xe0, byte(ledgerOpSignTypedMessage), 0x00, 0x00, 1 + 4*5 + 64}; !bytes.Equal(apdu[:5], want) {
+		t.Fatalf("request header mismatch: have %x, want %x", apdu[:5], want)
+	}
+	if want := append(domain, message...); !bytes.Equal(apdu[len(apdu)-64:], want) {
+		t.Fatalf("request payload mismatch: have %x, want %x", apdu[len(apdu)-64:], want)
+	}
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(crypto.Keccak256([]byte{0x19, 0x01}, domain, message), sig)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != address {
+		t.Fatalf("signature recovery failed: %v", err)
+	}
+	// Older firmwares don't support EIP-712
+	driver, _, _ = newTestLedger(t, [3]byte{1, 4, 9})
+	if _, err := driver.SignTypedMessage(accounts.DefaultBaseDerivationPath, domain, message); err == nil {
+		t.Fatal("typed message signed by old firmware")
+	}
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/golang/protobuf/proto"
 )
@@ -178,15 +179,79 @@ func (w *trezorDriver) Derive(path accounts.DerivationPath) (common.Address, err
 
 // SignTx implements usbwallet.driver, sending the transaction to the Trezor and
 // waiting for the user to confirm or deny the transaction.
+//
+// Note, EIP-1559 transactions need firmware v1.10.4 (Model One) or v2.4.2
+// (Model T). EIP-2930 access list transactions are not supported by any of the
+// firmwares.
 func (w *trezorDriver) SignTx(path accounts.DerivationPath, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error) {
 	if w.device == nil {
 		return common.Address{}, nil, accounts.ErrWalletClosed
 	}
-	return w.trezorSign(path, tx, chainID)
+	switch tx.Type() {
+	case types.LegacyTxType:
+		return w.trezorSign(path, tx, chainID)
+
+	case types.DynamicFeeTxType:
+		if chainID == nil {
+			return common.Address{}, nil, errors.New("typed transactions require a chain ID")
+		}
+		if !w.versionAtLeast([3]uint32{1, 10, 4}, [3]uint32{2, 4, 2}) {
+			//lint:ignore ST1005 brand name displayed on the console
+			return common.Address{}, nil, fmt.Errorf("Trezor v%d.%d.%d doesn't support EIP-1559 transactions", w.version[0], w.version[1], w.version[2])
+		}
+		return w.trezorSignDynamicFee(path, tx, chainID)
+
+	default:
+		return common.Address{}, nil, types.ErrTxTypeNotSupported
+	}
 }
 
+// SignTypedMessage implements usbwallet.driver, sending the EIP-712 domain and
+// message hashes to the Trezor and waiting for the user to sign or deny them.
+//
+// Note, blind signing of typed data hashes is only supported by the Model One,
+// starting with firmware v1.10.6.
 func (w *trezorDriver) SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) ([]byte, error) {
-	return nil, accounts.ErrNotSupported
+	if w.device == nil {
+		return nil, accounts.ErrWalletClosed
+	}
+	if !w.versionAtLeast([3]uint32{1, 10, 6}, [3]uint32{}) {
+		//lint:ignore ST1005 brand name displayed on the console
+		return nil, fmt.Errorf("Trezor v%d.%d.%d doesn't support EIP-712 hash signing", w.version[0], w.version[1], w.version[2])
+	}
+	request := &trezor.EthereumSignTypedHash{
+		AddressN:            path,
+		DomainSeparatorHash: domainHash,
+		MessageHash:         messageHash,
+	}
+	response := new(trezor.EthereumTypedDataSignature)
+	if _, err := w.trezorExchange(request, response); err != nil {
+		return nil, err
+	}
+	signature := response.GetSignature()
+	if len(signature) != crypto.SignatureLength {
+		return nil, errors.New("reply lacks signature")
+	}
+	return signature, nil
+}
+
+// versionAtLeast reports whether the firmware of the Trezor is of the given
+// version or newer. The minimum versions of the Model One and Model T differ,
+// a zero version means the feature is not available on that model.
+func (w *trezorDriver) versionAtLeast(one, t [3]uint32) bool {
+	min := one
+	if w.version[0] >= 2 {
+		min = t
+	}
+	if min == ([3]uint32{}) {
+		return false
+	}
+	for i := range min {
+		if w.version[i] != min[i] {
+			return w.version[i] > min[i]
+		}
+	}
+	return true
 }
 
 // trezorDerive sends a derivation request to the Trezor device and returns the
@@ -236,18 +301,10 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 		request.ChainId = &id
 	}
 	// Send the initiation message and stream content until a signature is returned
-	response := new(trezor.EthereumTxRequest)
-	if _, err := w.trezorExchange(request, response); err != nil {
+	response, err := w.trezorStreamTx(request, data)
+	if err != nil {
 		return common.Address{}, nil, err
 	}
-	for response.DataLength != nil && int(*response.DataLength) <= len(data) {
-		chunk := data[:*response.DataLength]
-		data = data[*response.DataLength:]
-
-		if _, err := w.trezorExchange(&trezor.EthereumTxAck{DataChunk: chunk}, response); err != nil {
-			return common.Address{}, nil, err
-		}
-	}
 	// Extract the Ethereum signature and do a sanity validation
 	if len(response.GetSignatureR()) == 0 || len(response.GetSignatureS()) == 0 || response.GetSignatureV() == 0 {
 		return common.Address{}, nil, errors.New("reply lacks signature")
@@ -259,12 +316,88 @@ func (w *trezorDriver) trezorSign(derivationPath []uint32, tx *types.Transaction
 	if chainID == nil {
 		signer = new(types.HomesteadSigner)
 	} else {
-		// Trezor backend does not support typed transactions yet.
 		signer = types.NewEIP155Signer(chainID)
 		signature[64] -= byte(chainID.Uint64()*2 + 35)
 	}
 
 	// Inject the final signature into the transaction and sanity check the sender
+	return trezorSigned(signer, tx, signature)
+}
+
+// trezorSignDynamicFee sends an EIP-1559 transaction to the Trezor wallet, and
+// waits for the user to confirm or deny the transaction.
+func (w *trezorDriver) trezorSignDynamicFee(derivationPath []uint32, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error) {
+	// Create the transaction initiation message
+	var (
+		data   = tx.Data()
+		length = uint32(len(data))
+		id     = chainID.Uint64()
+		to     string
+	)
+	if tx.To() != nil {
+		to = tx.To().Hex()
+	}
+	request := &trezor.EthereumSignTxEIP1559{
+		AddressN:       derivationPath,
+		Nonce:          new(big.Int).SetUint64(tx.Nonce()).Bytes(),
+		MaxGasFee:      tx.FeeCap().Bytes(),
+		MaxPriorityFee: tx.Tip().Bytes(),
+		GasLimit:       new(big.Int).SetUint64(tx.Gas()).Bytes(),
+		To:             &to,
+		Value:          tx.Value().Bytes(),
+		DataLength:     &length,
+		ChainId:        &id,
+	}
+	for _, tuple := range tx.AccessList() {
+		address := tuple.Address.Hex()
+		entry := &trezor.EthereumSignTxEIP1559_EthereumAccessList{Address: &address}
+		for _, key := range tuple.StorageKeys {
+			entry.StorageKeys = append(entry.StorageKeys, common.CopyBytes(key[:]))
+		}
+		request.AccessList = append(request.AccessList, entry)
+	}
+	if length > 1024 { // Send the data chunked if that was requested
+		request.DataInitialChunk, data = data[:1024], data[1024:]
+	} else {
+		request.DataInitialChunk, data = data, nil
+	}
+	// Send the initiation message and stream content until a signature is returned
+	response, err := w.trezorStreamTx(request, data)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+	// Extract the Ethereum signature and do a sanity validation. Typed
+	// transactions use 0 and 1 as their recovery id.
+	if len(response.GetSignatureR()) == 0 || len(response.GetSignatureS()) == 0 || response.SignatureV == nil || response.GetSignatureV() > 1 {
+		return common.Address{}, nil, errors.New("reply lacks signature")
+	}
+	signature := append(append(response.GetSignatureR(), response.GetSignatureS()...), byte(response.GetSignatureV()))
+
+	// Inject the final signature into the transaction and sanity check the sender
+	return trezorSigned(types.LatestSignerForChainID(chainID), tx, signature)
+}
+
+// trezorStreamTx sends a transaction signing request to the Trezor and streams
+// the remainder of the transaction payload until a signature is returned.
+func (w *trezorDriver) trezorStreamTx(request proto.Message, data []byte) (*trezor.EthereumTxRequest, error) {
+	response := new(trezor.EthereumTxRequest)
+	if _, err := w.trezorExchange(request, response); err != nil {
+		return nil, err
+	}
+	for response.DataLength != nil && int(*response.DataLength) <= len(data) {
+		chunk := data[:*response.DataLength]
+		data = data[*response.DataLength:]
+
+		if _, err := w.trezorExchange(&trezor.EthereumTxAck{DataChunk: chunk}, response); err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// trezorSigned injects the signature returned by the Trezor into the transaction
+// and recovers the sender from it.
+func trezorSigned(signer types.Signer, tx *types.Transaction, signature []byte) (common.Address, *types.Transaction, error) {
 	signed, err := tx.WithSignature(signer, signature)
 	if err != nil {
 		return common.Address{}, nil, err
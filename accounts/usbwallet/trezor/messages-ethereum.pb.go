@@ -640,6 +640,290 @@ func (m *EthereumVerifyMessage) GetAddressHex() string {
 	return ""
 }
 
+//*
+// Request: Ask device to sign an EIP-1559 dynamic fee transaction
+// Note: the first at most 1024 bytes of data MUST be transmitted as part of this message.
+// @start
+// @next EthereumTxRequest
+// @next Failure
+type EthereumSignTxEIP1559 struct {
+	AddressN             []uint32                                    `protobuf:"varint,1,rep,name=address_n,json=addressN" json:"address_n,omitempty"`
+	Nonce                []byte                                      `protobuf:"bytes,2,req,name=nonce" json:"nonce,omitempty"`
+	MaxGasFee            []byte                                      `protobuf:"bytes,3,req,name=max_gas_fee,json=maxGasFee" json:"max_gas_fee,omitempty"`
+	MaxPriorityFee       []byte                                      `protobuf:"bytes,4,req,name=max_priority_fee,json=maxPriorityFee" json:"max_priority_fee,omitempty"`
+	GasLimit             []byte                                      `protobuf:"bytes,5,req,name=gas_limit,json=gasLimit" json:"gas_limit,omitempty"`
+	To                   *string                                     `protobuf:"bytes,6,opt,name=to" json:"to,omitempty"`
+	Value                []byte                                      `protobuf:"bytes,7,req,name=value" json:"value,omitempty"`
+	DataInitialChunk     []byte                                      `protobuf:"bytes,8,opt,name=data_initial_chunk,json=dataInitialChunk" json:"data_initial_chunk,omitempty"`
+	DataLength           *uint32                                     `protobuf:"varint,9,req,name=data_length,json=dataLength" json:"data_length,omitempty"`
+	ChainId              *uint64                                     `protobuf:"varint,10,req,name=chain_id,json=chainId" json:"chain_id,omitempty"`
+	AccessList           []*EthereumSignTxEIP1559_EthereumAccessList `protobuf:"bytes,11,rep,name=access_list,json=accessList" json:"access_list,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                                    `json:"-"`
+	XXX_unrecognized     []byte                                      `json:"-"`
+	XXX_sizecache        int32                                       `json:"-"`
+}
+
+func (m *EthereumSignTxEIP1559) Reset()         { *m = EthereumSignTxEIP1559{} }
+func (m *EthereumSignTxEIP1559) String() string { return proto.CompactTextString(m) }
+func (*EthereumSignTxEIP1559) ProtoMessage()    {}
+func (*EthereumSignTxEIP1559) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{10}
+}
+
+func (m *EthereumSignTxEIP1559) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EthereumSignTxEIP1559.Unmarshal(m, b)
+}
+func (m *EthereumSignTxEIP1559) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EthereumSignTxEIP1559.Marshal(b, m, deterministic)
+}
+func (m *EthereumSignTxEIP1559) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EthereumSignTxEIP1559.Merge(m, src)
+}
+func (m *EthereumSignTxEIP1559) XXX_Size() int {
+	return xxx_messageInfo_EthereumSignTxEIP1559.Size(m)
+}
+func (m *EthereumSignTxEIP1559) XXX_DiscardUnknown() {
+	xxx_messageInfo_EthereumSignTxEIP1559.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EthereumSignTxEIP1559 proto.InternalMessageInfo
+
+func (m *EthereumSignTxEIP1559) GetAddressN() []uint32 {
+	if m != nil {
+		return m.AddressN
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetNonce() []byte {
+	if m != nil {
+		return m.Nonce
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetMaxGasFee() []byte {
+	if m != nil {
+		return m.MaxGasFee
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetMaxPriorityFee() []byte {
+	if m != nil {
+		return m.MaxPriorityFee
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetGasLimit() []byte {
+	if m != nil {
+		return m.GasLimit
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetTo() string {
+	if m != nil && m.To != nil {
+		return *m.To
+	}
+	return ""
+}
+
+func (m *EthereumSignTxEIP1559) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetDataInitialChunk() []byte {
+	if m != nil {
+		return m.DataInitialChunk
+	}
+	return nil
+}
+
+func (m *EthereumSignTxEIP1559) GetDataLength() uint32 {
+	if m != nil && m.DataLength != nil {
+		return *m.DataLength
+	}
+	return 0
+}
+
+func (m *EthereumSignTxEIP1559) GetChainId() uint64 {
+	if m != nil && m.ChainId != nil {
+		return *m.ChainId
+	}
+	return 0
+}
+
+func (m *EthereumSignTxEIP1559) GetAccessList() []*EthereumSignTxEIP1559_EthereumAccessList {
+	if m != nil {
+		return m.AccessList
+	}
+	return nil
+}
+
+type EthereumSignTxEIP1559_EthereumAccessList struct {
+	Address              *string  `protobuf:"bytes,1,req,name=address" json:"address,omitempty"`
+	StorageKeys          [][]byte `protobuf:"bytes,2,rep,name=storage_keys,json=storageKeys" json:"storage_keys,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EthereumSignTxEIP1559_EthereumAccessList) Reset() {
+	*m = EthereumSignTxEIP1559_EthereumAccessList{}
+}
+func (m *EthereumSignTxEIP1559_EthereumAccessList) String() string { return proto.CompactTextString(m) }
+func (*EthereumSignTxEIP1559_EthereumAccessList) ProtoMessage()    {}
+func (*EthereumSignTxEIP1559_EthereumAccessList) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{10, 0}
+}
+
+func (m *EthereumSignTxEIP1559_EthereumAccessList) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList.Unmarshal(m, b)
+}
+func (m *EthereumSignTxEIP1559_EthereumAccessList) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList.Marshal(b, m, deterministic)
+}
+func (m *EthereumSignTxEIP1559_EthereumAccessList) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList.Merge(m, src)
+}
+func (m *EthereumSignTxEIP1559_EthereumAccessList) XXX_Size() int {
+	return xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList.Size(m)
+}
+func (m *EthereumSignTxEIP1559_EthereumAccessList) XXX_DiscardUnknown() {
+	xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EthereumSignTxEIP1559_EthereumAccessList proto.InternalMessageInfo
+
+func (m *EthereumSignTxEIP1559_EthereumAccessList) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
+func (m *EthereumSignTxEIP1559_EthereumAccessList) GetStorageKeys() [][]byte {
+	if m != nil {
+		return m.StorageKeys
+	}
+	return nil
+}
+
+//*
+// Request: Ask device to sign hash of typed data
+// @start
+// @next EthereumTypedDataSignature
+// @next Failure
+type EthereumSignTypedHash struct {
+	AddressN             []uint32 `protobuf:"varint,1,rep,name=address_n,json=addressN" json:"address_n,omitempty"`
+	DomainSeparatorHash  []byte   `protobuf:"bytes,2,req,name=domain_separator_hash,json=domainSeparatorHash" json:"domain_separator_hash,omitempty"`
+	MessageHash          []byte   `protobuf:"bytes,3,opt,name=message_hash,json=messageHash" json:"message_hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EthereumSignTypedHash) Reset()         { *m = EthereumSignTypedHash{} }
+func (m *EthereumSignTypedHash) String() string { return proto.CompactTextString(m) }
+func (*EthereumSignTypedHash) ProtoMessage()    {}
+func (*EthereumSignTypedHash) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{11}
+}
+
+func (m *EthereumSignTypedHash) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EthereumSignTypedHash.Unmarshal(m, b)
+}
+func (m *EthereumSignTypedHash) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EthereumSignTypedHash.Marshal(b, m, deterministic)
+}
+func (m *EthereumSignTypedHash) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EthereumSignTypedHash.Merge(m, src)
+}
+func (m *EthereumSignTypedHash) XXX_Size() int {
+	return xxx_messageInfo_EthereumSignTypedHash.Size(m)
+}
+func (m *EthereumSignTypedHash) XXX_DiscardUnknown() {
+	xxx_messageInfo_EthereumSignTypedHash.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EthereumSignTypedHash proto.InternalMessageInfo
+
+func (m *EthereumSignTypedHash) GetAddressN() []uint32 {
+	if m != nil {
+		return m.AddressN
+	}
+	return nil
+}
+
+func (m *EthereumSignTypedHash) GetDomainSeparatorHash() []byte {
+	if m != nil {
+		return m.DomainSeparatorHash
+	}
+	return nil
+}
+
+func (m *EthereumSignTypedHash) GetMessageHash() []byte {
+	if m != nil {
+		return m.MessageHash
+	}
+	return nil
+}
+
+//*
+// Response: Signed typed data
+// @end
+type EthereumTypedDataSignature struct {
+	Signature            []byte   `protobuf:"bytes,1,req,name=signature" json:"signature,omitempty"`
+	Address              *string  `protobuf:"bytes,2,req,name=address" json:"address,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *EthereumTypedDataSignature) Reset()         { *m = EthereumTypedDataSignature{} }
+func (m *EthereumTypedDataSignature) String() string { return proto.CompactTextString(m) }
+func (*EthereumTypedDataSignature) ProtoMessage()    {}
+func (*EthereumTypedDataSignature) Descriptor() ([]byte, []int) {
+	return fileDescriptor_cb33f46ba915f15c, []int{12}
+}
+
+func (m *EthereumTypedDataSignature) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_EthereumTypedDataSignature.Unmarshal(m, b)
+}
+func (m *EthereumTypedDataSignature) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_EthereumTypedDataSignature.Marshal(b, m, deterministic)
+}
+func (m *EthereumTypedDataSignature) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_EthereumTypedDataSignature.Merge(m, src)
+}
+func (m *EthereumTypedDataSignature) XXX_Size() int {
+	return xxx_messageInfo_EthereumTypedDataSignature.Size(m)
+}
+func (m *EthereumTypedDataSignature) XXX_DiscardUnknown() {
+	xxx_messageInfo_EthereumTypedDataSignature.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_EthereumTypedDataSignature proto.InternalMessageInfo
+
+func (m *EthereumTypedDataSignature) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *EthereumTypedDataSignature) GetAddress() string {
+	if m != nil && m.Address != nil {
+		return *m.Address
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*EthereumGetPublicKey)(nil), "hw.trezor.messages.ethereum.EthereumGetPublicKey")
 	proto.RegisterType((*EthereumPublicKey)(nil), "hw.trezor.messages.ethereum.EthereumPublicKey")
@@ -651,48 +935,68 @@ func init() {
 	proto.RegisterType((*EthereumSignMessage)(nil), "hw.trezor.messages.ethereum.EthereumSignMessage")
 	proto.RegisterType((*EthereumMessageSignature)(nil), "hw.trezor.messages.ethereum.EthereumMessageSignature")
 	proto.RegisterType((*EthereumVerifyMessage)(nil), "hw.trezor.messages.ethereum.EthereumVerifyMessage")
+	proto.RegisterType((*EthereumSignTxEIP1559)(nil), "hw.trezor.messages.ethereum.EthereumSignTxEIP1559")
+	proto.RegisterType((*EthereumSignTxEIP1559_EthereumAccessList)(nil), "hw.trezor.messages.ethereum.EthereumSignTxEIP1559.EthereumAccessList")
+	proto.RegisterType((*EthereumSignTypedHash)(nil), "hw.trezor.messages.ethereum.EthereumSignTypedHash")
+	proto.RegisterType((*EthereumTypedDataSignature)(nil), "hw.trezor.messages.ethereum.EthereumTypedDataSignature")
 }
 
 func init() { proto.RegisterFile("messages-ethereum.proto", fileDescriptor_cb33f46ba915f15c) }
 
 var fileDescriptor_cb33f46ba915f15c = []byte{
-	// 593 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0x4d, 0x6f, 0xd3, 0x40,
-	0x10, 0x95, 0x9b, 0xb4, 0x49, 0x26, 0x0d, 0x1f, 0xa6, 0x55, 0x17, 0x0a, 0x34, 0x18, 0x21, 0xe5,
-	0x00, 0x3e, 0x70, 0x43, 0xe2, 0xd2, 0x52, 0x44, 0x2b, 0x4a, 0x55, 0xdc, 0xa8, 0x57, 0x6b, 0x63,
-	0x6f, 0xe3, 0x55, 0x9d, 0xdd, 0xe0, 0x5d, 0xb7, 0x0e, 0x7f, 0x82, 0x23, 0xff, 0x87, 0x5f, 0x86,
-	0xf6, 0x2b, 0x71, 0x52, 0x54, 0x0e, 0xbd, 0x65, 0xde, 0xbc, 0x7d, 0xf3, 0x66, 0xf4, 0x62, 0xd8,
-	0x99, 0x10, 0x21, 0xf0, 0x98, 0x88, 0x77, 0x44, 0x66, 0xa4, 0x20, 0xe5, 0x24, 0x9c, 0x16, 0x5c,
-	0x72, 0x7f, 0x37, 0xbb, 0x09, 0x65, 0x41, 0x7e, 0xf2, 0x22, 0x74, 0x94, 0xd0, 0x51, 0x9e, 0x6d,
-	0xcf, 0x5f, 0x25, 0x7c, 0x32, 0xe1, 0xcc, 0xbc, 0x09, 0x2e, 0x60, 0xeb, 0xb3, 0xa5, 0x7c, 0x21,
-	0xf2, 0xac, 0x1c, 0xe5, 0x34, 0xf9, 0x4a, 0x66, 0xfe, 0x2e, 0x74, 0x70, 0x9a, 0x16, 0x44, 0x88,
-	0x98, 0x21, 0xaf, 0xdf, 0x18, 0xf4, 0xa2, 0xb6, 0x05, 0x4e, 0xfd, 0x57, 0xb0, 0x29, 0x32, 0x7e,
-	0x13, 0xa7, 0x54, 0x4c, 0x73, 0x3c, 0x43, 0x6b, 0x7d, 0x6f, 0xd0, 0x8e, 0xba, 0x0a, 0x3b, 0x34,
-	0x50, 0x30, 0x82, 0xc7, 0x4e, 0x77, 0x21, 0xfa, 0x01, 0x9a, 0x8c, 0xa7, 0x04, 0x79, 0x7d, 0x6f,
-	0xd0, 0x7d, 0xff, 0x26, 0xfc, 0x87, 0x5f, 0x6b, 0xee, 0xe8, 0xf0, 0x94, 0xa7, 0x64, 0x38, 0x9b,
-	0x92, 0x48, 0x3f, 0xf1, 0x7d, 0x68, 0x56, 0xd3, 0x72, 0xa4, 0x47, 0x75, 0x22, 0xfd, 0x3b, 0x18,
-	0x82, 0x5f, 0xf3, 0xbe, 0x6f, 0xdc, 0xdd, 0xdb, 0xf9, 0x77, 0x78, 0xe8, 0x54, 0x9d, 0xe4, 0x4b,
-	0x00, 0xab, 0x70, 0x40, 0x99, 0x76, 0xbf, 0x19, 0xd5, 0x90, 0x5a, 0xff, 0x88, 0x54, 0xd6, 0x62,
-	0x0d, 0x09, 0xfe, 0xac, 0xc1, 0x03, 0xa7, 0x79, 0x4e, 0xc7, 0x6c, 0x58, 0xdd, 0xed, 0x72, 0x0b,
-	0xd6, 0x19, 0x67, 0x09, 0xd1, 0x52, 0x9b, 0x91, 0x29, 0xd4, 0x93, 0x31, 0x16, 0xf1, 0xb4, 0xa0,
-	0x09, 0x41, 0x0d, 0xdd, 0x69, 0x8f, 0xb1, 0x38, 0x53, 0xb5, 0x6b, 0xe6, 0x74, 0x42, 0x25, 0x6a,
-	0xce, 0x9b, 0x27, 0xaa, 0x56, 0x7a, 0x92, 0x2b, 0xeb, 0xeb, 0x46, 0x4f, 0x17, 0x06, 0x55, 0x86,
-	0xbb, 0xda, 0xb0, 0x29, 0x14, 0x7a, 0x8d, 0xf3, 0x92, 0xa0, 0x0d, 0xc3, 0xd5, 0x85, 0xff, 0x16,
-	0xfc, 0x14, 0x4b, 0x1c, 0x53, 0x46, 0x25, 0xc5, 0x79, 0x9c, 0x64, 0x25, 0xbb, 0x42, 0x2d, 0x4d,
-	0x79, 0xa4, 0x3a, 0xc7, 0xa6, 0xf1, 0x49, 0xe1, 0xfe, 0x1e, 0x74, 0x35, 0x3b, 0x27, 0x6c, 0x2c,
-	0x33, 0xd4, 0xee, 0x7b, 0x83, 0x5e, 0x04, 0x0a, 0x3a, 0xd1, 0x88, 0xff, 0x14, 0xda, 0x49, 0x86,
-	0x29, 0x8b, 0x69, 0x8a, 0x3a, 0xba, 0xdb, 0xd2, 0xf5, 0x71, 0xea, 0xef, 0x40, 0x4b, 0x56, 0xb1,
-	0x9c, 0x4d, 0x09, 0x02, 0xdd, 0xd9, 0x90, 0x95, 0xca, 0x41, 0xf0, 0xdb, 0x5b, 0x44, 0x6a, 0x58,
-	0x45, 0xe4, 0x47, 0x49, 0x84, 0x5c, 0x1d, 0xe5, 0xdd, 0x1a, 0xb5, 0x07, 0x5d, 0x41, 0xc7, 0x0c,
-	0xcb, 0xb2, 0x20, 0xf1, 0xb5, 0xbe, 0x68, 0x2f, 0x82, 0x39, 0x74, 0xb1, 0x4c, 0x28, 0xec, 0x61,
-	0x17, 0x84, 0x68, 0x99, 0x20, 0xec, 0x71, 0x17, 0x84, 0xf3, 0x20, 0x84, 0xde, 0xc2, 0xd8, 0x7e,
-	0x72, 0xe5, 0xbf, 0x00, 0xed, 0xc0, 0x5e, 0xc9, 0xe4, 0xa5, 0xa3, 0x10, 0x7d, 0x9e, 0xe0, 0x04,
-	0x9e, 0xd4, 0xd3, 0xf0, 0xcd, 0x64, 0xff, 0xee, 0x48, 0x20, 0x68, 0xd9, 0xff, 0x88, 0x0d, 0x85,
-	0x2b, 0x83, 0x0a, 0x90, 0x53, 0xb3, 0x4a, 0xe7, 0xce, 0xda, 0x7f, 0x83, 0xfb, 0x1c, 0x3a, 0xf3,
-	0x3d, 0xac, 0xee, 0x02, 0x58, 0x89, 0x75, 0xe3, 0x56, 0xac, 0x7f, 0x79, 0xb0, 0xed, 0x46, 0x5f,
-	0x90, 0x82, 0x5e, 0xce, 0xdc, 0x2a, 0xf7, 0x9b, 0x5b, 0xdb, 0xb5, 0xb1, 0xb4, 0xeb, 0x8a, 0xa3,
-	0xe6, 0xaa, 0xa3, 0x83, 0x8f, 0xf0, 0x3a, 0xe1, 0x93, 0x50, 0x60, 0xc9, 0x45, 0x46, 0x73, 0x3c,
-	0x12, 0xee, 0x03, 0x93, 0xd3, 0x91, 0xf9, 0xe2, 0x8d, 0xca, 0xcb, 0x83, 0xed, 0xa1, 0x06, 0xad,
-	0x5b, 0xb7, 0xc2, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x8a, 0xce, 0x81, 0xc8, 0x59, 0x05, 0x00,
-	0x00,
+	// 853 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4d, 0x6f, 0xdb, 0x46,
+	0x14, 0x04, 0x29, 0x39, 0x12, 0x1f, 0x65, 0x37, 0x65, 0x62, 0x84, 0x75, 0xda, 0x84, 0x61, 0x51,
+	0x80, 0x87, 0x96, 0x40, 0x0d, 0xe4, 0x10, 0xa0, 0x17, 0xbb, 0x76, 0x62, 0x23, 0x6e, 0xe0, 0xd0,
+	0x82, 0xaf, 0xc4, 0x8a, 0x5c, 0x8b, 0x0b, 0x93, 0x5c, 0x96, 0xbb, 0x4a, 0xc8, 0xfe, 0x85, 0x1e,
+	0x7a, 0xec, 0xff, 0xe9, 0xef, 0xea, 0xa1, 0xd8, 0x0f, 0x8a, 0x92, 0x6c, 0xa8, 0x01, 0x7c, 0xd3,
+	0xce, 0x9b, 0x9d, 0x9d, 0x7d, 0xfb, 0x46, 0x84, 0x67, 0x05, 0x66, 0x0c, 0xcd, 0x31, 0xfb, 0x09,
+	0xf3, 0x0c, 0xd7, 0x78, 0x51, 0x84, 0x55, 0x4d, 0x39, 0x75, 0x9e, 0x67, 0x9f, 0x43, 0x5e, 0xe3,
+	0x3f, 0x68, 0x1d, 0x76, 0x94, 0xb0, 0xa3, 0x1c, 0xec, 0x2f, 0x77, 0x25, 0xb4, 0x28, 0x68, 0xa9,
+	0xf6, 0xf8, 0xd7, 0xf0, 0xf4, 0x54, 0x53, 0xde, 0x61, 0x7e, 0xb9, 0x98, 0xe5, 0x24, 0x79, 0x8f,
+	0x5b, 0xe7, 0x39, 0x58, 0x28, 0x4d, 0x6b, 0xcc, 0x58, 0x5c, 0xba, 0x86, 0x37, 0x08, 0x76, 0xa3,
+	0xb1, 0x06, 0x3e, 0x38, 0xaf, 0x60, 0xc2, 0x32, 0xfa, 0x39, 0x4e, 0x09, 0xab, 0x72, 0xd4, 0xba,
+	0xa6, 0x67, 0x04, 0xe3, 0xc8, 0x16, 0xd8, 0x89, 0x82, 0xfc, 0x19, 0x7c, 0xdd, 0xe9, 0xf6, 0xa2,
+	0x6f, 0x60, 0x58, 0xd2, 0x14, 0xbb, 0x86, 0x67, 0x04, 0xf6, 0xe1, 0x0f, 0xe1, 0x3d, 0x7e, 0xb5,
+	0xb9, 0xb3, 0x93, 0x0f, 0x34, 0xc5, 0xd3, 0xb6, 0xc2, 0x91, 0xdc, 0xe2, 0x38, 0x30, 0x6c, 0xaa,
+	0xc5, 0x4c, 0x1e, 0x65, 0x45, 0xf2, 0xb7, 0x3f, 0x05, 0x67, 0xc5, 0xfb, 0x91, 0x72, 0xf7, 0x60,
+	0xe7, 0x1f, 0xe1, 0xab, 0x4e, 0xb5, 0x93, 0x7c, 0x01, 0xa0, 0x15, 0x8e, 0x49, 0x29, 0xdd, 0x4f,
+	0xa2, 0x15, 0x64, 0xa5, 0x7e, 0x86, 0x1b, 0x6d, 0x71, 0x05, 0xf1, 0xff, 0x31, 0x61, 0xaf, 0xd3,
+	0xbc, 0x22, 0xf3, 0x72, 0xda, 0x6c, 0x77, 0xf9, 0x14, 0x76, 0x4a, 0x5a, 0x26, 0x58, 0x4a, 0x4d,
+	0x22, 0xb5, 0x10, 0x5b, 0xe6, 0x88, 0xc5, 0x55, 0x4d, 0x12, 0xec, 0x0e, 0x64, 0x65, 0x3c, 0x47,
+	0xec, 0xb2, 0x26, 0x7d, 0x31, 0x27, 0x05, 0xe1, 0xee, 0x70, 0x59, 0xbc, 0x10, 0x6b, 0xa1, 0xc7,
+	0xa9, 0xb0, 0xbe, 0xa3, 0xf4, 0xe4, 0x42, 0xa1, 0xc2, 0xb0, 0x2d, 0x0d, 0xab, 0x85, 0x40, 0x3f,
+	0xa1, 0x7c, 0x81, 0xdd, 0x47, 0x8a, 0x2b, 0x17, 0xce, 0x8f, 0xe0, 0xa4, 0x88, 0xa3, 0x98, 0x94,
+	0x84, 0x13, 0x94, 0xc7, 0x49, 0xb6, 0x28, 0x6f, 0xdd, 0x91, 0xa4, 0x3c, 0x16, 0x95, 0x73, 0x55,
+	0xf8, 0x55, 0xe0, 0xce, 0x4b, 0xb0, 0x25, 0x3b, 0xc7, 0xe5, 0x9c, 0x67, 0xee, 0xd8, 0x33, 0x82,
+	0xdd, 0x08, 0x04, 0x74, 0x21, 0x11, 0xe7, 0x1b, 0x18, 0x27, 0x19, 0x22, 0x65, 0x4c, 0x52, 0xd7,
+	0x92, 0xd5, 0x91, 0x5c, 0x9f, 0xa7, 0xce, 0x33, 0x18, 0xf1, 0x26, 0xe6, 0x6d, 0x85, 0x5d, 0x90,
+	0x95, 0x47, 0xbc, 0x11, 0x73, 0xe0, 0xff, 0x6d, 0xf4, 0x23, 0x35, 0x6d, 0x22, 0xfc, 0xfb, 0x02,
+	0x33, 0xbe, 0x79, 0x94, 0x71, 0xe7, 0xa8, 0x97, 0x60, 0x33, 0x32, 0x2f, 0x11, 0x5f, 0xd4, 0x38,
+	0xfe, 0x24, 0x3b, 0xba, 0x1b, 0xc1, 0x12, 0xba, 0x5e, 0x27, 0xd4, 0xba, 0xb1, 0x3d, 0x21, 0x5a,
+	0x27, 0x30, 0x77, 0xb8, 0x41, 0xb8, 0xf2, 0x43, 0xd8, 0xed, 0x8d, 0x1d, 0x25, 0xb7, 0xce, 0x77,
+	0x20, 0x1d, 0xe8, 0x2e, 0xa9, 0x79, 0xb1, 0x04, 0x22, 0xdb, 0xe3, 0x5f, 0xc0, 0x93, 0xd5, 0x69,
+	0xf8, 0x4d, 0xcd, 0xfe, 0xf6, 0x91, 0x70, 0x61, 0xa4, 0x33, 0xa2, 0x87, 0xa2, 0x5b, 0xfa, 0x0d,
+	0xb8, 0x9d, 0x9a, 0x56, 0xba, 0xea, 0xac, 0xfd, 0xef, 0xe0, 0x7e, 0x0b, 0xd6, 0xf2, 0x1e, 0x5a,
+	0xd7, 0x62, 0xf7, 0xec, 0x16, 0x53, 0x32, 0xb8, 0x33, 0xd6, 0x7f, 0x19, 0xb0, 0xdf, 0x1d, 0x7d,
+	0x8d, 0x6b, 0x72, 0xd3, 0x76, 0x57, 0x79, 0xd8, 0xb9, 0x2b, 0x77, 0x1d, 0xac, 0xdd, 0x75, 0xc3,
+	0xd1, 0xf0, 0x8e, 0xa3, 0x7f, 0x07, 0xbd, 0x23, 0x15, 0xb4, 0xd3, 0xf3, 0xcb, 0x9f, 0x5f, 0xbf,
+	0x7e, 0xf3, 0xc5, 0x79, 0x33, 0xfb, 0xbc, 0xbd, 0x00, 0xbb, 0x40, 0x4d, 0x2c, 0x62, 0x75, 0x83,
+	0x85, 0x15, 0x51, 0xb3, 0x0a, 0xd4, 0xbc, 0x43, 0xec, 0x2d, 0xc6, 0x4e, 0x00, 0x8f, 0x45, 0xbd,
+	0xaa, 0x09, 0xad, 0x09, 0x6f, 0x25, 0x69, 0x28, 0x49, 0x7b, 0x05, 0x6a, 0x2e, 0x35, 0xfc, 0x16,
+	0x6f, 0x84, 0x73, 0xc7, 0x33, 0xd7, 0xc2, 0xb9, 0x07, 0x26, 0xa7, 0x32, 0x6d, 0x56, 0x64, 0x72,
+	0xda, 0x07, 0x70, 0xa4, 0xcc, 0x6c, 0x0b, 0xe0, 0xf8, 0xcb, 0x02, 0x68, 0x79, 0xe6, 0x96, 0x00,
+	0x82, 0x67, 0x06, 0xc3, 0x3e, 0x80, 0x37, 0x60, 0xa3, 0x24, 0x11, 0x8d, 0xca, 0x09, 0xe3, 0xae,
+	0xed, 0x0d, 0x02, 0xfb, 0xf0, 0x34, 0xdc, 0xf2, 0x6d, 0x09, 0xef, 0x6d, 0xf9, 0x12, 0x3d, 0x92,
+	0x6a, 0x17, 0x84, 0xf1, 0x08, 0xd0, 0xf2, 0xf7, 0xc1, 0x47, 0x70, 0xee, 0x32, 0xc4, 0xdb, 0xeb,
+	0x67, 0x71, 0x0d, 0xcf, 0x0c, 0xac, 0xa8, 0x5b, 0xca, 0xbf, 0x6e, 0x4e, 0x6b, 0x34, 0xc7, 0xf1,
+	0x2d, 0x6e, 0x99, 0x6b, 0x7a, 0x83, 0x60, 0x12, 0xd9, 0x1a, 0x7b, 0x8f, 0x5b, 0xe6, 0xff, 0x69,
+	0x6c, 0x3c, 0x7f, 0x5b, 0xe1, 0xf4, 0x0c, 0xb1, 0x6c, 0xfb, 0xf3, 0x1f, 0xc2, 0x7e, 0x4a, 0x0b,
+	0xd1, 0x0d, 0x86, 0x2b, 0x54, 0x23, 0x4e, 0xeb, 0x38, 0x43, 0x2c, 0xd3, 0xe3, 0xf0, 0x44, 0x15,
+	0xaf, 0xba, 0x9a, 0x14, 0x7c, 0x05, 0x13, 0xdd, 0x07, 0x45, 0x55, 0x83, 0x6a, 0x6b, 0x4c, 0x50,
+	0xfc, 0x29, 0x1c, 0x2c, 0xff, 0x16, 0x84, 0x91, 0x13, 0xc4, 0x51, 0x1f, 0xcd, 0xb5, 0x08, 0x18,
+	0x6a, 0xb6, 0xd6, 0x22, 0xd0, 0xb5, 0xc1, 0x5c, 0x6b, 0xc3, 0xf1, 0x2f, 0xf0, 0x7d, 0x42, 0x8b,
+	0x90, 0x21, 0x4e, 0x59, 0x46, 0x72, 0x34, 0x63, 0xdd, 0xbb, 0xe4, 0x64, 0xa6, 0x3e, 0xea, 0xb3,
+	0xc5, 0xcd, 0xf1, 0xfe, 0x54, 0x82, 0x3a, 0x90, 0x9d, 0x8f, 0xff, 0x06, 0x00, 0x88, 0xe2, 0x55,
+	0x22, 0x3c, 0x08, 0x00, 0x00,
 }
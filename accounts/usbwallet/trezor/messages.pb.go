@@ -97,16 +97,19 @@ const (
 	MessageType_MessageType_DebugLinkMemoryWrite MessageType = 112
 	MessageType_MessageType_DebugLinkFlashErase  MessageType = 113
 	// Ethereum
-	MessageType_MessageType_EthereumGetPublicKey     MessageType = 450
-	MessageType_MessageType_EthereumPublicKey        MessageType = 451
-	MessageType_MessageType_EthereumGetAddress       MessageType = 56
-	MessageType_MessageType_EthereumAddress          MessageType = 57
-	MessageType_MessageType_EthereumSignTx           MessageType = 58
-	MessageType_MessageType_EthereumTxRequest        MessageType = 59
-	MessageType_MessageType_EthereumTxAck            MessageType = 60
-	MessageType_MessageType_EthereumSignMessage      MessageType = 64
-	MessageType_MessageType_EthereumVerifyMessage    MessageType = 65
-	MessageType_MessageType_EthereumMessageSignature MessageType = 66
+	MessageType_MessageType_EthereumGetPublicKey       MessageType = 450
+	MessageType_MessageType_EthereumPublicKey          MessageType = 451
+	MessageType_MessageType_EthereumGetAddress         MessageType = 56
+	MessageType_MessageType_EthereumAddress            MessageType = 57
+	MessageType_MessageType_EthereumSignTx             MessageType = 58
+	MessageType_MessageType_EthereumTxRequest          MessageType = 59
+	MessageType_MessageType_EthereumTxAck              MessageType = 60
+	MessageType_MessageType_EthereumSignMessage        MessageType = 64
+	MessageType_MessageType_EthereumVerifyMessage      MessageType = 65
+	MessageType_MessageType_EthereumMessageSignature   MessageType = 66
+	MessageType_MessageType_EthereumSignTxEIP1559      MessageType = 452
+	MessageType_MessageType_EthereumTypedDataSignature MessageType = 469
+	MessageType_MessageType_EthereumSignTypedHash      MessageType = 470
 	// NEM
 	MessageType_MessageType_NEMGetAddress       MessageType = 67
 	MessageType_MessageType_NEMAddress          MessageType = 68
@@ -316,6 +319,9 @@ var MessageType_name = map[int32]string{
 	64:  "MessageType_EthereumSignMessage",
 	65:  "MessageType_EthereumVerifyMessage",
 	66:  "MessageType_EthereumMessageSignature",
+	452: "MessageType_EthereumSignTxEIP1559",
+	469: "MessageType_EthereumTypedDataSignature",
+	470: "MessageType_EthereumSignTypedHash",
 	67:  "MessageType_NEMGetAddress",
 	68:  "MessageType_NEMAddress",
 	69:  "MessageType_NEMSignTx",
@@ -512,6 +518,9 @@ var MessageType_value = map[string]int32{
 	"MessageType_EthereumSignMessage":                       64,
 	"MessageType_EthereumVerifyMessage":                     65,
 	"MessageType_EthereumMessageSignature":                  66,
+	"MessageType_EthereumSignTxEIP1559":                     452,
+	"MessageType_EthereumTypedDataSignature":                469,
+	"MessageType_EthereumSignTypedHash":                     470,
 	"MessageType_NEMGetAddress":                             67,
 	"MessageType_NEMAddress":                                68,
 	"MessageType_NEMSignTx":                                 69,
@@ -733,157 +742,160 @@ func init() {
 func init() { proto.RegisterFile("messages.proto", fileDescriptor_4dc296cbfe5ffcd5) }
 
 var fileDescriptor_4dc296cbfe5ffcd5 = []byte{
-	// 2430 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x9a, 0xd9, 0x73, 0x1c, 0xc5,
-	0x1d, 0xc7, 0xb3, 0xab, 0x11, 0x88, 0xf6, 0x41, 0x23, 0xb0, 0x2d, 0xaf, 0x2f, 0xf9, 0xc0, 0x96,
-	0x2f, 0xd9, 0x10, 0x0c, 0x44, 0x38, 0x60, 0x69, 0xb5, 0x12, 0x8a, 0xb5, 0x5a, 0x97, 0x76, 0xb1,
-	0x1f, 0x5d, 0xa3, 0x9d, 0xd6, 0x6e, 0x97, 0x67, 0x67, 0x86, 0x9e, 0x1e, 0x49, 0xeb, 0xa7, 0x9c,
-	0x3c, 0x13, 0x48, 0xc0, 0xb9, 0xa9, 0xa4, 0x2a, 0x21, 0x57, 0x85, 0x1c, 0x4e, 0x25, 0x55, 0x39,
-	0x08, 0x24, 0x2f, 0xc9, 0x43, 0x52, 0x9c, 0x86, 0x40, 0xee, 0x90, 0xe4, 0x0f, 0xc8, 0xc5, 0x91,
-	0xa4, 0x7a, 0xa6, 0xbb, 0xe7, 0xd8, 0xdf, 0xae, 0x36, 0x6f, 0x58, 0xf3, 0xf9, 0x7d, 0x7f, 0x47,
-	0xff, 0xfa, 0x37, 0xdd, 0xb3, 0xa0, 0xcd, 0x2d, 0xe2, 0xfb, 0x66, 0x83, 0xf8, 0xe3, 0x1e, 0x73,
-	0xb9, 0x3b, 0x3c, 0xdc, 0x5c, 0x1d, 0xe7, 0x8c, 0x5c, 0x76, 0xd9, 0xb8, 0x7a, 0x52, 0x18, 0x6d,
-	0xb8, 0x6e, 0xc3, 0x26, 0x27, 0x42, 0x62, 0x29, 0x58, 0x3e, 0x61, 0x11, 0xbf, 0xce, 0xa8, 0xc7,
-	0x5d, 0x16, 0x59, 0x1d, 0xf9, 0xfe, 0x7d, 0x68, 0x43, 0x39, 0xc2, 0x6b, 0x6d, 0x8f, 0x0c, 0x1f,
-	0x40, 0x5b, 0x13, 0xff, 0xbc, 0x38, 0xe7, 0x50, 0x4e, 0x4d, 0x9b, 0x5e, 0x26, 0xf8, 0x5d, 0x85,
-	0xa1, 0x87, 0xaf, 0x8e, 0xe4, 0x9e, 0xba, 0x3a, 0x92, 0x1b, 0x2e, 0x20, 0x9c, 0xa4, 0xce, 0x51,
-	0xa7, 0x81, 0x73, 0x05, 0x43, 0x3c, 0x1f, 0xde, 0x85, 0x6e, 0x4e, 0x3e, 0xab, 0x06, 0xf5, 0x3a,
-	0xf1, 0x7d, 0x9c, 0x2f, 0x18, 0x57, 0x80, 0xc7, 0x33, 0x26, 0xb5, 0x03, 0x46, 0xf0, 0x80, 0x7c,
-	0xbc, 0x07, 0x6d, 0x49, 0x3e, 0x2e, 0x36, 0x4d, 0xa7, 0x41, 0xce, 0x51, 0x07, 0x1b, 0x52, 0x7e,
-	0x34, 0x1d, 0xe0, 0x05, 0xea, 0x91, 0x69, 0xb2, 0x42, 0xeb, 0x04, 0x0f, 0xc2, 0xc4, 0x2c, 0xe1,
-	0x25, 0x87, 0x33, 0xd7, 0x6b, 0xe3, 0x1b, 0xe0, 0x10, 0xd5, 0x63, 0x24, 0x63, 0xc8, 0x08, 0xcc,
-	0xbb, 0xa6, 0x25, 0x5d, 0x6c, 0x92, 0x02, 0x7b, 0xd1, 0xb6, 0x24, 0xb1, 0x48, 0x7c, 0xc2, 0x25,
-	0xb2, 0x59, 0x22, 0xbb, 0xd1, 0x2d, 0xa9, 0x3c, 0x89, 0xc9, 0x03, 0x46, 0x7c, 0x7c, 0x93, 0x74,
-	0x72, 0x10, 0xed, 0xcc, 0x94, 0xb0, 0x6c, 0x72, 0x46, 0xd7, 0x16, 0xc9, 0x83, 0x01, 0xf1, 0x39,
-	0x1e, 0x96, 0xdc, 0x11, 0x34, 0x02, 0x72, 0x93, 0xf5, 0x4b, 0xf8, 0xe6, 0xc2, 0x46, 0xb5, 0x24,
-	0x4f, 0x47, 0x81, 0x0f, 0xa7, 0x8a, 0x67, 0x3a, 0x75, 0x62, 0xe3, 0x5b, 0x12, 0x0b, 0xb7, 0x2f,
-	0xad, 0x56, 0xb4, 0x89, 0xc9, 0xaa, 0xc4, 0xf7, 0xa9, 0xeb, 0xe0, 0x11, 0x19, 0xf9, 0x7e, 0xb4,
-	0x3d, 0xc9, 0x4c, 0x7a, 0x9e, 0xdd, 0xae, 0x12, 0xce, 0xa9, 0xd3, 0xf0, 0xf1, 0x76, 0x18, 0x9a,
-	0x0a, 0x38, 0x77, 0x1d, 0x15, 0x7b, 0x41, 0xc6, 0x7e, 0x28, 0xbd, 0x98, 0x11, 0x24, 0x02, 0xdf,
-	0xd1, 0x11, 0xf8, 0xd6, 0x0e, 0x97, 0x33, 0xb6, 0xd9, 0xf0, 0xf1, 0x4e, 0xe9, 0x2f, 0x13, 0xf8,
-	0x94, 0x59, 0xbf, 0x14, 0x78, 0xb2, 0xe4, 0xfb, 0x24, 0x73, 0x00, 0x15, 0x80, 0x65, 0x55, 0x41,
-	0xed, 0x87, 0x57, 0x57, 0x52, 0x22, 0xaa, 0x03, 0x52, 0xe7, 0x10, 0xda, 0x95, 0x2a, 0xb9, 0xe9,
-	0xfb, 0x5e, 0x93, 0x99, 0x3e, 0x51, 0x52, 0x87, 0xa5, 0xd4, 0xd1, 0x74, 0x11, 0x62, 0x50, 0xa8,
-	0x1d, 0xc9, 0xe4, 0x78, 0x0c, 0xed, 0x83, 0xe1, 0x2a, 0x37, 0xb9, 0x96, 0x2e, 0x4b, 0xe9, 0x93,
-	0x68, 0x77, 0x0f, 0x5a, 0xe8, 0x2f, 0x64, 0xf4, 0x33, 0xd9, 0x2f, 0x92, 0xba, 0xbb, 0x42, 0x58,
-	0x5b, 0xd6, 0xe8, 0x38, 0xdc, 0xb9, 0x17, 0x5c, 0x66, 0x29, 0xd7, 0xe3, 0xf0, 0x0e, 0x15, 0x88,
-	0xf0, 0x77, 0x02, 0x56, 0x98, 0x25, 0x5c, 0xf7, 0xf6, 0x5d, 0x70, 0x73, 0x54, 0x09, 0x7f, 0xe0,
-	0xf6, 0x99, 0xa2, 0x1b, 0x38, 0x9c, 0x30, 0x7c, 0x9f, 0xae, 0x72, 0x0a, 0x9a, 0xa1, 0xac, 0xb5,
-	0x6a, 0x32, 0x52, 0x12, 0x49, 0xe2, 0xeb, 0xa2, 0x9e, 0xfd, 0x9e, 0x00, 0xc7, 0xd2, 0x89, 0x29,
-	0xf0, 0x01, 0xcf, 0x76, 0x4d, 0x0b, 0x5f, 0x9f, 0x20, 0x0f, 0xa3, 0x1d, 0x10, 0xa9, 0x12, 0x1c,
-	0x2a, 0x0c, 0x5d, 0x51, 0xe8, 0xbe, 0xf4, 0xf6, 0xac, 0x12, 0x7b, 0xb9, 0x26, 0x98, 0xd1, 0x84,
-	0x5c, 0xa6, 0xe7, 0x66, 0x09, 0x3f, 0x17, 0x2c, 0xd9, 0xb4, 0x7e, 0x96, 0xb4, 0xf1, 0x06, 0x99,
-	0x45, 0x66, 0x5e, 0xc5, 0xc0, 0x46, 0x59, 0xcd, 0x9d, 0xe9, 0x3d, 0x59, 0xa5, 0x0d, 0xa7, 0xb6,
-	0x86, 0x6f, 0x84, 0xcd, 0x6b, 0x7a, 0xfb, 0x6f, 0x91, 0xe6, 0x3b, 0xd0, 0x4d, 0x69, 0x40, 0x2c,
-	0xc5, 0xd6, 0xae, 0x93, 0x6e, 0xd2, 0xb2, 0x98, 0x98, 0xb6, 0xbb, 0xe0, 0x49, 0xa7, 0x1e, 0xef,
-	0x96, 0xea, 0x99, 0xb5, 0x14, 0xc1, 0xc9, 0x7f, 0xe3, 0x83, 0xf0, 0x5a, 0x9e, 0x27, 0x8c, 0x2e,
-	0xb7, 0x15, 0x74, 0x48, 0x42, 0x99, 0x61, 0x26, 0xff, 0x5b, 0xc8, 0x85, 0x9d, 0x81, 0xc7, 0xa4,
-	0xbf, 0x4c, 0x8f, 0x16, 0xa9, 0xd7, 0x24, 0xec, 0x2c, 0x69, 0x9f, 0x37, 0xed, 0x80, 0xe0, 0x6d,
-	0xb0, 0x5a, 0x44, 0x11, 0x4b, 0x73, 0x27, 0xa5, 0x5a, 0x66, 0x7d, 0x84, 0xbb, 0x39, 0x8b, 0x38,
-	0x9c, 0xf2, 0x36, 0x3e, 0x05, 0xcf, 0x04, 0xc1, 0x10, 0x4b, 0x53, 0x77, 0xea, 0x41, 0xb5, 0x2b,
-	0xfb, 0xca, 0x28, 0x4e, 0xdf, 0x2f, 0x07, 0xa3, 0x58, 0xcd, 0xf7, 0x76, 0x19, 0x31, 0x69, 0xea,
-	0x5e, 0x78, 0xc4, 0x14, 0x5d, 0x9f, 0x16, 0xdd, 0x56, 0x8b, 0x72, 0x3c, 0x0b, 0xeb, 0xc4, 0x44,
-	0x8b, 0x38, 0x1c, 0xdf, 0x2f, 0x75, 0x32, 0xef, 0x10, 0x41, 0x89, 0x04, 0xf0, 0x1c, 0xbc, 0x36,
-	0xea, 0x79, 0x54, 0xf3, 0xf7, 0x49, 0x91, 0x13, 0xe9, 0xdc, 0xa6, 0xc9, 0x52, 0xd0, 0x98, 0xa7,
-	0xce, 0xa5, 0x69, 0x52, 0xa7, 0xe1, 0xdc, 0xb7, 0x0a, 0x1b, 0x9f, 0x48, 0x0e, 0x92, 0xa3, 0x5d,
-	0x0c, 0x66, 0x09, 0x0f, 0x87, 0x0f, 0x26, 0x85, 0x21, 0x65, 0x90, 0x4d, 0x44, 0xc3, 0x11, 0xb9,
-	0x5c, 0x30, 0x9e, 0x04, 0x02, 0x4d, 0x50, 0xae, 0x87, 0x1b, 0x05, 0xe3, 0x09, 0x60, 0x39, 0x35,
-	0x34, 0xef, 0x36, 0x70, 0x53, 0x0a, 0x1d, 0x46, 0x7b, 0x40, 0xa6, 0x4c, 0x5a, 0x2e, 0x6b, 0x2f,
-	0x12, 0xd3, 0xc2, 0x8e, 0x94, 0xbb, 0x35, 0x3d, 0x0c, 0x32, 0x28, 0x76, 0xa5, 0xe2, 0x11, 0x34,
-	0xda, 0x03, 0xbb, 0xc0, 0x28, 0x27, 0xd8, 0x93, 0x92, 0xdd, 0xbc, 0xcf, 0xd8, 0xa6, 0xdf, 0x8c,
-	0x06, 0xd7, 0x83, 0x12, 0x3d, 0x9a, 0x96, 0x2d, 0x71, 0xd1, 0xc2, 0x41, 0x2b, 0x35, 0x43, 0x9e,
-	0x19, 0x90, 0xeb, 0x38, 0x96, 0xae, 0xb8, 0x82, 0x63, 0xf2, 0x59, 0x75, 0x3c, 0x1a, 0x4b, 0xbf,
-	0x16, 0x12, 0xb2, 0x6a, 0x6b, 0xdf, 0x2d, 0x35, 0x33, 0xe9, 0x2b, 0x52, 0x61, 0xef, 0x81, 0x77,
-	0xa4, 0xc2, 0xe4, 0x98, 0x9a, 0x80, 0xdf, 0x88, 0x8a, 0x8a, 0xc7, 0xd5, 0x3d, 0x52, 0x2e, 0xb3,
-	0xd0, 0x31, 0x28, 0xc6, 0xd6, 0x69, 0xa9, 0x96, 0x29, 0x63, 0xd2, 0xa7, 0x1a, 0x2c, 0x67, 0x24,
-	0x7a, 0x14, 0xed, 0x85, 0xd0, 0xf4, 0x14, 0x9a, 0x94, 0xf0, 0x38, 0x3a, 0x00, 0xc1, 0x1d, 0xd3,
-	0x68, 0x0a, 0x0e, 0x76, 0xa1, 0x54, 0x4e, 0xd4, 0xb1, 0x08, 0xcf, 0xd8, 0x85, 0x52, 0x59, 0x11,
-	0xd3, 0xf0, 0x91, 0x75, 0xa1, 0x54, 0x96, 0xd5, 0x2b, 0xc1, 0x6f, 0x4c, 0x09, 0x10, 0xab, 0xb6,
-	0x86, 0x67, 0xe0, 0x01, 0xb4, 0x50, 0x2a, 0x4f, 0x93, 0x3a, 0x6b, 0x7b, 0x5c, 0xe5, 0x78, 0x16,
-	0xae, 0x5d, 0x0c, 0x12, 0x4b, 0xa1, 0xf3, 0xf0, 0xd2, 0xce, 0x53, 0xff, 0x52, 0x22, 0x3f, 0x06,
-	0x07, 0x27, 0x28, 0x85, 0xf8, 0x5d, 0xce, 0xc3, 0xd4, 0xbf, 0x24, 0x33, 0xe4, 0xf0, 0xe9, 0x4c,
-	0x11, 0x61, 0x8a, 0x81, 0x54, 0xc9, 0x34, 0xa4, 0x62, 0x54, 0xd4, 0x2b, 0x52, 0x2a, 0xb3, 0x1f,
-	0x05, 0xd6, 0xb1, 0x80, 0xab, 0x70, 0xd5, 0x04, 0x9b, 0xee, 0x8c, 0x35, 0xf8, 0x8d, 0x22, 0x4b,
-	0x11, 0xef, 0xaf, 0x36, 0x3c, 0x50, 0x05, 0x17, 0x43, 0x97, 0xf5, 0xc9, 0x3d, 0x95, 0x48, 0x8d,
-	0x5c, 0x76, 0xfd, 0x44, 0x61, 0x1f, 0xcb, 0x69, 0xb1, 0x91, 0x0e, 0x4e, 0x41, 0x8f, 0xe7, 0xf4,
-	0x3b, 0x6c, 0x5b, 0x07, 0x24, 0x8b, 0x7b, 0x25, 0xa7, 0x5f, 0x16, 0xdb, 0x41, 0x26, 0x2c, 0xef,
-	0x27, 0x72, 0x7a, 0x34, 0xec, 0x82, 0xc2, 0x8a, 0xe3, 0xff, 0x64, 0x4e, 0x8f, 0x86, 0x42, 0x07,
-	0x19, 0x63, 0x9f, 0xca, 0xe9, 0xfe, 0x49, 0x9f, 0xe2, 0x38, 0xb1, 0x6d, 0x93, 0xc9, 0xe0, 0x7e,
-	0x9e, 0xd3, 0x0d, 0xb9, 0x1b, 0xa0, 0x6a, 0x6b, 0x15, 0x4f, 0xcd, 0x86, 0x5f, 0x74, 0x89, 0x50,
-	0xa2, 0x89, 0xd2, 0xfd, 0xb2, 0x4b, 0x84, 0x92, 0x54, 0xd8, 0xaf, 0x94, 0xe0, 0xf1, 0xf4, 0x91,
-	0x5a, 0x62, 0x45, 0x46, 0xc2, 0x23, 0x72, 0x5d, 0x1c, 0x38, 0x2b, 0x1e, 0x7e, 0x2e, 0xa7, 0xa7,
-	0xd8, 0x4e, 0x00, 0x3f, 0x67, 0xb6, 0xc5, 0x4b, 0xb7, 0xe2, 0xe1, 0xe7, 0x73, 0x7a, 0xea, 0x8c,
-	0x82, 0x20, 0x6f, 0xc6, 0xf0, 0x0b, 0xbd, 0xe1, 0xb2, 0xe9, 0x98, 0x0d, 0x52, 0x59, 0x5e, 0x26,
-	0xac, 0xe2, 0xe1, 0x17, 0x15, 0x7c, 0x3b, 0x3a, 0xd4, 0x35, 0x62, 0x71, 0xc6, 0xa7, 0x2b, 0xda,
-	0xe6, 0xa5, 0x9c, 0xde, 0x11, 0x7b, 0xa0, 0x75, 0x20, 0xbc, 0xe2, 0x71, 0xea, 0x3a, 0x7e, 0xc5,
-	0xc3, 0x2f, 0xf7, 0x0e, 0x26, 0xba, 0x45, 0xd7, 0x58, 0xe0, 0x8b, 0xc8, 0xaf, 0xf5, 0x16, 0x9e,
-	0xb4, 0x6d, 0x77, 0x55, 0xb1, 0xaf, 0x28, 0xf6, 0x58, 0x7a, 0x10, 0x2b, 0x36, 0x2a, 0x72, 0x99,
-	0xb0, 0x06, 0xa9, 0x78, 0xf8, 0xd5, 0xde, 0xca, 0x51, 0x4d, 0xa6, 0x4d, 0x6e, 0x56, 0x3c, 0xfc,
-	0x5a, 0x6f, 0xe5, 0xa9, 0xa0, 0xe5, 0x55, 0x45, 0x03, 0x39, 0x75, 0xa1, 0xfc, 0x7a, 0x4e, 0xef,
-	0xe4, 0x1d, 0x5d, 0x9a, 0x32, 0xdc, 0x0d, 0x6f, 0xe4, 0xf4, 0xb4, 0x49, 0xf7, 0x38, 0x73, 0x9d,
-	0x44, 0xa3, 0xbd, 0x99, 0xd3, 0x83, 0x6b, 0x5b, 0x16, 0x53, 0xcc, 0x5b, 0x39, 0x7d, 0x48, 0xde,
-	0x9a, 0x65, 0xe4, 0x26, 0x78, 0xbb, 0xdb, 0x56, 0x97, 0x48, 0x18, 0xd2, 0x3b, 0x5d, 0xf6, 0x53,
-	0xd1, 0x64, 0x96, 0xe9, 0xb8, 0x52, 0xea, 0x1b, 0x79, 0xb8, 0x49, 0x25, 0x15, 0xbf, 0x69, 0x9f,
-	0xca, 0xeb, 0x0f, 0x03, 0x7b, 0x00, 0x30, 0xb5, 0xe3, 0xbf, 0xd9, 0x5b, 0x34, 0x06, 0xbf, 0x95,
-	0x87, 0xb7, 0x68, 0x2c, 0xaa, 0xaa, 0xf2, 0xed, 0x3c, 0xbc, 0x45, 0x25, 0xa9, 0xb0, 0xef, 0xe4,
-	0xf5, 0x3b, 0x76, 0x04, 0x4c, 0x47, 0x9c, 0x07, 0xae, 0xe6, 0xe1, 0x45, 0x4d, 0x54, 0x26, 0xac,
-	0xe0, 0x77, 0x95, 0x58, 0x66, 0xd6, 0x54, 0x1c, 0xee, 0xda, 0x6e, 0xa3, 0x9d, 0x08, 0xef, 0x37,
-	0x5d, 0x24, 0x15, 0xaa, 0xb8, 0xdf, 0xe6, 0xf5, 0x15, 0x7e, 0xb4, 0x8b, 0x64, 0x5c, 0x9d, 0xdf,
-	0xe5, 0xe1, 0x73, 0x9a, 0x82, 0x63, 0xf2, 0xf7, 0xeb, 0xc8, 0x86, 0x8b, 0xcd, 0x4c, 0xc7, 0x5f,
-	0x26, 0x0c, 0xff, 0x41, 0xc9, 0x66, 0xc6, 0x58, 0x12, 0x26, 0x96, 0xc6, 0xff, 0xa8, 0xb4, 0xc7,
-	0xd1, 0xfe, 0x6e, 0xf8, 0x05, 0xca, 0x9b, 0x16, 0x33, 0x57, 0x2b, 0x4e, 0x03, 0xff, 0x49, 0xc9,
-	0x9f, 0x44, 0xb7, 0x76, 0x97, 0x4f, 0x5a, 0xfc, 0x39, 0xaf, 0x3f, 0x3e, 0x74, 0xb5, 0xa8, 0x38,
-	0x7c, 0xce, 0x5a, 0x24, 0x0d, 0xea, 0x8b, 0xbb, 0xfc, 0x1b, 0x79, 0x78, 0xae, 0xa5, 0x7d, 0xa4,
-	0x6d, 0xfe, 0xa2, 0xbc, 0x9c, 0x42, 0x47, 0x7a, 0x7a, 0x99, 0xb4, 0xac, 0x49, 0xce, 0x19, 0x5d,
-	0x0a, 0x38, 0xf1, 0xf1, 0x5f, 0x95, 0xab, 0xbb, 0xd0, 0xb1, 0x75, 0x5c, 0xa5, 0x0d, 0xff, 0x96,
-	0xd7, 0xa7, 0x85, 0xd4, 0x26, 0x58, 0xa4, 0x9e, 0x67, 0x93, 0x44, 0xef, 0x3c, 0x3c, 0x00, 0xbf,
-	0x6f, 0x23, 0x50, 0x51, 0x1f, 0x1d, 0x80, 0x3b, 0x3b, 0xa2, 0xe4, 0x6e, 0x7e, 0x64, 0x00, 0xde,
-	0x25, 0x31, 0x14, 0x36, 0xf6, 0xa3, 0x0a, 0x7b, 0x37, 0x1a, 0x4b, 0xdd, 0x9f, 0x5d, 0x87, 0x30,
-	0x37, 0x5c, 0x79, 0xb3, 0x2e, 0x66, 0xfc, 0x9c, 0x43, 0xb9, 0x1a, 0x00, 0x7f, 0x1f, 0xd0, 0x17,
-	0xbb, 0x03, 0xeb, 0x1a, 0x89, 0x6d, 0xf6, 0x0f, 0x65, 0x90, 0xa9, 0x5c, 0x87, 0x41, 0x95, 0xf0,
-	0x39, 0xc7, 0x0b, 0xb4, 0xa7, 0x7f, 0x2a, 0xc3, 0xf5, 0xc2, 0x53, 0x86, 0xc2, 0xdb, 0xbf, 0x94,
-	0xd1, 0x19, 0x74, 0x6a, 0x9d, 0xf0, 0xbc, 0x80, 0xfb, 0xe7, 0x08, 0x6b, 0x05, 0xdc, 0x14, 0x7f,
-	0x50, 0x6e, 0xff, 0xad, 0x14, 0x4e, 0xa3, 0xdb, 0xfe, 0x3f, 0x05, 0xe1, 0xff, 0x4d, 0x65, 0x7d,
-	0x37, 0x3a, 0xbe, 0xbe, 0xf5, 0x79, 0xea, 0x50, 0xe5, 0xf7, 0x2d, 0x65, 0x79, 0x07, 0x3a, 0xdc,
-	0x9f, 0xa5, 0xf0, 0xf7, 0xb6, 0xb2, 0xba, 0x07, 0x9d, 0xec, 0x69, 0x35, 0x69, 0xdb, 0x51, 0xc0,
-	0x55, 0xa2, 0x2b, 0xfc, 0x4e, 0xbf, 0x4b, 0x93, 0x34, 0x16, 0x5e, 0xff, 0xd3, 0x6f, 0x96, 0xe2,
-	0x98, 0x10, 0xf0, 0xc4, 0xa2, 0xfe, 0xb7, 0xdf, 0x2c, 0xb5, 0xa5, 0xf0, 0xf7, 0x7e, 0xa3, 0x4f,
-	0x7f, 0x93, 0xb6, 0x5d, 0x09, 0x78, 0x22, 0xc5, 0x0f, 0x18, 0x7d, 0xfa, 0xd3, 0x96, 0xc2, 0xdf,
-	0x07, 0xfb, 0xf5, 0x17, 0x7e, 0xf4, 0x49, 0x36, 0xed, 0x87, 0xfa, 0xf5, 0xa7, 0x2d, 0x85, 0xbf,
-	0x0f, 0xf7, 0x6b, 0x35, 0x43, 0x1d, 0xd3, 0x56, 0xbe, 0x3e, 0x62, 0xc0, 0x03, 0x13, 0xb6, 0x12,
-	0x7e, 0x1e, 0x52, 0x16, 0x77, 0xa2, 0xa3, 0x9d, 0x16, 0x67, 0x49, 0x7b, 0xae, 0x65, 0x36, 0x48,
-	0x69, 0xcd, 0x73, 0x19, 0x4f, 0x6e, 0xfa, 0x47, 0x94, 0x5d, 0x66, 0xd0, 0x76, 0xb3, 0x13, 0xbe,
-	0x1e, 0xed, 0x99, 0x93, 0xb2, 0xa9, 0xb6, 0x9d, 0x7a, 0x95, 0x13, 0x7d, 0x5a, 0xff, 0x58, 0xcf,
-	0x9c, 0xb2, 0x56, 0xc2, 0xcf, 0xc7, 0x0d, 0x78, 0xa0, 0x77, 0x5a, 0xa4, 0x8a, 0xf7, 0x98, 0x32,
-	0xbb, 0x0d, 0x1d, 0xec, 0xc3, 0x4c, 0x78, 0x7a, 0xdc, 0x80, 0x47, 0x79, 0x64, 0x92, 0x18, 0xe5,
-	0x9f, 0x36, 0xe0, 0x51, 0x1e, 0x81, 0x8a, 0xfa, 0x8c, 0x01, 0x9f, 0x7a, 0xb4, 0xdc, 0x05, 0x93,
-	0xd7, 0x9b, 0xe2, 0xbd, 0xfe, 0x59, 0x03, 0x9e, 0xe7, 0x11, 0xa9, 0xb1, 0xcf, 0x19, 0xf0, 0xc5,
-	0x24, 0xfc, 0x50, 0x14, 0xb1, 0xd3, 0xd4, 0x6c, 0xa8, 0x0a, 0x7c, 0xde, 0x80, 0xef, 0x50, 0x19,
-	0x5c, 0x64, 0xfe, 0x05, 0xa5, 0x9c, 0x39, 0x2d, 0xeb, 0x50, 0x6b, 0x6b, 0x67, 0x89, 0xfe, 0xa9,
-	0xe3, 0x8b, 0x06, 0x7c, 0x60, 0x49, 0xd3, 0x42, 0xf7, 0x4b, 0x3d, 0x7b, 0x64, 0x9e, 0xae, 0x90,
-	0x45, 0xb2, 0xcc, 0x88, 0xdf, 0xac, 0x72, 0x93, 0xe9, 0x6e, 0x7c, 0xd2, 0x80, 0x8f, 0x16, 0xb0,
-	0x95, 0xf0, 0xf3, 0x65, 0xa3, 0xd7, 0xab, 0x24, 0x65, 0x11, 0xb7, 0xe2, 0x57, 0x94, 0x1b, 0xf0,
-	0x4d, 0x97, 0x31, 0x12, 0x5e, 0xbe, 0xda, 0x6f, 0x36, 0xa9, 0x46, 0xfc, 0x5a, 0xbf, 0xd9, 0xe8,
-	0x3e, 0xfc, 0xba, 0x01, 0x7f, 0x0a, 0x28, 0x65, 0x6e, 0xdc, 0xd7, 0x0c, 0xf8, 0x7e, 0x50, 0x4a,
-	0xde, 0xb7, 0x5f, 0x31, 0xf4, 0x67, 0x96, 0x2d, 0x19, 0x48, 0x9e, 0x26, 0x5e, 0xed, 0xd2, 0x27,
-	0x25, 0xd7, 0x17, 0x07, 0xe9, 0xe4, 0xbb, 0xf3, 0xd7, 0x06, 0x7c, 0xff, 0x49, 0xa0, 0x22, 0x81,
-	0xd7, 0x0c, 0xf8, 0xfe, 0x53, 0x4a, 0x7c, 0x58, 0x78, 0xbd, 0xcb, 0xee, 0x98, 0xa2, 0x8e, 0xe9,
-	0xd4, 0x93, 0x07, 0xa7, 0x1f, 0x0c, 0xc2, 0xbb, 0x43, 0x92, 0x0a, 0xfb, 0xe1, 0x20, 0x7c, 0x73,
-	0x89, 0x05, 0xe3, 0xa2, 0xfc, 0x68, 0x10, 0xbe, 0xb9, 0x48, 0x36, 0x06, 0x7f, 0x3c, 0x08, 0xdf,
-	0xae, 0x24, 0x28, 0x2b, 0xf8, 0x74, 0x6f, 0xb9, 0xf8, 0x76, 0xf5, 0x93, 0x41, 0xf8, 0xaa, 0xa1,
-	0x40, 0x79, 0x18, 0x2f, 0xfb, 0x0d, 0xfc, 0xcc, 0x20, 0x7c, 0xd5, 0x90, 0x68, 0x85, 0x59, 0x11,
-	0xf7, 0x6c, 0x6f, 0xdf, 0xd1, 0x8f, 0xb4, 0x02, 0xfc, 0x69, 0x6f, 0x41, 0xbd, 0x30, 0x3f, 0x93,
-	0x31, 0x4e, 0x9c, 0x46, 0xd7, 0xaf, 0x52, 0x46, 0x2e, 0x52, 0x67, 0x78, 0xef, 0x78, 0xf4, 0x4b,
-	0xff, 0xb8, 0xfa, 0xa5, 0x7f, 0xbc, 0xe4, 0x04, 0xad, 0xf0, 0xe7, 0x12, 0xf9, 0x95, 0x60, 0xe4,
-	0xb9, 0x87, 0x06, 0x46, 0x73, 0x63, 0x43, 0x8b, 0xd7, 0x09, 0x9b, 0x39, 0x67, 0xe2, 0x5e, 0x34,
-	0x14, 0x5a, 0xbb, 0x01, 0xef, 0xc7, 0xfc, 0x79, 0x69, 0x1e, 0xba, 0xac, 0x04, 0x7c, 0x62, 0x16,
-	0x6d, 0x0a, 0xed, 0x2d, 0x31, 0xad, 0xfa, 0x8c, 0xe1, 0x05, 0x29, 0xb2, 0x41, 0x58, 0x86, 0x63,
-	0x6e, 0xce, 0x99, 0x98, 0x43, 0x9b, 0x13, 0x42, 0x7d, 0x86, 0xf3, 0xa2, 0x54, 0xda, 0xa8, 0x95,
-	0x44, 0x4c, 0x67, 0xd0, 0x0d, 0xa1, 0x14, 0xa7, 0x4e, 0xbb, 0x1f, 0x95, 0x97, 0xa4, 0x4a, 0x58,
-	0x89, 0x1a, 0x75, 0xda, 0x13, 0xf3, 0xe8, 0xc6, 0x50, 0x61, 0xc9, 0x75, 0xb9, 0xed, 0x9a, 0x16,
-	0x61, 0xfd, 0xe8, 0xbc, 0x2c, 0x75, 0xc2, 0x44, 0xa6, 0xb4, 0xe9, 0x44, 0x11, 0x85, 0x99, 0x5e,
-	0x74, 0xdc, 0x8b, 0xcb, 0x7e, 0xab, 0x1f, 0xa5, 0x6b, 0x52, 0x29, 0xcc, 0x63, 0xc1, 0x9d, 0xf1,
-	0x5b, 0x53, 0x77, 0xa0, 0xfd, 0x75, 0xb7, 0x35, 0xee, 0x9b, 0xdc, 0xf5, 0x9b, 0xd4, 0x36, 0x97,
-	0x7c, 0xf5, 0xff, 0x79, 0xd8, 0x74, 0x49, 0x4b, 0x4d, 0x6d, 0xaa, 0x85, 0x7f, 0x94, 0x9d, 0xf3,
-	0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0xa3, 0x69, 0x67, 0x5d, 0x1f, 0x22, 0x00, 0x00,
+	// 2468 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x9a, 0xe9, 0x73, 0x1c, 0xc5,
+	0xf9, 0xc7, 0x7f, 0xbb, 0x1a, 0x81, 0x68, 0x8c, 0x69, 0x04, 0xb6, 0xe5, 0xb5, 0x65, 0xcb, 0x07,
+	0xb6, 0x7c, 0xc9, 0x36, 0x3f, 0xcc, 0x21, 0x1c, 0x62, 0x1d, 0x2b, 0x59, 0xb1, 0x56, 0xab, 0xd2,
+	0x2e, 0xf6, 0x4b, 0xd7, 0x68, 0xa7, 0xb5, 0xdb, 0xe5, 0xd9, 0x99, 0xa1, 0xa7, 0xc7, 0xd2, 0xfa,
+	0x55, 0x4e, 0x5e, 0x13, 0x48, 0xc0, 0xb9, 0xa9, 0xa4, 0x2a, 0x21, 0x57, 0x85, 0x1c, 0x4e, 0xe5,
+	0x45, 0x0e, 0x02, 0xa4, 0x2a, 0x95, 0xbc, 0x48, 0x0a, 0xb0, 0x31, 0x04, 0x72, 0x87, 0x24, 0x7f,
+	0x40, 0x2e, 0x8e, 0x24, 0xd5, 0x33, 0xdd, 0x3d, 0xc7, 0x3e, 0xbb, 0xda, 0xbc, 0xb3, 0x35, 0x9f,
+	0xe7, 0xfb, 0x1c, 0xfd, 0xf4, 0x33, 0xdd, 0x23, 0xa1, 0x8d, 0x4d, 0xe2, 0xfb, 0x66, 0x9d, 0xf8,
+	0x63, 0x1e, 0x73, 0xb9, 0x3b, 0x38, 0xd8, 0x58, 0x1d, 0xe3, 0x8c, 0x5c, 0x72, 0xd9, 0x98, 0x7a,
+	0x52, 0x18, 0xa9, 0xbb, 0x6e, 0xdd, 0x26, 0x47, 0x43, 0x62, 0x39, 0x58, 0x39, 0x6a, 0x11, 0xbf,
+	0xc6, 0xa8, 0xc7, 0x5d, 0x16, 0x59, 0x1d, 0xfc, 0xe9, 0x29, 0x74, 0x73, 0x29, 0xc2, 0xab, 0x2d,
+	0x8f, 0x0c, 0xee, 0x45, 0x9b, 0x13, 0xff, 0x3d, 0x3f, 0xe7, 0x50, 0x4e, 0x4d, 0x9b, 0x5e, 0x22,
+	0xf8, 0xff, 0x0a, 0x03, 0x8f, 0x5e, 0x19, 0xca, 0x3d, 0x73, 0x65, 0x28, 0x37, 0x58, 0x40, 0x38,
+	0x49, 0x2d, 0x52, 0xa7, 0x8e, 0x73, 0x05, 0x43, 0x3c, 0x1f, 0x1c, 0x46, 0xb7, 0x27, 0x9f, 0x55,
+	0x82, 0x5a, 0x8d, 0xf8, 0x3e, 0xce, 0x17, 0x8c, 0xcb, 0xc0, 0xe3, 0x19, 0x93, 0xda, 0x01, 0x23,
+	0xb8, 0x4f, 0x3e, 0xde, 0x89, 0x36, 0x25, 0x1f, 0x4f, 0x35, 0x4c, 0xa7, 0x4e, 0x16, 0xa9, 0x83,
+	0x0d, 0x29, 0x3f, 0x92, 0x0e, 0xf0, 0x1c, 0xf5, 0xc8, 0x34, 0xb9, 0x48, 0x6b, 0x04, 0xf7, 0xc3,
+	0xc4, 0x2c, 0xe1, 0x45, 0x87, 0x33, 0xd7, 0x6b, 0xe1, 0x9b, 0xe0, 0x10, 0xd5, 0x63, 0x24, 0x63,
+	0xc8, 0x08, 0xcc, 0xbb, 0xa6, 0x25, 0x5d, 0xdc, 0x22, 0x05, 0x76, 0xa1, 0x2d, 0x49, 0x62, 0x89,
+	0xf8, 0x84, 0x4b, 0x64, 0xa3, 0x44, 0x76, 0xa0, 0x3b, 0x52, 0x79, 0x12, 0x93, 0x07, 0x8c, 0xf8,
+	0xf8, 0x36, 0xe9, 0x64, 0x1f, 0xda, 0x9e, 0x29, 0x61, 0xc9, 0xe4, 0x8c, 0xae, 0x2d, 0x91, 0x87,
+	0x03, 0xe2, 0x73, 0x3c, 0x28, 0xb9, 0x83, 0x68, 0x08, 0xe4, 0x26, 0x6a, 0x17, 0xf0, 0xed, 0x85,
+	0x0d, 0x6a, 0x49, 0x9e, 0x8d, 0x02, 0x1f, 0x4c, 0x15, 0xcf, 0x74, 0x6a, 0xc4, 0xc6, 0x77, 0x24,
+	0x16, 0x6e, 0x77, 0x5a, 0x6d, 0xca, 0x26, 0x26, 0xab, 0x10, 0xdf, 0xa7, 0xae, 0x83, 0x87, 0x64,
+	0xe4, 0x7b, 0xd0, 0xd6, 0x24, 0x33, 0xe1, 0x79, 0x76, 0xab, 0x42, 0x38, 0xa7, 0x4e, 0xdd, 0xc7,
+	0x5b, 0x61, 0x68, 0x32, 0xe0, 0xdc, 0x75, 0x54, 0xec, 0x05, 0x19, 0xfb, 0x7e, 0xb4, 0xa9, 0x1d,
+	0x12, 0x81, 0x6f, 0x6b, 0x0b, 0x7c, 0x73, 0x9b, 0xcb, 0x19, 0xdb, 0xac, 0xfb, 0x78, 0xbb, 0xf4,
+	0x97, 0x09, 0x7c, 0xd2, 0xac, 0x5d, 0x08, 0x3c, 0x59, 0xf2, 0xdd, 0x92, 0xd9, 0x8b, 0x0a, 0xc0,
+	0xb2, 0xaa, 0xa0, 0xf6, 0xc0, 0xab, 0x2b, 0x29, 0x11, 0xd5, 0x5e, 0xa9, 0xb3, 0x1f, 0x0d, 0xa7,
+	0x4a, 0x6e, 0xfa, 0xbe, 0xd7, 0x60, 0xa6, 0x4f, 0x94, 0xd4, 0x01, 0x29, 0x75, 0x08, 0x6d, 0x85,
+	0x41, 0xa1, 0x76, 0x30, 0x93, 0xe3, 0x61, 0xb4, 0x1b, 0x86, 0x2b, 0xdc, 0xe4, 0x5a, 0xba, 0x24,
+	0xa5, 0x8f, 0xa1, 0x1d, 0x5d, 0x68, 0xa1, 0xbf, 0x90, 0xd1, 0xcf, 0x64, 0xbf, 0x44, 0x6a, 0xee,
+	0x45, 0xc2, 0x5a, 0xb2, 0x46, 0x47, 0xe0, 0xce, 0x3d, 0xe7, 0x32, 0x4b, 0xb9, 0x1e, 0x83, 0x77,
+	0xa8, 0x40, 0x84, 0xbf, 0xa3, 0xb0, 0xc2, 0x2c, 0xe1, 0xba, 0xb7, 0xef, 0x85, 0x9b, 0xa3, 0x42,
+	0xf8, 0x43, 0x77, 0xcd, 0x4c, 0xb9, 0x81, 0xc3, 0x09, 0xc3, 0xef, 0xd5, 0x55, 0x4e, 0x41, 0x33,
+	0x94, 0x35, 0x57, 0x4d, 0x46, 0x8a, 0x22, 0x49, 0x7c, 0x43, 0xd4, 0xb3, 0xdf, 0x13, 0xe0, 0x28,
+	0x2a, 0x40, 0xe0, 0x43, 0x9e, 0xed, 0x9a, 0x16, 0xbe, 0x31, 0x41, 0x1e, 0x40, 0xdb, 0x20, 0x52,
+	0x25, 0x38, 0x50, 0x18, 0xb8, 0xac, 0xd0, 0xdd, 0xe9, 0xed, 0x59, 0x21, 0xf6, 0x4a, 0x55, 0x30,
+	0x23, 0x09, 0xb9, 0x4c, 0xcf, 0xcd, 0x12, 0xbe, 0x18, 0x2c, 0xdb, 0xb4, 0x76, 0x86, 0xb4, 0xf0,
+	0xcd, 0x32, 0x8b, 0xcc, 0xbc, 0x8a, 0x81, 0x0d, 0xb2, 0x9a, 0xdb, 0xd3, 0x7b, 0xb2, 0x42, 0xeb,
+	0x4e, 0x75, 0x0d, 0xdf, 0x0a, 0x9b, 0x57, 0xf5, 0xf6, 0xdf, 0x24, 0xcd, 0xb7, 0xa1, 0xdb, 0xd2,
+	0x80, 0x58, 0x8a, 0xcd, 0x1d, 0x27, 0xdd, 0x84, 0x65, 0x31, 0x31, 0x6d, 0x87, 0xe1, 0x49, 0xa7,
+	0x1e, 0xef, 0x90, 0xea, 0x99, 0xb5, 0x14, 0xc1, 0xc9, 0xff, 0xe3, 0x7d, 0xf0, 0x5a, 0x9e, 0x25,
+	0x8c, 0xae, 0xb4, 0x14, 0xb4, 0x5f, 0x42, 0x99, 0x61, 0x26, 0xff, 0x2d, 0xe4, 0xc2, 0xce, 0xc0,
+	0xa3, 0xd2, 0x5f, 0xa6, 0x47, 0xa7, 0xa8, 0xd7, 0x20, 0xec, 0x0c, 0x69, 0x9d, 0x35, 0xed, 0x80,
+	0xe0, 0x2d, 0xb0, 0x5a, 0x44, 0x11, 0x4b, 0x73, 0xc7, 0xa4, 0x5a, 0x66, 0x7d, 0x84, 0xbb, 0x39,
+	0x8b, 0x38, 0x9c, 0xf2, 0x16, 0x3e, 0x01, 0xcf, 0x04, 0xc1, 0x10, 0x4b, 0x53, 0xf7, 0xe8, 0x41,
+	0x35, 0x9c, 0x7d, 0x65, 0x4c, 0x4d, 0x9f, 0x96, 0x83, 0x51, 0xac, 0xe6, 0x7b, 0x3a, 0x8c, 0x98,
+	0x34, 0xf5, 0x20, 0x3c, 0x62, 0xa6, 0x5c, 0x9f, 0x4e, 0xb9, 0xcd, 0x26, 0xe5, 0x78, 0x16, 0xd6,
+	0x89, 0x89, 0x26, 0x71, 0x38, 0x3e, 0x2d, 0x75, 0x32, 0xef, 0x10, 0x41, 0x89, 0x04, 0xf0, 0x1c,
+	0xbc, 0x36, 0xea, 0x79, 0x54, 0xf3, 0xf7, 0x49, 0x91, 0xa3, 0xe9, 0xdc, 0xa6, 0xc9, 0x72, 0x50,
+	0x9f, 0xa7, 0xce, 0x85, 0x69, 0x52, 0xa3, 0xe1, 0xdc, 0xb7, 0x0a, 0x1b, 0x9e, 0x4a, 0x0e, 0x92,
+	0x43, 0x1d, 0x0c, 0x66, 0x09, 0x0f, 0x87, 0x0f, 0x26, 0x85, 0x01, 0x65, 0x90, 0x4d, 0x44, 0xc3,
+	0x11, 0xb9, 0x52, 0x30, 0x9e, 0x06, 0x02, 0x4d, 0x50, 0xae, 0x87, 0xeb, 0x05, 0xe3, 0x29, 0x60,
+	0x39, 0x35, 0x34, 0xef, 0xd6, 0x71, 0x43, 0x0a, 0x1d, 0x40, 0x3b, 0x41, 0xa6, 0x44, 0x9a, 0x2e,
+	0x6b, 0x2d, 0x11, 0xd3, 0xc2, 0x8e, 0x94, 0xbb, 0x13, 0x6d, 0xeb, 0x82, 0x62, 0x57, 0x2a, 0x1e,
+	0x44, 0x23, 0x5d, 0xb0, 0x73, 0x8c, 0x72, 0x82, 0x3d, 0x29, 0xd9, 0xc9, 0xfb, 0x8c, 0x6d, 0xfa,
+	0x8d, 0x68, 0x70, 0x3d, 0x2c, 0xd1, 0x43, 0x69, 0xd9, 0x22, 0x17, 0x2d, 0x1c, 0x34, 0x53, 0x33,
+	0xe4, 0xb9, 0x3e, 0xb9, 0x8e, 0xa3, 0x68, 0x18, 0x82, 0x63, 0xf2, 0x79, 0x75, 0x3c, 0x1a, 0x45,
+	0x3b, 0x20, 0x32, 0xb1, 0xf3, 0xef, 0x93, 0x9a, 0x99, 0xf4, 0x15, 0xa9, 0xb0, 0xfb, 0xe1, 0x1d,
+	0xa9, 0x30, 0x39, 0xa6, 0xc6, 0xe1, 0x37, 0xa2, 0xa2, 0xe2, 0x71, 0xf5, 0x80, 0x94, 0xcb, 0x2c,
+	0x74, 0x0c, 0x8a, 0xb1, 0x75, 0x52, 0xaa, 0x65, 0xca, 0x98, 0xf4, 0x29, 0x7f, 0x8e, 0x4f, 0x49,
+	0xf4, 0x10, 0xda, 0x05, 0xa1, 0xe9, 0x29, 0x34, 0x21, 0xe1, 0x31, 0xb4, 0x17, 0x82, 0xdb, 0xa6,
+	0xd1, 0xa4, 0x0c, 0xf6, 0x30, 0x2c, 0x1e, 0xe5, 0x5e, 0x9c, 0x5b, 0x3c, 0x7e, 0xe2, 0xc4, 0xfd,
+	0xf8, 0x05, 0xb5, 0x48, 0xc7, 0xd1, 0x3e, 0x30, 0xb5, 0x96, 0x47, 0xac, 0x69, 0x93, 0x9b, 0xb1,
+	0xfe, 0xd5, 0xbe, 0x1e, 0x1c, 0x08, 0xb3, 0xd3, 0xa6, 0xdf, 0xc0, 0xd7, 0xfa, 0xe0, 0xdd, 0xbc,
+	0x50, 0x2c, 0x25, 0x96, 0x75, 0x0a, 0x1e, 0xf9, 0x0b, 0xc5, 0x92, 0x22, 0xa6, 0xe1, 0x13, 0xf4,
+	0x42, 0xb1, 0x24, 0x17, 0xb3, 0x08, 0xbf, 0xc0, 0x25, 0x40, 0xac, 0xea, 0x1a, 0x9e, 0x81, 0xe7,
+	0xe1, 0x42, 0xb1, 0x34, 0x4d, 0x6a, 0xac, 0xe5, 0x71, 0x55, 0xf2, 0x33, 0xf0, 0x52, 0xc6, 0x20,
+	0xb1, 0x14, 0x3a, 0x0f, 0x77, 0xda, 0x3c, 0xf5, 0x2f, 0x24, 0xf2, 0x63, 0x70, 0x70, 0x82, 0x52,
+	0x88, 0xdf, 0xe1, 0x78, 0x4e, 0xfd, 0x0b, 0x32, 0x43, 0x0e, 0x1f, 0x16, 0x15, 0x11, 0xa6, 0x18,
+	0x48, 0x95, 0xcc, 0xfe, 0x50, 0x8c, 0x8a, 0xfa, 0xa2, 0x94, 0xca, 0x8c, 0x07, 0x81, 0xb5, 0xf5,
+	0xd3, 0x2a, 0x5c, 0x35, 0xc1, 0xa6, 0x1b, 0x75, 0x0d, 0x7e, 0xc1, 0xc9, 0x52, 0xc4, 0xdb, 0xbd,
+	0x05, 0x77, 0x84, 0xe0, 0x62, 0xe8, 0x92, 0xbe, 0x48, 0xa4, 0x12, 0xa9, 0x92, 0x4b, 0xae, 0x9f,
+	0x28, 0xec, 0x13, 0x39, 0x2d, 0x36, 0xd4, 0xc6, 0x29, 0xe8, 0xc9, 0x9c, 0x7e, 0xa5, 0x6e, 0x69,
+	0x83, 0x64, 0x71, 0x2f, 0xe7, 0xf4, 0xbb, 0x6b, 0x2b, 0xc8, 0x84, 0xe5, 0xfd, 0x44, 0x4e, 0x4f,
+	0xaa, 0x61, 0x28, 0xac, 0x38, 0xfe, 0x4f, 0xe6, 0xf4, 0xa4, 0x2a, 0xb4, 0x91, 0x31, 0xf6, 0xa9,
+	0x9c, 0xee, 0x9f, 0xf4, 0xa1, 0x92, 0x13, 0xdb, 0x36, 0x99, 0x0c, 0xee, 0x67, 0x39, 0xdd, 0x90,
+	0x3b, 0x00, 0xaa, 0xba, 0x56, 0xf6, 0xd4, 0xa8, 0xfa, 0x79, 0x87, 0x08, 0x25, 0x9a, 0x28, 0xdd,
+	0x2f, 0x3a, 0x44, 0x28, 0x49, 0x85, 0xfd, 0x52, 0x09, 0x1e, 0x41, 0xbb, 0x01, 0x6c, 0x8a, 0x91,
+	0xf0, 0xc4, 0x5e, 0x13, 0xe7, 0xdf, 0xb2, 0x87, 0x5f, 0xcc, 0xe9, 0xa1, 0xba, 0x1d, 0xc0, 0x17,
+	0xcd, 0x96, 0x38, 0x03, 0x94, 0x3d, 0xfc, 0x52, 0x4e, 0x0f, 0xc1, 0x11, 0x10, 0xe4, 0x8d, 0x18,
+	0x7e, 0xb9, 0x3b, 0x5c, 0x32, 0x1d, 0xb3, 0x4e, 0xca, 0x2b, 0x2b, 0x84, 0x95, 0x3d, 0x7c, 0x55,
+	0xc1, 0x77, 0xa1, 0xfd, 0x1d, 0x23, 0x16, 0x57, 0x0e, 0x7a, 0x51, 0xdb, 0x5c, 0xcb, 0xe9, 0x1d,
+	0xb1, 0x13, 0x5a, 0x07, 0xc2, 0xcb, 0x1e, 0xa7, 0xae, 0xe3, 0x97, 0x3d, 0xfc, 0x4a, 0xf7, 0x60,
+	0xa2, 0x4b, 0x7d, 0x95, 0x05, 0xbe, 0x88, 0xfc, 0x7a, 0x77, 0xe1, 0x09, 0xdb, 0x76, 0x57, 0x15,
+	0xfb, 0xaa, 0x62, 0x33, 0x93, 0x55, 0xb1, 0x51, 0x91, 0x4b, 0x84, 0xd5, 0x49, 0xd9, 0xc3, 0xaf,
+	0x75, 0x57, 0x8e, 0x6a, 0x22, 0x46, 0x77, 0xd9, 0xc3, 0xaf, 0x77, 0x57, 0x9e, 0x0c, 0x9a, 0x5e,
+	0x45, 0x34, 0x90, 0x53, 0x13, 0xca, 0x6f, 0xe4, 0xf4, 0x4e, 0xde, 0xd6, 0xa1, 0x29, 0xc3, 0xdd,
+	0xf0, 0x66, 0x4e, 0x4f, 0x9b, 0x74, 0x8f, 0x33, 0xd7, 0x49, 0x34, 0xda, 0x5b, 0x39, 0x3d, 0xb8,
+	0xb6, 0x64, 0x31, 0xc5, 0xbc, 0x9d, 0xd3, 0x67, 0xf6, 0xcd, 0x59, 0x46, 0x6e, 0x82, 0x77, 0x3a,
+	0x6d, 0x75, 0x89, 0x84, 0x21, 0xbd, 0xdb, 0x61, 0x3f, 0x4d, 0x99, 0xcc, 0x32, 0x1d, 0x57, 0x4a,
+	0x7d, 0x23, 0x0f, 0x37, 0xa9, 0xa4, 0xe2, 0x17, 0xff, 0x33, 0x79, 0xfd, 0x9d, 0x62, 0x27, 0x00,
+	0xa6, 0x76, 0xfc, 0x37, 0xbb, 0x8b, 0xc6, 0xe0, 0xb7, 0xf2, 0xf0, 0x16, 0x8d, 0x45, 0x55, 0x55,
+	0xbe, 0x9d, 0x87, 0xb7, 0xa8, 0x24, 0x15, 0xf6, 0x9d, 0xbc, 0x3e, 0x9f, 0x0c, 0x81, 0xe9, 0x88,
+	0xe3, 0xc9, 0x95, 0x3c, 0xbc, 0xa8, 0x89, 0xca, 0x84, 0x15, 0xfc, 0xae, 0x12, 0xcb, 0xcc, 0x9a,
+	0xb2, 0xc3, 0x5d, 0xdb, 0xad, 0xb7, 0x12, 0xe1, 0xfd, 0xba, 0x83, 0xa4, 0x42, 0x15, 0xf7, 0x9b,
+	0xbc, 0xfe, 0xa2, 0x30, 0xd2, 0x41, 0x32, 0xae, 0xce, 0x6f, 0xf3, 0xf0, 0xb1, 0x51, 0xc1, 0x31,
+	0xf9, 0xbb, 0x75, 0x64, 0xc3, 0xc5, 0x66, 0xa6, 0xe3, 0xaf, 0x10, 0x86, 0x7f, 0xaf, 0x64, 0x33,
+	0x63, 0x2c, 0x09, 0x13, 0x4b, 0xe3, 0x7f, 0x50, 0xda, 0x63, 0x68, 0x4f, 0x27, 0xfc, 0x1c, 0xe5,
+	0x0d, 0x8b, 0x99, 0xab, 0x65, 0xa7, 0x8e, 0xff, 0xa8, 0xe4, 0x8f, 0xa1, 0x3b, 0x3b, 0xcb, 0x27,
+	0x2d, 0xfe, 0x94, 0xd7, 0xdf, 0x42, 0x3a, 0x5a, 0x94, 0x1d, 0x3e, 0x67, 0x2d, 0x91, 0x3a, 0xf5,
+	0xc5, 0xa7, 0x85, 0x37, 0xf3, 0xf0, 0x5c, 0x4b, 0xfb, 0x48, 0xdb, 0xfc, 0x59, 0x79, 0x39, 0x81,
+	0x0e, 0x76, 0xf5, 0x32, 0x61, 0x59, 0x13, 0x9c, 0x33, 0xba, 0x1c, 0x70, 0xe2, 0xe3, 0xbf, 0x28,
+	0x57, 0xf7, 0xa2, 0xc3, 0xeb, 0xb8, 0x4a, 0x1b, 0xfe, 0x35, 0xaf, 0x4f, 0x0b, 0xa9, 0x4d, 0xb0,
+	0x44, 0x3d, 0xcf, 0x26, 0x89, 0xde, 0x79, 0xb4, 0x0f, 0x7e, 0xdf, 0x46, 0xa0, 0xa2, 0x3e, 0xda,
+	0x07, 0x77, 0x76, 0x44, 0xc9, 0xdd, 0xfc, 0x58, 0x1f, 0xbc, 0x4b, 0x62, 0x28, 0x6c, 0xec, 0xc7,
+	0x15, 0xf6, 0xff, 0x68, 0x34, 0x89, 0x95, 0x5c, 0x87, 0x30, 0x37, 0x5c, 0x79, 0xb3, 0x26, 0x66,
+	0xbc, 0xf8, 0x2a, 0xac, 0x06, 0xc0, 0xdf, 0xfa, 0xf4, 0x3d, 0x73, 0xef, 0xba, 0x46, 0x62, 0x9b,
+	0xfd, 0x5d, 0x19, 0x64, 0x2a, 0xd7, 0x66, 0x50, 0x21, 0x7c, 0xce, 0xf1, 0x02, 0xed, 0xe9, 0x1f,
+	0xca, 0x70, 0xbd, 0xf0, 0x94, 0xa1, 0xf0, 0xf6, 0x4f, 0x65, 0x74, 0x0a, 0x9d, 0x58, 0x27, 0x3c,
+	0x2f, 0xe0, 0xfe, 0x22, 0x61, 0xcd, 0x80, 0x9b, 0xe2, 0x07, 0xca, 0xed, 0xbf, 0x94, 0xc2, 0x49,
+	0x74, 0xfc, 0x7f, 0x53, 0x10, 0xfe, 0xdf, 0x52, 0xd6, 0xf7, 0xa1, 0x23, 0xeb, 0x5b, 0x9f, 0xa5,
+	0x0e, 0x55, 0x7e, 0xdf, 0x56, 0x96, 0x77, 0xa3, 0x03, 0xbd, 0x59, 0x0a, 0x7f, 0xef, 0x28, 0xab,
+	0x07, 0xd0, 0xb1, 0xae, 0x56, 0x13, 0xb6, 0x1d, 0x05, 0x5c, 0x21, 0xba, 0xc2, 0xef, 0xf6, 0xba,
+	0x34, 0x49, 0x63, 0xe1, 0xf5, 0xdf, 0xbd, 0x66, 0x29, 0x8e, 0x09, 0x01, 0x4f, 0x2c, 0xea, 0x7f,
+	0x7a, 0xcd, 0x52, 0x5b, 0x0a, 0x7f, 0xef, 0x37, 0x7a, 0xf4, 0x37, 0x61, 0xdb, 0xe5, 0x80, 0x27,
+	0x52, 0xfc, 0x80, 0xd1, 0xa3, 0x3f, 0x6d, 0x29, 0xfc, 0x7d, 0xb0, 0x57, 0x7f, 0xe1, 0x37, 0xa8,
+	0x64, 0xd3, 0x7e, 0xa8, 0x57, 0x7f, 0xda, 0x52, 0xf8, 0xfb, 0x70, 0xaf, 0x56, 0x33, 0xd4, 0x31,
+	0x6d, 0xe5, 0xeb, 0x23, 0x06, 0x3c, 0x30, 0x61, 0x2b, 0xe1, 0xe7, 0x11, 0x65, 0x71, 0x0f, 0x3a,
+	0xd4, 0x6e, 0x71, 0x86, 0xb4, 0xe6, 0x9a, 0x66, 0x9d, 0x14, 0xd7, 0x3c, 0x97, 0xf1, 0xe4, 0xa6,
+	0x7f, 0x4c, 0xd9, 0x65, 0x06, 0x6d, 0x27, 0x3b, 0xe1, 0xeb, 0xf1, 0xae, 0x39, 0x29, 0x9b, 0x4a,
+	0xcb, 0xa9, 0x55, 0x38, 0xd1, 0xa7, 0xf5, 0x8f, 0x75, 0xcd, 0x29, 0x6b, 0x25, 0xfc, 0x7c, 0xdc,
+	0x80, 0x07, 0x7a, 0xbb, 0x45, 0xaa, 0x78, 0x4f, 0x28, 0xb3, 0xcc, 0x3d, 0xbf, 0x83, 0x99, 0xf0,
+	0xf4, 0xa4, 0x01, 0x8f, 0xf2, 0xc8, 0x24, 0x31, 0xca, 0x3f, 0x6d, 0xc0, 0xa3, 0x3c, 0x02, 0x15,
+	0xf5, 0x19, 0x03, 0x3e, 0xf5, 0x68, 0xb9, 0x73, 0x26, 0xaf, 0x35, 0xc4, 0x7b, 0xfd, 0xb3, 0x06,
+	0x3c, 0xcf, 0x23, 0x52, 0x63, 0x9f, 0x33, 0xe0, 0x8b, 0x49, 0xf8, 0xdd, 0x2a, 0x62, 0xa7, 0xa9,
+	0x59, 0x57, 0x15, 0xf8, 0xbc, 0x01, 0xdf, 0xa1, 0x32, 0xb8, 0xc8, 0xfc, 0x0b, 0x06, 0xfc, 0x85,
+	0x43, 0x87, 0x5a, 0x5d, 0x3b, 0x43, 0xf4, 0x6f, 0x5e, 0xbe, 0x68, 0xc0, 0x07, 0x96, 0x34, 0x2d,
+	0x74, 0xbf, 0xd4, 0xb5, 0x47, 0xe6, 0xe9, 0x45, 0xb2, 0x44, 0x56, 0x18, 0xf1, 0x1b, 0x15, 0x6e,
+	0x32, 0xdd, 0x8d, 0x4f, 0x1b, 0xf0, 0xd1, 0x02, 0xb6, 0x12, 0x7e, 0xbe, 0x6c, 0x74, 0x7b, 0x95,
+	0xa4, 0x2c, 0xe2, 0x56, 0xfc, 0x8a, 0x72, 0x03, 0xbe, 0xe9, 0x32, 0x46, 0xc2, 0xcb, 0x57, 0x7b,
+	0xcd, 0x26, 0xd5, 0x88, 0x5f, 0xeb, 0x35, 0x1b, 0xdd, 0x87, 0x5f, 0x37, 0xe0, 0x4f, 0x01, 0xc5,
+	0xcc, 0x8d, 0xfb, 0xba, 0x01, 0xdf, 0x0f, 0x8a, 0xc9, 0xfb, 0xf6, 0xab, 0x86, 0xfe, 0xcc, 0xb2,
+	0x29, 0x03, 0xc9, 0xd3, 0xc4, 0x6b, 0x1d, 0xfa, 0xa4, 0xe8, 0xfa, 0xe2, 0x20, 0x9d, 0x7c, 0x77,
+	0xfe, 0xca, 0x80, 0xef, 0x3f, 0x09, 0x54, 0x24, 0xf0, 0xba, 0x01, 0xdf, 0x7f, 0x8a, 0x89, 0x0f,
+	0x0b, 0x6f, 0x74, 0xd8, 0x1d, 0x93, 0xd4, 0x11, 0xbf, 0xe6, 0x4c, 0xec, 0xb6, 0xef, 0xf7, 0xc3,
+	0xbb, 0x43, 0x92, 0x0a, 0xfb, 0x41, 0x3f, 0x7c, 0x73, 0x89, 0x05, 0xe3, 0xa2, 0xfc, 0xb0, 0x1f,
+	0xbe, 0xb9, 0x48, 0x36, 0x06, 0x7f, 0xd4, 0x0f, 0xdf, 0xae, 0x24, 0x28, 0x2b, 0xf8, 0x6c, 0x77,
+	0xb9, 0xf8, 0x76, 0xf5, 0xe3, 0x7e, 0xf8, 0xaa, 0xa1, 0x40, 0x79, 0x18, 0x2f, 0xf9, 0x75, 0xfc,
+	0x5c, 0x3f, 0x7c, 0xd5, 0x90, 0x68, 0x99, 0x59, 0x11, 0xf7, 0x7c, 0x77, 0xdf, 0xd1, 0xef, 0x8c,
+	0x05, 0xf8, 0x42, 0x77, 0x41, 0xbd, 0x30, 0x3f, 0x91, 0x31, 0x8e, 0x9f, 0x44, 0x37, 0xae, 0x52,
+	0x46, 0xce, 0x53, 0x67, 0x70, 0xd7, 0x58, 0xf4, 0x87, 0x07, 0x63, 0xea, 0x0f, 0x0f, 0xc6, 0x8a,
+	0x4e, 0xd0, 0x0c, 0x7f, 0x7b, 0x23, 0xbf, 0x12, 0x0c, 0xbd, 0xf8, 0x48, 0xdf, 0x48, 0x6e, 0x74,
+	0x60, 0xe9, 0x06, 0x61, 0x33, 0xe7, 0x8c, 0x3f, 0x88, 0x06, 0x42, 0x6b, 0x37, 0xe0, 0xbd, 0x98,
+	0xbf, 0x24, 0xcd, 0x43, 0x97, 0xe5, 0x80, 0x8f, 0xcf, 0xa2, 0x5b, 0x42, 0x7b, 0x4b, 0x4c, 0xab,
+	0x1e, 0x63, 0x78, 0x59, 0x8a, 0xdc, 0x2c, 0x2c, 0xc3, 0x31, 0x37, 0xe7, 0x8c, 0xcf, 0xa1, 0x8d,
+	0x09, 0xa1, 0x1e, 0xc3, 0xb9, 0x2a, 0x95, 0x36, 0x68, 0x25, 0x11, 0xd3, 0x29, 0x74, 0x53, 0x28,
+	0xc5, 0xa9, 0xd3, 0xea, 0x45, 0xe5, 0x9a, 0x54, 0x09, 0x2b, 0x51, 0xa5, 0x4e, 0x6b, 0x7c, 0x1e,
+	0xdd, 0x1a, 0x2a, 0x2c, 0xbb, 0x2e, 0x17, 0xbf, 0xee, 0x24, 0xac, 0x17, 0x9d, 0x57, 0xa4, 0x4e,
+	0x98, 0xc8, 0xa4, 0x36, 0x1d, 0x9f, 0x42, 0x61, 0xa6, 0xe7, 0x1d, 0xf7, 0xfc, 0x8a, 0xdf, 0xec,
+	0x45, 0xe9, 0xba, 0x54, 0x0a, 0xf3, 0x58, 0x70, 0x67, 0xfc, 0xe6, 0xe4, 0xdd, 0x68, 0x4f, 0xcd,
+	0x6d, 0x8e, 0xf9, 0x26, 0x77, 0xfd, 0x06, 0xb5, 0xcd, 0x65, 0x5f, 0xfd, 0xd9, 0x89, 0x4d, 0x97,
+	0xb5, 0xd4, 0xe4, 0x2d, 0xd5, 0xf0, 0x87, 0xb2, 0x73, 0xfe, 0x3b, 0x00, 0x8c, 0x93, 0xf7, 0x3f,
+	0xae, 0x22, 0x00, 0x00,
 }
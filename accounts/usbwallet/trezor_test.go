@@ -0,0 +1,360 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet/trezor"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/golang/protobuf/proto"
+)
+
+// mockTrezor emulates a Trezor device on the USB transport level. It decodes
+// the protobuf messages written by the driver, signs with a fixed key and
+// streams the framed replies back. Every signature is preceded by a button
+// request, like on the real device.
+type mockTrezor struct {
+	key     *ecdsa.PrivateKey
+	version [3]uint32
+
+	lock    sync.Mutex
+	request []byte       // Message being received
+	kind    uint16       // Type of the message being received
+	size    int          // Total length of the message being received
+	reply   bytes.Buffer // Reply chunks not yet read
+	pending proto.Message
+	msgs    []proto.Message // All messages received so far
+
+	tx      types.TxData // Transaction being signed
+	txdata  []byte       // Transaction payload received so far
+	txtotal int          // Total length of the transaction payload
+}
+
+func newMockTrezor(key *ecdsa.PrivateKey, version [3]uint32) *mockTrezor {
+	return &mockTrezor{key: key, version: version}
+}
+
+func (d *mockTrezor) Close() error { return nil }
+
+func (d *mockTrezor) Read(b []byte) (int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.reply.Len() == 0 {
+		return 0, io.EOF
+	}
+	return d.reply.Read(b)
+}
+
+func (d *mockTrezor) Write(chunk []byte) (int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(chunk) != 64 || chunk[0] != 0x3f {
+		return 0, errors.New("invalid report")
+	}
+	if d.request == nil {
+		if chunk[1] != 0x23 || chunk[2] != 0x23 {
+			return 0, errors.New("invalid message header")
+		}
+		d.kind = binary.BigEndian.Uint16(chunk[3:5])
+		d.size = int(binary.BigEndian.Uint32(chunk[5:9]))
+		d.request = append([]byte{}, chunk[9:]...)
+	} else {
+		d.request = append(d.request, chunk[1:]...)
+	}
+	if len(d.request) >= d.size {
+		data := d.request[:d.size]
+		d.request = nil
+
+		reply, err := d.handle(d.kind, data)
+		if err != nil {
+			msg := err.Error()
+			reply = &trezor.Failure{Message: &msg}
+		}
+		d.respond(reply)
+	}
+	return len(chunk), nil
+}
+
+// respond frames a reply message into 64 byte reports.
+func (d *mockTrezor) respond(msg proto.Message) {
+	data, _ := proto.Marshal(msg)
+	payload := make([]byte, 8, 8+len(data))
+	copy(payload, []byte{0x23, 0x23})
+	binary.BigEndian.PutUint16(payload[2:], trezor.Type(msg))
+	binary.BigEndian.PutUint32(payload[4:], uint32(len(data)))
+	payload = append(payload, data...)
+
+	for len(payload) > 0 {
+		chunk := make([]byte, 64)
+		chunk[0] = 0x3f
+		n := copy(chunk[1:], payload)
+		payload = payload[n:]
+		d.reply.Write(chunk)
+	}
+}
+
+// confirm returns a button request, deferring the given reply until the user
+// confirmation is acknowledged.
+func (d *mockTrezor) confirm(reply proto.Message) proto.Message {
+	d.pending = reply
+	return new(trezor.ButtonRequest)
+}
+
+// handle processes a single request message and returns the reply.
+func (d *mockTrezor) handle(kind uint16, data []byte) (proto.Message, error) {
+	var (
+		req   proto.Message
+		known = []proto.Message{
+			new(trezor.Initialize), new(trezor.Ping), new(trezor.ButtonAck),
+			new(trezor.EthereumGetAddress), new(trezor.EthereumSignTx), new(trezor.EthereumSignTxEIP1559),
+			new(trezor.EthereumTxAck), new(trezor.EthereumSignTypedHash),
+		}
+	)
+	for _, msg := range known {
+		if trezor.Type(msg) == kind {
+			req = msg
+		}
+	}
+	if req == nil {
+		return nil, errors.New("unexpected message " + trezor.Name(kind))
+	}
+	if err := proto.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	d.msgs = append(d.msgs, req)
+
+	switch req := req.(type) {
+	case *trezor.Initialize:
+		label := "mock"
+		return &trezor.Features{MajorVersion: &d.version[0], MinorVersion: &d.version[1], PatchVersion: &d.version[2], Label: &label}, nil
+
+	case *trezor.Ping:
+		return new(trezor.Success), nil
+
+	case *trezor.ButtonAck:
+		if d.pending == nil {
+			return nil, errors.New("unexpected button ack")
+		}
+		reply := d.pending
+		d.pending = nil
+		return reply, nil
+
+	case *trezor.EthereumGetAddress:
+		address := crypto.PubkeyToAddress(d.key.PublicKey).Hex()
+		return &trezor.EthereumAddress{AddressHex: &address}, nil
+
+	case *trezor.EthereumSignTx:
+		tx := &types.LegacyTx{
+			Nonce:    new(big.Int).SetBytes(req.Nonce).Uint64(),
+			GasPrice: new(big.Int).SetBytes(req.GasPrice),
+			Gas:      new(big.Int).SetBytes(req.GasLimit).Uint64(),
+			Value:    new(big.Int).SetBytes(req.Value),
+		}
+		if req.ToHex != nil {
+			to := common.HexToAddress(req.GetToHex())
+			tx.To = &to
+		}
+		return d.startTx(tx, req.DataInitialChunk, int(req.GetDataLength()))
+
+	case *trezor.EthereumSignTxEIP1559:
+		tx := &types.DynamicFeeTx{
+			ChainID: new(big.Int).SetUint64(req.GetChainId()),
+			Nonce:   new(big.Int).SetBytes(req.Nonce).Uint64(),
+			Tip:     new(big.Int).SetBytes(req.MaxPriorityFee),
+			FeeCap:  new(big.Int).SetBytes(req.MaxGasFee),
+			Gas:     new(big.Int).SetBytes(req.GasLimit).Uint64(),
+			Value:   new(big.Int).SetBytes(req.Value),
+		}
+		if req.GetTo() != "" {
+			to := common.HexToAddress(req.GetTo())
+			tx.To = &to
+		}
+		for _, entry := range req.AccessList {
+			tuple := types.AccessTuple{Address: common.HexToAddress(entry.GetAddress())}
+			for _, key := range entry.StorageKeys {
+				tuple.StorageKeys = append(tuple.StorageKeys, common.BytesToHash(key))
+			}
+			tx.AccessList = append(tx.AccessList, tuple)
+		}
+		return d.startTx(tx, req.DataInitialChunk, int(req.GetDataLength()))
+
+	case *trezor.EthereumTxAck:
+		if d.tx == nil {
+			return nil, errors.New("unexpected transaction data")
+		}
+		d.txdata = append(d.txdata, req.DataChunk...)
+		return d.continueTx()
+
+	case *trezor.EthereumSignTypedHash:
+		sig, err := crypto.Sign(crypto.Keccak256([]byte{0x19, 0x01}, req.DomainSeparatorHash, req.MessageHash), d.key)
+		if err != nil {
+			return nil, err
+		}
+		sig[64] += 27
+		address := crypto.PubkeyToAddress(d.key.PublicKey).Hex()
+		return d.confirm(&trezor.EthereumTypedDataSignature{Signature: sig, Address: &address}), nil
+	}
+	return nil, errors.New("unhandled message")
+}
+
+func (d *mockTrezor) startTx(tx types.TxData, data []byte, length int) (proto.Message, error) {
+	d.tx, d.txdata, d.txtotal = tx, append([]byte{}, data...), length
+	return d.continueTx()
+}
+
+// continueTx requests the next chunk of transaction data, or signs the
+// transaction if all of it was received.
+func (d *mockTrezor) continueTx() (proto.Message, error) {
+	if left := d.txtotal - len(d.txdata); left > 0 {
+		if left > 1024 {
+			left = 1024
+		}
+		length := uint32(left)
+		return &trezor.EthereumTxRequest{DataLength: &length}, nil
+	}
+	var signer types.Signer
+	switch tx := d.tx.(type) {
+	case *types.LegacyTx:
+		tx.Data = d.txdata
+		signer = types.NewEIP155Signer(big.NewInt(1337))
+	case *types.DynamicFeeTx:
+		tx.Data = d.txdata
+		signer = types.NewLondonSigner(tx.ChainID)
+	}
+	sig, err := crypto.Sign(signer.Hash(types.NewTx(d.tx)).Bytes(), d.key)
+	if err != nil {
+		return nil, err
+	}
+	v := uint32(sig[64])
+	if _, legacy := d.tx.(*types.LegacyTx); legacy {
+		v += 1337*2 + 35
+	}
+	d.tx, d.txdata = nil, nil
+	return d.confirm(&trezor.EthereumTxRequest{SignatureV: &v, SignatureR: sig[:32], SignatureS: sig[32:64]}), nil
+}
+
+func newTestTrezor(t *testing.T, version [3]uint32) (*trezorDriver, *mockTrezor, common.Address) {
+	key, _ := crypto.GenerateKey()
+	device := newMockTrezor(key, version)
+	driver := newTrezorDriver(log.Root()).(*trezorDriver)
+	if err := driver.Open(device, ""); err != nil {
+		t.Fatalf("failed to open trezor: %v", err)
+	}
+	return driver, device, crypto.PubkeyToAddress(key.PublicKey)
+}
+
+func TestTrezorSignTx(t *testing.T) {
+	to := common.HexToAddress("0x1234")
+	chainID := big.NewInt(1337)
+	accessList := types.AccessList{{Address: to, StorageKeys: []common.Hash{{0x01}, {0x02}}}}
+
+	tests := []struct {
+		name string
+		tx   types.TxData
+	}{
+		{"eip155", &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 21000, To: &to, Value: big.NewInt(3)}},
+		{"eip155-data", &types.LegacyTx{Nonce: 1, GasPrice: big.NewInt(2), Gas: 100000, To: &to, Data: make([]byte, 3000)}},
+		{"eip1559", &types.DynamicFeeTx{ChainID: chainID, Nonce: 1, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 30000, To: &to, Value: big.NewInt(3), AccessList: accessList}},
+		{"eip1559-create", &types.DynamicFeeTx{ChainID: chainID, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 1000000, Data: make([]byte, 2500)}},
+	}
+	for _, test := range tests {
+		driver, _, address := newTestTrezor(t, [3]uint32{2, 4, 2})
+		tx := types.NewTx(test.tx)
+
+		sender, signed, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, chainID)
+		if err != nil {
+			t.Errorf("%s: failed to sign transaction: %v", test.name, err)
+			continue
+		}
+		if sender != address {
+			t.Errorf("%s: sender mismatch: have %x, want %x", test.name, sender, address)
+		}
+		if signed.Type() != tx.Type() {
+			t.Errorf("%s: transaction type mismatch: have %d, want %d", test.name, signed.Type(), tx.Type())
+		}
+		if from, err := types.Sender(types.LatestSignerForChainID(chainID), signed); err != nil || from != address {
+			t.Errorf("%s: signature recovery failed: have %x (%v), want %x", test.name, from, err, address)
+		}
+	}
+}
+
+func TestTrezorSignTxUnsupported(t *testing.T) {
+	chainID := big.NewInt(1337)
+
+	// EIP-1559 needs recent firmwares
+	for _, version := range [][3]uint32{{1, 10, 3}, {2, 4, 1}} {
+		driver, _, _ := newTestTrezor(t, version)
+		tx := types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Tip: big.NewInt(1), FeeCap: big.NewInt(5), Gas: 21000})
+		if _, _, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, chainID); err == nil {
+			t.Errorf("v%v: dynamic fee transaction signed by old firmware", version)
+		}
+	}
+	// Access list transactions are not supported at all
+	driver, device, _ := newTestTrezor(t, [3]uint32{2, 4, 2})
+	tx := types.NewTx(&types.AccessListTx{ChainID: chainID, GasPrice: big.NewInt(1), Gas: 21000})
+	if _, _, err := driver.SignTx(accounts.DefaultBaseDerivationPath, tx, chainID); err != types.ErrTxTypeNotSupported {
+		t.Errorf("access list transaction error mismatch: have %v, want %v", err, types.ErrTxTypeNotSupported)
+	}
+	for _, msg := range device.msgs {
+		if _, ok := msg.(*trezor.EthereumSignTx); ok {
+			t.Errorf("access list transaction sent to device")
+		}
+	}
+}
+
+func TestTrezorSignTypedMessage(t *testing.T) {
+	domain, message := crypto.Keccak256([]byte("domain")), crypto.Keccak256([]byte("message"))
+
+	driver, device, address := newTestTrezor(t, [3]uint32{1, 10, 6})
+	sig, err := driver.SignTypedMessage(accounts.DefaultBaseDerivationPath, domain, message)
+	if err != nil {
+		t.Fatalf("failed to sign typed message: %v", err)
+	}
+	req, ok := device.msgs[len(device.msgs)-2].(*trezor.EthereumSignTypedHash)
+	if !ok {
+		t.Fatalf("unexpected request type %T", device.msgs[len(device.msgs)-2])
+	}
+	if !bytes.Equal(req.DomainSeparatorHash, domain) || !bytes.Equal(req.MessageHash, message) {
+		t.Fatalf("request mismatch: have %x/%x, want %x/%x", req.DomainSeparatorHash, req.MessageHash, domain, message)
+	}
+	sig[64] -= 27
+	pubkey, err := crypto.SigToPub(crypto.Keccak256([]byte{0x19, 0x01}, domain, message), sig)
+	if err != nil || crypto.PubkeyToAddress(*pubkey) != address {
+		t.Fatalf("signature recovery failed: %v", err)
+	}
+	// Old firmwares and the Model T can't sign typed data hashes
+	for _, version := range [][3]uint32{{1, 10, 5}, {2, 4, 2}} {
+		driver, _, _ := newTestTrezor(t, version)
+		if _, err := driver.SignTypedMessage(accounts.DefaultBaseDerivationPath, domain, message); err == nil {
+			t.Errorf("v%v: typed message signed by unsupported firmware", version)
+		}
+	}
+}
@@ -68,7 +68,9 @@ type driver interface {
 	// or deny the transaction.
 	SignTx(path accounts.DerivationPath, tx *types.Transaction, chainID *big.Int) (common.Address, *types.Transaction, error)
 
-	SignTypedMessage(path accounts.DerivationPath, messageHash []byte, domainHash []byte) ([]byte, error)
+	// SignTypedMessage sends the EIP-712 domain and message hashes to the USB
+	// device and waits for the user to sign or deny them.
+	SignTypedMessage(path accounts.DerivationPath, domainHash []byte, messageHash []byte) ([]byte, error)
 }
 
 // wallet represents the common functionality shared by all USB hardware
@@ -560,11 +562,15 @@ func (w *wallet) SignData(account accounts.Account, mimeType string, data []byte
 		w.hub.commsPend--
 		w.hub.commsLock.Unlock()
 	}()
-	// Sign the transaction
+	// Sign the typed data
 	signature, err := w.driver.SignTypedMessage(path, data[2:34], data[34:66])
 	if err != nil {
 		return nil, err
 	}
+	// The devices return V as 27/28, convert it to 0/1 like the other wallets
+	if len(signature) == crypto.SignatureLength && signature[64] >= 27 {
+		signature[64] -= 27
+	}
 	return signature, nil
 }
 
@@ -0,0 +1,101 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package usbwallet
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/karalabe/usb"
+)
+
+// newTestWallet creates an opened wallet communicating with a mock device.
+func newTestWallet(t *testing.T, scheme string, driver driver, device usb.Device) *wallet {
+	w := &wallet{
+		hub:    new(Hub),
+		driver: driver,
+		url:    &accounts.URL{Scheme: scheme, Path: "mock"},
+		device: device,
+		log:    log.Root(),
+	}
+	w.commsLock = make(chan struct{}, 1)
+	w.commsLock <- struct{}{}
+
+	if err := w.Open(""); err != nil {
+		t.Fatalf("failed to open wallet: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestWalletSigning(t *testing.T) {
+	var (
+		ledgerKey, _ = crypto.GenerateKey()
+		trezorKey, _ = crypto.GenerateKey()
+	)
+	wallets := map[string]*wallet{
+		LedgerScheme: newTestWallet(t, LedgerScheme, newLedgerDriver(log.Root()), newMockLedger(ledgerKey, [3]byte{1, 9, 0})),
+		TrezorScheme: newTestWallet(t, TrezorScheme, newTrezorDriver(log.Root()), newMockTrezor(trezorKey, [3]uint32{1, 10, 6})),
+	}
+	for scheme, w := range wallets {
+		account, err := w.Derive(accounts.DefaultBaseDerivationPath, true)
+		if err != nil {
+			t.Fatalf("%s: failed to derive account: %v", scheme, err)
+		}
+		if !w.Contains(account) {
+			t.Fatalf("%s: derived account not pinned", scheme)
+		}
+		// Sign a dynamic fee transaction
+		var (
+			chainID = big.NewInt(1337)
+			to      = common.HexToAddress("0x1234")
+			tx      = types.NewTx(&types.DynamicFeeTx{ChainID: chainID, Nonce: 5, Tip: big.NewInt(1), FeeCap: big.NewInt(10), Gas: 21000, To: &to, Value: big.NewInt(1)})
+		)
+		signed, err := w.SignTxWithPassphrase(account, "", tx, chainID)
+		if err != nil {
+			t.Fatalf("%s: failed to sign transaction: %v", scheme, err)
+		}
+		if sender, err := types.Sender(types.NewLondonSigner(chainID), signed); err != nil || sender != account.Address {
+			t.Fatalf("%s: sender mismatch: have %x (%v), want %x", scheme, sender, err, account.Address)
+		}
+		// Sign EIP-712 typed data, the signature must be in the [R || S || V]
+		// format with V being 0 or 1, like the other wallets return it
+		data := append([]byte{0x19, 0x01}, crypto.Keccak256([]byte("domain"))...)
+		data = append(data, crypto.Keccak256([]byte("message"))...)
+
+		sig, err := w.SignData(account, accounts.MimetypeTypedData, data)
+		if err != nil {
+			t.Fatalf("%s: failed to sign typed data: %v", scheme, err)
+		}
+		pubkey, err := crypto.SigToPub(crypto.Keccak256(data), sig)
+		if err != nil {
+			t.Fatalf("%s: failed to recover signer: %v", scheme, err)
+		}
+		if signer := crypto.PubkeyToAddress(*pubkey); signer != account.Address {
+			t.Fatalf("%s: signer mismatch: have %x, want %x", scheme, signer, account.Address)
+		}
+		// Arbitrary data can't be signed by hardware wallets
+		if _, err := w.SignData(account, accounts.MimetypeTextPlain, []byte("hello")); err != accounts.ErrNotSupported {
+			t.Fatalf("%s: plain data signing error mismatch: have %v, want %v", scheme, err, accounts.ErrNotSupported)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package light implements a beacon chain light client.
+//
+// The client follows the head of the chain using light client updates signed
+// by the sync committee and verifies the BLS aggregate signatures of the
+// updates. Light client data is fetched from a file or a beacon node REST API.
+// The execution payload headers proven by the updates anchor the state of the
+// execution layer, which is queried with eth_getProof from untrusted nodes.
+//
+// The client is run by cmd/rpcproxy (--beacon.api or --beacon.file, along with
+// --beacon.checkpoint), which serves the verified state over JSON-RPC. It is not
+// available as a sync mode of geth itself.
+package light
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxUpdates is the number of periods requested from the update source at once.
+const maxUpdates = 128
+
+// Config contains the settings of a light client.
+type Config struct {
+	// Chain is the configuration of the beacon chain.
+	Chain *ChainConfig
+
+	// Checkpoint is the root of a trusted beacon block the client starts
+	// syncing from. It should be a recent finalized block.
+	Checkpoint common.Hash
+
+	// SyncInterval is the time between polls of the update source. It
+	// defaults to 12 seconds, the slot time of the beacon chain.
+	SyncInterval time.Duration
+}
+
+// Client is a beacon chain light client. It tracks the head of the chain and
+// answers state queries with proofs retrieved from an execution node.
+type Client struct {
+	config Config
+	source UpdateSource
+	store  *Store
+	exec   *rpc.Client
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewClient creates a light client. The bootstrap data of the checkpoint is
+// fetched from the source. State queries are sent to the given execution
+// layer node, which doesn't need to be trusted.
+func NewClient(ctx context.Context, config Config, source UpdateSource, exec *rpc.Client) (*Client, error) {
+	if config.Chain == nil {
+		config.Chain = MainnetConfig
+	}
+	if config.SyncInterval == 0 {
+		config.SyncInterval = 12 * time.Second
+	}
+	bootstrap, err := source.Bootstrap(ctx, config.Checkpoint)
+	if err != nil {
+		return nil, fmt.Errorf("can't fetch bootstrap data: %v", err)
+	}
+	store, err := NewStore(config.Chain, config.Checkpoint, bootstrap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bootstrap data: %v", err)
+	}
+	return &Client{
+		config:  config,
+		source:  source,
+		store:   store,
+		exec:    exec,
+		closeCh: make(chan struct{}),
+	}, nil
+}
+
+// Store returns the light client store.
+func (c *Client) Store() *Store {
+	return c.store
+}
+
+// Start launches the sync loop.
+func (c *Client) Start() {
+	c.wg.Add(1)
+	go c.loop()
+}
+
+// Stop terminates the sync loop.
+func (c *Client) Stop() {
+	close(c.closeCh)
+	c.wg.Wait()
+}
+
+func (c *Client) loop() {
+	defer c.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-c.closeCh
+		cancel()
+	}()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			if err := c.Sync(ctx); err != nil && ctx.Err() == nil {
+				log.Warn("Beacon light client sync failed", "err", err)
+			}
+			head := c.store.Optimistic()
+			log.Debug("Beacon light client synced", "slot", head.Beacon.Slot, "finalized", c.store.Finalized().Beacon.Slot)
+			timer.Reset(c.config.SyncInterval)
+		case <-c.closeCh:
+			return
+		}
+	}
+}
+
+// Sync fetches and processes all available updates from the source.
+func (c *Client) Sync(ctx context.Context) error {
+	for {
+		period, nextKnown := c.store.Period()
+		updates, err := c.source.Updates(ctx, period, maxUpdates)
+		if err != nil && err != ErrNotAvailable {
+			return err
+		}
+		for _, u := range updates {
+			if err := c.store.ProcessUpdate(u); err != nil {
+				return fmt.Errorf("invalid update for slot %d: %v", u.AttestedHeader.Beacon.Slot, err)
+			}
+		}
+		if newPeriod, newNextKnown := c.store.Period(); newPeriod == period && newNextKnown == nextKnown {
+			break
+		}
+	}
+	for _, fetch := range []func(context.Context) (*Update, error){c.source.FinalityUpdate, c.source.OptimisticUpdate} {
+		u, err := fetch(ctx)
+		if err == ErrNotAvailable {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.store.ProcessUpdate(u); err != nil {
+			return fmt.Errorf("invalid update for slot %d: %v", u.AttestedHeader.Beacon.Slot, err)
+		}
+	}
+	return nil
+}
+
+// Head returns the execution payload header of the latest block signed by the
+// sync committee.
+func (c *Client) Head() *ExecutionHeader {
+	return c.store.Optimistic().Execution
+}
+
+// Finalized returns the execution payload header of the latest finalized block.
+func (c *Client) Finalized() *ExecutionHeader {
+	return c.store.Finalized().Execution
+}
+
+// Proof fetches the proof of an account and the given storage slots at the
+// head block and verifies it against the proven state root.
+func (c *Client) Proof(ctx context.Context, account common.Address, keys []common.Hash) (*AccountProof, error) {
+	head := c.Head()
+	if head == nil {
+		return nil, errors.New("no execution header available")
+	}
+	return c.proofAt(ctx, head, account, keys)
+}
+
+func (c *Client) proofAt(ctx context.Context, head *ExecutionHeader, account common.Address, keys []common.Hash) (*AccountProof, error) {
+	proof, _, err := FetchProof(ctx, c.exec, head.BlockHash, head.StateRoot, account, keys)
+	return proof, err
+}
+
+// BalanceAt returns the balance of an account at the head block.
+func (c *Client) BalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	proof, err := c.Proof(ctx, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Balance.ToInt(), nil
+}
+
+// NonceAt returns the nonce of an account at the head block.
+func (c *Client) NonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	proof, err := c.Proof(ctx, account, nil)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(proof.Nonce), nil
+}
+
+// StorageAt returns the value of a storage slot of an account at the head block.
+func (c *Client) StorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	proof, err := c.Proof(ctx, account, []common.Hash{key})
+	if err != nil {
+		return nil, err
+	}
+	return common.BigToHash(proof.StorageProof[0].Value.ToInt()).Bytes(), nil
+}
+
+// CodeAt returns the code of an account at the head block. The code is
+// checked against the proven code hash of the account.
+func (c *Client) CodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	head := c.Head()
+	if head == nil {
+		return nil, errors.New("no execution header available")
+	}
+	proof, err := c.proofAt(ctx, head, account, nil)
+	if err != nil {
+		return nil, err
+	}
+	var code hexutil.Bytes
+	if err := c.exec.CallContext(ctx, &code, "eth_getCode", account, head.BlockHash); err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(code); hash != proof.CodeHash {
+		return nil, fmt.Errorf("code hash mismatch: have %x, proven %x", hash, proof.CodeHash)
+	}
+	return code, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/internal/testproof"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testAccount = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testSlot    = common.HexToHash("0x01")
+	testCode    = []byte{0x60, 0x00, 0x60, 0x00, 0xf3}
+)
+
+func newTestClient(t *testing.T, api *testproof.API) *Client {
+	chain := newTestChain(api.State.IntermediateRoot(false))
+
+	// Write the light client data to a file.
+	dir, err := ioutil.TempDir("", "beacon-light-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "updates.json")
+	blob, err := json.Marshal(&fileData{Bootstraps: []*Bootstrap{chain.bootstrap}, Updates: chain.updates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, blob, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", api); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Stop)
+
+	config := Config{Chain: testConfig, Checkpoint: chain.checkpoint}
+	client, err := NewClient(context.Background(), config, NewFileSource(path), rpc.DialInProc(server))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sync(context.Background()); err != nil {
+		t.Fatal("sync failed:", err)
+	}
+	if slot := client.Store().Optimistic().Beacon.Slot; slot != SlotsPerPeriod+300 {
+		t.Fatalf("wrong head slot %d after sync", slot)
+	}
+	return client
+}
+
+func newTestState(t *testing.T) *state.StateDB {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetBalance(testAccount, big.NewInt(1000))
+	statedb.SetNonce(testAccount, 5)
+	statedb.SetCode(testAccount, testCode)
+	statedb.SetState(testAccount, testSlot, common.HexToHash("0x2a"))
+	statedb.SetBalance(common.HexToAddress("0x02"), big.NewInt(1))
+	if _, err := statedb.Commit(false); err != nil {
+		t.Fatal(err)
+	}
+	return statedb
+}
+
+func TestClientState(t *testing.T) {
+	client := newTestClient(t, &testproof.API{State: newTestState(t)})
+	ctx := context.Background()
+
+	if balance, err := client.BalanceAt(ctx, testAccount); err != nil || balance.Int64() != 1000 {
+		t.Errorf("BalanceAt: %v, %v", balance, err)
+	}
+	if nonce, err := client.NonceAt(ctx, testAccount); err != nil || nonce != 5 {
+		t.Errorf("NonceAt: %v, %v", nonce, err)
+	}
+	if code, err := client.CodeAt(ctx, testAccount); err != nil || !bytes.Equal(code, testCode) {
+		t.Errorf("CodeAt: %x, %v", code, err)
+	}
+	if value, err := client.StorageAt(ctx, testAccount, testSlot); err != nil || common.BytesToHash(value) != common.HexToHash("0x2a") {
+		t.Errorf("StorageAt: %x, %v", value, err)
+	}
+	// Accounts which don't exist are proven absent.
+	missing := common.HexToAddress("0x03")
+	if balance, err := client.BalanceAt(ctx, missing); err != nil || balance.Sign() != 0 {
+		t.Errorf("BalanceAt of missing account: %v, %v", balance, err)
+	}
+	if value, err := client.StorageAt(ctx, missing, testSlot); err != nil || common.BytesToHash(value) != (common.Hash{}) {
+		t.Errorf("StorageAt of missing account: %x, %v", value, err)
+	}
+}
+
+func TestClientTamperedState(t *testing.T) {
+	client := newTestClient(t, &testproof.API{State: newTestState(t), Tamper: true})
+	ctx := context.Background()
+
+	if _, err := client.BalanceAt(ctx, testAccount); err == nil {
+		t.Error("tampered balance accepted")
+	}
+	if _, err := client.StorageAt(ctx, testAccount, testSlot); err == nil {
+		t.Error("tampered storage accepted")
+	}
+	if _, err := client.CodeAt(ctx, testAccount); err == nil {
+		t.Error("tampered code accepted")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+// signatureDST is the domain separation tag of consensus layer BLS signatures.
+var signatureDST = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+// committee is a sync committee with decoded public keys, ready to verify
+// signatures.
+type committee struct {
+	root    common.Hash
+	pubkeys []*bls12381.PointG1
+}
+
+// newCommittee decodes and checks the public keys of a sync committee.
+func newCommittee(sc *SyncCommittee) (*committee, error) {
+	g1 := bls12381.NewG1()
+	c := &committee{
+		root:    sc.Root(),
+		pubkeys: make([]*bls12381.PointG1, len(sc.Pubkeys)),
+	}
+	for i := range sc.Pubkeys {
+		pk, err := g1.FromCompressed(sc.Pubkeys[i][:])
+		if err != nil {
+			return nil, err
+		}
+		if g1.IsZero(pk) {
+			return nil, errors.New("public key is the point at infinity")
+		}
+		c.pubkeys[i] = pk
+	}
+	return c, nil
+}
+
+// verify checks the aggregate signature of the participating committee
+// members over the given signing root.
+func (c *committee) verify(signingRoot common.Hash, aggregate *SyncAggregate) bool {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+
+	pk := g1.Zero()
+	for i, key := range c.pubkeys {
+		if aggregate.signed(i) {
+			g1.Add(pk, pk, key)
+		}
+	}
+	if g1.IsZero(pk) {
+		return false
+	}
+	sig, err := g2.FromCompressed(aggregate.Signature[:])
+	if err != nil {
+		return false
+	}
+	msg, err := g2.HashToCurve(signingRoot[:], signatureDST)
+	if err != nil {
+		return false
+	}
+	return bls12381.NewPairingEngine().AddPair(pk, msg).AddPairInv(g1.One(), sig).Check()
+}
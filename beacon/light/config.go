@@ -0,0 +1,128 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// SlotsPerEpoch is the number of slots in an epoch.
+	SlotsPerEpoch = 32
+
+	// EpochsPerPeriod is the number of epochs a sync committee serves.
+	EpochsPerPeriod = 256
+
+	// SlotsPerPeriod is the number of slots a sync committee serves.
+	SlotsPerPeriod = SlotsPerEpoch * EpochsPerPeriod
+
+	// SyncCommitteeSize is the number of validators in a sync committee.
+	SyncCommitteeSize = 512
+)
+
+// domainSyncCommittee is the signature domain type of sync committee messages.
+var domainSyncCommittee = [4]byte{0x07, 0x00, 0x00, 0x00}
+
+// Fork is a consensus layer fork, identified by its version.
+type Fork struct {
+	Name    string
+	Epoch   uint64
+	Version [4]byte
+}
+
+// ChainConfig contains the parameters of a beacon chain needed to verify
+// sync committee signatures.
+type ChainConfig struct {
+	GenesisValidatorsRoot common.Hash
+
+	// Forks are the scheduled forks of the chain, sorted by epoch.
+	Forks []Fork
+}
+
+// MainnetConfig is the chain config of the Ethereum mainnet beacon chain.
+var MainnetConfig = &ChainConfig{
+	GenesisValidatorsRoot: common.HexToHash("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+	Forks: []Fork{
+		{Name: "phase0", Epoch: 0, Version: [4]byte{0x00, 0x00, 0x00, 0x00}},
+		{Name: "altair", Epoch: 74240, Version: [4]byte{0x01, 0x00, 0x00, 0x00}},
+		{Name: "bellatrix", Epoch: 144896, Version: [4]byte{0x02, 0x00, 0x00, 0x00}},
+		{Name: "capella", Epoch: 194048, Version: [4]byte{0x03, 0x00, 0x00, 0x00}},
+		{Name: "deneb", Epoch: 269568, Version: [4]byte{0x04, 0x00, 0x00, 0x00}},
+		{Name: "electra", Epoch: 364032, Version: [4]byte{0x05, 0x00, 0x00, 0x00}},
+	},
+}
+
+// fork returns the fork active at the given slot.
+func (c *ChainConfig) fork(slot uint64) Fork {
+	var active Fork
+	for _, f := range c.Forks {
+		if f.Epoch <= slot/SlotsPerEpoch {
+			active = f
+		}
+	}
+	return active
+}
+
+// electra reports whether the beacon state at the given slot has the layout
+// introduced by the Electra fork, which moves the proven state fields one
+// level deeper.
+func (c *ChainConfig) electra(slot uint64) bool {
+	for _, f := range c.Forks {
+		if f.Name == "electra" {
+			return slot/SlotsPerEpoch >= f.Epoch
+		}
+	}
+	return false
+}
+
+// signingRoot computes the root signed by the sync committee for a header
+// attested in a block of the given signature slot.
+func (c *ChainConfig) signingRoot(header common.Hash, signatureSlot uint64) common.Hash {
+	slot := signatureSlot
+	if slot > 0 {
+		slot--
+	}
+	version := c.fork(slot).Version
+
+	var versionChunk common.Hash
+	copy(versionChunk[:], version[:])
+	forkDataRoot := hashPair(versionChunk, c.GenesisValidatorsRoot)
+
+	var domain common.Hash
+	copy(domain[:4], domainSyncCommittee[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return hashPair(header, domain)
+}
+
+// Generalized indices of the fields proven by light client data. The state
+// indices differ before and after the Electra fork.
+const (
+	executionPayloadIndex = 25 // execution payload header in the block body
+
+	finalizedRootIndex        = 105 // finalized checkpoint root in the state
+	currentSyncCommitteeIndex = 54
+	nextSyncCommitteeIndex    = 55
+
+	finalizedRootIndexElectra        = 169
+	currentSyncCommitteeIndexElectra = 86
+	nextSyncCommitteeIndexElectra    = 87
+)
+
+// SyncPeriod returns the sync committee period of a slot.
+func SyncPeriod(slot uint64) uint64 {
+	return slot / SlotsPerPeriod
+}
@@ -0,0 +1,274 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// The JSON encoding of the light client data structures follows the beacon
+// node REST API, which encodes integers as decimal strings.
+
+// decimal marshals a uint64 as a decimal string.
+type decimal uint64
+
+// MarshalText implements encoding.TextMarshaler.
+func (d decimal) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatUint(uint64(d), 10)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *decimal) UnmarshalText(input []byte) error {
+	v, err := strconv.ParseUint(string(input), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid decimal %q", input)
+	}
+	*d = decimal(v)
+	return nil
+}
+
+type headerJSON struct {
+	Slot          decimal     `json:"slot"`
+	ProposerIndex decimal     `json:"proposer_index"`
+	ParentRoot    common.Hash `json:"parent_root"`
+	StateRoot     common.Hash `json:"state_root"`
+	BodyRoot      common.Hash `json:"body_root"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h Header) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&headerJSON{
+		Slot:          decimal(h.Slot),
+		ProposerIndex: decimal(h.ProposerIndex),
+		ParentRoot:    h.ParentRoot,
+		StateRoot:     h.StateRoot,
+		BodyRoot:      h.BodyRoot,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *Header) UnmarshalJSON(input []byte) error {
+	var dec headerJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*h = Header{
+		Slot:          uint64(dec.Slot),
+		ProposerIndex: uint64(dec.ProposerIndex),
+		ParentRoot:    dec.ParentRoot,
+		StateRoot:     dec.StateRoot,
+		BodyRoot:      dec.BodyRoot,
+	}
+	return nil
+}
+
+type executionHeaderJSON struct {
+	ParentHash       common.Hash      `json:"parent_hash"`
+	FeeRecipient     common.Address   `json:"fee_recipient"`
+	StateRoot        common.Hash      `json:"state_root"`
+	ReceiptsRoot     common.Hash      `json:"receipts_root"`
+	LogsBloom        types.Bloom      `json:"logs_bloom"`
+	PrevRandao       common.Hash      `json:"prev_randao"`
+	BlockNumber      decimal          `json:"block_number"`
+	GasLimit         decimal          `json:"gas_limit"`
+	GasUsed          decimal          `json:"gas_used"`
+	Timestamp        decimal          `json:"timestamp"`
+	ExtraData        hexutil.Bytes    `json:"extra_data"`
+	BaseFeePerGas    *math.Decimal256 `json:"base_fee_per_gas"`
+	BlockHash        common.Hash      `json:"block_hash"`
+	TransactionsRoot common.Hash      `json:"transactions_root"`
+	WithdrawalsRoot  common.Hash      `json:"withdrawals_root"`
+	BlobGasUsed      *decimal         `json:"blob_gas_used,omitempty"`
+	ExcessBlobGas    *decimal         `json:"excess_blob_gas,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h ExecutionHeader) MarshalJSON() ([]byte, error) {
+	enc := executionHeaderJSON{
+		ParentHash:       h.ParentHash,
+		FeeRecipient:     h.FeeRecipient,
+		StateRoot:        h.StateRoot,
+		ReceiptsRoot:     h.ReceiptsRoot,
+		LogsBloom:        h.LogsBloom,
+		PrevRandao:       h.PrevRandao,
+		BlockNumber:      decimal(h.BlockNumber),
+		GasLimit:         decimal(h.GasLimit),
+		GasUsed:          decimal(h.GasUsed),
+		Timestamp:        decimal(h.Timestamp),
+		ExtraData:        h.ExtraData,
+		BaseFeePerGas:    (*math.Decimal256)(h.BaseFeePerGas),
+		BlockHash:        h.BlockHash,
+		TransactionsRoot: h.TransactionsRoot,
+		WithdrawalsRoot:  h.WithdrawalsRoot,
+		BlobGasUsed:      (*decimal)(h.BlobGasUsed),
+		ExcessBlobGas:    (*decimal)(h.ExcessBlobGas),
+	}
+	if enc.BaseFeePerGas == nil {
+		enc.BaseFeePerGas = new(math.Decimal256)
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *ExecutionHeader) UnmarshalJSON(input []byte) error {
+	var dec executionHeaderJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.BaseFeePerGas == nil {
+		return errors.New("missing required field 'base_fee_per_gas' for ExecutionHeader")
+	}
+	if len(dec.ExtraData) > 32 {
+		return errors.New("extra data too long")
+	}
+	*h = ExecutionHeader{
+		ParentHash:       dec.ParentHash,
+		FeeRecipient:     dec.FeeRecipient,
+		StateRoot:        dec.StateRoot,
+		ReceiptsRoot:     dec.ReceiptsRoot,
+		LogsBloom:        dec.LogsBloom,
+		PrevRandao:       dec.PrevRandao,
+		BlockNumber:      uint64(dec.BlockNumber),
+		GasLimit:         uint64(dec.GasLimit),
+		GasUsed:          uint64(dec.GasUsed),
+		Timestamp:        uint64(dec.Timestamp),
+		ExtraData:        dec.ExtraData,
+		BaseFeePerGas:    (*big.Int)(dec.BaseFeePerGas),
+		BlockHash:        dec.BlockHash,
+		TransactionsRoot: dec.TransactionsRoot,
+		WithdrawalsRoot:  dec.WithdrawalsRoot,
+		BlobGasUsed:      (*uint64)(dec.BlobGasUsed),
+		ExcessBlobGas:    (*uint64)(dec.ExcessBlobGas),
+	}
+	return nil
+}
+
+type syncCommitteeJSON struct {
+	Pubkeys   []hexutil.Bytes `json:"pubkeys"`
+	Aggregate hexutil.Bytes   `json:"aggregate_pubkey"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (sc SyncCommittee) MarshalJSON() ([]byte, error) {
+	enc := syncCommitteeJSON{
+		Pubkeys:   make([]hexutil.Bytes, len(sc.Pubkeys)),
+		Aggregate: sc.Aggregate[:],
+	}
+	for i := range sc.Pubkeys {
+		enc.Pubkeys[i] = sc.Pubkeys[i][:]
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sc *SyncCommittee) UnmarshalJSON(input []byte) error {
+	var dec syncCommitteeJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.Pubkeys) != SyncCommitteeSize {
+		return fmt.Errorf("wrong number of sync committee keys %d", len(dec.Pubkeys))
+	}
+	for i, key := range dec.Pubkeys {
+		if len(key) != 48 {
+			return fmt.Errorf("wrong length of sync committee key %d", i)
+		}
+		copy(sc.Pubkeys[i][:], key)
+	}
+	if len(dec.Aggregate) != 48 {
+		return errors.New("wrong length of aggregate key")
+	}
+	copy(sc.Aggregate[:], dec.Aggregate)
+	return nil
+}
+
+type syncAggregateJSON struct {
+	Bits      hexutil.Bytes `json:"sync_committee_bits"`
+	Signature hexutil.Bytes `json:"sync_committee_signature"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (sa SyncAggregate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&syncAggregateJSON{Bits: sa.Bits[:], Signature: sa.Signature[:]})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (sa *SyncAggregate) UnmarshalJSON(input []byte) error {
+	var dec syncAggregateJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if len(dec.Bits) != len(sa.Bits) {
+		return errors.New("wrong length of sync committee bits")
+	}
+	if len(dec.Signature) != len(sa.Signature) {
+		return errors.New("wrong length of sync committee signature")
+	}
+	copy(sa.Bits[:], dec.Bits)
+	copy(sa.Signature[:], dec.Signature)
+	return nil
+}
+
+type updateJSON struct {
+	AttestedHeader          LightClientHeader  `json:"attested_header"`
+	NextSyncCommittee       *SyncCommittee     `json:"next_sync_committee,omitempty"`
+	NextSyncCommitteeBranch []common.Hash      `json:"next_sync_committee_branch,omitempty"`
+	FinalizedHeader         *LightClientHeader `json:"finalized_header,omitempty"`
+	FinalityBranch          []common.Hash      `json:"finality_branch,omitempty"`
+	SyncAggregate           SyncAggregate      `json:"sync_aggregate"`
+	SignatureSlot           decimal            `json:"signature_slot"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (u Update) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&updateJSON{
+		AttestedHeader:          u.AttestedHeader,
+		NextSyncCommittee:       u.NextSyncCommittee,
+		NextSyncCommitteeBranch: u.NextSyncCommitteeBranch,
+		FinalizedHeader:         u.FinalizedHeader,
+		FinalityBranch:          u.FinalityBranch,
+		SyncAggregate:           u.SyncAggregate,
+		SignatureSlot:           decimal(u.SignatureSlot),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *Update) UnmarshalJSON(input []byte) error {
+	var dec updateJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	*u = Update{
+		AttestedHeader:          dec.AttestedHeader,
+		NextSyncCommittee:       dec.NextSyncCommittee,
+		NextSyncCommitteeBranch: dec.NextSyncCommitteeBranch,
+		FinalizedHeader:         dec.FinalizedHeader,
+		FinalityBranch:          dec.FinalityBranch,
+		SyncAggregate:           dec.SyncAggregate,
+		SignatureSlot:           uint64(dec.SignatureSlot),
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// AccountProof is the result of an eth_getProof call.
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// StorageProof is the proof of a storage slot in an eth_getProof result.
+type StorageProof struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+// FetchProof retrieves the proof of an account and the given storage slots in
+// the block with the given hash using eth_getProof and verifies it against
+// the state root of the block.
+func FetchProof(ctx context.Context, client *rpc.Client, block, root common.Hash, account common.Address, keys []common.Hash) (*AccountProof, *state.Account, error) {
+	hexKeys := make([]string, len(keys))
+	for i, key := range keys {
+		hexKeys[i] = key.Hex()
+	}
+	proof := new(AccountProof)
+	if err := client.CallContext(ctx, proof, "eth_getProof", account, hexKeys, block); err != nil {
+		return nil, nil, err
+	}
+	proven, err := proof.Verify(root, account, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, proven, nil
+}
+
+// proofDB creates a database from a list of trie nodes, keyed by their hash.
+func proofDB(nodes []hexutil.Bytes) ethdb.KeyValueReader {
+	db := memorydb.New()
+	for _, node := range nodes {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// Verify checks that the proof is a proof of the given account and storage
+// slots, and verifies it against a state root. It returns the proven account,
+// or nil if the account doesn't exist. The proven account matches the one
+// claimed by the proof.
+func (p *AccountProof) Verify(root common.Hash, address common.Address, keys []common.Hash) (*state.Account, error) {
+	if p.Address != address {
+		return nil, fmt.Errorf("proof for wrong account %x", p.Address)
+	}
+	if len(p.StorageProof) != len(keys) {
+		return nil, fmt.Errorf("wrong number of storage proofs %d, want %d", len(p.StorageProof), len(keys))
+	}
+	for i, sp := range p.StorageProof {
+		if common.HexToHash(sp.Key) != keys[i] {
+			return nil, fmt.Errorf("storage proof for wrong slot %s", sp.Key)
+		}
+	}
+	val, err := trie.VerifyProof(root, crypto.Keccak256(p.Address[:]), proofDB(p.AccountProof))
+	if err != nil {
+		return nil, fmt.Errorf("invalid account proof: %v", err)
+	}
+	account := &state.Account{
+		Balance:  new(big.Int),
+		Root:     types.EmptyRootHash,
+		CodeHash: crypto.Keccak256(nil),
+	}
+	if val != nil {
+		if err := rlp.DecodeBytes(val, account); err != nil {
+			return nil, fmt.Errorf("invalid account in proof: %v", err)
+		}
+	}
+	switch {
+	case uint64(p.Nonce) != account.Nonce:
+		return nil, fmt.Errorf("nonce mismatch: have %d, proven %d", p.Nonce, account.Nonce)
+	case p.Balance == nil || p.Balance.ToInt().Cmp(account.Balance) != 0:
+		return nil, fmt.Errorf("balance mismatch: have %v, proven %v", p.Balance, account.Balance)
+	case p.StorageHash != account.Root:
+		return nil, fmt.Errorf("storage hash mismatch: have %x, proven %x", p.StorageHash, account.Root)
+	case p.CodeHash != common.BytesToHash(account.CodeHash):
+		return nil, fmt.Errorf("code hash mismatch: have %x, proven %x", p.CodeHash, account.CodeHash)
+	}
+	for _, sp := range p.StorageProof {
+		if err := sp.verify(account.Root); err != nil {
+			return nil, err
+		}
+	}
+	if val == nil {
+		return nil, nil
+	}
+	return account, nil
+}
+
+// verify checks the storage proof against a storage root.
+func (sp *StorageProof) verify(root common.Hash) error {
+	key := common.HexToHash(sp.Key)
+	value := new(big.Int)
+	if root != types.EmptyRootHash {
+		val, err := trie.VerifyProof(root, crypto.Keccak256(key[:]), proofDB(sp.Proof))
+		if err != nil {
+			return fmt.Errorf("invalid storage proof of slot %x: %v", key, err)
+		}
+		if val != nil {
+			_, content, _, err := rlp.Split(val)
+			if err != nil {
+				return fmt.Errorf("invalid storage value of slot %x: %v", key, err)
+			}
+			value.SetBytes(content)
+		}
+	}
+	if sp.Value == nil || sp.Value.ToInt().Cmp(value) != 0 {
+		return fmt.Errorf("storage value mismatch in slot %x: have %v, proven %v", key, sp.Value, value)
+	}
+	return nil
+}
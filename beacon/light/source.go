@@ -0,0 +1,235 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNotAvailable is returned by update sources when the requested data is
+// not available.
+var ErrNotAvailable = errors.New("light client data not available")
+
+// UpdateSource provides light client data. Sources are not trusted, all data
+// is verified by the Store.
+type UpdateSource interface {
+	// Bootstrap returns the bootstrap data of the block with the given root.
+	Bootstrap(ctx context.Context, root common.Hash) (*Bootstrap, error)
+
+	// Updates returns the best updates of count periods, starting at the
+	// given sync committee period.
+	Updates(ctx context.Context, start, count uint64) ([]*Update, error)
+
+	// FinalityUpdate returns the update proving the latest finalized header.
+	FinalityUpdate(ctx context.Context) (*Update, error)
+
+	// OptimisticUpdate returns the update proving the latest attested header.
+	OptimisticUpdate(ctx context.Context) (*Update, error)
+}
+
+// fileData is the content of a file read by FileSource.
+type fileData struct {
+	Bootstraps       []*Bootstrap `json:"bootstraps"`
+	Updates          []*Update    `json:"updates"`
+	FinalityUpdate   *Update      `json:"finality_update"`
+	OptimisticUpdate *Update      `json:"optimistic_update"`
+}
+
+// FileSource serves light client data from a JSON file. The file is read on
+// every request, so it can be updated while the client is running.
+type FileSource struct {
+	path string
+}
+
+// NewFileSource creates a source reading the given file.
+func NewFileSource(path string) *FileSource {
+	return &FileSource{path: path}
+}
+
+func (fs *FileSource) load() (*fileData, error) {
+	blob, err := ioutil.ReadFile(fs.path)
+	if err != nil {
+		return nil, err
+	}
+	data := new(fileData)
+	if err := json.Unmarshal(blob, data); err != nil {
+		return nil, fmt.Errorf("invalid light client data file %s: %v", fs.path, err)
+	}
+	return data, nil
+}
+
+// Bootstrap implements UpdateSource.
+func (fs *FileSource) Bootstrap(ctx context.Context, root common.Hash) (*Bootstrap, error) {
+	data, err := fs.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range data.Bootstraps {
+		if b.Header.Beacon.Hash() == root {
+			return b, nil
+		}
+	}
+	return nil, ErrNotAvailable
+}
+
+// Updates implements UpdateSource.
+func (fs *FileSource) Updates(ctx context.Context, start, count uint64) ([]*Update, error) {
+	data, err := fs.load()
+	if err != nil {
+		return nil, err
+	}
+	var updates []*Update
+	for _, u := range data.Updates {
+		if period := SyncPeriod(u.AttestedHeader.Beacon.Slot); period >= start && period < start+count {
+			updates = append(updates, u)
+		}
+	}
+	return updates, nil
+}
+
+// FinalityUpdate implements UpdateSource.
+func (fs *FileSource) FinalityUpdate(ctx context.Context) (*Update, error) {
+	data, err := fs.load()
+	if err != nil {
+		return nil, err
+	}
+	if data.FinalityUpdate == nil {
+		return nil, ErrNotAvailable
+	}
+	return data.FinalityUpdate, nil
+}
+
+// OptimisticUpdate implements UpdateSource.
+func (fs *FileSource) OptimisticUpdate(ctx context.Context) (*Update, error) {
+	data, err := fs.load()
+	if err != nil {
+		return nil, err
+	}
+	if data.OptimisticUpdate == nil {
+		return nil, ErrNotAvailable
+	}
+	return data.OptimisticUpdate, nil
+}
+
+// RESTSource fetches light client data from the light client endpoints of
+// the beacon node REST API.
+type RESTSource struct {
+	url    string
+	client *http.Client
+}
+
+// NewRESTSource creates a source using the beacon node API at the given URL.
+func NewRESTSource(url string) *RESTSource {
+	return &RESTSource{
+		url:    strings.TrimRight(url, "/"),
+		client: new(http.Client),
+	}
+}
+
+// versioned is the envelope of beacon API responses.
+type versioned struct {
+	Version string          `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func (rs *RESTSource) get(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequest("GET", rs.url+path, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	resp, err := rs.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotAvailable
+	case resp.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("beacon API request %s failed: %s: %s", path, resp.Status, body)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// getData fetches a single versioned object.
+func (rs *RESTSource) getData(ctx context.Context, path string, result interface{}) error {
+	var resp versioned
+	if err := rs.get(ctx, path, &resp); err != nil {
+		return err
+	}
+	return json.Unmarshal(resp.Data, result)
+}
+
+// Bootstrap implements UpdateSource.
+func (rs *RESTSource) Bootstrap(ctx context.Context, root common.Hash) (*Bootstrap, error) {
+	b := new(Bootstrap)
+	if err := rs.getData(ctx, "/eth/v1/beacon/light_client/bootstrap/"+root.Hex(), b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Updates implements UpdateSource.
+func (rs *RESTSource) Updates(ctx context.Context, start, count uint64) ([]*Update, error) {
+	query := url.Values{}
+	query.Set("start_period", fmt.Sprint(start))
+	query.Set("count", fmt.Sprint(count))
+
+	var resp []versioned
+	if err := rs.get(ctx, "/eth/v1/beacon/light_client/updates?"+query.Encode(), &resp); err != nil {
+		return nil, err
+	}
+	updates := make([]*Update, len(resp))
+	for i, r := range resp {
+		updates[i] = new(Update)
+		if err := json.Unmarshal(r.Data, updates[i]); err != nil {
+			return nil, err
+		}
+	}
+	return updates, nil
+}
+
+// FinalityUpdate implements UpdateSource.
+func (rs *RESTSource) FinalityUpdate(ctx context.Context) (*Update, error) {
+	u := new(Update)
+	if err := rs.getData(ctx, "/eth/v1/beacon/light_client/finality_update", u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// OptimisticUpdate implements UpdateSource.
+func (rs *RESTSource) OptimisticUpdate(ctx context.Context) (*Update, error) {
+	u := new(Update)
+	if err := rs.getData(ctx, "/eth/v1/beacon/light_client/optimistic_update", u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
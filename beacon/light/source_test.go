@@ -0,0 +1,65 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestRESTSource(t *testing.T) {
+	chain := newTestChain(common.Hash{})
+	versioned := func(v interface{}) map[string]interface{} {
+		return map[string]interface{}{"version": "capella", "data": v}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/light_client/bootstrap/"+chain.checkpoint.Hex(), func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(versioned(chain.bootstrap))
+	})
+	mux.HandleFunc("/eth/v1/beacon/light_client/updates", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start_period") != "0" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode([]interface{}{versioned(chain.updates[0]), versioned(chain.updates[1])})
+	})
+	mux.HandleFunc("/eth/v1/beacon/light_client/finality_update", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(versioned(chain.updates[1]))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	source := NewRESTSource(server.URL + "/")
+	if _, err := source.Bootstrap(context.Background(), common.Hash{}); err != ErrNotAvailable {
+		t.Fatalf("wrong error for unknown bootstrap: %v", err)
+	}
+	client, err := NewClient(context.Background(), Config{Chain: testConfig, Checkpoint: chain.checkpoint}, source, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Sync(context.Background()); err != nil {
+		t.Fatal("sync failed:", err)
+	}
+	if slot := client.Store().Finalized().Beacon.Slot; slot != SlotsPerPeriod+100 {
+		t.Fatalf("wrong finalized slot %d after sync", slot)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// This file contains the subset of SSZ merkleization needed to compute the
+// hash tree roots of the light client data structures.
+
+// zeroHashes[i] is the root of a tree of depth i with all leaves zero.
+var zeroHashes [64]common.Hash
+
+func init() {
+	for i := 1; i < len(zeroHashes); i++ {
+		zeroHashes[i] = hashPair(zeroHashes[i-1], zeroHashes[i-1])
+	}
+}
+
+// hashPair returns the hash of two concatenated tree nodes.
+func hashPair(a, b common.Hash) common.Hash {
+	h := sha256.New()
+	h.Write(a[:])
+	h.Write(b[:])
+	var out common.Hash
+	h.Sum(out[:0])
+	return out
+}
+
+// merkleize computes the root of a binary tree with the given leaves, padded
+// with zero chunks to the next power of two of limit.
+func merkleize(chunks []common.Hash, limit int) common.Hash {
+	depth := 0
+	for 1<<depth < limit {
+		depth++
+	}
+	if len(chunks) == 0 {
+		return zeroHashes[depth]
+	}
+	layer := append([]common.Hash{}, chunks...)
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[d])
+		}
+		next := make([]common.Hash, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+// mixInLength mixes the length of a list into its root.
+func mixInLength(root common.Hash, length int) common.Hash {
+	return hashPair(root, uint64Root(uint64(length)))
+}
+
+// uint64Root returns the hash tree root of a uint64.
+func uint64Root(v uint64) common.Hash {
+	var out common.Hash
+	binary.LittleEndian.PutUint64(out[:], v)
+	return out
+}
+
+// uint256Root returns the hash tree root of a uint256.
+func uint256Root(v *big.Int) common.Hash {
+	var out common.Hash
+	if v != nil {
+		b := v.Bytes()
+		for i := range b {
+			out[i] = b[len(b)-1-i]
+		}
+	}
+	return out
+}
+
+// pack splits a byte string into zero padded chunks.
+func pack(b []byte) []common.Hash {
+	chunks := make([]common.Hash, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+	return chunks
+}
+
+// bytesRoot returns the hash tree root of a fixed size byte vector.
+func bytesRoot(b []byte) common.Hash {
+	return merkleize(pack(b), (len(b)+31)/32)
+}
+
+// VerifyBranch checks a merkle proof of the leaf at the given generalized
+// index in the tree with the given root. The branch is ordered from the leaf
+// up to the root.
+func VerifyBranch(root, leaf common.Hash, gindex uint64, branch []common.Hash) bool {
+	depth := 0
+	for gindex>>uint(depth+1) != 0 {
+		depth++
+	}
+	if depth == 0 || len(branch) != depth {
+		return false
+	}
+	value := leaf
+	for i, sibling := range branch {
+		if (gindex>>uint(i))&1 == 1 {
+			value = hashPair(sibling, value)
+		} else {
+			value = hashPair(value, sibling)
+		}
+	}
+	return value == root
+}
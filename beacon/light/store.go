@@ -0,0 +1,226 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	errNoSupermajority      = errors.New("sync committee participation below supermajority")
+	errUnknownCommittee     = errors.New("sync committee of signature period is unknown")
+	errInvalidSignature     = errors.New("invalid sync committee signature")
+	errInvalidFinality      = errors.New("invalid finality branch")
+	errInvalidNextCommittee = errors.New("invalid next sync committee branch")
+	errPeriodGap            = errors.New("update skips a sync committee period")
+	errSignatureSlot        = errors.New("signature slot not after attested header")
+)
+
+// Store is the state of a beacon chain light client. Starting from a trusted
+// bootstrap, it follows the chain by processing light client updates which
+// are signed by the sync committee of their period. The store tracks the
+// latest finalized header and the latest attested (optimistic) header.
+type Store struct {
+	config *ChainConfig
+
+	lock       sync.RWMutex
+	finalized  *LightClientHeader
+	optimistic *LightClientHeader
+	current    *committee
+	next       *committee // nil if not known yet
+}
+
+// NewStore creates a light client store from the bootstrap data of the
+// block with the given trusted root.
+func NewStore(config *ChainConfig, trustedRoot common.Hash, bootstrap *Bootstrap) (*Store, error) {
+	header := &bootstrap.Header
+	if root := header.Beacon.Hash(); root != trustedRoot {
+		return nil, fmt.Errorf("bootstrap header root mismatch: have %x, want %x", root, trustedRoot)
+	}
+	if err := header.Verify(); err != nil {
+		return nil, err
+	}
+	gindex := uint64(currentSyncCommitteeIndex)
+	if config.electra(header.Beacon.Slot) {
+		gindex = currentSyncCommitteeIndexElectra
+	}
+	if !VerifyBranch(header.Beacon.StateRoot, bootstrap.CurrentSyncCommittee.Root(), gindex, bootstrap.CurrentSyncCommitteeBranch) {
+		return nil, errors.New("invalid current sync committee branch")
+	}
+	current, err := newCommittee(&bootstrap.CurrentSyncCommittee)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		config:     config,
+		finalized:  header,
+		optimistic: header,
+		current:    current,
+	}, nil
+}
+
+// Finalized returns the latest finalized header.
+func (s *Store) Finalized() *LightClientHeader {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.finalized
+}
+
+// Optimistic returns the latest header signed by the sync committee.
+func (s *Store) Optimistic() *LightClientHeader {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.optimistic
+}
+
+// Period returns the sync committee period of the finalized header and
+// whether the committee of the next period is known.
+func (s *Store) Period() (period uint64, nextKnown bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return SyncPeriod(s.finalized.Beacon.Slot), s.next != nil
+}
+
+// ProcessUpdate validates a light client update and applies it to the store.
+// Updates which don't carry any new information are ignored.
+func (s *Store) ProcessUpdate(u *Update) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.useful(u) {
+		return nil
+	}
+	next, err := s.validate(u)
+	if err != nil {
+		return err
+	}
+	s.apply(u, next)
+	return nil
+}
+
+// useful reports whether applying the update would change the store.
+func (s *Store) useful(u *Update) bool {
+	if u.AttestedHeader.Beacon.Slot > s.optimistic.Beacon.Slot {
+		return true
+	}
+	if u.FinalizedHeader != nil && u.FinalizedHeader.Beacon.Slot > s.finalized.Beacon.Slot {
+		return true
+	}
+	return u.NextSyncCommittee != nil && s.next == nil
+}
+
+// validate checks the update against the store, returning the decoded next
+// sync committee if the update contains one.
+func (s *Store) validate(u *Update) (*committee, error) {
+	attested := &u.AttestedHeader.Beacon
+	if participants := u.SyncAggregate.Participants(); participants*3 < SyncCommitteeSize*2 {
+		return nil, errNoSupermajority
+	}
+	if u.SignatureSlot <= attested.Slot {
+		return nil, errSignatureSlot
+	}
+	if err := u.AttestedHeader.Verify(); err != nil {
+		return nil, err
+	}
+	electra := s.config.electra(attested.Slot)
+
+	// Check the finalized header.
+	storePeriod := SyncPeriod(s.finalized.Beacon.Slot)
+	if u.FinalizedHeader != nil {
+		finalized := &u.FinalizedHeader.Beacon
+		if finalized.Slot > attested.Slot {
+			return nil, errors.New("finalized header after attested header")
+		}
+		if err := u.FinalizedHeader.Verify(); err != nil {
+			return nil, err
+		}
+		gindex := uint64(finalizedRootIndex)
+		if electra {
+			gindex = finalizedRootIndexElectra
+		}
+		if !VerifyBranch(attested.StateRoot, finalized.Hash(), gindex, u.FinalityBranch) {
+			return nil, errInvalidFinality
+		}
+		if period := SyncPeriod(finalized.Slot); period > storePeriod+1 || (period == storePeriod+1 && s.next == nil) {
+			return nil, errPeriodGap
+		}
+	}
+
+	// Check the next sync committee.
+	var next *committee
+	if u.NextSyncCommittee != nil {
+		gindex := uint64(nextSyncCommitteeIndex)
+		if electra {
+			gindex = nextSyncCommitteeIndexElectra
+		}
+		root := u.NextSyncCommittee.Root()
+		if !VerifyBranch(attested.StateRoot, root, gindex, u.NextSyncCommitteeBranch) {
+			return nil, errInvalidNextCommittee
+		}
+		if s.next != nil && SyncPeriod(attested.Slot) == storePeriod && root != s.next.root {
+			return nil, errors.New("next sync committee conflicts with known committee")
+		}
+		var err error
+		if next, err = newCommittee(u.NextSyncCommittee); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check the signature.
+	var signers *committee
+	switch SyncPeriod(u.SignatureSlot) {
+	case storePeriod:
+		signers = s.current
+	case storePeriod + 1:
+		signers = s.next
+	}
+	if signers == nil {
+		return nil, errUnknownCommittee
+	}
+	if !signers.verify(s.config.signingRoot(attested.Hash(), u.SignatureSlot), &u.SyncAggregate) {
+		return nil, errInvalidSignature
+	}
+	return next, nil
+}
+
+// apply updates the store with a validated update.
+func (s *Store) apply(u *Update, next *committee) {
+	storePeriod := SyncPeriod(s.finalized.Beacon.Slot)
+	attestedPeriod := SyncPeriod(u.AttestedHeader.Beacon.Slot)
+	if next != nil && s.next == nil && attestedPeriod == storePeriod {
+		s.next = next
+	}
+	if u.FinalizedHeader != nil && u.FinalizedHeader.Beacon.Slot > s.finalized.Beacon.Slot {
+		if period := SyncPeriod(u.FinalizedHeader.Beacon.Slot); period == storePeriod+1 {
+			s.current, s.next = s.next, nil
+			if next != nil && attestedPeriod == period {
+				s.next = next
+			}
+		}
+		s.finalized = u.FinalizedHeader
+	}
+	if u.AttestedHeader.Beacon.Slot > s.optimistic.Beacon.Slot {
+		s.optimistic = &u.AttestedHeader
+	}
+	if s.finalized.Beacon.Slot > s.optimistic.Beacon.Slot {
+		s.optimistic = s.finalized
+	}
+}
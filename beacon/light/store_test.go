@@ -0,0 +1,253 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto/bls12381"
+)
+
+var testConfig = &ChainConfig{
+	GenesisValidatorsRoot: common.HexToHash("0x01"),
+	Forks:                 []Fork{{Name: "altair", Version: [4]byte{0x01}}},
+}
+
+// testCommittee is a sync committee with known secret keys.
+type testCommittee struct {
+	keys      []*big.Int
+	committee SyncCommittee
+}
+
+func newTestCommittee(seed int64) *testCommittee {
+	g1 := bls12381.NewG1()
+	tc := &testCommittee{keys: make([]*big.Int, SyncCommitteeSize)}
+	aggregate := g1.Zero()
+	for i := range tc.keys {
+		tc.keys[i] = big.NewInt(seed*SyncCommitteeSize + int64(i) + 1)
+		pk := g1.MulScalar(g1.New(), g1.One(), tc.keys[i])
+		g1.Add(aggregate, aggregate, pk)
+		copy(tc.committee.Pubkeys[i][:], g1.ToCompressed(pk))
+	}
+	copy(tc.committee.Aggregate[:], g1.ToCompressed(aggregate))
+	return tc
+}
+
+// sign creates the aggregate signature of the first participants members.
+func (tc *testCommittee) sign(header *Header, signatureSlot uint64, participants int) SyncAggregate {
+	g1, g2 := bls12381.NewG1(), bls12381.NewG2()
+	var agg SyncAggregate
+	sum := new(big.Int)
+	for i := 0; i < participants; i++ {
+		agg.Bits[i/8] |= 1 << (uint(i) % 8)
+		sum.Add(sum, tc.keys[i])
+	}
+	sum.Mod(sum, g1.Q())
+	msg, err := g2.HashToCurve(testConfig.signingRoot(header.Hash(), signatureSlot).Bytes(), signatureDST)
+	if err != nil {
+		panic(err)
+	}
+	copy(agg.Signature[:], g2.ToCompressed(g2.MulScalar(g2.New(), msg, sum)))
+	return agg
+}
+
+// sparseTree is a merkle tree given by some of its nodes, all other leaves
+// are zero.
+type sparseTree map[uint64]common.Hash
+
+func (t sparseTree) node(gindex uint64) common.Hash {
+	if h, ok := t[gindex]; ok {
+		return h
+	}
+	if gindex >= 1<<7 {
+		return common.Hash{}
+	}
+	return hashPair(t.node(2*gindex), t.node(2*gindex+1))
+}
+
+func (t sparseTree) branch(gindex uint64) []common.Hash {
+	var branch []common.Hash
+	for ; gindex > 1; gindex /= 2 {
+		branch = append(branch, t.node(gindex^1))
+	}
+	return branch
+}
+
+// makeHeader creates a header with the given state tree and execution state root.
+func makeHeader(slot uint64, state sparseTree, stateRoot common.Hash) *LightClientHeader {
+	exec := &ExecutionHeader{
+		StateRoot:     stateRoot,
+		BlockNumber:   slot,
+		BaseFeePerGas: big.NewInt(7),
+		BlockHash:     common.BigToHash(new(big.Int).SetUint64(slot)),
+		ExtraData:     []byte("test"),
+	}
+	body := sparseTree{executionPayloadIndex: exec.Hash()}
+	return &LightClientHeader{
+		Beacon:          Header{Slot: slot, StateRoot: state.node(1), BodyRoot: body.node(1)},
+		Execution:       exec,
+		ExecutionBranch: body.branch(executionPayloadIndex),
+	}
+}
+
+// testChain contains a bootstrap and two updates. The first update proves the
+// next sync committee, the second one finalizes a header of the next period.
+type testChain struct {
+	committees []*testCommittee
+	checkpoint common.Hash
+	bootstrap  *Bootstrap
+	updates    []*Update
+}
+
+var testCommittees = []*testCommittee{newTestCommittee(0), newTestCommittee(1), newTestCommittee(2)}
+
+func newTestChain(stateRoot common.Hash) *testChain {
+	tc := &testChain{committees: testCommittees}
+
+	state := sparseTree{currentSyncCommitteeIndex: tc.committees[0].committee.Root()}
+	header := makeHeader(100, state, stateRoot)
+	tc.checkpoint = header.Beacon.Hash()
+	tc.bootstrap = &Bootstrap{
+		Header:                     *header,
+		CurrentSyncCommittee:       tc.committees[0].committee,
+		CurrentSyncCommitteeBranch: state.branch(currentSyncCommitteeIndex),
+	}
+	tc.updates = append(tc.updates,
+		tc.makeUpdate(200, 150, 0, stateRoot),
+		tc.makeUpdate(SlotsPerPeriod+300, SlotsPerPeriod+100, 1, stateRoot),
+	)
+	return tc
+}
+
+// makeUpdate creates an update signed by the committee of the attested period,
+// which also proves the committee of the next period.
+func (tc *testChain) makeUpdate(attested, finalized uint64, period int, stateRoot common.Hash) *Update {
+	finalizedHeader := makeHeader(finalized, sparseTree{}, stateRoot)
+	state := sparseTree{
+		nextSyncCommitteeIndex: tc.committees[period+1].committee.Root(),
+		finalizedRootIndex:     finalizedHeader.Beacon.Hash(),
+	}
+	header := makeHeader(attested, state, stateRoot)
+	return &Update{
+		AttestedHeader:          *header,
+		NextSyncCommittee:       &tc.committees[period+1].committee,
+		NextSyncCommitteeBranch: state.branch(nextSyncCommitteeIndex),
+		FinalizedHeader:         finalizedHeader,
+		FinalityBranch:          state.branch(finalizedRootIndex),
+		SyncAggregate:           tc.committees[period].sign(&header.Beacon, attested+1, SyncCommitteeSize),
+		SignatureSlot:           attested + 1,
+	}
+}
+
+func TestStoreUpdates(t *testing.T) {
+	chain := newTestChain(common.Hash{})
+	if _, err := NewStore(testConfig, common.HexToHash("0x02"), chain.bootstrap); err == nil {
+		t.Fatal("bootstrap accepted with wrong checkpoint")
+	}
+	store, err := NewStore(testConfig, chain.checkpoint, chain.bootstrap)
+	if err != nil {
+		t.Fatal("bootstrap failed:", err)
+	}
+
+	// The update of the next period can't be verified yet.
+	if err := store.ProcessUpdate(chain.updates[1]); err != errPeriodGap {
+		t.Fatalf("wrong error for update of unknown period: %v", err)
+	}
+	if err := store.ProcessUpdate(chain.updates[0]); err != nil {
+		t.Fatal("update failed:", err)
+	}
+	if period, next := store.Period(); period != 0 || !next {
+		t.Fatalf("wrong period %d (next committee known: %v) after first update", period, next)
+	}
+	if slot := store.Finalized().Beacon.Slot; slot != 150 {
+		t.Fatalf("wrong finalized slot %d", slot)
+	}
+	if slot := store.Optimistic().Beacon.Slot; slot != 200 {
+		t.Fatalf("wrong optimistic slot %d", slot)
+	}
+
+	if err := store.ProcessUpdate(chain.updates[1]); err != nil {
+		t.Fatal("update failed:", err)
+	}
+	if period, next := store.Period(); period != 1 || !next {
+		t.Fatalf("wrong period %d (next committee known: %v) after second update", period, next)
+	}
+	if slot := store.Optimistic().Beacon.Slot; slot != SlotsPerPeriod+300 {
+		t.Fatalf("wrong optimistic slot %d", slot)
+	}
+}
+
+func TestStoreInvalidUpdates(t *testing.T) {
+	chain := newTestChain(common.Hash{})
+	store, err := NewStore(testConfig, chain.checkpoint, chain.bootstrap)
+	if err != nil {
+		t.Fatal("bootstrap failed:", err)
+	}
+	tests := []struct {
+		name   string
+		modify func(u *Update)
+		err    error
+	}{
+		{
+			name: "low participation",
+			modify: func(u *Update) {
+				u.SyncAggregate = chain.committees[0].sign(&u.AttestedHeader.Beacon, u.SignatureSlot, 300)
+			},
+			err: errNoSupermajority,
+		},
+		{
+			name: "wrong committee",
+			modify: func(u *Update) {
+				u.SyncAggregate = chain.committees[1].sign(&u.AttestedHeader.Beacon, u.SignatureSlot, SyncCommitteeSize)
+			},
+			err: errInvalidSignature,
+		},
+		{
+			name:   "signature slot before attested header",
+			modify: func(u *Update) { u.SignatureSlot = u.AttestedHeader.Beacon.Slot },
+			err:    errSignatureSlot,
+		},
+		{
+			name:   "invalid finality branch",
+			modify: func(u *Update) { u.FinalityBranch[0] = common.Hash{1} },
+			err:    errInvalidFinality,
+		},
+		{
+			name:   "invalid committee branch",
+			modify: func(u *Update) { u.NextSyncCommittee = &chain.committees[2].committee },
+			err:    errInvalidNextCommittee,
+		},
+		{
+			name:   "unknown signature period",
+			modify: func(u *Update) { u.SignatureSlot = 2*SlotsPerPeriod + 1 },
+			err:    errUnknownCommittee,
+		},
+	}
+	for _, test := range tests {
+		u := *chain.updates[0]
+		u.FinalityBranch = append([]common.Hash{}, u.FinalityBranch...)
+		test.modify(&u)
+		if err := store.ProcessUpdate(&u); err != test.err {
+			t.Errorf("%s: wrong error %v, want %v", test.name, err, test.err)
+		}
+	}
+	if slot := store.Optimistic().Beacon.Slot; slot != 100 {
+		t.Fatalf("store changed by invalid updates, optimistic slot %d", slot)
+	}
+}
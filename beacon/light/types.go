@@ -0,0 +1,177 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Header is a beacon block header.
+type Header struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    common.Hash
+	StateRoot     common.Hash
+	BodyRoot      common.Hash
+}
+
+// Hash returns the hash tree root of the header, which is also the root of
+// the block it belongs to.
+func (h *Header) Hash() common.Hash {
+	return merkleize([]common.Hash{
+		uint64Root(h.Slot),
+		uint64Root(h.ProposerIndex),
+		h.ParentRoot,
+		h.StateRoot,
+		h.BodyRoot,
+	}, 5)
+}
+
+// ExecutionHeader is the execution payload header of a beacon block. The
+// blob gas fields are only present since the Deneb fork.
+type ExecutionHeader struct {
+	ParentHash       common.Hash
+	FeeRecipient     common.Address
+	StateRoot        common.Hash
+	ReceiptsRoot     common.Hash
+	LogsBloom        types.Bloom
+	PrevRandao       common.Hash
+	BlockNumber      uint64
+	GasLimit         uint64
+	GasUsed          uint64
+	Timestamp        uint64
+	ExtraData        []byte
+	BaseFeePerGas    *big.Int
+	BlockHash        common.Hash
+	TransactionsRoot common.Hash
+	WithdrawalsRoot  common.Hash
+	BlobGasUsed      *uint64
+	ExcessBlobGas    *uint64
+}
+
+// Hash returns the hash tree root of the execution payload header.
+func (h *ExecutionHeader) Hash() common.Hash {
+	var feeRecipient common.Hash
+	copy(feeRecipient[:], h.FeeRecipient[:])
+	extra := mixInLength(merkleize(pack(h.ExtraData), 1), len(h.ExtraData))
+
+	fields := []common.Hash{
+		h.ParentHash,
+		feeRecipient,
+		h.StateRoot,
+		h.ReceiptsRoot,
+		bytesRoot(h.LogsBloom[:]),
+		h.PrevRandao,
+		uint64Root(h.BlockNumber),
+		uint64Root(h.GasLimit),
+		uint64Root(h.GasUsed),
+		uint64Root(h.Timestamp),
+		extra,
+		uint256Root(h.BaseFeePerGas),
+		h.BlockHash,
+		h.TransactionsRoot,
+		h.WithdrawalsRoot,
+	}
+	if h.BlobGasUsed != nil && h.ExcessBlobGas != nil {
+		fields = append(fields, uint64Root(*h.BlobGasUsed), uint64Root(*h.ExcessBlobGas))
+	}
+	return merkleize(fields, len(fields))
+}
+
+// LightClientHeader is a beacon block header along with the header of its
+// execution payload and the proof linking the two.
+type LightClientHeader struct {
+	Beacon          Header           `json:"beacon"`
+	Execution       *ExecutionHeader `json:"execution"`
+	ExecutionBranch []common.Hash    `json:"execution_branch"`
+}
+
+// Verify checks that the execution header belongs to the beacon header.
+func (h *LightClientHeader) Verify() error {
+	if h.Execution == nil {
+		return errors.New("missing execution header")
+	}
+	if !VerifyBranch(h.Beacon.BodyRoot, h.Execution.Hash(), executionPayloadIndex, h.ExecutionBranch) {
+		return errors.New("invalid execution payload branch")
+	}
+	return nil
+}
+
+// SyncCommittee is a committee of validators signing the head of the chain
+// during a sync committee period.
+type SyncCommittee struct {
+	Pubkeys   [SyncCommitteeSize][48]byte
+	Aggregate [48]byte
+}
+
+// Root returns the hash tree root of the committee.
+func (sc *SyncCommittee) Root() common.Hash {
+	leaves := make([]common.Hash, len(sc.Pubkeys))
+	for i := range sc.Pubkeys {
+		leaves[i] = bytesRoot(sc.Pubkeys[i][:])
+	}
+	return hashPair(merkleize(leaves, len(leaves)), bytesRoot(sc.Aggregate[:]))
+}
+
+// SyncAggregate is the aggregated signature of the sync committee members
+// which signed a block header.
+type SyncAggregate struct {
+	Bits      [SyncCommitteeSize / 8]byte
+	Signature [96]byte
+}
+
+// Participants returns the number of committee members which signed.
+func (sa *SyncAggregate) Participants() int {
+	var count int
+	for _, b := range sa.Bits {
+		for ; b != 0; b &= b - 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// signed reports whether the committee member with the given index signed.
+func (sa *SyncAggregate) signed(index int) bool {
+	return sa.Bits[index/8]&(1<<(uint(index)%8)) != 0
+}
+
+// Update is a light client update. It proves a recent header of the chain,
+// the attested header, with the signature of the sync committee and can also
+// prove a finalized header and the sync committee of the next period. The
+// optional parts are nil in finality and optimistic updates.
+type Update struct {
+	AttestedHeader          LightClientHeader
+	NextSyncCommittee       *SyncCommittee
+	NextSyncCommitteeBranch []common.Hash
+	FinalizedHeader         *LightClientHeader
+	FinalityBranch          []common.Hash
+	SyncAggregate           SyncAggregate
+	SignatureSlot           uint64
+}
+
+// Bootstrap is the initial data a light client starts from. It contains a
+// trusted header and the sync committee of its period.
+type Bootstrap struct {
+	Header                     LightClientHeader `json:"header"`
+	CurrentSyncCommittee       SyncCommittee     `json:"current_sync_committee"`
+	CurrentSyncCommitteeBranch []common.Hash     `json:"current_sync_committee_branch"`
+}
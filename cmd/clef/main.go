@@ -125,6 +125,33 @@ var (
 		Name:  "rules",
 		Usage: "Path to the rule file to auto-authorize requests with",
 	}
+	policyFlag = cli.StringFlag{
+		Name:  "policy",
+		Usage: "Path to the declarative policy file to auto-authorize requests with (alternative to --rules)",
+	}
+	quorumFlag = cli.StringFlag{
+		Name:  "quorum",
+		Usage: "Path to the M-of-N reviewer configuration to require for transaction approvals",
+	}
+	quorumAddrFlag = cli.StringFlag{
+		Name:  "quorum.addr",
+		Usage: "Listening interface of the reviewer HTTP-RPC server",
+		Value: "localhost",
+	}
+	quorumPortFlag = cli.IntFlag{
+		Name:  "quorum.port",
+		Usage: "Listening port of the reviewer HTTP-RPC server",
+		Value: node.DefaultHTTPPort + 15,
+	}
+	quorumVHostsFlag = cli.StringFlag{
+		Name:  "quorum.vhosts",
+		Usage: "Comma separated list of virtual hostnames from which to accept requests on the reviewer server (server enforced). Accepts '*' wildcard.",
+		Value: "localhost",
+	}
+	policyExplainFlag = cli.BoolFlag{
+		Name:  "policy.explain",
+		Usage: "Report which policy rule approved or rejected each request to the UI",
+	}
 	stdiouiFlag = cli.BoolFlag{
 		Name: "stdio-ui",
 		Usage: "Use STDIN/STDOUT as a channel for an external UI. " +
@@ -152,7 +179,7 @@ the rule-engine to work.`,
 	attestCommand = cli.Command{
 		Action:    utils.MigrateFlags(attestFile),
 		Name:      "attest",
-		Usage:     "Attest that a js-file is to be used",
+		Usage:     "Attest that a js-file or policy file is to be used",
 		ArgsUsage: "<sha256sum>",
 		Flags: []cli.Flag{
 			logLevelFlag,
@@ -160,10 +187,10 @@ the rule-engine to work.`,
 			signerSecretFlag,
 		},
 		Description: `
-The attest command stores the sha256 of the rule.js-file that you want to use for automatic processing of
-incoming requests.
+The attest command stores the sha256 of the rule.js-file or the policy file that you want to use for
+automatic processing of incoming requests.
 
-Whenever you make an edit to the rule file, you need to use attestation to tell
+Whenever you make an edit to the rule or policy file, you need to use attestation to tell
 Clef that the file is 'safe' to execute.`,
 	}
 	setCredentialCommand = cli.Command{
@@ -208,6 +235,24 @@ The newaccount command creates a new keystore-backed account. It is a convenienc
 which can be used in lieu of an external UI.`,
 	}
 
+	newHDWalletCommand = cli.Command{
+		Action:    utils.MigrateFlags(newHDWallet),
+		Name:      "newhdwallet",
+		Usage:     "Create or import a mnemonic backed HD wallet",
+		ArgsUsage: "[ <mnemonicfile> ]",
+		Flags: []cli.Flag{
+			logLevelFlag,
+			keystoreFlag,
+			utils.LightKDFFlag,
+			acceptFlag,
+		},
+		Description: `
+The newhdwallet command creates a new BIP-39 mnemonic backed HD wallet in the keystore,
+printing the generated mnemonic for backup. If a file containing an existing mnemonic
+is given, that mnemonic is imported instead. Accounts are derived from the wallet via
+clef_deriveAccount.`,
+	}
+
 	gendocCommand = cli.Command{
 		Action: GenDoc,
 		Name:   "gendoc",
@@ -239,6 +284,12 @@ var AppHelpFlagGroups = []flags.FlagGroup{
 			customDBFlag,
 			auditLogFlag,
 			ruleFlag,
+			policyFlag,
+			policyExplainFlag,
+			quorumFlag,
+			quorumAddrFlag,
+			quorumPortFlag,
+			quorumVHostsFlag,
 			stdiouiFlag,
 			testFlag,
 			advancedMode,
@@ -268,6 +319,12 @@ func init() {
 		customDBFlag,
 		auditLogFlag,
 		ruleFlag,
+		policyFlag,
+		policyExplainFlag,
+		quorumFlag,
+		quorumAddrFlag,
+		quorumPortFlag,
+		quorumVHostsFlag,
 		stdiouiFlag,
 		testFlag,
 		advancedMode,
@@ -279,6 +336,7 @@ func init() {
 		setCredentialCommand,
 		delCredentialCommand,
 		newAccountCommand,
+		newHDWalletCommand,
 		gendocCommand}
 	cli.CommandHelpTemplate = flags.CommandHelpTemplate
 	// Override the default app help template
@@ -498,6 +556,49 @@ func newAccount(c *cli.Context) error {
 	return err
 }
 
+func newHDWallet(c *cli.Context) error {
+	if err := initialize(c); err != nil {
+		return err
+	}
+	var (
+		ksLoc    = c.GlobalString(keystoreFlag.Name)
+		lightKdf = c.GlobalBool(utils.LightKDFFlag.Name)
+		mnemonic string
+	)
+	if c.NArg() > 0 {
+		blob, err := ioutil.ReadFile(c.Args().First())
+		if err != nil {
+			utils.Fatalf("Failed to read mnemonic file: %v", err)
+		}
+		mnemonic = strings.Join(strings.Fields(string(blob)), " ")
+	}
+	log.Info("Starting clef", "keystore", ksLoc, "light-kdf", lightKdf)
+	am := core.StartClefAccountManager(ksLoc, true, lightKdf, "")
+	apiImpl := core.NewSignerAPI(am, 0, true, core.NewCommandlineUI(), nil, false, &storage.NoStorage{})
+	internalApi := core.NewUIServerAPI(apiImpl)
+
+	password := utils.GetPassPhrase("Please enter a password to encrypt the wallet seed with:", true)
+	fmt.Println()
+
+	if mnemonic != "" {
+		url, err := internalApi.ImportMnemonic(mnemonic, password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Imported HD wallet %s\n", url)
+		return nil
+	}
+	result, err := internalApi.NewHDWallet(password)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Generated HD wallet %s\n\n", result.URL)
+	fmt.Printf("Mnemonic: %s\n\n", result.Mnemonic)
+	fmt.Println("Write down the mnemonic and store it safely, it is the only way to recover")
+	fmt.Println("the wallet if the seed file or its password is lost. It is not shown again.")
+	return nil
+}
+
 func initialize(c *cli.Context) error {
 	// Set up the logger to print everything
 	logOutput := os.Stdout
@@ -562,6 +663,29 @@ func signer(c *cli.Context) error {
 		log.Info("Using CLI as UI-channel")
 		ui = core.NewCommandlineUI()
 	}
+	// Transactions require a quorum of reviewers, if configured. The quorum replaces
+	// the transaction approval of the UI, so it can't be bypassed by auto-approvals.
+	var quorumUI *core.QuorumUI
+	if quorumFile := c.GlobalString(quorumFlag.Name); quorumFile != "" {
+		for _, flag := range []string{ruleFlag.Name, policyFlag.Name} {
+			if c.GlobalIsSet(flag) {
+				utils.Fatalf("Flags --%s and --%s are mutually exclusive", quorumFlag.Name, flag)
+			}
+		}
+		blob, err := ioutil.ReadFile(quorumFile)
+		if err != nil {
+			utils.Fatalf("Could not load quorum configuration: %v", err)
+		}
+		var config core.QuorumConfig
+		if err := json.Unmarshal(blob, &config); err != nil {
+			utils.Fatalf("Invalid quorum configuration: %v", err)
+		}
+		if quorumUI, err = core.NewQuorumUI(ui, &config); err != nil {
+			utils.Fatalf("Invalid quorum configuration: %v", err)
+		}
+		ui = quorumUI
+		log.Info("Quorum approval configured", "file", quorumFile, "threshold", config.Threshold, "reviewers", len(config.Reviewers))
+	}
 	// 4bytedb data
 	fourByteLocal := c.GlobalString(customDBFlag.Name)
 	db, err := fourbyte.NewWithFile(fourByteLocal)
@@ -591,6 +715,36 @@ func signer(c *cli.Context) error {
 		jsStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "jsstorage.json"), jskey)
 		configStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "config.json"), confkey)
 
+		// Do we have a rule-file or a policy-file?
+		if c.GlobalIsSet(ruleFlag.Name) && c.GlobalIsSet(policyFlag.Name) {
+			utils.Fatalf("Flags --%s and --%s are mutually exclusive", ruleFlag.Name, policyFlag.Name)
+		}
+		if policyFile := c.GlobalString(policyFlag.Name); policyFile != "" {
+			policyJSON, err := ioutil.ReadFile(policyFile)
+			if err != nil {
+				log.Warn("Could not load policy, disabling", "file", policyFile, "err", err)
+			} else {
+				shasum := sha256.Sum256(policyJSON)
+				foundShaSum := hex.EncodeToString(shasum[:])
+				storedShasum, _ := configStorage.Get("ruleset_sha256")
+				if storedShasum != foundShaSum {
+					log.Warn("Policy hash not attested, disabling", "hash", foundShaSum, "attested", storedShasum)
+				} else {
+					policykey := crypto.Keccak256([]byte("policystorage"), stretchedKey)
+					policyStorage := storage.NewAESEncryptedStorage(filepath.Join(vaultLocation, "policystorage.json"), policykey)
+
+					policyEngine, err := rules.NewPolicyEvaluator(ui, policyStorage, c.GlobalBool(policyExplainFlag.Name))
+					if err != nil {
+						utils.Fatalf(err.Error())
+					}
+					if err := policyEngine.Init(policyJSON); err != nil {
+						utils.Fatalf("Failed to load policy: %v", err)
+					}
+					ui = policyEngine
+					log.Info("Policy engine configured", "file", policyFile)
+				}
+			}
+		}
 		// Do we have a rule-file?
 		if ruleFile := c.GlobalString(ruleFlag.Name); ruleFile != "" {
 			ruleJS, err := ioutil.ReadFile(ruleFile)
@@ -634,10 +788,14 @@ func signer(c *cli.Context) error {
 	api = apiImpl
 	// Audit logging
 	if logfile := c.GlobalString(auditLogFlag.Name); logfile != "" {
-		api, err = core.NewAuditLogger(logfile, api)
+		auditLogger, err := core.NewAuditLogger(logfile, api)
 		if err != nil {
 			utils.Fatalf(err.Error())
 		}
+		if quorumUI != nil {
+			quorumUI.SetAuditor(auditLogger)
+		}
+		api = auditLogger
 		log.Info("Audit logs configured", "file", logfile)
 	}
 	// register signer API with server
@@ -645,6 +803,12 @@ func signer(c *cli.Context) error {
 		extapiURL = "n/a"
 		ipcapiURL = "n/a"
 	)
+	// Transaction requests are held until the quorum decides on them, so the
+	// responses must be allowed to take longer than the quorum timeout.
+	httpTimeouts := rpc.DefaultHTTPTimeouts
+	if quorumUI != nil {
+		httpTimeouts.WriteTimeout += quorumUI.Timeout()
+	}
 	rpcAPI := []rpc.API{
 		{
 			Namespace: "account",
@@ -652,12 +816,13 @@ func signer(c *cli.Context) error {
 			Service:   api,
 			Version:   "1.0"},
 	}
+	whitelist := []string{"account"}
 	if c.GlobalBool(utils.HTTPEnabledFlag.Name) {
 		vhosts := utils.SplitAndTrim(c.GlobalString(utils.HTTPVirtualHostsFlag.Name))
 		cors := utils.SplitAndTrim(c.GlobalString(utils.HTTPCORSDomainFlag.Name))
 
 		srv := rpc.NewServer()
-		err := node.RegisterApisFromWhitelist(rpcAPI, []string{"account"}, srv, false)
+		err := node.RegisterApisFromWhitelist(rpcAPI, whitelist, srv, false)
 		if err != nil {
 			utils.Fatalf("Could not register API: %w", err)
 		}
@@ -668,7 +833,7 @@ func signer(c *cli.Context) error {
 
 		// start http server
 		httpEndpoint := fmt.Sprintf("%s:%d", c.GlobalString(utils.HTTPListenAddrFlag.Name), port)
-		httpServer, addr, err := node.StartHTTPEndpoint(httpEndpoint, rpc.DefaultHTTPTimeouts, handler)
+		httpServer, addr, err := node.StartHTTPEndpoint(httpEndpoint, httpTimeouts, handler)
 		if err != nil {
 			utils.Fatalf("Could not start RPC api: %v", err)
 		}
@@ -695,6 +860,28 @@ func signer(c *cli.Context) error {
 		}()
 	}
 
+	if quorumUI != nil {
+		// Reviewers vote on their own endpoint, separate from the requesters
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("quorum", core.NewQuorumAPI(quorumUI)); err != nil {
+			utils.Fatalf("Could not register quorum API: %v", err)
+		}
+		vhosts := utils.SplitAndTrim(c.GlobalString(quorumVHostsFlag.Name))
+		handler := node.NewHTTPHandlerStack(srv, nil, vhosts)
+
+		endpoint := fmt.Sprintf("%s:%d", c.GlobalString(quorumAddrFlag.Name), c.GlobalInt(quorumPortFlag.Name))
+		quorumServer, addr, err := node.StartHTTPEndpoint(endpoint, httpTimeouts, handler)
+		if err != nil {
+			utils.Fatalf("Could not start quorum api: %v", err)
+		}
+		quorumURL := fmt.Sprintf("http://%v/", addr)
+		log.Info("Quorum endpoint opened", "url", quorumURL)
+
+		defer func() {
+			quorumServer.Shutdown(context.Background())
+			log.Info("Quorum endpoint closed", "url", quorumURL)
+		}()
+	}
 	if c.GlobalBool(testFlag.Name) {
 		log.Info("Performing UI test")
 		go testExternalUI(apiImpl)
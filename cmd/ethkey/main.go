@@ -42,6 +42,7 @@ func init() {
 		commandChangePassphrase,
 		commandSignMessage,
 		commandVerifyMessage,
+		commandSignTypedData,
 	}
 	cli.CommandHelpTemplate = flags.OriginCommandHelpTemplate
 }
@@ -0,0 +1,100 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/accounts/eip712"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type outputSignTypedData struct {
+	Hash      string
+	Signature string
+}
+
+var commandSignTypedData = cli.Command{
+	Name:      "signtypeddata",
+	Usage:     "sign EIP-712 typed data",
+	ArgsUsage: "<keyfile> <datafile>",
+	Description: `
+Sign the EIP-712 typed data contained in the JSON datafile with a keyfile.
+
+The datafile holds the types, primaryType, domain and message fields of the
+typed data, the same format as accepted by eth_signTypedData. The signature V
+value is 0 or 1.
+`,
+	Flags: []cli.Flag{
+		passphraseFlag,
+		jsonFlag,
+	},
+	Action: func(ctx *cli.Context) error {
+		if len(ctx.Args()) != 2 {
+			utils.Fatalf("This command requires a keyfile and a datafile argument.")
+		}
+		// Load and validate the typed data.
+		datafile := ctx.Args().Get(1)
+		blob, err := ioutil.ReadFile(datafile)
+		if err != nil {
+			utils.Fatalf("Failed to read the datafile at '%s': %v", datafile, err)
+		}
+		var typedData eip712.TypedData
+		if err := json.Unmarshal(blob, &typedData); err != nil {
+			utils.Fatalf("Failed to parse the typed data: %v", err)
+		}
+		hash, err := typedData.Hash()
+		if err != nil {
+			utils.Fatalf("Invalid typed data: %v", err)
+		}
+
+		// Load the keyfile.
+		keyfilepath := ctx.Args().First()
+		keyjson, err := ioutil.ReadFile(keyfilepath)
+		if err != nil {
+			utils.Fatalf("Failed to read the keyfile at '%s': %v", keyfilepath, err)
+		}
+
+		// Decrypt key with passphrase.
+		passphrase := getPassphrase(ctx, false)
+		key, err := keystore.DecryptKey(keyjson, passphrase)
+		if err != nil {
+			utils.Fatalf("Error decrypting key: %v", err)
+		}
+
+		signature, err := eip712.Sign(&typedData, key.PrivateKey)
+		if err != nil {
+			utils.Fatalf("Failed to sign typed data: %v", err)
+		}
+		out := outputSignTypedData{
+			Hash:      hash.Hex(),
+			Signature: hex.EncodeToString(signature),
+		}
+		if ctx.Bool(jsonFlag.Name) {
+			mustPrintJSON(out)
+		} else {
+			fmt.Println("Hash:     ", out.Hash)
+			fmt.Println("Signature:", out.Signature)
+		}
+		return nil
+	},
+}
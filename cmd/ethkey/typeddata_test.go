@@ -0,0 +1,97 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/eip712"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const testTypedData = `{
+	"types": {
+		"EIP712Domain": [{"name": "name", "type": "string"}, {"name": "chainId", "type": "uint256"}],
+		"Greeting": [{"name": "from", "type": "address"}, {"name": "text", "type": "string"}]
+	},
+	"primaryType": "Greeting",
+	"domain": {"name": "ethkey", "chainId": "1"},
+	"message": {"from": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826", "text": "hello"}
+}`
+
+func TestTypedDataSign(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ethkey-test")
+	if err != nil {
+		t.Fatal("Can't create temporary directory:", err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	keyfile := filepath.Join(tmpdir, "the-keyfile")
+	datafile := filepath.Join(tmpdir, "typeddata.json")
+	if err := ioutil.WriteFile(datafile, []byte(testTypedData), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// Create the key.
+	generate := runEthkey(t, "generate", "--lightkdf", keyfile)
+	generate.Expect(`
+!! Unsupported terminal, password will be echoed.
+Password: {{.InputLine "foobar"}}
+Repeat password: {{.InputLine "foobar"}}
+`)
+	_, matches := generate.ExpectRegexp(`Address: (0x[0-9a-fA-F]{40})\n`)
+	address := matches[1]
+	generate.ExpectExit()
+
+	// Sign the typed data.
+	sign := runEthkey(t, "signtypeddata", keyfile, datafile)
+	sign.Expect(`
+!! Unsupported terminal, password will be echoed.
+Password: {{.InputLine "foobar"}}
+`)
+	_, matches = sign.ExpectRegexp(`Hash:      (0x[0-9a-f]{64})\nSignature: ([0-9a-f]+)\n`)
+	hash, signature := matches[1], matches[2]
+	sign.ExpectExit()
+
+	// Verify the output against the typed data.
+	var typedData eip712.TypedData
+	if err := json.Unmarshal([]byte(testTypedData), &typedData); err != nil {
+		t.Fatal(err)
+	}
+	want, err := typedData.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != want.Hex() {
+		t.Errorf("hash mismatch: have %s, want %s", hash, want.Hex())
+	}
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := eip712.Recover(&typedData, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != common.HexToAddress(address) {
+		t.Errorf("recovered address doesn't match generated key")
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const debuggerHelp = `Commands:
+  s, step [n]             execute the next n instructions (default 1)
+  n, next                 step over calls made by the current instruction
+  o, out                  run until the current call frame returns
+  c, continue             run until the next breakpoint
+  b, break pc <pc>        halt before the instruction at pc
+  b, break op <opcode>    halt before every instance of the opcode
+  b, break depth <depth>  halt when entering a call frame at the given depth
+  d, delete <id>          delete a breakpoint
+  i, info                 list the breakpoints
+  st, stack               print the stack, top first
+  m, memory [off [size]]  print the memory, or a part of it
+  sto, storage [slot]     print the storage slots accessed so far, or the given one
+  l, list                 print the source around the current instruction
+  w, where                print the active call frames
+  q, quit                 abort execution
+  h, help                 print this help
+An empty line repeats the last command.
+`
+
+// debugMode is the condition the debugger halts execution on, besides the
+// breakpoints.
+type debugMode int
+
+const (
+	modeStep     debugMode = iota // Halt after a number of instructions
+	modeNext                      // Halt at the next instruction in the current frame or its parents
+	modeOut                       // Halt once the current frame returned
+	modeContinue                  // Halt at breakpoints only
+	modeDetached                  // Never halt again
+)
+
+// breakpoint is a single halting condition set by the user.
+type breakpoint struct {
+	id    int
+	kind  string // One of "pc", "op" or "depth"
+	pc    uint64
+	op    vm.OpCode
+	depth int
+}
+
+func (b *breakpoint) String() string {
+	switch b.kind {
+	case "pc":
+		return fmt.Sprintf("%d: pc %#x", b.id, b.pc)
+	case "op":
+		return fmt.Sprintf("%d: op %v", b.id, b.op)
+	default:
+		return fmt.Sprintf("%d: depth %d", b.id, b.depth)
+	}
+}
+
+// debugFrame is an active call frame of the execution.
+type debugFrame struct {
+	address common.Address
+	code    []byte
+	pc      uint64
+}
+
+// debugger is an interactive vm.Tracer which halts before selected
+// instructions and lets the user inspect the state of the machine from a
+// command prompt. Execution continues once the prompt returns, so the EVM is
+// stepped synchronously from within CaptureState.
+type debugger struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	source *sourceMapper // Optional Solidity source mapping
+
+	mode      debugMode
+	remaining int // Instructions left to execute in step mode
+	depth     int // Frame depth to halt at in next and out modes
+	lastCmd   string
+
+	breakpoints []*breakpoint
+	nextID      int
+
+	frames  []debugFrame
+	touched map[common.Address][]common.Hash // Storage slots accessed, in order
+	seen    map[common.Address]map[common.Hash]bool
+
+	// Machine state of the instruction execution is halted at
+	env   *vm.EVM
+	scope *vm.ScopeContext
+	pc    uint64
+	op    vm.OpCode
+}
+
+// newDebugger creates a debugger reading commands from in and writing to out,
+// which halts before the first instruction. The source mapper is optional.
+func newDebugger(in io.Reader, out io.Writer, source *sourceMapper) *debugger {
+	return &debugger{
+		in:        bufio.NewScanner(in),
+		out:       out,
+		source:    source,
+		mode:      modeStep,
+		remaining: 1,
+		nextID:    1,
+		touched:   make(map[common.Address][]common.Hash),
+		seen:      make(map[common.Address]map[common.Hash]bool),
+	}
+}
+
+func (d *debugger) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	kind := "call"
+	if create {
+		kind = "create"
+	}
+	fmt.Fprintf(d.out, "Starting %s from %x to %x, gas %d, input %d bytes\n", kind, from, to, gas, len(input))
+	fmt.Fprintf(d.out, "Type 'help' for a list of commands.\n")
+}
+
+func (d *debugger) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	entered := depth > len(d.frames)
+	if entered {
+		d.frames = append(d.frames, debugFrame{address: scope.Contract.Address(), code: scope.Contract.Code})
+	} else if depth < len(d.frames) {
+		d.frames = d.frames[:depth]
+	}
+	d.frames[depth-1].pc = pc
+
+	// Failing steps (e.g. stack underflow) are reported before execution, so
+	// the stack can't be trusted to hold the accessed slot.
+	if (op == vm.SLOAD || op == vm.SSTORE) && err == nil && len(scope.Stack.Data()) > 0 {
+		d.touch(scope.Contract.Address(), common.Hash(scope.Stack.Back(0).Bytes32()))
+	}
+	if !d.halt(pc, op, depth, entered) {
+		return
+	}
+	d.env, d.scope, d.pc, d.op = env, scope, pc, op
+	defer func() { d.env, d.scope = nil, nil }()
+
+	fmt.Fprintf(d.out, "[%d] 0x%04x %-14v gas=%d cost=%d\n", depth, pc, op, gas, cost)
+	if loc := d.location(); loc != nil {
+		fmt.Fprintf(d.out, "    %v\n", loc)
+		if span := strings.TrimSpace(loc.span); span != "" && !strings.Contains(span, "\n") && span != strings.TrimSpace(loc.file.line(loc.line)) {
+			fmt.Fprintf(d.out, "    > %s\n", span)
+		}
+	}
+	d.prompt()
+}
+
+func (d *debugger) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+	if d.mode != modeDetached {
+		fmt.Fprintf(d.out, "[%d] 0x%04x %v failed: %v\n", depth, pc, op, err)
+	}
+}
+
+func (d *debugger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	fmt.Fprintf(d.out, "Execution finished, gas used %d\n", gasUsed)
+	if err != nil {
+		fmt.Fprintf(d.out, "Execution failed: %v\n", err)
+	}
+}
+
+// halt reports whether execution should be stopped before the instruction.
+func (d *debugger) halt(pc uint64, op vm.OpCode, depth int, entered bool) bool {
+	halt := false
+	switch d.mode {
+	case modeDetached:
+		return false
+	case modeStep:
+		d.remaining--
+		halt = d.remaining <= 0
+	case modeNext:
+		halt = depth <= d.depth
+	case modeOut:
+		halt = depth < d.depth
+	}
+	for _, b := range d.breakpoints {
+		hit := false
+		switch b.kind {
+		case "pc":
+			hit = b.pc == pc
+		case "op":
+			hit = b.op == op
+		case "depth":
+			hit = entered && b.depth == depth
+		}
+		if hit {
+			fmt.Fprintf(d.out, "Hit breakpoint %v\n", b)
+			halt = true
+		}
+	}
+	return halt
+}
+
+// touch records an accessed storage slot of the given account.
+func (d *debugger) touch(addr common.Address, slot common.Hash) {
+	if d.seen[addr] == nil {
+		d.seen[addr] = make(map[common.Hash]bool)
+	}
+	if !d.seen[addr][slot] {
+		d.seen[addr][slot] = true
+		d.touched[addr] = append(d.touched[addr], slot)
+	}
+}
+
+// location returns the source position of the current instruction, if known.
+func (d *debugger) location() *sourceLocation {
+	if d.source == nil {
+		return nil
+	}
+	return d.source.location(d.scope.Contract.Code, d.pc, len(d.frames) == 1)
+}
+
+// prompt reads and runs commands until one of them resumes execution. If the
+// input is exhausted, the debugger detaches and lets the execution finish.
+func (d *debugger) prompt() {
+	for {
+		fmt.Fprint(d.out, "(evm) ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			d.mode = modeDetached
+			return
+		}
+		line := strings.TrimSpace(d.in.Text())
+		if line == "" {
+			line = d.lastCmd
+		}
+		if line == "" {
+			continue
+		}
+		d.lastCmd = line
+		if d.command(strings.Fields(line)) {
+			return
+		}
+	}
+}
+
+// command runs a single command and reports whether execution should resume.
+func (d *debugger) command(args []string) bool {
+	depth := len(d.frames)
+	switch args[0] {
+	case "s", "step":
+		n := 1
+		if len(args) > 1 {
+			v, err := strconv.Atoi(args[1])
+			if err != nil || v < 1 {
+				fmt.Fprintf(d.out, "Invalid step count %q\n", args[1])
+				return false
+			}
+			n = v
+		}
+		d.mode, d.remaining = modeStep, n
+		return true
+	case "n", "next":
+		d.mode, d.depth = modeNext, depth
+		return true
+	case "o", "out":
+		if depth == 1 {
+			d.mode = modeContinue
+		} else {
+			d.mode, d.depth = modeOut, depth
+		}
+		return true
+	case "c", "continue":
+		d.mode = modeContinue
+		return true
+	case "q", "quit":
+		d.mode = modeDetached
+		d.env.Cancel()
+		return true
+	case "b", "break":
+		d.addBreakpoint(args[1:])
+	case "d", "delete":
+		d.deleteBreakpoint(args[1:])
+	case "i", "info":
+		if len(d.breakpoints) == 0 {
+			fmt.Fprintln(d.out, "No breakpoints")
+		}
+		for _, b := range d.breakpoints {
+			fmt.Fprintln(d.out, b)
+		}
+	case "st", "stack":
+		d.printStack()
+	case "m", "memory":
+		d.printMemory(args[1:])
+	case "sto", "storage":
+		d.printStorage(args[1:])
+	case "l", "list":
+		d.printSource()
+	case "w", "where":
+		for i := len(d.frames) - 1; i >= 0; i-- {
+			fmt.Fprintf(d.out, "[%d] %x pc=%#x\n", i+1, d.frames[i].address, d.frames[i].pc)
+		}
+	case "h", "help":
+		fmt.Fprint(d.out, debuggerHelp)
+	default:
+		fmt.Fprintf(d.out, "Unknown command %q, type 'help' for a list of commands\n", args[0])
+	}
+	return false
+}
+
+func (d *debugger) addBreakpoint(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(d.out, "Usage: break pc <pc> | break op <opcode> | break depth <depth>")
+		return
+	}
+	b := &breakpoint{kind: args[0]}
+	switch args[0] {
+	case "pc":
+		pc, err := strconv.ParseUint(args[1], 0, 64)
+		if err != nil {
+			fmt.Fprintf(d.out, "Invalid pc %q\n", args[1])
+			return
+		}
+		b.pc = pc
+	case "op":
+		op := vm.StringToOp(strings.ToUpper(args[1]))
+		if op.String() != strings.ToUpper(args[1]) {
+			fmt.Fprintf(d.out, "Unknown opcode %q\n", args[1])
+			return
+		}
+		b.op = op
+	case "depth":
+		depth, err := strconv.Atoi(args[1])
+		if err != nil || depth < 1 {
+			fmt.Fprintf(d.out, "Invalid depth %q\n", args[1])
+			return
+		}
+		b.depth = depth
+	default:
+		fmt.Fprintf(d.out, "Unknown breakpoint type %q\n", args[0])
+		return
+	}
+	b.id = d.nextID
+	d.nextID++
+	d.breakpoints = append(d.breakpoints, b)
+	fmt.Fprintf(d.out, "Added breakpoint %v\n", b)
+}
+
+func (d *debugger) deleteBreakpoint(args []string) {
+	if len(args) != 1 {
+		fmt.Fprintln(d.out, "Usage: delete <id>")
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		fmt.Fprintf(d.out, "Invalid breakpoint id %q\n", args[0])
+		return
+	}
+	for i, b := range d.breakpoints {
+		if b.id == id {
+			d.breakpoints = append(d.breakpoints[:i], d.breakpoints[i+1:]...)
+			return
+		}
+	}
+	fmt.Fprintf(d.out, "No breakpoint %d\n", id)
+}
+
+func (d *debugger) printStack() {
+	data := d.scope.Stack.Data()
+	if len(data) == 0 {
+		fmt.Fprintln(d.out, "Stack is empty")
+	}
+	for i := len(data) - 1; i >= 0; i-- {
+		fmt.Fprintf(d.out, "%3d: %s\n", len(data)-1-i, data[i].Hex())
+	}
+}
+
+func (d *debugger) printMemory(args []string) {
+	data := d.scope.Memory.Data()
+	start, end := 0, len(data)
+	if len(args) > 0 {
+		offset, err := strconv.ParseUint(args[0], 0, 64)
+		if err != nil {
+			fmt.Fprintf(d.out, "Invalid offset %q\n", args[0])
+			return
+		}
+		size := uint64(32)
+		if len(args) > 1 {
+			if size, err = strconv.ParseUint(args[1], 0, 64); err != nil {
+				fmt.Fprintf(d.out, "Invalid size %q\n", args[1])
+				return
+			}
+		}
+		if offset > uint64(len(data)) {
+			offset = uint64(len(data))
+		}
+		if size > uint64(len(data))-offset {
+			size = uint64(len(data)) - offset
+		}
+		start, end = int(offset), int(offset+size)
+	}
+	if start == end {
+		fmt.Fprintln(d.out, "Memory is empty")
+		return
+	}
+	for i := start; i < end; i += 32 {
+		row := i + 32
+		if row > end {
+			row = end
+		}
+		fmt.Fprintf(d.out, "0x%04x: %x\n", i, data[i:row])
+	}
+}
+
+func (d *debugger) printStorage(args []string) {
+	addr := d.scope.Contract.Address()
+	slots := d.touched[addr]
+	if len(args) > 0 {
+		slot, err := strconv.ParseUint(args[0], 0, 64)
+		if err == nil {
+			slots = []common.Hash{common.BigToHash(new(big.Int).SetUint64(slot))}
+		} else if strings.HasPrefix(args[0], "0x") && len(args[0]) == 66 {
+			slots = []common.Hash{common.HexToHash(args[0])}
+		} else {
+			fmt.Fprintf(d.out, "Invalid slot %q\n", args[0])
+			return
+		}
+	}
+	if len(slots) == 0 {
+		fmt.Fprintf(d.out, "No storage of %x accessed yet\n", addr)
+	}
+	for _, slot := range slots {
+		fmt.Fprintf(d.out, "%x: %x\n", slot, d.env.StateDB.GetState(addr, slot))
+	}
+}
+
+func (d *debugger) printSource() {
+	loc := d.location()
+	if loc == nil {
+		fmt.Fprintln(d.out, "No source available")
+		return
+	}
+	for i := loc.line - 3; i <= loc.line+3; i++ {
+		if i < 0 || i >= len(loc.file.lines) {
+			continue
+		}
+		marker := " "
+		if i == loc.line {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s %4d  %s\n", marker, i+1, loc.file.line(i))
+	}
+}
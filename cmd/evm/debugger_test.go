@@ -0,0 +1,181 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+var (
+	debugCallee = common.HexToAddress("0xc0de")
+
+	// debugCallerCode stores 0x2a in slot 0, calls debugCallee, then stores 1
+	// in memory:
+	//
+	//	0x00 PUSH1 0x2a, PUSH1 0x00, SSTORE
+	//	0x05 PUSH1 0x00 (x5), PUSH20 debugCallee, GAS, CALL
+	//	0x26 PUSH1 0x01, PUSH1 0x00, MSTORE, STOP
+	debugCallerCode = append(append(common.FromHex("602a6000556000600060006000600073"), debugCallee.Bytes()...), common.FromHex("5af160016000520000")...)
+
+	// debugCalleeCode stores 7 in slot 1.
+	debugCalleeCode = common.FromHex("600760015500")
+)
+
+// runDebugger executes the test contracts under the debugger, driven by the
+// given commands, and returns its output.
+func runDebugger(t *testing.T, commands ...string) string {
+	output, err := debugCode(debugCallerCode, commands...)
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	return output
+}
+
+// debugCode executes the given code under the debugger, driven by the given
+// commands, and returns its output along with the execution error.
+func debugCode(code []byte, commands ...string) (string, error) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(debugCallee, debugCalleeCode)
+
+	var (
+		out    = new(bytes.Buffer)
+		tracer = newDebugger(strings.NewReader(strings.Join(commands, "\n")+"\n"), out, nil)
+	)
+	_, _, err := runtime.Execute(code, nil, &runtime.Config{
+		State:     statedb,
+		EVMConfig: vm.Config{Debug: true, Tracer: tracer},
+	})
+	return out.String(), err
+}
+
+// checkOutput ensures the debugger output contains the wanted lines in order,
+// and none of the unwanted ones.
+func checkOutput(t *testing.T, output string, want []string, unwanted []string) {
+	t.Helper()
+
+	rest := output
+	for _, line := range want {
+		i := strings.Index(rest, line)
+		if i < 0 {
+			t.Fatalf("output missing %q after preceding lines, have:\n%s", line, output)
+		}
+		rest = rest[i+len(line):]
+	}
+	for _, line := range unwanted {
+		if strings.Contains(output, line) {
+			t.Errorf("output contains %q, have:\n%s", line, output)
+		}
+	}
+}
+
+func TestDebuggerStepping(t *testing.T) {
+	output := runDebugger(t,
+		"step 2",
+		"stack",
+		"break op call",
+		"continue",
+		"storage",
+		"next",
+		"break pc 0x2b",
+		"info",
+		"continue",
+		"memory",
+		"memory 31 8",
+		"continue",
+	)
+	checkOutput(t, output, []string{
+		"[1] 0x0000 PUSH1",
+		"(evm) [1] 0x0004 SSTORE",
+		"  0: 0x0\n  1: 0x2a\n",
+		"Added breakpoint 1: op CALL",
+		"Hit breakpoint 1: op CALL",
+		"[1] 0x0025 CALL",
+		common.Hash{}.Hex()[2:] + ": " + common.BigToHash(big.NewInt(0x2a)).Hex()[2:],
+		"(evm) [1] 0x0026 PUSH1",
+		"Added breakpoint 2: pc 0x2b",
+		"1: op CALL\n2: pc 0x2b\n",
+		"Hit breakpoint 2: pc 0x2b",
+		"[1] 0x002b STOP",
+		"0x0000: " + strings.Repeat("00", 31) + "01\n",
+		"0x001f: 01\n",
+		"Execution finished",
+	}, []string{
+		"[2] ", // the call is stepped over
+	})
+}
+
+func TestDebuggerFrames(t *testing.T) {
+	output := runDebugger(t,
+		"break depth 2",
+		"continue",
+		"storage",
+		"step",
+		"",
+		"",
+		"storage",
+		"storage 0x01",
+		"where",
+		"out",
+		"quit",
+	)
+	checkOutput(t, output, []string{
+		"Added breakpoint 1: depth 2",
+		"Hit breakpoint 1: depth 2",
+		"[2] 0x0000 PUSH1",
+		"No storage of " + strings.ToLower(debugCallee.Hex()[2:]) + " accessed yet",
+		"[2] 0x0002 PUSH1",
+		"[2] 0x0004 SSTORE",
+		"[2] 0x0005 STOP",
+		common.BigToHash(common.Big1).Hex()[2:] + ": " + common.BigToHash(big.NewInt(7)).Hex()[2:],
+		"[2] " + strings.ToLower(debugCallee.Hex()[2:]) + " pc=0x5\n[1] ",
+		"(evm) [1] 0x0026 PUSH1",
+		"Execution finished",
+	}, []string{
+		"0x0028 PUSH1", // quit aborts execution
+	})
+}
+
+func TestDebuggerDetach(t *testing.T) {
+	output := runDebugger(t, "bogus")
+	checkOutput(t, output, []string{
+		"[1] 0x0000 PUSH1",
+		`Unknown command "bogus"`,
+		"(evm) \n",
+		"Execution finished",
+	}, []string{
+		"[1] 0x0002",
+	})
+}
+
+func TestDebuggerStackUnderflow(t *testing.T) {
+	for _, code := range []string{"54", "55"} {
+		output, err := debugCode(common.FromHex(code), "continue")
+		if err == nil {
+			t.Fatalf("code %s: expected stack underflow", code)
+		}
+		checkOutput(t, output, []string{"[1] 0x0000 S", "Execution finished"}, nil)
+	}
+}
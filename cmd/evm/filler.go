@@ -0,0 +1,161 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/tests"
+	"gopkg.in/urfave/cli.v1"
+	"gopkg.in/yaml.v3"
+)
+
+var fillCommand = cli.Command{
+	Action:    fillCmd,
+	Name:      "fill",
+	Usage:     "fills state tests from the given filler",
+	ArgsUsage: "<filler> [<output>]",
+	Description: `
+The fill command executes the state test fillers in the given JSON or YAML file
+across the supported forks, verifies the expectations on the post-state and
+emits the populated state tests, which can be run with 'evm statetest'. The
+tests are written to stdout, unless an output file is given.`,
+}
+
+func fillCmd(ctx *cli.Context) error {
+	if len(ctx.Args().First()) == 0 {
+		return errors.New("path-to-filler argument required")
+	}
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	// Load the fillers from the input file
+	path := ctx.Args().First()
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yml" || ext == ".yaml" {
+		if src, err = yamlToJSON(src); err != nil {
+			return fmt.Errorf("invalid yaml: %v", err)
+		}
+	}
+	var fillers map[string]tests.StateFiller
+	if err = json.Unmarshal(src, &fillers); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fillers))
+	for name := range fillers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Fill all the tests, aborting on the first failure
+	filled := make(map[string]*tests.StateTest, len(fillers))
+	for _, name := range names {
+		filler := fillers[name]
+		test, err := filler.Fill(vm.Config{})
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		log.Info("Filled state test", "name", name, "subtests", len(test.Subtests()))
+		filled[name] = test
+	}
+	out, err := json.MarshalIndent(filled, "", "  ")
+	if err != nil {
+		return err
+	}
+	if ctx.NArg() > 1 {
+		return ioutil.WriteFile(ctx.Args().Get(1), out, 0644)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// yamlToJSON converts a YAML document into JSON. Scalars are converted into
+// strings, apart from booleans and nulls, so hex numbers and addresses are not
+// mangled by YAML's number parsing.
+func yamlToJSON(src []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	value, err := yamlValue(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// yamlValue converts a YAML node into its JSON representation.
+func yamlValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return yamlValue(node.Content[0])
+
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+
+	case yaml.MappingNode:
+		obj := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := yamlValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			obj[node.Content[i].Value] = value
+		}
+		return obj, nil
+
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := yamlValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!bool":
+			return strconv.ParseBool(node.Value)
+		default:
+			return node.Value, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d: unsupported yaml node", node.Line)
+}
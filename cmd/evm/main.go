@@ -38,6 +38,18 @@ var (
 		Name:  "debug",
 		Usage: "output full trace logs",
 	}
+	DebuggerFlag = cli.BoolFlag{
+		Name:  "debugger",
+		Usage: "step through the execution interactively",
+	}
+	SourceMapFlag = cli.StringFlag{
+		Name:  "srcmap",
+		Usage: "solc --combined-json output with source maps to show Solidity sources in the debugger",
+	}
+	SourceMapContractFlag = cli.StringFlag{
+		Name:  "srcmap.contract",
+		Usage: "name of the contract in the source maps the executed code was compiled from",
+	}
 	MemProfileFlag = cli.StringFlag{
 		Name:  "memprofile",
 		Usage: "creates a memory profile at the given path",
@@ -46,6 +58,14 @@ var (
 		Name:  "cpuprofile",
 		Usage: "creates a CPU profile at the given path",
 	}
+	GasProfileFlag = cli.StringFlag{
+		Name:  "gasprofile",
+		Usage: "creates an opcode-level gas profile in pprof format at the given path",
+	}
+	GasFoldedFlag = cli.StringFlag{
+		Name:  "gasprofile.folded",
+		Usage: "creates an opcode-level gas profile as folded stacks for flame graphs at the given path",
+	}
 	StatDumpFlag = cli.BoolFlag{
 		Name:  "statdump",
 		Usage: "displays stack and heap memory information",
@@ -165,6 +185,9 @@ func init() {
 		BenchFlag,
 		CreateFlag,
 		DebugFlag,
+		DebuggerFlag,
+		SourceMapFlag,
+		SourceMapContractFlag,
 		VerbosityFlag,
 		CodeFlag,
 		CodeFileFlag,
@@ -176,6 +199,8 @@ func init() {
 		InputFileFlag,
 		MemProfileFlag,
 		CPUProfileFlag,
+		GasProfileFlag,
+		GasFoldedFlag,
 		StatDumpFlag,
 		GenesisFlag,
 		MachineFlag,
@@ -190,6 +215,7 @@ func init() {
 	app.Commands = []cli.Command{
 		compileCommand,
 		disasmCommand,
+		fillCommand,
 		runCommand,
 		stateTestCommand,
 		stateTransitionCommand,
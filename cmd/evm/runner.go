@@ -19,7 +19,9 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math/big"
 	"os"
@@ -118,6 +120,8 @@ func runCmd(ctx *cli.Context) error {
 	var (
 		tracer        vm.Tracer
 		debugLogger   *vm.StructLogger
+		gasProfiler   *vm.GasProfiler
+		debugger      *debugger
 		statedb       *state.StateDB
 		chainConfig   *params.ChainConfig
 		sender        = common.BytesToAddress([]byte("sender"))
@@ -132,6 +136,33 @@ func runCmd(ctx *cli.Context) error {
 	} else {
 		debugLogger = vm.NewStructLogger(logconfig)
 	}
+	if ctx.GlobalString(GasProfileFlag.Name) != "" || ctx.GlobalString(GasFoldedFlag.Name) != "" {
+		if tracer != nil {
+			return errors.New("gas profiling can't be combined with --json or --debug")
+		}
+		gasProfiler = vm.NewGasProfiler()
+		tracer = gasProfiler
+	}
+	if ctx.GlobalBool(DebuggerFlag.Name) {
+		if tracer != nil {
+			return errors.New("the debugger can't be combined with --json, --debug or gas profiling")
+		}
+		if ctx.GlobalBool(BenchFlag.Name) {
+			return errors.New("the debugger can't be combined with --bench")
+		}
+		if ctx.GlobalString(CodeFileFlag.Name) == "-" {
+			return errors.New("the debugger reads commands from stdin, code can't be read from it")
+		}
+		var source *sourceMapper
+		if path := ctx.GlobalString(SourceMapFlag.Name); path != "" {
+			var err error
+			if source, err = newSourceMapper(path, ctx.GlobalString(SourceMapContractFlag.Name), ctx.GlobalBool(CreateFlag.Name)); err != nil {
+				return fmt.Errorf("could not load source maps: %v", err)
+			}
+		}
+		debugger = newDebugger(os.Stdin, os.Stdout, source)
+		tracer = debugger
+	}
 	if ctx.GlobalString(GenesisFlag.Name) != "" {
 		gen := readGenesis(ctx.GlobalString(GenesisFlag.Name))
 		genesisConfig = gen
@@ -212,7 +243,7 @@ func runCmd(ctx *cli.Context) error {
 		BlockNumber: new(big.Int).SetUint64(genesisConfig.Number),
 		EVMConfig: vm.Config{
 			Tracer:         tracer,
-			Debug:          ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
+			Debug:          ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name) || gasProfiler != nil || debugger != nil,
 			EVMInterpreter: ctx.GlobalString(EVMInterpreterFlag.Name),
 		},
 	}
@@ -286,6 +317,21 @@ func runCmd(ctx *cli.Context) error {
 		f.Close()
 	}
 
+	if gasProfiler != nil {
+		if path := ctx.GlobalString(GasProfileFlag.Name); path != "" {
+			if err := writeGasProfile(path, gasProfiler.WritePprof); err != nil {
+				fmt.Println("could not write gas profile: ", err)
+				os.Exit(1)
+			}
+		}
+		if path := ctx.GlobalString(GasFoldedFlag.Name); path != "" {
+			if err := writeGasProfile(path, gasProfiler.WriteFolded); err != nil {
+				fmt.Println("could not write gas profile: ", err)
+				os.Exit(1)
+			}
+		}
+	}
+
 	if ctx.GlobalBool(DebugFlag.Name) {
 		if debugLogger != nil {
 			fmt.Fprintln(os.Stderr, "#### TRACE ####")
@@ -302,7 +348,7 @@ allocations:     %d
 allocated bytes: %d
 `, initialGas-leftOverGas, stats.time, stats.allocs, stats.bytesAllocated)
 	}
-	if tracer == nil {
+	if tracer == nil || gasProfiler != nil || debugger != nil {
 		fmt.Printf("0x%x\n", output)
 		if err != nil {
 			fmt.Printf(" error: %v\n", err)
@@ -311,3 +357,17 @@ allocated bytes: %d
 
 	return nil
 }
+
+// writeGasProfile creates the file at the given path and writes a gas profile
+// into it with the given writer.
+func writeGasProfile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,229 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	solidity "github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// sourceMapper resolves program counters to Solidity source lines using the
+// source maps in the combined JSON output of solc.
+type sourceMapper struct {
+	contracts map[string]*solidity.Contract
+	sources   []string           // Source list of the combined JSON, indexed by file id
+	dir       string             // Directory of the combined JSON, to resolve relative sources
+	top       *solidity.Contract // Contract explicitly chosen for the outermost frame
+	topCreate bool               // Whether the outermost frame runs creation code
+
+	maps  map[codeMapKey]*codeMap // Resolved maps, nil for code without one
+	files map[int]*sourceFile     // Loaded sources, nil for unreadable files
+}
+
+type codeMapKey struct {
+	hash common.Hash
+	top  bool
+}
+
+// codeMap is the decoded source map of a single piece of bytecode.
+type codeMap struct {
+	entries []solidity.SourceMapEntry
+	instrs  map[uint64]int // Instruction index of each opcode position
+}
+
+// sourceFile is a loaded Solidity source with its line offsets.
+type sourceFile struct {
+	name  string
+	data  []byte
+	lines []int // Byte offset of the start of each line
+}
+
+// sourceLocation is a resolved position in a Solidity source file.
+type sourceLocation struct {
+	file *sourceFile
+	line int // Zero based line number
+	span string
+}
+
+func (loc *sourceLocation) String() string {
+	return fmt.Sprintf("%s:%d: %s", loc.file.name, loc.line+1, strings.TrimSpace(loc.file.line(loc.line)))
+}
+
+// newSourceMapper loads the solc combined JSON output at path. If name is not
+// empty, the named contract is used for the outermost call frame regardless
+// of its code, otherwise all frames are matched to contracts by bytecode.
+func newSourceMapper(path string, name string, create bool) (*sourceMapper, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	contracts, err := solidity.ParseCombinedJSON(blob, "", "", "", "")
+	if err != nil {
+		return nil, err
+	}
+	var output struct {
+		SourceList []string `json:"sourceList"`
+	}
+	if err := json.Unmarshal(blob, &output); err != nil {
+		return nil, err
+	}
+	m := &sourceMapper{
+		contracts: contracts,
+		sources:   output.SourceList,
+		dir:       filepath.Dir(path),
+		topCreate: create,
+		maps:      make(map[codeMapKey]*codeMap),
+		files:     make(map[int]*sourceFile),
+	}
+	if name != "" {
+		for id, contract := range contracts {
+			if id == name || strings.HasSuffix(id, ":"+name) {
+				m.top = contract
+				break
+			}
+		}
+		if m.top == nil {
+			return nil, fmt.Errorf("contract %q not found in %s", name, path)
+		}
+	}
+	return m, nil
+}
+
+// location returns the source position the instruction at pc of the given
+// code was generated from, or nil if it cannot be resolved.
+func (m *sourceMapper) location(code []byte, pc uint64, top bool) *sourceLocation {
+	cm := m.codeMap(code, top)
+	if cm == nil {
+		return nil
+	}
+	idx, ok := cm.instrs[pc]
+	if !ok || idx >= len(cm.entries) {
+		return nil
+	}
+	entry := cm.entries[idx]
+	if entry.File < 0 || entry.Start < 0 {
+		return nil
+	}
+	file := m.file(entry.File)
+	if file == nil || entry.Start > len(file.data) {
+		return nil
+	}
+	end := entry.Start + entry.Length
+	if end > len(file.data) {
+		end = len(file.data)
+	}
+	if end < entry.Start {
+		end = entry.Start
+	}
+	line := sort.Search(len(file.lines), func(i int) bool { return file.lines[i] > entry.Start }) - 1
+	return &sourceLocation{file: file, line: line, span: string(file.data[entry.Start:end])}
+}
+
+// codeMap returns the decoded source map belonging to the given code.
+func (m *sourceMapper) codeMap(code []byte, top bool) *codeMap {
+	key := codeMapKey{hash: crypto.Keccak256Hash(code), top: top && m.top != nil}
+	if cm, ok := m.maps[key]; ok {
+		return cm
+	}
+	var srcmap string
+	if key.top {
+		srcmap = m.top.Info.SrcMapRuntime
+		if m.topCreate {
+			srcmap, _ = m.top.Info.SrcMap.(string)
+		}
+	} else {
+		hexcode := "0x" + common.Bytes2Hex(code)
+		for _, contract := range m.contracts {
+			if contract.RuntimeCode == hexcode {
+				srcmap = contract.Info.SrcMapRuntime
+				break
+			}
+			if len(contract.Code) > 2 && strings.HasPrefix(hexcode, contract.Code) {
+				srcmap, _ = contract.Info.SrcMap.(string)
+				break
+			}
+		}
+	}
+	var cm *codeMap
+	if entries, err := solidity.ParseSourceMap(srcmap); err == nil && len(entries) > 0 {
+		cm = &codeMap{entries: entries, instrs: instructionIndices(code)}
+	}
+	m.maps[key] = cm
+	return cm
+}
+
+// file returns the source file with the given id, loading it if needed.
+func (m *sourceMapper) file(id int) *sourceFile {
+	if file, ok := m.files[id]; ok {
+		return file
+	}
+	var file *sourceFile
+	if id < len(m.sources) {
+		name := m.sources[id]
+		data, err := ioutil.ReadFile(name)
+		if os.IsNotExist(err) && !filepath.IsAbs(name) {
+			data, err = ioutil.ReadFile(filepath.Join(m.dir, name))
+		}
+		if err == nil {
+			file = &sourceFile{name: name, data: data, lines: []int{0}}
+			for i, b := range data {
+				if b == '\n' {
+					file.lines = append(file.lines, i+1)
+				}
+			}
+		}
+	}
+	m.files[id] = file
+	return file
+}
+
+// line returns the text of the given zero based line, without the newline.
+func (f *sourceFile) line(n int) string {
+	if n < 0 || n >= len(f.lines) {
+		return ""
+	}
+	end := len(f.data)
+	if n+1 < len(f.lines) {
+		end = f.lines[n+1]
+	}
+	return strings.TrimRight(string(f.data[f.lines[n]:end]), "\r\n")
+}
+
+// instructionIndices maps the position of every opcode in the code to its
+// instruction index, skipping over push data the way solc source maps do.
+func instructionIndices(code []byte) map[uint64]int {
+	indices := make(map[uint64]int)
+	for pc, idx := uint64(0), 0; pc < uint64(len(code)); idx++ {
+		indices[pc] = idx
+		op := vm.OpCode(code[pc])
+		pc++
+		if op.IsPush() {
+			pc += uint64(op - vm.PUSH1 + 1)
+		}
+	}
+	return indices
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	solidity "github.com/ethereum/go-ethereum/common/compiler"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestInstructionIndices(t *testing.T) {
+	tests := []struct {
+		code string
+		want map[uint64]int
+	}{
+		{"", map[uint64]int{}},
+		{"600160020100", map[uint64]int{0: 0, 2: 1, 4: 2, 5: 3}},
+		// Push data running past the end of the code
+		{"60016203", map[uint64]int{0: 0, 2: 1}},
+		{"7f", map[uint64]int{0: 0}},
+	}
+	for i, tt := range tests {
+		if have := instructionIndices(hexutil.MustDecode("0x" + tt.code)); !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: indices mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+func TestSourceMapperLocation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "evm-srcmap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	source := "contract C {\n  uint x;\n}\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "C.sol"), []byte(source), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// A push followed by a stop for every source map entry past the first
+	code := hexutil.MustDecode("0x60010000000000")
+	m := &sourceMapper{
+		contracts: map[string]*solidity.Contract{
+			"C.sol:C": {
+				RuntimeCode: hexutil.Encode(code),
+				Info:        solidity.ContractInfo{SrcMapRuntime: "0:8:0;15:6:0;100:5:0;23:100:0;9:-1:0;0:1:-1;0:1:7"},
+			},
+		},
+		sources: []string{"C.sol"},
+		dir:     dir,
+		maps:    make(map[codeMapKey]*codeMap),
+		files:   make(map[int]*sourceFile),
+	}
+	tests := []struct {
+		pc   uint64
+		line int // -1 if unresolvable
+		span string
+	}{
+		{pc: 0, line: 0, span: "contract"},
+		{pc: 1, line: -1}, // push data
+		{pc: 2, line: 1, span: "uint x"},
+		{pc: 3, line: -1},             // start past the end of the file
+		{pc: 4, line: 2, span: "}\n"}, // length past the end of the file
+		{pc: 5, line: 0, span: ""},    // negative length
+		{pc: 6, line: -1},             // compiler generated
+		{pc: 7, line: -1},             // file missing from the source list
+		{pc: 8, line: -1},             // past the end of the code
+	}
+	for _, tt := range tests {
+		loc := m.location(code, tt.pc, false)
+		if tt.line < 0 {
+			if loc != nil {
+				t.Errorf("pc %d: unexpected location %v", tt.pc, loc)
+			}
+			continue
+		}
+		if loc == nil {
+			t.Errorf("pc %d: location missing", tt.pc)
+			continue
+		}
+		if loc.line != tt.line || loc.span != tt.span {
+			t.Errorf("pc %d: location mismatch: have line %d span %q, want line %d span %q", tt.pc, loc.line, loc.span, tt.line, tt.span)
+		}
+	}
+}
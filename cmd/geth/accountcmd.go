@@ -19,8 +19,10 @@ package main
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/hdwallet"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -185,6 +187,49 @@ Note:
 As you can directly copy your encrypted accounts to another ethereum instance,
 this import mechanism is not needed when you transfer an account between
 nodes.
+`,
+			},
+			{
+				Name:   "newhd",
+				Usage:  "Create a new mnemonic backed HD wallet",
+				Action: utils.MigrateFlags(accountNewHD),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+				},
+				Description: `
+    geth account newhd
+
+Creates a new BIP-39 mnemonic backed HD wallet, pins its first account at the
+default derivation path and prints the mnemonic and the address.
+
+The wallet seed is saved in encrypted format under <KEYSTORE>/hd, you are
+prompted for a password.
+
+Write down the mnemonic, it is the only way to recover the wallet if the seed
+file or its password is lost. It is not shown again.
+`,
+			},
+			{
+				Name:   "importhd",
+				Usage:  "Import a BIP-39 mnemonic into a new HD wallet",
+				Action: utils.MigrateFlags(accountImportHD),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.LightKDFFlag,
+				},
+				ArgsUsage: "<mnemonicFile>",
+				Description: `
+    geth account importhd <mnemonicfile>
+
+Imports the BIP-39 mnemonic from <mnemonicfile> into a new HD wallet, pins its
+first account at the default derivation path and prints the address.
+
+The wallet seed is saved in encrypted format, you are prompted for a password.
 `,
 			},
 		},
@@ -352,3 +397,65 @@ func accountImport(ctx *cli.Context) error {
 	fmt.Printf("Address: {%x}\n", acct.Address)
 	return nil
 }
+
+// accountNewHD creates a new HD wallet and pins its first account.
+func accountNewHD(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	passphrase := utils.GetPassPhraseWithList("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	store := stack.AccountManager().Backends(hdwallet.StoreType)[0].(*hdwallet.Store)
+	wallet, mnemonic, err := store.NewWallet(passphrase)
+	if err != nil {
+		utils.Fatalf("Failed to create wallet: %v", err)
+	}
+	account := pinFirstHDAccount(wallet, passphrase)
+
+	fmt.Printf("\nYour new HD wallet was generated\n\n")
+	fmt.Printf("Mnemonic:                     %s\n", mnemonic)
+	fmt.Printf("Public address of the key:    %s\n", account.Address.Hex())
+	fmt.Printf("Path of the wallet seed file: %s\n\n", wallet.URL().Path)
+	fmt.Printf("- Write down the mnemonic and keep it safe, it is not shown again!\n")
+	fmt.Printf("- You must REMEMBER your password! Without the password, the wallet can\n")
+	fmt.Printf("  only be restored from the mnemonic.\n")
+	return nil
+}
+
+// accountImportHD imports a mnemonic from file and pins its first account.
+func accountImportHD(ctx *cli.Context) error {
+	mnemonicfile := ctx.Args().First()
+	if len(mnemonicfile) == 0 {
+		utils.Fatalf("mnemonic file must be given as argument")
+	}
+	blob, err := ioutil.ReadFile(mnemonicfile)
+	if err != nil {
+		utils.Fatalf("Failed to read the mnemonic: %v", err)
+	}
+	mnemonic := strings.Join(strings.Fields(string(blob)), " ")
+
+	stack, _ := makeConfigNode(ctx)
+	passphrase := utils.GetPassPhraseWithList("Your new wallet is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	store := stack.AccountManager().Backends(hdwallet.StoreType)[0].(*hdwallet.Store)
+	wallet, err := store.Import(mnemonic, passphrase)
+	if err != nil {
+		utils.Fatalf("Could not import the mnemonic: %v", err)
+	}
+	account := pinFirstHDAccount(wallet, passphrase)
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
+
+// pinFirstHDAccount derives and pins the account at the default derivation path
+// of a HD wallet, so it's listed even while the wallet is locked.
+func pinFirstHDAccount(wallet accounts.Wallet, passphrase string) accounts.Account {
+	if err := wallet.Open(passphrase); err != nil {
+		utils.Fatalf("Failed to open wallet: %v", err)
+	}
+	defer wallet.Close()
+
+	account, err := wallet.Derive(accounts.DefaultBaseDerivationPath, true)
+	if err != nil {
+		utils.Fatalf("Failed to derive account: %v", err)
+	}
+	return account
+}
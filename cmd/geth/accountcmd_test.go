@@ -125,6 +125,27 @@ func importAccountWithExpect(t *testing.T, key string, expected string) {
 	geth.Expect(expected)
 }
 
+func TestAccountImportHD(t *testing.T) {
+	dir := tmpdir(t)
+	mnemonicFile := filepath.Join(dir, "mnemonic.txt")
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about\n"
+	if err := ioutil.WriteFile(mnemonicFile, []byte(mnemonic), 0600); err != nil {
+		t.Fatal(err)
+	}
+	passwordFile := filepath.Join(dir, "password.txt")
+	if err := ioutil.WriteFile(passwordFile, []byte("foobar"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	geth := runGeth(t, "account", "importhd", mnemonicFile, "--datadir", dir, "--lightkdf", "--password", passwordFile)
+	geth.Expect("Address: {9858effd232b4033e47d90003d41ec34ecaeda94}\n")
+	geth.ExpectExit()
+
+	// The pinned account should be listed without unlocking the wallet
+	geth = runGeth(t, "account", "list", "--datadir", dir)
+	geth.ExpectRegexp(`Account #0: \{9858effd232b4033e47d90003d41ec34ecaeda94\} hd://.+/m/44'/60'/0'/0/0\n`)
+	geth.WaitExit()
+}
+
 func TestAccountNewBadRepeat(t *testing.T) {
 	geth := runGeth(t, "account", "new", "--lightkdf")
 	defer geth.ExpectExit()
@@ -17,6 +17,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -28,9 +30,11 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/console/prompt"
+	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/trie"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -62,6 +66,7 @@ Remove blockchain and state databases`,
 			dbPutCmd,
 			dbGetSlotsCmd,
 			dbDumpFreezerIndex,
+			dbRebuildLogIndexCmd,
 		},
 	}
 	dbInspectCmd = cli.Command{
@@ -195,6 +200,23 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		},
 		Description: "This command displays information about the freezer index.",
 	}
+	dbRebuildLogIndexCmd = cli.Command{
+		Action: utils.MigrateFlags(dbRebuildLogIndex),
+		Name:   "rebuild-logindex",
+		Usage:  "Drop and rebuild the log index used by eth_getLogs",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.SyncModeFlag,
+			utils.MainnetFlag,
+			utils.RopstenFlag,
+			utils.RinkebyFlag,
+			utils.GoerliFlag,
+			utils.CalaverasFlag,
+		},
+		Description: `This command deletes the log index and reindexes the logs of all confirmed
+sections of the canonical chain. Sections above the confirmation distance are left
+for the node to index after startup.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -507,3 +529,21 @@ func freezerInspect(ctx *cli.Context) error {
 	}
 	return nil
 }
+
+// dbRebuildLogIndex drops the log index and rebuilds it from the stored receipts.
+func dbRebuildLogIndex(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	head := rawdb.ReadHeadHeader(db)
+	if head == nil {
+		return errors.New("no head header found")
+	}
+	index := core.NewLogIndex(db, params.BloomBitsBlocks, params.BloomConfirms)
+	defer index.Indexer().Close()
+
+	return index.Rebuild(context.Background(), head.Number.Uint64())
+}
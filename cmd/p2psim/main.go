@@ -34,6 +34,14 @@
 //     $ p2psim node connect node01 node02
 //     Connected node01 to node02
 //
+// The link between the two nodes can then be degraded and partitioned:
+//
+//     $ p2psim link set --latency 100ms --bandwidth 100000 --loss 0.01 node01 node02
+//     Updated link node01 <-> node02
+//
+//     $ p2psim partition node01 node02
+//     Partitioned network into 2 groups
+//
 package main
 
 import (
@@ -50,6 +58,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/p2p/simulations/pipes"
 	"github.com/ethereum/go-ethereum/rpc"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -179,6 +188,59 @@ func main() {
 				},
 			},
 		},
+		{
+			Name:  "link",
+			Usage: "manage simulated links between nodes",
+			Subcommands: []cli.Command{
+				{
+					Name:      "show",
+					ArgsUsage: "<node> <peer>",
+					Usage:     "show link configuration",
+					Action:    showLink,
+				},
+				{
+					Name:      "set",
+					ArgsUsage: "<node> <peer>",
+					Usage:     "change link configuration",
+					Action:    setLink,
+					Flags: []cli.Flag{
+						cli.DurationFlag{
+							Name:  "latency",
+							Usage: "one-way latency",
+						},
+						cli.IntFlag{
+							Name:  "bandwidth",
+							Usage: "bandwidth in bytes per second (0 = unlimited)",
+						},
+						cli.Float64Flag{
+							Name:  "loss",
+							Usage: "probability of a write being lost",
+						},
+						cli.BoolFlag{
+							Name:  "partitioned",
+							Usage: "block all traffic over the link",
+						},
+					},
+				},
+				{
+					Name:      "reset",
+					ArgsUsage: "<node> <peer>",
+					Usage:     "reset link to a perfect connection",
+					Action:    resetLink,
+				},
+			},
+		},
+		{
+			Name:      "partition",
+			ArgsUsage: "<group> <group> [<group>...]",
+			Usage:     "partition the network into groups of comma separated nodes",
+			Action:    partitionNetwork,
+		},
+		{
+			Name:   "heal",
+			Usage:  "remove all network partitions",
+			Action: healNetwork,
+		},
 	}
 	if err := app.Run(os.Args); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -378,6 +440,81 @@ func disconnectNode(ctx *cli.Context) error {
 	return nil
 }
 
+func showLink(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	config, err := client.GetLink(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(ctx.App.Writer, 1, 2, 2, ' ', 0)
+	defer w.Flush()
+	fmt.Fprintf(w, "LATENCY\t%v\n", config.Latency)
+	fmt.Fprintf(w, "BANDWIDTH\t%d\n", config.Bandwidth)
+	fmt.Fprintf(w, "LOSS\t%v\n", config.Loss)
+	fmt.Fprintf(w, "PARTITIONED\t%t\n", config.Partitioned)
+	return nil
+}
+
+func setLink(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	config := &pipes.LinkConfig{
+		Latency:     ctx.Duration("latency"),
+		Bandwidth:   ctx.Int("bandwidth"),
+		Loss:        ctx.Float64("loss"),
+		Partitioned: ctx.Bool("partitioned"),
+	}
+	if err := client.SetLink(args[0], args[1], config); err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.App.Writer, "Updated link", args[0], "<->", args[1])
+	return nil
+}
+
+func resetLink(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) != 2 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	if err := client.SetLink(args[0], args[1], &pipes.LinkConfig{}); err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.App.Writer, "Reset link", args[0], "<->", args[1])
+	return nil
+}
+
+func partitionNetwork(ctx *cli.Context) error {
+	args := ctx.Args()
+	if len(args) < 2 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	groups := make([][]string, len(args))
+	for i, arg := range args {
+		groups[i] = strings.Split(arg, ",")
+	}
+	if err := client.Partition(groups); err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.App.Writer, "Partitioned network into", len(groups), "groups")
+	return nil
+}
+
+func healNetwork(ctx *cli.Context) error {
+	if len(ctx.Args()) != 0 {
+		return cli.ShowCommandHelp(ctx, ctx.Command.Name)
+	}
+	if err := client.Heal(); err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.App.Writer, "Healed network")
+	return nil
+}
+
 func rpcNode(ctx *cli.Context) error {
 	args := ctx.Args()
 	if len(args) < 2 {
@@ -0,0 +1,220 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// rpcproxy is a JSON-RPC proxy which verifies the state returned by an
+// untrusted node against a trusted source of block headers.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	beacon "github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/flags"
+	"github.com/ethereum/go-ethereum/light/proxy"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	// Git SHA1 commit hash of the release (set via linker flags)
+	gitCommit = ""
+	gitDate   = ""
+)
+
+var app = flags.NewApp(gitCommit, gitDate, "verifying JSON-RPC proxy")
+
+var (
+	upstreamFlag = cli.StringFlag{
+		Name:  "upstream",
+		Usage: "The rpc endpoint of the untrusted node serving state",
+	}
+	httpAddrFlag = cli.StringFlag{
+		Name:  "http.addr",
+		Value: "127.0.0.1",
+		Usage: "HTTP-RPC server listening interface",
+	}
+	httpPortFlag = cli.IntFlag{
+		Name:  "http.port",
+		Value: 8545,
+		Usage: "HTTP-RPC server listening port",
+	}
+	trustedRPCFlag = cli.StringFlag{
+		Name:  "trusted.rpc",
+		Usage: "The rpc endpoint of a trusted node providing block headers",
+	}
+	beaconAPIFlag = cli.StringFlag{
+		Name:  "beacon.api",
+		Usage: "Beacon node REST API providing light client updates",
+	}
+	beaconFileFlag = cli.StringFlag{
+		Name:  "beacon.file",
+		Usage: "JSON file containing light client updates",
+	}
+	beaconCheckpointFlag = cli.StringFlag{
+		Name:  "beacon.checkpoint",
+		Usage: "Root of a trusted finalized beacon block to sync from",
+	}
+	networkFlag = cli.StringFlag{
+		Name:  "network",
+		Value: "mainnet",
+		Usage: "Network whose rules calls are executed with (mainnet, ropsten, rinkeby, goerli)",
+	}
+	gasCapFlag = cli.Uint64Flag{
+		Name:  "rpc.gascap",
+		Value: 25000000,
+		Usage: "Gas limit of eth_call",
+	}
+	evmTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc.evmtimeout",
+		Value: 30 * time.Second,
+		Usage: "Timeout of eth_call, including the retrieval of proofs",
+	}
+	verbosityFlag = cli.IntFlag{
+		Name:  "verbosity",
+		Value: 3,
+		Usage: "Logging verbosity: 0=silent, 1=error, 2=warn, 3=info, 4=debug, 5=detail",
+	}
+)
+
+func init() {
+	app.Flags = []cli.Flag{
+		upstreamFlag,
+		httpAddrFlag,
+		httpPortFlag,
+		trustedRPCFlag,
+		beaconAPIFlag,
+		beaconFileFlag,
+		beaconCheckpointFlag,
+		networkFlag,
+		gasCapFlag,
+		evmTimeoutFlag,
+		verbosityFlag,
+	}
+	app.Action = runProxy
+	cli.CommandHelpTemplate = flags.OriginCommandHelpTemplate
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runProxy(ctx *cli.Context) error {
+	log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(ctx.Int(verbosityFlag.Name)), log.StreamHandler(os.Stderr, log.TerminalFormat(true))))
+
+	if !ctx.IsSet(upstreamFlag.Name) {
+		return errors.New("missing --" + upstreamFlag.Name)
+	}
+	chainConfig, err := networkConfig(ctx.String(networkFlag.Name))
+	if err != nil {
+		return err
+	}
+	upstream, err := rpc.Dial(ctx.String(upstreamFlag.Name))
+	if err != nil {
+		return fmt.Errorf("can't connect to upstream: %v", err)
+	}
+	defer upstream.Close()
+
+	headers, stop, err := headerSource(ctx, upstream)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	p := proxy.New(proxy.Config{
+		ChainConfig: chainConfig,
+		GasCap:      ctx.Uint64(gasCapFlag.Name),
+		CallTimeout: ctx.Duration(evmTimeoutFlag.Name),
+	}, headers, upstream)
+	defer p.Close()
+
+	addr := net.JoinHostPort(ctx.String(httpAddrFlag.Name), strconv.Itoa(ctx.Int(httpPortFlag.Name)))
+	log.Info("Serving verified RPC", "addr", "http://"+addr, "upstream", ctx.String(upstreamFlag.Name))
+	return http.ListenAndServe(addr, p)
+}
+
+// headerSource creates the trusted header source selected on the command line.
+// The returned function releases its resources.
+func headerSource(ctx *cli.Context, upstream *rpc.Client) (proxy.HeaderSource, func(), error) {
+	if ctx.IsSet(trustedRPCFlag.Name) {
+		client, err := rpc.Dial(ctx.String(trustedRPCFlag.Name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't connect to trusted node: %v", err)
+		}
+		return proxy.NewRPCHeaderSource(client), client.Close, nil
+	}
+
+	var source beacon.UpdateSource
+	switch {
+	case ctx.IsSet(beaconAPIFlag.Name):
+		source = beacon.NewRESTSource(ctx.String(beaconAPIFlag.Name))
+	case ctx.IsSet(beaconFileFlag.Name):
+		source = beacon.NewFileSource(ctx.String(beaconFileFlag.Name))
+	default:
+		return nil, nil, fmt.Errorf("no trusted header source, use --%s, --%s or --%s", trustedRPCFlag.Name, beaconAPIFlag.Name, beaconFileFlag.Name)
+	}
+	if ctx.String(networkFlag.Name) != "mainnet" {
+		return nil, nil, errors.New("beacon light client is only supported on mainnet")
+	}
+	if !ctx.IsSet(beaconCheckpointFlag.Name) {
+		return nil, nil, errors.New("missing --" + beaconCheckpointFlag.Name)
+	}
+	var checkpoint common.Hash
+	if err := checkpoint.UnmarshalText([]byte(ctx.String(beaconCheckpointFlag.Name))); err != nil {
+		return nil, nil, fmt.Errorf("invalid checkpoint: %v", err)
+	}
+	client, err := beacon.NewClient(context.Background(), beacon.Config{
+		Chain:      beacon.MainnetConfig,
+		Checkpoint: checkpoint,
+	}, source, upstream)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Info("Syncing beacon light client", "checkpoint", checkpoint)
+	if err := client.Sync(context.Background()); err != nil {
+		return nil, nil, fmt.Errorf("light client sync failed: %v", err)
+	}
+	client.Start()
+	return proxy.NewBeaconHeaderSource(client), client.Stop, nil
+}
+
+// networkConfig returns the chain configuration of the named network.
+func networkConfig(name string) (*params.ChainConfig, error) {
+	switch name {
+	case "mainnet":
+		return params.MainnetChainConfig, nil
+	case "ropsten":
+		return params.RopstenChainConfig, nil
+	case "rinkeby":
+		return params.RinkebyChainConfig, nil
+	case "goerli":
+		return params.GoerliChainConfig, nil
+	default:
+		return nil, fmt.Errorf("unknown network %q", name)
+	}
+}
@@ -230,6 +230,18 @@ var (
 		Usage: "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
 		Value: ethconfig.Defaults.TxLookupLimit,
 	}
+	TxHistoryFlag = cli.BoolFlag{
+		Name:  "txhistory",
+		Usage: "Maintain an address to transaction index including internal value transfers (re-executes imported blocks)",
+	}
+	TraceStoreFlag = cli.BoolFlag{
+		Name:  "tracestore",
+		Usage: "Persist the results of the built-in tracers to avoid re-executing historical blocks",
+	}
+	TraceStoreTracersFlag = cli.StringFlag{
+		Name:  "tracestore.tracers",
+		Usage: "Comma separated list of built-in tracers to run on every imported block (implies --tracestore)",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
@@ -567,6 +579,11 @@ var (
 		Usage: "HTTP path path prefix on which JSON-RPC is served. Use '/' to serve on all paths.",
 		Value: "",
 	}
+	HTTPWriteTimeoutFlag = cli.DurationFlag{
+		Name:  "http.writetimeout",
+		Usage: "Maximum duration for writing an HTTP-RPC response, including /debug/trace streams",
+		Value: node.DefaultConfig.HTTPTimeouts.WriteTimeout,
+	}
 	GraphQLEnabledFlag = cli.BoolFlag{
 		Name:  "graphql",
 		Usage: "Enable GraphQL on the HTTP-RPC server. Note that GraphQL can only be started if an HTTP server is started as well.",
@@ -581,6 +598,21 @@ var (
 		Usage: "Comma separated list of virtual hostnames from which to accept requests (server enforced). Accepts '*' wildcard.",
 		Value: strings.Join(node.DefaultConfig.GraphQLVirtualHosts, ","),
 	}
+	GraphQLMaxCostFlag = cli.Uint64Flag{
+		Name:  "graphql.maxcost",
+		Usage: "Maximum estimated cost of a GraphQL query (0 = no limit)",
+		Value: node.DefaultConfig.GraphQLMaxCost,
+	}
+	GraphQLMaxDepthFlag = cli.IntFlag{
+		Name:  "graphql.maxdepth",
+		Usage: "Maximum nesting depth of a GraphQL query (0 = no limit)",
+		Value: node.DefaultConfig.GraphQLMaxDepth,
+	}
+	GraphQLTimeoutFlag = cli.DurationFlag{
+		Name:  "graphql.timeout",
+		Usage: "Maximum execution time of a GraphQL request (0 = no limit)",
+		Value: node.DefaultConfig.GraphQLTimeout,
+	}
 	WSEnabledFlag = cli.BoolFlag{
 		Name:  "ws",
 		Usage: "Enable the WS-RPC server",
@@ -975,6 +1007,9 @@ func setHTTP(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(HTTPPathPrefixFlag.Name) {
 		cfg.HTTPPathPrefix = ctx.GlobalString(HTTPPathPrefixFlag.Name)
 	}
+	if ctx.GlobalIsSet(HTTPWriteTimeoutFlag.Name) {
+		cfg.HTTPTimeouts.WriteTimeout = ctx.GlobalDuration(HTTPWriteTimeoutFlag.Name)
+	}
 	if ctx.GlobalIsSet(AllowUnprotectedTxs.Name) {
 		cfg.AllowUnprotectedTxs = ctx.GlobalBool(AllowUnprotectedTxs.Name)
 	}
@@ -989,6 +1024,15 @@ func setGraphQL(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(GraphQLVirtualHostsFlag.Name) {
 		cfg.GraphQLVirtualHosts = SplitAndTrim(ctx.GlobalString(GraphQLVirtualHostsFlag.Name))
 	}
+	if ctx.GlobalIsSet(GraphQLMaxCostFlag.Name) {
+		cfg.GraphQLMaxCost = ctx.GlobalUint64(GraphQLMaxCostFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLMaxDepthFlag.Name) {
+		cfg.GraphQLMaxDepth = ctx.GlobalInt(GraphQLMaxDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(GraphQLTimeoutFlag.Name) {
+		cfg.GraphQLTimeout = ctx.GlobalDuration(GraphQLTimeoutFlag.Name)
+	}
 }
 
 // setWS creates the WebSocket RPC listener interface string from the set
@@ -1555,6 +1599,16 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.GlobalIsSet(TxLookupLimitFlag.Name) {
 		cfg.TxLookupLimit = ctx.GlobalUint64(TxLookupLimitFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxHistoryFlag.Name) {
+		cfg.TxHistory = ctx.GlobalBool(TxHistoryFlag.Name)
+	}
+	if ctx.GlobalIsSet(TraceStoreFlag.Name) {
+		cfg.TraceStore = ctx.GlobalBool(TraceStoreFlag.Name)
+	}
+	if ctx.GlobalIsSet(TraceStoreTracersFlag.Name) {
+		cfg.TraceStore = true
+		cfg.TraceStoreTracers = SplitAndTrim(ctx.GlobalString(TraceStoreTracersFlag.Name))
+	}
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
 	}
@@ -1726,6 +1780,7 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 			Fatalf("Failed to register the Ethereum service: %v", err)
 		}
 		stack.RegisterAPIs(tracers.APIs(backend.ApiBackend))
+		registerTraceStream(stack, backend.ApiBackend)
 		return backend.ApiBackend, nil
 	}
 	backend, err := eth.New(stack, cfg)
@@ -1739,9 +1794,23 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (ethapi.Backend
 		}
 	}
 	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	registerTraceStream(stack, backend.APIBackend)
 	return backend.APIBackend, backend
 }
 
+// registerTraceStream mounts the streaming block range tracer on the HTTP
+// server if the debug namespace is exposed over HTTP.
+func registerTraceStream(stack *node.Node, backend tracers.Backend) {
+	cfg := stack.Config()
+	for _, module := range cfg.HTTPModules {
+		if module == "debug" {
+			handler := node.NewHTTPHandlerStack(tracers.NewStreamHandler(backend), cfg.HTTPCors, cfg.HTTPVirtualHosts)
+			stack.RegisterHandler("Trace stream", "/debug/trace", handler)
+			return
+		}
+	}
+}
+
 // RegisterEthStatsService configures the Ethereum Stats daemon and adds it to
 // the given node.
 func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url string) {
@@ -1752,7 +1821,12 @@ func RegisterEthStatsService(stack *node.Node, backend ethapi.Backend, url strin
 
 // RegisterGraphQLService is a utility function to construct a new service and register it against a node.
 func RegisterGraphQLService(stack *node.Node, backend ethapi.Backend, cfg node.Config) {
-	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts); err != nil {
+	limits := graphql.Limits{
+		MaxCost:  cfg.GraphQLMaxCost,
+		MaxDepth: cfg.GraphQLMaxDepth,
+		Timeout:  cfg.GraphQLTimeout,
+	}
+	if err := graphql.New(stack, backend, cfg.GraphQLCors, cfg.GraphQLVirtualHosts, limits); err != nil {
 		Fatalf("Failed to register the GraphQL service: %v", err)
 	}
 }
@@ -0,0 +1,84 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SourceMapEntry is a single decoded element of a solc source map, describing
+// the source range an instruction was generated from.
+type SourceMapEntry struct {
+	Start         int  // Byte offset of the range in the source file
+	Length        int  // Length of the range in bytes
+	File          int  // Index into the source list, -1 for compiler generated code
+	Jump          byte // Jump type: 'i' into a function, 'o' out of one, '-' otherwise
+	ModifierDepth int  // Depth of the modifier placeholder the instruction is in
+}
+
+// ParseSourceMap decodes the compressed source map format emitted by solc in
+// the srcmap and srcmap-runtime fields of its combined JSON output. The result
+// holds one entry per instruction (not per byte) of the corresponding bytecode.
+//
+// The format is a semicolon separated list of s:l:f:j:m elements, where empty
+// or missing fields inherit their value from the preceding element.
+func ParseSourceMap(srcmap string) ([]SourceMapEntry, error) {
+	if srcmap == "" {
+		return nil, nil
+	}
+	var (
+		elems   = strings.Split(srcmap, ";")
+		entries = make([]SourceMapEntry, 0, len(elems))
+		last    = SourceMapEntry{File: -1, Jump: '-'}
+	)
+	for i, elem := range elems {
+		entry := last
+		for j, field := range strings.Split(elem, ":") {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				if len(field) != 1 || !strings.Contains("io-", field) {
+					return nil, fmt.Errorf("source map entry %d: invalid jump type %q", i, field)
+				}
+				entry.Jump = field[0]
+				continue
+			}
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("source map entry %d: %v", i, err)
+			}
+			switch j {
+			case 0:
+				entry.Start = n
+			case 1:
+				entry.Length = n
+			case 2:
+				entry.File = n
+			case 4:
+				entry.ModifierDepth = n
+			default:
+				return nil, fmt.Errorf("source map entry %d: too many fields", i)
+			}
+		}
+		entries = append(entries, entry)
+		last = entry
+	}
+	return entries, nil
+}
@@ -0,0 +1,401 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	logTermAddress = 0x00 // Index term prefix of log emitter addresses
+	logTermTopic   = 0x01 // Index term prefix of positional log topics
+)
+
+var (
+	// ErrLogIndexUnfiltered is returned if a log index query has no address or
+	// topic criteria at all, in which case every log would match.
+	ErrLogIndexUnfiltered = errors.New("log index query without criteria")
+
+	// errLogPostingsCorrupt is returned if a stored log position list cannot be
+	// decoded.
+	errLogPostingsCorrupt = errors.New("corrupt log postings")
+)
+
+// LogPosition identifies a single log within the canonical chain.
+type LogPosition struct {
+	Block uint64 // Number of the block containing the log
+	Index uint   // Index of the log within the block
+}
+
+// LogIndexer implements a core.ChainIndexerBackend, building up a positional
+// index which maps log addresses and topics to the positions of the logs that
+// contain them, permitting exact log filtering without scanning receipts.
+type LogIndexer struct {
+	db       ethdb.Database           // database instance to read receipts from and write index data into
+	size     uint64                   // section size to generate the index for
+	section  uint64                   // Section is the section number being processed currently
+	head     common.Hash              // Head is the hash of the last header processed
+	postings map[string][]LogPosition // Log positions gathered for each index term
+}
+
+// LogIndex is the log index of the canonical chain, bundling the chain indexer
+// maintaining it with the means to query it.
+type LogIndex struct {
+	db      ethdb.Database
+	size    uint64
+	indexer *ChainIndexer
+}
+
+// NewLogIndex creates a log index over the canonical chain with the given
+// section size. The returned index's chain indexer is meant to be attached as a
+// child of the bloombits indexer, so that sections are only processed after the
+// parent confirmed them.
+func NewLogIndex(db ethdb.Database, size, confirms uint64) *LogIndex {
+	backend := &LogIndexer{
+		db:   db,
+		size: size,
+	}
+	table := rawdb.NewTable(db, string(rawdb.LogIndexPrefix))
+
+	return &LogIndex{
+		db:      db,
+		size:    size,
+		indexer: NewChainIndexer(db, table, backend, size, confirms, bloomThrottling, "logindex"),
+	}
+}
+
+// Reset implements core.ChainIndexerBackend, starting a new log index section.
+func (b *LogIndexer) Reset(ctx context.Context, section uint64, lastSectionHead common.Hash) error {
+	b.section, b.head = section, common.Hash{}
+	b.postings = make(map[string][]LogPosition)
+	return nil
+}
+
+// Process implements core.ChainIndexerBackend, adding the logs of a new header's
+// receipts into the index.
+func (b *LogIndexer) Process(ctx context.Context, header *types.Header) error {
+	var (
+		hash   = header.Hash()
+		number = header.Number.Uint64()
+	)
+	receipts := rawdb.ReadRawReceipts(b.db, hash, number)
+	if receipts == nil && header.ReceiptHash != types.EmptyRootHash {
+		return fmt.Errorf("receipts of block #%d [%x..] not found", number, hash[:4])
+	}
+	var index uint
+	for _, receipt := range receipts {
+		for _, entry := range receipt.Logs {
+			pos := LogPosition{Block: number, Index: index}
+
+			term := string(logAddressTerm(entry.Address))
+			b.postings[term] = append(b.postings[term], pos)
+			for i, topic := range entry.Topics {
+				term := string(logTopicTerm(i, topic))
+				b.postings[term] = append(b.postings[term], pos)
+			}
+			index++
+		}
+	}
+	b.head = hash
+	return nil
+}
+
+// Commit implements core.ChainIndexerBackend, finalizing the log index section
+// and writing it out into the database. Any leftovers of the same section from
+// before a reorg are dropped first.
+func (b *LogIndexer) Commit() error {
+	rawdb.DeleteLogPostings(b.db, b.section, b.section+1)
+
+	batch := b.db.NewBatch()
+	for term, positions := range b.postings {
+		rawdb.WriteLogPostings(batch, b.section, b.head, []byte(term), encodeLogPositions(positions, b.section*b.size))
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	return batch.Write()
+}
+
+// Prune returns an empty error since we don't support pruning here.
+func (b *LogIndexer) Prune(threshold uint64) error {
+	return nil
+}
+
+// Indexer returns the chain indexer maintaining the log index.
+func (li *LogIndex) Indexer() *ChainIndexer {
+	return li.indexer
+}
+
+// Status returns the section size of the log index and the number of sections
+// fully indexed so far.
+func (li *LogIndex) Status() (uint64, uint64) {
+	sections, _, _ := li.indexer.Sections()
+	return li.size, sections
+}
+
+// LogIndexProgress is the backfill progress report of the log index.
+type LogIndexProgress struct {
+	SectionSize uint64 // Number of blocks in a single index section
+	Sections    uint64 // Number of sections fully indexed
+	Indexed     uint64 // Number of blocks covered by the index
+	Head        uint64 // Number of the current chain head
+}
+
+// Progress reports how far the log index has caught up with the chain head.
+func (li *LogIndex) Progress() LogIndexProgress {
+	size, sections := li.Status()
+
+	var head uint64
+	if number := rawdb.ReadHeaderNumber(li.db, rawdb.ReadHeadHeaderHash(li.db)); number != nil {
+		head = *number
+	}
+	return LogIndexProgress{
+		SectionSize: size,
+		Sections:    sections,
+		Indexed:     sections * size,
+		Head:        head,
+	}
+}
+
+// Match returns the positions of all logs within the given block range which
+// may satisfy the address and topic criteria, in chain order. The range must be
+// covered by the indexed sections. Addresses and topic positions with empty
+// criteria act as wildcards, but at least one of them must be constrained.
+//
+// The index is exact for the criteria it holds, but it knows nothing about the
+// number of topics a log has, so callers still need to filter the logs found.
+func (li *LogIndex) Match(ctx context.Context, begin, end uint64, addresses []common.Address, topics [][]common.Hash) ([]LogPosition, error) {
+	terms := logIndexTerms(addresses, topics)
+	if len(terms) == 0 {
+		return nil, ErrLogIndexUnfiltered
+	}
+	var matches []LogPosition
+	for section := begin / li.size; section <= end/li.size; section++ {
+		if err := ctx.Err(); err != nil {
+			return matches, err
+		}
+		head := li.indexer.SectionHead(section)
+		if head == (common.Hash{}) {
+			return matches, fmt.Errorf("log index section %d not available", section)
+		}
+		var result []LogPosition
+		for i, alternatives := range terms {
+			var union []LogPosition
+			for _, term := range alternatives {
+				positions, err := decodeLogPositions(rawdb.ReadLogPostings(li.db, section, head, term), section*li.size)
+				if err != nil {
+					return matches, fmt.Errorf("log index section %d: %v", section, err)
+				}
+				union = mergeLogPositions(union, positions)
+			}
+			if i == 0 {
+				result = union
+			} else {
+				result = intersectLogPositions(result, union)
+			}
+			if len(result) == 0 {
+				break
+			}
+		}
+		for _, pos := range result {
+			if pos.Block >= begin && pos.Block <= end {
+				matches = append(matches, pos)
+			}
+		}
+	}
+	return matches, nil
+}
+
+// Rebuild drops the entire log index and synchronously reindexes the canonical
+// chain up to the given head, leaving the sections within the confirmation
+// distance to the live indexer. It must not be used on an index attached to a
+// running chain.
+func (li *LogIndex) Rebuild(ctx context.Context, head uint64) error {
+	c := li.indexer
+
+	c.lock.Lock()
+	c.setValidSections(0)
+	c.lock.Unlock()
+	rawdb.DeleteLogPostings(li.db, 0, math.MaxUint64)
+
+	var sections uint64
+	if head+1 > c.confirmsReq {
+		sections = (head + 1 - c.confirmsReq) / li.size
+	}
+	var (
+		lastHead common.Hash
+		start    = time.Now()
+		logged   = time.Now()
+	)
+	for section := uint64(0); section < sections; section++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		hash, err := c.processSection(section, lastHead)
+		if err != nil {
+			return err
+		}
+		c.lock.Lock()
+		c.setSectionHead(section, hash)
+		c.setValidSections(section + 1)
+		c.lock.Unlock()
+
+		lastHead = hash
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Rebuilding log index", "sections", section+1, "total", sections, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	log.Info("Rebuilt log index", "sections", sections, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// logAddressTerm returns the index term of a log emitter address.
+func logAddressTerm(address common.Address) []byte {
+	return append([]byte{logTermAddress}, address.Bytes()...)
+}
+
+// logTopicTerm returns the index term of a log topic at the given position.
+func logTopicTerm(position int, topic common.Hash) []byte {
+	return append([]byte{logTermTopic, byte(position)}, topic.Bytes()...)
+}
+
+// logIndexTerms converts a set of filter criteria into index terms. The outer
+// slice of the result holds the criteria which all need to match, the inner the
+// alternatives of which any may match.
+func logIndexTerms(addresses []common.Address, topics [][]common.Hash) [][][]byte {
+	var terms [][][]byte
+	if len(addresses) > 0 {
+		alternatives := make([][]byte, len(addresses))
+		for i, address := range addresses {
+			alternatives[i] = logAddressTerm(address)
+		}
+		terms = append(terms, alternatives)
+	}
+	for i, sub := range topics {
+		if len(sub) == 0 {
+			continue // empty rule set == wildcard
+		}
+		alternatives := make([][]byte, len(sub))
+		for j, topic := range sub {
+			alternatives[j] = logTopicTerm(i, topic)
+		}
+		terms = append(terms, alternatives)
+	}
+	return terms
+}
+
+// encodeLogPositions packs an ordered list of log positions into a sequence of
+// varint pairs, each holding the block number delta to the previous position and
+// the log index within the block.
+func encodeLogPositions(positions []LogPosition, start uint64) []byte {
+	var (
+		blob = make([]byte, 0, 2*len(positions))
+		buf  = make([]byte, binary.MaxVarintLen64)
+		prev = start
+	)
+	for _, pos := range positions {
+		blob = append(blob, buf[:binary.PutUvarint(buf, pos.Block-prev)]...)
+		blob = append(blob, buf[:binary.PutUvarint(buf, uint64(pos.Index))]...)
+		prev = pos.Block
+	}
+	return blob
+}
+
+// decodeLogPositions unpacks a list of log positions packed by encodeLogPositions.
+func decodeLogPositions(blob []byte, start uint64) ([]LogPosition, error) {
+	var (
+		positions []LogPosition
+		prev      = start
+	)
+	for len(blob) > 0 {
+		delta, n := binary.Uvarint(blob)
+		if n <= 0 {
+			return nil, errLogPostingsCorrupt
+		}
+		blob = blob[n:]
+		index, n := binary.Uvarint(blob)
+		if n <= 0 {
+			return nil, errLogPostingsCorrupt
+		}
+		blob = blob[n:]
+
+		prev += delta
+		positions = append(positions, LogPosition{Block: prev, Index: uint(index)})
+	}
+	return positions, nil
+}
+
+// logPositionLess reports whether position a precedes position b in the chain.
+func logPositionLess(a, b LogPosition) bool {
+	return a.Block < b.Block || (a.Block == b.Block && a.Index < b.Index)
+}
+
+// mergeLogPositions returns the ordered union of two ordered position lists.
+func mergeLogPositions(a, b []LogPosition) []LogPosition {
+	if len(a) == 0 {
+		return b
+	}
+	if len(b) == 0 {
+		return a
+	}
+	merged := make([]LogPosition, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case logPositionLess(a[0], b[0]):
+			merged, a = append(merged, a[0]), a[1:]
+		case logPositionLess(b[0], a[0]):
+			merged, b = append(merged, b[0]), b[1:]
+		default:
+			merged, a, b = append(merged, a[0]), a[1:], b[1:]
+		}
+	}
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
+
+// intersectLogPositions returns the ordered intersection of two ordered position
+// lists.
+func intersectLogPositions(a, b []LogPosition) []LogPosition {
+	var shared []LogPosition
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case logPositionLess(a[0], b[0]):
+			a = a[sort.Search(len(a), func(i int) bool { return !logPositionLess(a[i], b[0]) }):]
+		case logPositionLess(b[0], a[0]):
+			b = b[sort.Search(len(b), func(i int) bool { return !logPositionLess(b[i], a[0]) }):]
+		default:
+			shared, a, b = append(shared, a[0]), a[1:], b[1:]
+		}
+	}
+	return shared
+}
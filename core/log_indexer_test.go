@@ -0,0 +1,264 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	logIndexAddrA  = common.HexToAddress("0xaaaa")
+	logIndexAddrB  = common.HexToAddress("0xbbbb")
+	logIndexTopic1 = common.HexToHash("0x01")
+	logIndexTopic2 = common.HexToHash("0x02")
+)
+
+// writeLogIndexChain writes a canonical chain of headers and receipts into the
+// database, with a deterministic set of logs sprinkled over the blocks. The
+// salt is mixed into the headers to allow creating competing chains.
+func writeLogIndexChain(db ethdb.Database, blocks int, salt uint64) []*types.Log {
+	var (
+		parent common.Hash
+		all    []*types.Log
+	)
+	for n := uint64(0); n < uint64(blocks); n++ {
+		var logs []*types.Log
+		if n%3 == 0 {
+			logs = append(logs, &types.Log{Address: logIndexAddrA, Topics: []common.Hash{logIndexTopic1}})
+		}
+		if n%4 == 0 {
+			logs = append(logs, &types.Log{Address: logIndexAddrB, Topics: []common.Hash{logIndexTopic2, logIndexTopic1}})
+		}
+		if salt != 0 && n%5 == 0 {
+			logs = append(logs, &types.Log{Address: logIndexAddrA, Topics: []common.Hash{logIndexTopic2}})
+		}
+		header := &types.Header{
+			ParentHash:  parent,
+			Number:      new(big.Int).SetUint64(n),
+			ReceiptHash: types.EmptyRootHash,
+			Nonce:       types.EncodeNonce(salt),
+		}
+		var receipts types.Receipts
+		if len(logs) > 0 {
+			header.ReceiptHash = common.Hash{0x01}
+			receipts = types.Receipts{&types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: logs}}
+		}
+		for i, log := range logs {
+			log.BlockNumber, log.Index = n, uint(i)
+		}
+		all = append(all, logs...)
+
+		rawdb.WriteHeader(db, header)
+		rawdb.WriteCanonicalHash(db, header.Hash(), n)
+		rawdb.WriteReceipts(db, header.Hash(), n, receipts)
+		rawdb.WriteHeadHeaderHash(db, header.Hash())
+		parent = header.Hash()
+	}
+	return all
+}
+
+// indexLogSections synchronously processes the given number of log index
+// sections.
+func indexLogSections(t *testing.T, index *LogIndex, sections uint64) {
+	var head common.Hash
+	for section := uint64(0); section < sections; section++ {
+		var err error
+		if head, err = index.indexer.processSection(section, head); err != nil {
+			t.Fatalf("section %d: failed to process: %v", section, err)
+		}
+		index.indexer.setSectionHead(section, head)
+		index.indexer.setValidSections(section + 1)
+	}
+}
+
+// expectedLogPositions filters the given logs by brute force.
+func expectedLogPositions(logs []*types.Log, begin, end uint64, addresses []common.Address, topics [][]common.Hash) []LogPosition {
+	var positions []LogPosition
+	for _, log := range logs {
+		if log.BlockNumber < begin || log.BlockNumber > end {
+			continue
+		}
+		if len(addresses) > 0 && !containsAddress(addresses, log.Address) {
+			continue
+		}
+		match := true
+		for i, sub := range topics {
+			if len(sub) == 0 {
+				continue
+			}
+			if i >= len(log.Topics) || !containsHash(sub, log.Topics[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			positions = append(positions, LogPosition{Block: log.BlockNumber, Index: log.Index})
+		}
+	}
+	return positions
+}
+
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// Tests that the log index finds exactly the logs matching positional address
+// and topic criteria.
+func TestLogIndexMatch(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	logs := writeLogIndexChain(db, 24, 0)
+
+	index := NewLogIndex(db, 8, 0)
+	defer index.Indexer().Close()
+	if err := index.Rebuild(context.Background(), 23); err != nil {
+		t.Fatalf("failed to build log index: %v", err)
+	}
+	if size, sections := index.Status(); size != 8 || sections != 3 {
+		t.Fatalf("status mismatch: have %d/%d, want 8/3", size, sections)
+	}
+	if progress := index.Progress(); progress.Indexed != 24 || progress.Head != 23 {
+		t.Fatalf("progress mismatch: have %+v", progress)
+	}
+	tests := []struct {
+		begin, end uint64
+		addresses  []common.Address
+		topics     [][]common.Hash
+	}{
+		{0, 23, []common.Address{logIndexAddrA}, nil},
+		{0, 23, nil, [][]common.Hash{{logIndexTopic1}}},
+		{0, 23, nil, [][]common.Hash{nil, {logIndexTopic1}}},
+		{0, 23, []common.Address{logIndexAddrA, logIndexAddrB}, [][]common.Hash{{logIndexTopic1, logIndexTopic2}}},
+		{0, 23, []common.Address{logIndexAddrA}, [][]common.Hash{{logIndexTopic2}}},
+		{5, 17, []common.Address{logIndexAddrB}, nil},
+		{12, 12, nil, [][]common.Hash{{logIndexTopic2}, {logIndexTopic1}}},
+	}
+	for i, tt := range tests {
+		have, err := index.Match(context.Background(), tt.begin, tt.end, tt.addresses, tt.topics)
+		if err != nil {
+			t.Errorf("test %d: match failed: %v", i, err)
+			continue
+		}
+		if want := expectedLogPositions(logs, tt.begin, tt.end, tt.addresses, tt.topics); !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d: matches mismatch:\nhave %v\nwant %v", i, have, want)
+		}
+	}
+	if _, err := index.Match(context.Background(), 0, 23, nil, [][]common.Hash{nil}); err != ErrLogIndexUnfiltered {
+		t.Errorf("unfiltered query error mismatch: have %v, want %v", err, ErrLogIndexUnfiltered)
+	}
+}
+
+// Tests that reindexing a section after a reorg drops the postings of the old
+// section head and serves the logs of the new chain.
+func TestLogIndexReorg(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	writeLogIndexChain(db, 16, 0)
+
+	index := NewLogIndex(db, 8, 0)
+	defer index.Indexer().Close()
+	indexLogSections(t, index, 2)
+	oldHead := index.indexer.SectionHead(1)
+
+	logs := writeLogIndexChain(db, 16, 1)
+	indexLogSections(t, index, 2)
+	if newHead := index.indexer.SectionHead(1); newHead == oldHead {
+		t.Fatalf("section head not updated after reorg")
+	}
+	if data := rawdb.ReadLogPostings(db, 1, oldHead, logAddressTerm(logIndexAddrA)); data != nil {
+		t.Errorf("stale postings retained after reorg: %x", data)
+	}
+	topics := [][]common.Hash{{logIndexTopic2}}
+	have, err := index.Match(context.Background(), 0, 15, nil, topics)
+	if err != nil {
+		t.Fatalf("match failed: %v", err)
+	}
+	if want := expectedLogPositions(logs, 0, 15, nil, topics); !reflect.DeepEqual(have, want) {
+		t.Errorf("matches mismatch:\nhave %v\nwant %v", have, want)
+	}
+}
+
+// Tests that rebuilding the log index drops all its stale data and reindexes
+// the current canonical chain.
+func TestLogIndexRebuild(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	writeLogIndexChain(db, 16, 0)
+
+	index := NewLogIndex(db, 8, 0)
+	indexLogSections(t, index, 2)
+	oldHead := index.indexer.SectionHead(0)
+	index.Indexer().Close()
+
+	logs := writeLogIndexChain(db, 20, 1)
+	index = NewLogIndex(db, 8, 4)
+	defer index.Indexer().Close()
+	if err := index.Rebuild(context.Background(), 19); err != nil {
+		t.Fatalf("failed to rebuild log index: %v", err)
+	}
+	if _, sections := index.Status(); sections != 2 {
+		t.Errorf("section count mismatch: have %d, want %d", sections, 2)
+	}
+	if data := rawdb.ReadLogPostings(db, 0, oldHead, logAddressTerm(logIndexAddrA)); data != nil {
+		t.Errorf("stale postings retained after rebuild: %x", data)
+	}
+	addresses := []common.Address{logIndexAddrA}
+	have, err := index.Match(context.Background(), 0, 15, addresses, nil)
+	if err != nil {
+		t.Fatalf("match failed: %v", err)
+	}
+	if want := expectedLogPositions(logs, 0, 15, addresses, nil); !reflect.DeepEqual(have, want) {
+		t.Errorf("matches mismatch:\nhave %v\nwant %v", have, want)
+	}
+}
+
+// Tests that log position lists survive an encoding roundtrip.
+func TestLogPositionEncoding(t *testing.T) {
+	positions := []LogPosition{{4096, 0}, {4096, 3}, {4100, 1}, {8191, 70000}}
+	blob := encodeLogPositions(positions, 4096)
+
+	decoded, err := decodeLogPositions(blob, 4096)
+	if err != nil {
+		t.Fatalf("failed to decode positions: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, positions) {
+		t.Errorf("positions mismatch: have %v, want %v", decoded, positions)
+	}
+	if _, err := decodeLogPositions(blob[:len(blob)-1], 4096); !errors.Is(err, errLogPostingsCorrupt) {
+		t.Errorf("truncated positions error mismatch: have %v, want %v", err, errLogPostingsCorrupt)
+	}
+}
@@ -18,6 +18,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -175,3 +176,125 @@ func DeleteBloombits(db ethdb.Database, bit uint, from uint64, to uint64) {
 		log.Crit("Failed to delete bloom bits", "err", it.Error())
 	}
 }
+
+// ReadLogPostings retrieves the encoded log position list belonging to the given
+// index term within a log index section. Missing terms are reported as nil.
+func ReadLogPostings(db ethdb.KeyValueReader, section uint64, head common.Hash, term []byte) []byte {
+	data, _ := db.Get(logPostingsKey(section, head, term))
+	return data
+}
+
+// WriteLogPostings stores the encoded log position list belonging to the given
+// index term within a log index section.
+func WriteLogPostings(db ethdb.KeyValueWriter, section uint64, head common.Hash, term []byte, postings []byte) {
+	if err := db.Put(logPostingsKey(section, head, term), postings); err != nil {
+		log.Crit("Failed to store log postings", "err", err)
+	}
+}
+
+// DeleteLogPostings removes all log position lists belonging to the given
+// section range, regardless of the section head they were generated for.
+func DeleteLogPostings(db ethdb.Database, from uint64, to uint64) {
+	start, end := logPostingsKey(from, common.Hash{}, nil), logPostingsKey(to, common.Hash{}, nil)
+	it := db.NewIterator(nil, start)
+	defer it.Release()
+
+	for it.Next() {
+		if bytes.Compare(it.Key(), end) >= 0 {
+			break
+		}
+		if len(it.Key()) <= len(logPostingsPrefix)+8+32 {
+			continue
+		}
+		db.Delete(it.Key())
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to delete log postings", "err", it.Error())
+	}
+}
+
+// TxHistoryEntry is a single transaction in the history of an address.
+type TxHistoryEntry struct {
+	Number uint64      // Number of the block containing the transaction
+	Index  uint32      // Index of the transaction within the block
+	Hash   common.Hash // Hash of the block containing the transaction
+	Roles  byte        // Bitset of the roles the address played in the transaction
+	Traced bool        // Whether the internal calls of the transaction were traced
+}
+
+// ReadTxHistoryHead retrieves the number and hash of the latest block indexed
+// into the address transaction history.
+func ReadTxHistoryHead(db ethdb.KeyValueReader) (uint64, common.Hash, bool) {
+	data, _ := db.Get(txHistoryHeadKey)
+	if len(data) != 8+common.HashLength {
+		return 0, common.Hash{}, false
+	}
+	return binary.BigEndian.Uint64(data), common.BytesToHash(data[8:]), true
+}
+
+// WriteTxHistoryHead stores the number and hash of the latest block indexed
+// into the address transaction history.
+func WriteTxHistoryHead(db ethdb.KeyValueWriter, number uint64, hash common.Hash) {
+	if err := db.Put(txHistoryHeadKey, append(encodeBlockNumber(number), hash.Bytes()...)); err != nil {
+		log.Crit("Failed to store the transaction history head", "err", err)
+	}
+}
+
+// ReadTxHistoryTail retrieves the number of the oldest block indexed into the
+// address transaction history.
+func ReadTxHistoryTail(db ethdb.KeyValueReader) *uint64 {
+	data, _ := db.Get(txHistoryTailKey)
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+	return &number
+}
+
+// WriteTxHistoryTail stores the number of the oldest block indexed into the
+// address transaction history.
+func WriteTxHistoryTail(db ethdb.KeyValueWriter, number uint64) {
+	if err := db.Put(txHistoryTailKey, encodeBlockNumber(number)); err != nil {
+		log.Crit("Failed to store the transaction history tail", "err", err)
+	}
+}
+
+// WriteTxHistoryEntry stores a transaction into the history of an address.
+func WriteTxHistoryEntry(db ethdb.KeyValueWriter, address common.Address, entry TxHistoryEntry) {
+	var traced byte
+	if entry.Traced {
+		traced = 1
+	}
+	if err := db.Put(txHistoryKey(address, entry.Number, entry.Index), append(entry.Hash.Bytes(), entry.Roles, traced)); err != nil {
+		log.Crit("Failed to store transaction history entry", "err", err)
+	}
+}
+
+// IterateTxHistory iterates over the transaction history of an address from
+// newest to oldest, starting at the given position (inclusive) and stopping
+// when the callback returns false.
+func IterateTxHistory(db ethdb.Iteratee, address common.Address, number uint64, index uint32, fn func(TxHistoryEntry) bool) error {
+	prefix := append(txHistoryPrefix, address.Bytes()...)
+	start := txHistoryKey(address, number, index)[len(prefix):]
+
+	it := db.NewIterator(prefix, start)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if len(key) != len(prefix)+12 || len(value) != common.HashLength+2 {
+			continue
+		}
+		entry := TxHistoryEntry{
+			Number: ^binary.BigEndian.Uint64(key[len(prefix):]),
+			Index:  ^binary.BigEndian.Uint32(key[len(prefix)+8:]),
+			Hash:   common.BytesToHash(value[:common.HashLength]),
+			Roles:  value[common.HashLength],
+			Traced: value[common.HashLength+1] != 0,
+		}
+		if !fn(entry) {
+			break
+		}
+	}
+	return it.Error()
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// ReadTraceResult retrieves the stored trace of a single transaction produced
+// by the tracer identified by the given hash.
+func ReadTraceResult(db ethdb.KeyValueReader, block common.Hash, tracer common.Hash, index uint32) []byte {
+	data, _ := db.Get(traceResultKey(block, tracer, index))
+	return data
+}
+
+// ReadTraceResults retrieves the stored traces of all the transactions of a
+// block produced by the tracer identified by the given hash. Nil is returned
+// unless the traces of all count transactions are available.
+func ReadTraceResults(db ethdb.Iteratee, block common.Hash, tracer common.Hash, count int) [][]byte {
+	prefix := traceResultKey(block, tracer, 0)[:len(traceResultPrefix)+2*common.HashLength]
+
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	results := make([][]byte, 0, count)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != len(prefix)+4 || binary.BigEndian.Uint32(key[len(prefix):]) != uint32(len(results)) {
+			break
+		}
+		results = append(results, common.CopyBytes(it.Value()))
+	}
+	if len(results) != count {
+		return nil
+	}
+	return results
+}
+
+// WriteTraceResult stores the trace of a single transaction produced by the
+// tracer identified by the given hash.
+func WriteTraceResult(db ethdb.KeyValueWriter, block common.Hash, tracer common.Hash, index uint32, result []byte) {
+	if err := db.Put(traceResultKey(block, tracer, index), result); err != nil {
+		log.Crit("Failed to store trace result", "err", err)
+	}
+}
+
+// DeleteTraceResults removes the stored traces of all the transactions of a
+// block, regardless of the tracer that produced them.
+func DeleteTraceResults(db ethdb.Database, block common.Hash) {
+	prefix := append(traceResultPrefix, block.Bytes()...)
+
+	it := db.NewIterator(prefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		if len(it.Key()) != len(prefix)+common.HashLength+4 {
+			continue
+		}
+		db.Delete(it.Key())
+	}
+	if it.Error() != nil {
+		log.Crit("Failed to delete trace results", "err", it.Error())
+	}
+}
@@ -69,6 +69,12 @@ var (
 	// fastTxLookupLimitKey tracks the transaction lookup limit during fast sync.
 	fastTxLookupLimitKey = []byte("FastTransactionLookupLimit")
 
+	// txHistoryHeadKey tracks the latest block indexed into the address transaction history.
+	txHistoryHeadKey = []byte("TxHistoryHead")
+
+	// txHistoryTailKey tracks the oldest block indexed into the address transaction history.
+	txHistoryTailKey = []byte("TxHistoryTail")
+
 	// badBlockKey tracks the list of bad blocks seen by local
 	badBlockKey = []byte("InvalidBlock")
 
@@ -86,6 +92,9 @@ var (
 
 	txLookupPrefix        = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix       = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	logPostingsPrefix     = []byte("x") // logPostingsPrefix + section (uint64 big endian) + hash + term -> log positions
+	txHistoryPrefix       = []byte("A") // txHistoryPrefix + address + ^num (uint64 big endian) + ^tx index (uint32 big endian) -> block hash + roles + traced flag
+	traceResultPrefix     = []byte("X") // traceResultPrefix + block hash + tracer hash + tx index (uint32 big endian) -> trace result
 	SnapshotAccountPrefix = []byte("a") // SnapshotAccountPrefix + account hash -> account trie value
 	SnapshotStoragePrefix = []byte("o") // SnapshotStoragePrefix + account hash + storage hash -> storage trie value
 	CodePrefix            = []byte("c") // CodePrefix + code hash -> account code
@@ -95,6 +104,7 @@ var (
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
+	LogIndexPrefix       = []byte("iL") // LogIndexPrefix is the data table of the log indexer to track its progress
 
 	preimageCounter    = metrics.NewRegisteredCounter("db/preimage/total", nil)
 	preimageHitCounter = metrics.NewRegisteredCounter("db/preimage/hits", nil)
@@ -207,6 +217,35 @@ func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	return key
 }
 
+// logPostingsKey = logPostingsPrefix + section (uint64 big endian) + hash + term
+func logPostingsKey(section uint64, hash common.Hash, term []byte) []byte {
+	key := append(append(append(logPostingsPrefix, make([]byte, 8)...), hash.Bytes()...), term...)
+
+	binary.BigEndian.PutUint64(key[1:], section)
+
+	return key
+}
+
+// txHistoryKey = txHistoryPrefix + address + ^num (uint64 big endian) + ^tx index (uint32 big endian)
+//
+// The position is stored inverted so that iterating the keys of an address
+// yields its transactions from newest to oldest.
+func txHistoryKey(address common.Address, number uint64, index uint32) []byte {
+	key := append(append(txHistoryPrefix, address.Bytes()...), make([]byte, 12)...)
+
+	binary.BigEndian.PutUint64(key[len(txHistoryPrefix)+common.AddressLength:], ^number)
+	binary.BigEndian.PutUint32(key[len(txHistoryPrefix)+common.AddressLength+8:], ^index)
+
+	return key
+}
+
+// traceResultKey = traceResultPrefix + block hash + tracer hash + tx index (uint32 big endian)
+func traceResultKey(block common.Hash, tracer common.Hash, index uint32) []byte {
+	key := append(append(append(traceResultPrefix, block.Bytes()...), tracer.Bytes()...), make([]byte, 4)...)
+	binary.BigEndian.PutUint32(key[len(traceResultPrefix)+2*common.HashLength:], index)
+	return key
+}
+
 // preimageKey = preimagePrefix + hash
 func preimageKey(hash common.Hash) []byte {
 	return append(preimagePrefix, hash.Bytes()...)
@@ -0,0 +1,170 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DiffAccount is the state of an account as reported in a StateDiff.
+type DiffAccount struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[common.Hash]common.Hash // Only the slots modified in the diff
+}
+
+// StateDiff contains the state of all accounts modified since the last call to
+// Finalise, before and after the modifications.
+type StateDiff struct {
+	Pre  map[common.Address]*DiffAccount // Accounts before the changes, missing if they didn't exist
+	Post map[common.Address]*DiffAccount // Accounts after the changes, missing if they were deleted
+}
+
+// journalOrigin accumulates the original values of an account while walking
+// the journal. Only the first change of each field carries its original value.
+type journalOrigin struct {
+	created bool         // Whether the account didn't exist originally
+	prev    *stateObject // Original object of an account that was overwritten
+
+	balance *big.Int
+	nonce   *uint64
+	code    []byte
+	codeSet bool
+	storage map[common.Hash]common.Hash
+}
+
+// Diff assembles the changes made to the state since the last call to Finalise
+// from the state change journal. Reverted changes are not included, as they
+// are already dropped from the journal. If deleteEmptyObjects is set, touched
+// empty accounts are reported deleted, as they will be once finalised.
+func (s *StateDB) Diff(deleteEmptyObjects bool) *StateDiff {
+	var (
+		origins = make(map[common.Address]*journalOrigin)
+		order   []common.Address
+	)
+	origin := func(addr common.Address) (*journalOrigin, bool) {
+		if o, ok := origins[addr]; ok {
+			return o, false
+		}
+		o := &journalOrigin{storage: make(map[common.Hash]common.Hash)}
+		origins[addr] = o
+		order = append(order, addr)
+		return o, true
+	}
+	for _, entry := range s.journal.entries {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			if o, fresh := origin(*ch.account); fresh {
+				o.created = true
+			}
+		case resetObjectChange:
+			if o, fresh := origin(ch.prev.address); fresh {
+				if ch.prev.deleted {
+					o.created = true
+				} else {
+					o.prev = ch.prev
+				}
+			}
+		case suicideChange:
+			if o, _ := origin(*ch.account); o.balance == nil {
+				o.balance = ch.prevbalance
+			}
+		case balanceChange:
+			if o, _ := origin(*ch.account); o.balance == nil {
+				o.balance = ch.prev
+			}
+		case nonceChange:
+			if o, _ := origin(*ch.account); o.nonce == nil {
+				prev := ch.prev
+				o.nonce = &prev
+			}
+		case codeChange:
+			if o, _ := origin(*ch.account); !o.codeSet {
+				o.code, o.codeSet = ch.prevcode, true
+			}
+		case storageChange:
+			o, _ := origin(*ch.account)
+			if _, ok := o.storage[ch.key]; !ok {
+				o.storage[ch.key] = ch.prevalue
+			}
+		case touchChange:
+			origin(*ch.account)
+		}
+	}
+	diff := &StateDiff{
+		Pre:  make(map[common.Address]*DiffAccount),
+		Post: make(map[common.Address]*DiffAccount),
+	}
+	for _, addr := range order {
+		o := origins[addr]
+
+		// Assemble the current state of the account, unless it's deleted
+		obj := s.stateObjects[addr]
+		if obj != nil && !obj.deleted && !obj.suicided && !(deleteEmptyObjects && obj.empty()) {
+			post := &DiffAccount{
+				Balance: new(big.Int).Set(obj.Balance()),
+				Nonce:   obj.Nonce(),
+				Code:    obj.Code(s.db),
+				Storage: make(map[common.Hash]common.Hash, len(o.storage)),
+			}
+			for key := range o.storage {
+				post.Storage[key] = obj.GetState(s.db, key)
+			}
+			diff.Post[addr] = post
+		}
+		if o.created {
+			continue
+		}
+		// Assemble the original state of the account. Anything not changed
+		// before an overwrite is taken from the overwritten object, otherwise
+		// from the current one.
+		base := o.prev
+		if base == nil {
+			base = obj
+		}
+		if base == nil {
+			continue
+		}
+		pre := &DiffAccount{
+			Balance: o.balance,
+			Code:    o.code,
+			Storage: make(map[common.Hash]common.Hash, len(o.storage)),
+		}
+		if pre.Balance == nil {
+			pre.Balance = new(big.Int).Set(base.Balance())
+		}
+		if o.nonce != nil {
+			pre.Nonce = *o.nonce
+		} else {
+			pre.Nonce = base.Nonce()
+		}
+		if !o.codeSet {
+			pre.Code = base.Code(s.db)
+		}
+		for key, value := range o.storage {
+			if o.prev != nil {
+				value = o.prev.GetState(s.db, key)
+			}
+			pre.Storage[key] = value
+		}
+		diff.Pre[addr] = pre
+	}
+	return diff
+}
@@ -0,0 +1,90 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestStateDiff(t *testing.T) {
+	var (
+		a = common.Address{0xa}
+		b = common.Address{0xb}
+		c = common.Address{0xc}
+		d = common.Address{0xd}
+
+		k1 = common.Hash{0x01}
+		k2 = common.Hash{0x02}
+		k3 = common.Hash{0x03}
+	)
+	state, _ := New(common.Hash{}, NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	state.SetBalance(a, big.NewInt(100))
+	state.SetNonce(a, 1)
+	state.SetState(a, k1, common.Hash{0x11})
+	state.SetBalance(b, big.NewInt(5))
+	state.Finalise(true)
+
+	// Nothing changed yet
+	if diff := state.Diff(true); len(diff.Pre) != 0 || len(diff.Post) != 0 {
+		t.Fatalf("unexpected diff of untouched state: %+v", diff)
+	}
+	// Modify, create, destroy and touch accounts
+	state.SubBalance(a, big.NewInt(50))
+	state.SetNonce(a, 2)
+	state.SetState(a, k1, common.Hash{0x12})
+	state.SetState(a, k2, common.Hash{0x22})
+	state.SetState(a, k1, common.Hash{0x13})
+
+	snapshot := state.Snapshot()
+	state.SetState(a, k3, common.Hash{0x33})
+	state.RevertToSnapshot(snapshot)
+
+	state.CreateAccount(c)
+	state.AddBalance(c, big.NewInt(7))
+	state.SetCode(c, []byte{0x60, 0x00})
+	state.Suicide(b)
+	state.AddBalance(d, new(big.Int))
+
+	diff := state.Diff(true)
+	want := &StateDiff{
+		Pre: map[common.Address]*DiffAccount{
+			a: {Balance: big.NewInt(100), Nonce: 1, Storage: map[common.Hash]common.Hash{k1: {0x11}, k2: {}}},
+			b: {Balance: big.NewInt(5), Storage: map[common.Hash]common.Hash{}},
+		},
+		Post: map[common.Address]*DiffAccount{
+			a: {Balance: big.NewInt(50), Nonce: 2, Storage: map[common.Hash]common.Hash{k1: {0x13}, k2: {0x22}}},
+			c: {Balance: big.NewInt(7), Code: []byte{0x60, 0x00}, Storage: map[common.Hash]common.Hash{}},
+		},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("diff mismatch:\nhave pre %v post %v\nwant pre %v post %v", diff.Pre, diff.Post, want.Pre, want.Post)
+	}
+	// Empty accounts are only reported deleted if requested
+	if _, ok := state.Diff(false).Post[d]; !ok {
+		t.Errorf("touched empty account missing without deletion")
+	}
+	// Finalising starts a new diff
+	state.Finalise(true)
+	if diff := state.Diff(true); len(diff.Pre) != 0 || len(diff.Post) != 0 {
+		t.Fatalf("unexpected diff after finalise: %+v", diff)
+	}
+}
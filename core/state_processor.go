@@ -148,3 +148,34 @@ func ApplyTransaction(config *params.ChainConfig, bc ChainContext, author *commo
 	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, cfg)
 	return applyTransaction(msg, config, bc, author, gp, statedb, header, tx, usedGas, vmenv)
 }
+
+// ApplyTransactionFrom is like ApplyTransaction, but executes the transaction on
+// behalf of the given sender instead of recovering it from the signature. It's
+// meant for simulations which impersonate accounts whose keys are unknown.
+func ApplyTransactionFrom(config *params.ChainConfig, bc ChainContext, author *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, from common.Address, usedGas *uint64, cfg vm.Config) (*types.Receipt, error) {
+	signer := fixedSenderSigner{Signer: types.MakeSigner(config, header.Number), from: from}
+	msg, err := tx.AsMessage(signer, header.BaseFee)
+	if err != nil {
+		return nil, err
+	}
+	blockContext := NewEVMBlockContext(header, bc, author)
+	vmenv := vm.NewEVM(blockContext, vm.TxContext{}, statedb, config, cfg)
+	return applyTransaction(msg, config, bc, author, gp, statedb, header, tx, usedGas, vmenv)
+}
+
+// fixedSenderSigner is a signer which attributes every transaction to the same
+// sender, regardless of its signature.
+type fixedSenderSigner struct {
+	types.Signer
+	from common.Address
+}
+
+func (s fixedSenderSigner) Sender(tx *types.Transaction) (common.Address, error) {
+	return s.from, nil
+}
+
+// Equal always reports false, so the fixed sender is never served from the
+// sender cache of the transaction to other signers.
+func (s fixedSenderSigner) Equal(types.Signer) bool {
+	return false
+}
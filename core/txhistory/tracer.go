@@ -0,0 +1,129 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txhistory
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// transferTracer is a vm.Tracer collecting the addresses sending or receiving
+// value in the internal calls of a transaction. The top level transfer is left
+// out, as it is already covered by the transaction itself. Transfers are only
+// kept if the call making them and all its callers succeeded.
+type transferTracer struct {
+	frames  []*transferFrame // Call frames currently executing, indexed by depth-1
+	touched map[common.Address]struct{}
+}
+
+// transferFrame collects the transfers of a single call frame.
+type transferFrame struct {
+	touched []common.Address // Transfers of the frame and its successful calls
+	call    vm.OpCode        // Call or create opcode currently executed by the frame
+	pending []common.Address // Transfer made by the executed call if it succeeds
+	calling bool             // Whether the frame is waiting for a call to return
+}
+
+// newTransferTracer creates a tracer for a single transaction.
+func newTransferTracer() *transferTracer {
+	return &transferTracer{touched: make(map[common.Address]struct{})}
+}
+
+func (t *transferTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+// CaptureState follows the call frames of the transaction, settling the calls
+// made by a frame once it resumes execution and inspecting the value bearing
+// opcodes before their execution.
+func (t *transferTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	for len(t.frames) < depth {
+		t.frames = append(t.frames, new(transferFrame))
+	}
+	var (
+		stack = scope.Stack
+		frame = t.frames[depth-1]
+	)
+	if len(t.frames) > depth {
+		// A call returned, any deeper frames were reverted along with it
+		t.settle(frame, t.frames[depth], stack)
+		t.frames = t.frames[:depth]
+	} else if frame.calling {
+		// A call returned without entering a new frame
+		t.settle(frame, nil, stack)
+	}
+	if err != nil {
+		return
+	}
+	caller := scope.Contract.Address()
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		frame.call, frame.calling, frame.pending = op, true, nil
+		if value := stack.Back(2); !value.IsZero() {
+			frame.pending = []common.Address{caller, common.Address(stack.Back(1).Bytes20())}
+		}
+	case vm.DELEGATECALL, vm.STATICCALL:
+		frame.call, frame.calling, frame.pending = op, true, nil
+	case vm.CREATE, vm.CREATE2:
+		// The created address is only known once the call returned
+		frame.call, frame.calling, frame.pending = op, true, nil
+		if value := stack.Back(0); !value.IsZero() {
+			frame.pending = []common.Address{caller}
+		}
+	case vm.SELFDESTRUCT:
+		if env.StateDB.GetBalance(caller).Sign() > 0 {
+			frame.touched = append(frame.touched, caller, common.Address(stack.Back(0).Bytes20()))
+		}
+	}
+}
+
+// settle concludes the call a frame waited for, based on the result it pushed
+// onto the stack. On success the transfer of the call itself and the ones made
+// by the callee are attributed to the frame, otherwise they are discarded.
+func (t *transferTracer) settle(frame *transferFrame, callee *transferFrame, stack *vm.Stack) {
+	if !frame.calling {
+		return
+	}
+	frame.calling = false
+
+	result := stack.Back(0)
+	if result.IsZero() {
+		return
+	}
+	frame.touched = append(frame.touched, frame.pending...)
+	if (frame.call == vm.CREATE || frame.call == vm.CREATE2) && len(frame.pending) > 0 {
+		frame.touched = append(frame.touched, common.Address(result.Bytes20()))
+	}
+	if callee != nil {
+		frame.touched = append(frame.touched, callee.touched...)
+	}
+}
+
+func (t *transferTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+// CaptureEnd collects the transfers of the transaction, provided it succeeded.
+func (t *transferTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {
+	if err != nil || len(t.frames) == 0 {
+		return
+	}
+	for _, address := range t.frames[0].touched {
+		t.touched[address] = struct{}{}
+	}
+}
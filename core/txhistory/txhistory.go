@@ -0,0 +1,428 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package txhistory maintains an index of the transactions touching each address,
+// including the value transfers made by internal calls.
+package txhistory
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Roles an address may play in a transaction, stored as a bitset.
+const (
+	RoleFrom     = 1 << iota // Address sent the transaction
+	RoleTo                   // Address received the transaction or was created by it
+	RoleInternal             // Address sent or received value in an internal call
+)
+
+// ErrInvalidCursor is returned if a pagination cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid history cursor")
+
+// Chain is the blockchain the history is built from.
+type Chain interface {
+	core.ChainContext
+
+	// Config retrieves the chain's fork configuration.
+	Config() *params.ChainConfig
+
+	// CurrentBlock retrieves the current head block of the canonical chain.
+	CurrentBlock() *types.Block
+
+	// StateAt returns the state database of the given state root.
+	StateAt(root common.Hash) (*state.StateDB, error)
+
+	// SubscribeChainHeadEvent subscribes to new head notifications.
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// Entry is a transaction in the history of an address.
+type Entry struct {
+	BlockNumber uint64      // Number of the block containing the transaction
+	BlockHash   common.Hash // Hash of the block containing the transaction
+	TxIndex     uint        // Index of the transaction within the block
+	Roles       byte        // Bitset of the roles the address played
+	Traced      bool        // Whether internal value transfers were captured for the transaction
+}
+
+// Cursor returns the opaque pagination cursor pointing at the entry.
+func (e Entry) Cursor() string {
+	var blob [12]byte
+	binary.BigEndian.PutUint64(blob[:], e.BlockNumber)
+	binary.BigEndian.PutUint32(blob[8:], uint32(e.TxIndex))
+	return hexutil.Encode(blob[:])
+}
+
+// RoleNames returns the human readable names of the roles in the bitset.
+func RoleNames(roles byte) []string {
+	names := make([]string, 0, 3)
+	if roles&RoleFrom != 0 {
+		names = append(names, "from")
+	}
+	if roles&RoleTo != 0 {
+		names = append(names, "to")
+	}
+	if roles&RoleInternal != 0 {
+		names = append(names, "internal")
+	}
+	return names
+}
+
+// parseCursor decodes a pagination cursor created by Entry.Cursor.
+func parseCursor(cursor string) (uint64, uint32, error) {
+	blob, err := hexutil.Decode(cursor)
+	if err != nil || len(blob) != 12 {
+		return 0, 0, ErrInvalidCursor
+	}
+	return binary.BigEndian.Uint64(blob), binary.BigEndian.Uint32(blob[8:]), nil
+}
+
+// History returns up to limit transactions of the address on the canonical chain
+// from newest to oldest, positioned after the given cursor or at the newest one
+// if the cursor is empty. The returned flag reports whether more transactions
+// remain after the last one returned.
+func History(db ethdb.Database, address common.Address, after string, limit int) ([]Entry, bool, error) {
+	var (
+		number = ^uint64(0)
+		index  = ^uint32(0)
+	)
+	if after != "" {
+		var err error
+		if number, index, err = parseCursor(after); err != nil {
+			return nil, false, err
+		}
+	}
+	var (
+		entries   []Entry
+		more      bool
+		canonical = make(map[uint64]common.Hash)
+	)
+	err := rawdb.IterateTxHistory(db, address, number, index, func(entry rawdb.TxHistoryEntry) bool {
+		if after != "" && entry.Number == number && entry.Index == index {
+			return true
+		}
+		// Skip any entries left behind by blocks reorged out of the chain
+		hash, ok := canonical[entry.Number]
+		if !ok {
+			hash = rawdb.ReadCanonicalHash(db, entry.Number)
+			canonical[entry.Number] = hash
+		}
+		if hash != entry.Hash {
+			return true
+		}
+		if len(entries) == limit {
+			more = true
+			return false
+		}
+		entries = append(entries, Entry{
+			BlockNumber: entry.Number,
+			BlockHash:   entry.Hash,
+			TxIndex:     uint(entry.Index),
+			Roles:       entry.Roles,
+			Traced:      entry.Traced,
+		})
+		return true
+	})
+	return entries, more, err
+}
+
+// Indexer maintains the address transaction history of the canonical chain. New
+// blocks are indexed as they become the chain head, while older ones are built
+// retroactively in the background, down to the genesis block. Internal value
+// transfers are captured by re-executing the blocks with a tracer, which is only
+// possible for blocks whose parent state is still available. The entries of the
+// blocks that couldn't be traced are flagged as such, as internal transfers of
+// their transactions are missing from the history.
+type Indexer struct {
+	db    ethdb.Database
+	chain Chain
+
+	wake chan struct{} // Notification channel that new heads should be indexed
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewIndexer creates an address transaction history indexer.
+func NewIndexer(db ethdb.Database, chain Chain) *Indexer {
+	return &Indexer{
+		db:    db,
+		chain: chain,
+		wake:  make(chan struct{}, 1),
+		quit:  make(chan struct{}),
+	}
+}
+
+// Start launches the live indexing of new chain heads and the backfilling of
+// the historical blocks.
+func (idx *Indexer) Start() {
+	if _, _, ok := rawdb.ReadTxHistoryHead(idx.db); !ok {
+		// Fresh index, track new blocks from the current head on and
+		// backfill everything up to and including it
+		head := idx.chain.CurrentBlock()
+		rawdb.WriteTxHistoryHead(idx.db, head.NumberU64(), head.Hash())
+		rawdb.WriteTxHistoryTail(idx.db, head.NumberU64()+1)
+	}
+	events := make(chan core.ChainHeadEvent, 10)
+	sub := idx.chain.SubscribeChainHeadEvent(events)
+
+	// Catch up with any blocks imported while the indexer was not running
+	idx.wake <- struct{}{}
+
+	idx.wg.Add(3)
+	go idx.eventLoop(events, sub)
+	go idx.updateLoop()
+	go idx.backfill()
+}
+
+// Stop terminates all indexing goroutines.
+func (idx *Indexer) Stop() {
+	close(idx.quit)
+	idx.wg.Wait()
+}
+
+// eventLoop notifies the update loop of new chain heads. An indexing run may take
+// long, so the chain is not made to wait for it: notifications arriving while
+// one is already pending are dropped.
+func (idx *Indexer) eventLoop(events chan core.ChainHeadEvent, sub event.Subscription) {
+	defer idx.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-events:
+			select {
+			case idx.wake <- struct{}{}:
+			default:
+			}
+		case <-sub.Err():
+			return
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// updateLoop indexes the canonical chain up to its current head whenever it is
+// notified of new heads, until the indexer is stopped.
+func (idx *Indexer) updateLoop() {
+	defer idx.wg.Done()
+
+	for {
+		select {
+		case <-idx.wake:
+			idx.update()
+		case <-idx.quit:
+			return
+		}
+	}
+}
+
+// update indexes all canonical blocks between the last indexed head and the
+// current chain head, rewinding to the canonical chain first if the previously
+// indexed blocks were reorged out.
+func (idx *Indexer) update() {
+	number, hash, _ := rawdb.ReadTxHistoryHead(idx.db)
+	for number > 0 && rawdb.ReadCanonicalHash(idx.db, number) != hash {
+		header := rawdb.ReadHeader(idx.db, hash, number)
+		if header == nil {
+			break
+		}
+		number, hash = number-1, header.ParentHash
+	}
+	head := idx.chain.CurrentBlock().NumberU64()
+	if number > head {
+		number = head
+	}
+	if hash := rawdb.ReadCanonicalHash(idx.db, number); hash != (common.Hash{}) {
+		rawdb.WriteTxHistoryHead(idx.db, number, hash)
+	}
+	for number < head {
+		select {
+		case <-idx.quit:
+			return
+		default:
+		}
+		number++
+		block := rawdb.ReadBlock(idx.db, rawdb.ReadCanonicalHash(idx.db, number), number)
+		if block == nil {
+			log.Warn("Canonical block missing from transaction history", "number", number)
+			return
+		}
+		batch := idx.db.NewBatch()
+		idx.indexBlock(batch, block, true)
+		rawdb.WriteTxHistoryHead(batch, number, block.Hash())
+		if err := batch.Write(); err != nil {
+			log.Crit("Failed to write transaction history", "err", err)
+		}
+	}
+}
+
+// backfill indexes the historical blocks below the tail of the history, from
+// newest to oldest.
+func (idx *Indexer) backfill() {
+	defer idx.wg.Done()
+
+	tail := rawdb.ReadTxHistoryTail(idx.db)
+	if tail == nil || *tail == 0 {
+		return
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		traced = true
+		batch  = idx.db.NewBatch()
+		number = *tail
+	)
+	log.Info("Backfilling address transaction history", "from", number-1)
+	for number > 0 {
+		select {
+		case <-idx.quit:
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write transaction history", "err", err)
+			}
+			return
+		default:
+		}
+		block := rawdb.ReadBlock(idx.db, rawdb.ReadCanonicalHash(idx.db, number-1), number-1)
+		if block == nil {
+			log.Warn("Canonical block missing from transaction history", "number", number-1)
+			break
+		}
+		// Once the state of a block is unavailable, older ones won't have it either
+		traced = idx.indexBlock(batch, block, traced)
+		number--
+
+		rawdb.WriteTxHistoryTail(batch, number)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Crit("Failed to write transaction history", "err", err)
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Backfilling address transaction history", "tail", number, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write transaction history", "err", err)
+	}
+	log.Info("Finished address transaction history backfill", "tail", number, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// indexBlock writes the history entries of all transactions in the block into
+// the batch. If trace is set, internal value transfers are captured as well if
+// the block's parent state is available. The return value reports whether the
+// block was traced.
+func (idx *Indexer) indexBlock(batch ethdb.KeyValueWriter, block *types.Block, trace bool) bool {
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return trace
+	}
+	var transfers []map[common.Address]struct{}
+	if trace {
+		transfers = idx.traceTransfers(block)
+	}
+	signer := types.MakeSigner(idx.chain.Config(), block.Number())
+	for i, tx := range txs {
+		roles := make(map[common.Address]byte)
+
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Warn("Failed to recover transaction sender", "number", block.Number(), "index", i, "err", err)
+			continue
+		}
+		roles[from] |= RoleFrom
+		if to := tx.To(); to != nil {
+			roles[*to] |= RoleTo
+		} else {
+			roles[crypto.CreateAddress(from, tx.Nonce())] |= RoleTo
+		}
+		if transfers != nil {
+			for address := range transfers[i] {
+				roles[address] |= RoleInternal
+			}
+		}
+		for address, role := range roles {
+			rawdb.WriteTxHistoryEntry(batch, address, rawdb.TxHistoryEntry{
+				Number: block.NumberU64(),
+				Index:  uint32(i),
+				Hash:   block.Hash(),
+				Roles:  role,
+				Traced: transfers != nil,
+			})
+		}
+	}
+	return transfers != nil
+}
+
+// traceTransfers re-executes the block on top of its parent state, collecting
+// the addresses sending or receiving value in internal calls of each
+// transaction. Nil is returned if the parent state is unavailable.
+func (idx *Indexer) traceTransfers(block *types.Block) []map[common.Address]struct{} {
+	parent := rawdb.ReadHeader(idx.db, block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return nil
+	}
+	statedb, err := idx.chain.StateAt(parent.Root)
+	if err != nil {
+		return nil
+	}
+	var (
+		config    = idx.chain.Config()
+		header    = block.Header()
+		signer    = types.MakeSigner(config, block.Number())
+		blockCtx  = core.NewEVMBlockContext(header, idx.chain, nil)
+		transfers = make([]map[common.Address]struct{}, len(block.Transactions()))
+	)
+	if config.DAOForkSupport && config.DAOForkBlock != nil && config.DAOForkBlock.Cmp(block.Number()) == 0 {
+		misc.ApplyDAOHardFork(statedb)
+	}
+	for i, tx := range block.Transactions() {
+		msg, err := tx.AsMessage(signer, header.BaseFee)
+		if err != nil {
+			return nil
+		}
+		tracer := newTransferTracer()
+		vmenv := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, config, vm.Config{Debug: true, Tracer: tracer})
+
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if _, err := core.ApplyMessage(vmenv, msg, new(core.GasPool).AddGas(msg.Gas())); err != nil {
+			log.Debug("Failed to trace transaction history", "number", block.Number(), "index", i, "err", err)
+			return nil
+		}
+		statedb.Finalise(config.IsEIP158(block.Number()))
+		transfers[i] = tracer.touched
+	}
+	return transfers
+}
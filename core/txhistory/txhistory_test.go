@@ -0,0 +1,306 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package txhistory
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	testKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testTarget  = common.HexToAddress("0xbeef")
+	testForward = common.HexToAddress("0xf0f0")
+	testPlain   = common.HexToAddress("0xaaaa")
+	testRevert  = common.HexToAddress("0xdead")
+	testFail    = common.HexToAddress("0xfa11")
+	testUndo    = common.HexToAddress("0x0dd0")
+
+	// forwarderCode calls testTarget with the value it was called with.
+	forwarderCode = append(forwardCode(testTarget), 0x00)
+
+	// failForwarderCode forwards its value to a contract which always reverts.
+	failForwarderCode = append(forwardCode(testRevert), 0x00)
+
+	// undoForwarderCode forwards its value to testTarget, then reverts.
+	undoForwarderCode = append(forwardCode(testTarget), common.FromHex("5060006000fd")...)
+)
+
+// forwardCode returns the code calling the given address with the value the
+// contract was called with.
+func forwardCode(to common.Address) []byte {
+	return append(append(common.FromHex("60006000600060003473"), to.Bytes()...), common.FromHex("5af1")...)
+}
+
+// newTestChain creates a blockchain with a funded account and a contract which
+// forwards any value sent to it.
+func newTestChain(t *testing.T) (ethdb.Database, *core.BlockChain, *core.Genesis) {
+	db := rawdb.NewMemoryDatabase()
+	gspec := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: core.GenesisAlloc{
+			testAddr:    {Balance: big.NewInt(params.Ether)},
+			testForward: {Code: forwarderCode, Balance: new(big.Int)},
+			testRevert:  {Code: common.FromHex("60006000fd"), Balance: new(big.Int)},
+			testFail:    {Code: failForwarderCode, Balance: new(big.Int)},
+			testUndo:    {Code: undoForwarderCode, Balance: new(big.Int)},
+		},
+	}
+	gspec.MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return db, chain, gspec
+}
+
+// makeBlocks generates blocks on top of the parent, the odd ones calling the
+// forwarder contract and the even ones transferring to a plain account.
+func makeBlocks(db ethdb.Database, parent *types.Block, n int, seed byte) []*types.Block {
+	blocks, _ := core.GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), db, n, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{seed})
+		if seed != 0 {
+			return
+		}
+		to := testPlain
+		if i%2 == 1 {
+			to = testForward
+		}
+		signer := types.LatestSigner(params.TestChainConfig)
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(testAddr), to, big.NewInt(1000), 100000, big.NewInt(params.InitialBaseFee), nil), signer, testKey)
+		gen.AddTx(tx)
+	})
+	return blocks
+}
+
+// waitIndexed waits until the history covers the chain from genesis to head.
+func waitIndexed(t *testing.T, db ethdb.Database, head *types.Block) {
+	for start := time.Now(); time.Since(start) < 10*time.Second; time.Sleep(10 * time.Millisecond) {
+		number, hash, _ := rawdb.ReadTxHistoryHead(db)
+		if tail := rawdb.ReadTxHistoryTail(db); tail != nil && *tail == 0 && number == head.NumberU64() && hash == head.Hash() {
+			return
+		}
+	}
+	t.Fatalf("history not indexed up to #%d", head.NumberU64())
+}
+
+// collect pages through the entire history of an address.
+func collect(t *testing.T, db ethdb.Database, address common.Address, limit int) []Entry {
+	var (
+		entries []Entry
+		cursor  string
+	)
+	for {
+		page, more, err := History(db, address, cursor, limit)
+		if err != nil {
+			t.Fatalf("failed to read history: %v", err)
+		}
+		entries = append(entries, page...)
+		if !more {
+			return entries
+		}
+		cursor = page[len(page)-1].Cursor()
+	}
+}
+
+func TestHistory(t *testing.T) {
+	db, chain, gspec := newTestChain(t)
+	defer chain.Stop()
+
+	blocks := makeBlocks(db, gspec.ToBlock(nil), 6, 0)
+	if _, err := chain.InsertChain(blocks[:3]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	// Start indexing midway to exercise both the backfill and the live indexing
+	indexer := NewIndexer(db, chain)
+	indexer.Start()
+	defer indexer.Stop()
+
+	if _, err := chain.InsertChain(blocks[3:]); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	waitIndexed(t, db, blocks[5])
+
+	sender := collect(t, db, testAddr, 2)
+	if len(sender) != 6 {
+		t.Fatalf("sender history length mismatch: have %d, want 6", len(sender))
+	}
+	for i, entry := range sender {
+		if want := uint64(6 - i); entry.BlockNumber != want || entry.TxIndex != 0 || entry.Roles != RoleFrom || !entry.Traced {
+			t.Errorf("sender entry %d mismatch: have %+v, want block %d", i, entry, want)
+		}
+		if entry.BlockHash != blocks[entry.BlockNumber-1].Hash() {
+			t.Errorf("sender entry %d block hash mismatch", i)
+		}
+	}
+	// The forwarder is both called and makes internal transfers, the target
+	// only ever receives value internally
+	forwarder := collect(t, db, testForward, 10)
+	target := collect(t, db, testTarget, 10)
+	if len(forwarder) != 3 || len(target) != 3 {
+		t.Fatalf("history length mismatch: forwarder %d, target %d, want 3", len(forwarder), len(target))
+	}
+	for i := range target {
+		if forwarder[i].Roles != RoleTo|RoleInternal {
+			t.Errorf("forwarder entry %d roles mismatch: have %v", i, RoleNames(forwarder[i].Roles))
+		}
+		if target[i].Roles != RoleInternal {
+			t.Errorf("target entry %d roles mismatch: have %v", i, RoleNames(target[i].Roles))
+		}
+		if target[i].BlockNumber != forwarder[i].BlockNumber || target[i].BlockNumber%2 != 0 {
+			t.Errorf("target entry %d block mismatch: have %d", i, target[i].BlockNumber)
+		}
+	}
+	if !reflect.DeepEqual(collect(t, db, testPlain, 1), collect(t, db, testPlain, 100)) {
+		t.Errorf("paginated history mismatch")
+	}
+	if _, _, err := History(db, testAddr, "0x1234", 10); err != ErrInvalidCursor {
+		t.Errorf("invalid cursor error mismatch: have %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestHistoryReorg(t *testing.T) {
+	db, chain, gspec := newTestChain(t)
+	defer chain.Stop()
+
+	indexer := NewIndexer(db, chain)
+	indexer.Start()
+	defer indexer.Stop()
+
+	blocks := makeBlocks(db, gspec.ToBlock(nil), 4, 0)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	waitIndexed(t, db, blocks[3])
+
+	// Replace the last three blocks with a longer, empty fork
+	fork := makeBlocks(db, blocks[0], 5, 1)
+	if _, err := chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	waitIndexed(t, db, fork[4])
+
+	entries := collect(t, db, testAddr, 10)
+	if len(entries) != 1 || entries[0].BlockHash != blocks[0].Hash() {
+		t.Errorf("history after reorg mismatch: have %+v", entries)
+	}
+	if entries := collect(t, db, testTarget, 10); len(entries) != 0 {
+		t.Errorf("reorged internal transfers retained: %+v", entries)
+	}
+}
+
+// prunedChain is a blockchain missing the states below a given block.
+type prunedChain struct {
+	*core.BlockChain
+	pruned map[common.Hash]bool
+}
+
+func (c *prunedChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	if c.pruned[root] {
+		return nil, errors.New("missing state")
+	}
+	return c.BlockChain.StateAt(root)
+}
+
+// Tests that the transactions of blocks without parent state are indexed without
+// their internal transfers, flagged as untraced.
+func TestHistoryUntraced(t *testing.T) {
+	db, chain, gspec := newTestChain(t)
+	defer chain.Stop()
+
+	blocks := makeBlocks(db, gspec.ToBlock(nil), 6, 0)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	pruned := map[common.Hash]bool{chain.Genesis().Root(): true}
+	for _, block := range blocks[:2] {
+		pruned[block.Root()] = true
+	}
+	indexer := NewIndexer(db, &prunedChain{BlockChain: chain, pruned: pruned})
+	indexer.Start()
+	defer indexer.Stop()
+	waitIndexed(t, db, blocks[5])
+
+	sender := collect(t, db, testAddr, 10)
+	if len(sender) != 6 {
+		t.Fatalf("sender history length mismatch: have %d, want 6", len(sender))
+	}
+	for i, entry := range sender {
+		if want := entry.BlockNumber > 3; entry.Traced != want {
+			t.Errorf("sender entry %d traced mismatch: have %v, want %v", i, entry.Traced, want)
+		}
+	}
+	forwarder := collect(t, db, testForward, 10)
+	if len(forwarder) != 3 || forwarder[2].Roles != RoleTo || forwarder[2].Traced {
+		t.Errorf("untraced forwarder entry mismatch: have %+v", forwarder)
+	}
+	if target := collect(t, db, testTarget, 10); len(target) != 2 {
+		t.Errorf("target history length mismatch: have %d, want 2", len(target))
+	}
+}
+
+// Tests that value transfers reverted by the call making them or one of its
+// callers are not indexed.
+func TestHistoryRevertedTransfers(t *testing.T) {
+	db, chain, gspec := newTestChain(t)
+	defer chain.Stop()
+
+	indexer := NewIndexer(db, chain)
+	indexer.Start()
+	defer indexer.Stop()
+
+	blocks, _ := core.GenerateChain(params.TestChainConfig, gspec.ToBlock(nil), ethash.NewFaker(), db, 1, func(i int, gen *core.BlockGen) {
+		signer := types.LatestSigner(params.TestChainConfig)
+		for _, to := range []common.Address{testFail, testUndo} {
+			tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(testAddr), to, big.NewInt(1000), 100000, big.NewInt(params.InitialBaseFee), nil), signer, testKey)
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert blocks: %v", err)
+	}
+	waitIndexed(t, db, blocks[0])
+
+	if entries := collect(t, db, testRevert, 10); len(entries) != 0 {
+		t.Errorf("failed call indexed: %+v", entries)
+	}
+	if entries := collect(t, db, testTarget, 10); len(entries) != 0 {
+		t.Errorf("call reverted by its caller indexed: %+v", entries)
+	}
+	for _, address := range []common.Address{testFail, testUndo} {
+		entries := collect(t, db, address, 10)
+		if len(entries) != 1 || entries[0].Roles != RoleTo || !entries[0].Traced {
+			t.Errorf("history of %x mismatch: have %+v", address, entries)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// GasProfiler is a Tracer aggregating the gas spent by an execution by contract,
+// by function selector and by program counter. The gas of each step excludes
+// the gas spent in the calls it makes, which is accounted to the callee's steps
+// instead, so that the profile adds up to the total gas used by the execution
+// (excluding the intrinsic gas of transactions).
+//
+// The profile can be written as a pprof-compatible protobuf or as folded stacks
+// for flame graph tools.
+type GasProfiler struct {
+	frames  []*profileFrame           // Call frames currently executing
+	samples map[string]*profileSample // Aggregated gas by call stack and opcode
+	total   uint64                    // Total gas accounted so far
+	create  bool                      // Whether the execution is a contract creation
+}
+
+// profileFrame is a call frame being profiled.
+type profileFrame struct {
+	address  common.Address // Address of the code executing in the frame
+	function string         // Function selector of the call, or a placeholder
+	label    string         // Human readable identifier of the frame
+
+	pending  bool   // Whether a step is awaiting its gas accounting
+	pc       uint64 // Program counter of the pending step
+	op       OpCode // Opcode of the pending step
+	gas      uint64 // Gas available before the pending step
+	cost     uint64 // Gas cost of the pending step reported by the interpreter
+	children uint64 // Gas used by calls made by the pending step
+	used     uint64 // Gas used by the frame so far, including calls
+	failed   bool   // Whether the frame failed, consuming all its gas
+}
+
+// profileSample is the aggregated gas spent by one instruction within a given
+// call stack.
+type profileSample struct {
+	stack []profileLocation // Call stack of the instruction, outermost first
+	op    OpCode
+	gas   uint64
+	count uint64
+}
+
+// profileLocation is a call frame paired with the instruction executing in it.
+type profileLocation struct {
+	frame *profileFrame
+	pc    uint64
+}
+
+// NewGasProfiler creates a new gas profiling tracer.
+func NewGasProfiler() *GasProfiler {
+	return &GasProfiler{samples: make(map[string]*profileSample)}
+}
+
+// CaptureStart implements the Tracer interface to initialize the tracing operation.
+func (p *GasProfiler) CaptureStart(env *EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	p.create = create
+}
+
+// CaptureState implements the Tracer interface to account the gas of the previous
+// step of the frame and to track the call frames.
+func (p *GasProfiler) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, rData []byte, depth int, err error) {
+	// Close out any frames returned from
+	for len(p.frames) > depth {
+		p.pop()
+	}
+	if len(p.frames) < depth {
+		p.push(scope.Contract)
+	}
+	frame := p.frames[len(p.frames)-1]
+	if frame.pending {
+		p.account(frame.gas - gas)
+	}
+	frame.pending, frame.pc, frame.op, frame.gas, frame.cost = true, pc, op, gas, cost
+	if err != nil {
+		frame.failed = true
+	}
+}
+
+// CaptureFault implements the Tracer interface to mark the frame failed.
+func (p *GasProfiler) CaptureFault(env *EVM, pc uint64, op OpCode, gas, cost uint64, scope *ScopeContext, depth int, err error) {
+	if err != ErrExecutionReverted && len(p.frames) > 0 {
+		p.frames[len(p.frames)-1].failed = true
+	}
+}
+
+// CaptureEnd implements the Tracer interface to close out all frames.
+func (p *GasProfiler) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) {
+	for len(p.frames) > 0 {
+		p.pop()
+	}
+}
+
+// push starts profiling a new call frame.
+func (p *GasProfiler) push(contract *Contract) {
+	frame := &profileFrame{address: contract.Address()}
+	if contract.CodeAddr != nil {
+		frame.address = *contract.CodeAddr
+	}
+	switch {
+	case len(p.frames) > 0 && (p.frames[len(p.frames)-1].op == CREATE || p.frames[len(p.frames)-1].op == CREATE2):
+		frame.function = "constructor"
+	case len(p.frames) == 0 && p.create:
+		frame.function = "constructor"
+	case len(contract.Input) >= 4:
+		frame.function = hexutil.Encode(contract.Input[:4])
+	default:
+		frame.function = "fallback"
+	}
+	frame.label = frame.address.Hex() + ":" + frame.function
+	p.frames = append(p.frames, frame)
+}
+
+// pop closes out the innermost call frame, accounting its last step and adding
+// its gas to the step of the parent frame that made the call.
+func (p *GasProfiler) pop() {
+	frame := p.frames[len(p.frames)-1]
+	if frame.pending {
+		// A failed frame consumes all its gas in the failing step, a successful
+		// one returns the rest to the caller.
+		used := frame.cost
+		if frame.failed {
+			used = frame.gas
+		}
+		p.account(used)
+	}
+	p.frames = p.frames[:len(p.frames)-1]
+	if len(p.frames) > 0 {
+		p.frames[len(p.frames)-1].children += frame.used
+	}
+}
+
+// account records the gas used by the pending step of the innermost frame,
+// including the gas used by the calls made by the step.
+func (p *GasProfiler) account(used uint64) {
+	frame := p.frames[len(p.frames)-1]
+
+	self := uint64(0)
+	if used > frame.children {
+		self = used - frame.children
+	}
+	frame.used += self + frame.children
+	frame.children = 0
+	frame.pending = false
+
+	// Aggregate the gas into the sample of the step's call stack
+	var key strings.Builder
+	stack := make([]profileLocation, len(p.frames))
+	for i, f := range p.frames {
+		stack[i] = profileLocation{frame: f, pc: f.pc}
+		fmt.Fprintf(&key, "%s@%d;", f.label, f.pc)
+	}
+	sample := p.samples[key.String()]
+	if sample == nil {
+		sample = &profileSample{stack: stack, op: frame.op}
+		p.samples[key.String()] = sample
+	}
+	sample.gas += self
+	sample.count++
+	p.total += self
+}
+
+// GasProfile is the summary of a gas profile, with all entries ordered by gas
+// spent, most expensive first.
+type GasProfile struct {
+	Gas       uint64                  `json:"gas"`
+	Contracts []GasProfileContract    `json:"contracts"`
+	Functions []GasProfileFunction    `json:"functions"`
+	PCs       []GasProfileInstruction `json:"pcs"`
+}
+
+// GasProfileContract is the gas spent executing the code of a contract.
+type GasProfileContract struct {
+	Address common.Address `json:"address"`
+	Gas     uint64         `json:"gas"`
+}
+
+// GasProfileFunction is the gas spent executing a function of a contract,
+// identified by its selector or as "constructor" or "fallback".
+type GasProfileFunction struct {
+	Address  common.Address `json:"address"`
+	Function string         `json:"function"`
+	Gas      uint64         `json:"gas"`
+}
+
+// GasProfileInstruction is the gas spent executing an instruction of a
+// contract, along with the number of times it was executed.
+type GasProfileInstruction struct {
+	Address common.Address `json:"address"`
+	PC      uint64         `json:"pc"`
+	Op      string         `json:"op"`
+	Gas     uint64         `json:"gas"`
+	Count   uint64         `json:"count"`
+}
+
+// Profile returns the summary of the gas profile.
+func (p *GasProfiler) Profile() *GasProfile {
+	type function struct {
+		address  common.Address
+		function string
+	}
+	type instruction struct {
+		address common.Address
+		pc      uint64
+	}
+	var (
+		contracts    = make(map[common.Address]uint64)
+		functions    = make(map[function]uint64)
+		instructions = make(map[instruction]*GasProfileInstruction)
+	)
+	for _, sample := range p.samples {
+		leaf := sample.stack[len(sample.stack)-1]
+
+		contracts[leaf.frame.address] += sample.gas
+		functions[function{leaf.frame.address, leaf.frame.function}] += sample.gas
+
+		id := instruction{leaf.frame.address, leaf.pc}
+		if instructions[id] == nil {
+			instructions[id] = &GasProfileInstruction{Address: id.address, PC: id.pc, Op: sample.op.String()}
+		}
+		instructions[id].Gas += sample.gas
+		instructions[id].Count += sample.count
+	}
+	profile := &GasProfile{
+		Gas:       p.total,
+		Contracts: make([]GasProfileContract, 0, len(contracts)),
+		Functions: make([]GasProfileFunction, 0, len(functions)),
+		PCs:       make([]GasProfileInstruction, 0, len(instructions)),
+	}
+	for address, gas := range contracts {
+		profile.Contracts = append(profile.Contracts, GasProfileContract{Address: address, Gas: gas})
+	}
+	sort.Slice(profile.Contracts, func(i, j int) bool {
+		a, b := profile.Contracts[i], profile.Contracts[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return a.Address.Hash().Big().Cmp(b.Address.Hash().Big()) < 0
+	})
+	for fn, gas := range functions {
+		profile.Functions = append(profile.Functions, GasProfileFunction{Address: fn.address, Function: fn.function, Gas: gas})
+	}
+	sort.Slice(profile.Functions, func(i, j int) bool {
+		a, b := profile.Functions[i], profile.Functions[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		return a.Address.Hex()+a.Function < b.Address.Hex()+b.Function
+	})
+	for _, ins := range instructions {
+		profile.PCs = append(profile.PCs, *ins)
+	}
+	sort.Slice(profile.PCs, func(i, j int) bool {
+		a, b := profile.PCs[i], profile.PCs[j]
+		if a.Gas != b.Gas {
+			return a.Gas > b.Gas
+		}
+		if a.Address != b.Address {
+			return a.Address.Hex() < b.Address.Hex()
+		}
+		return a.PC < b.PC
+	})
+	return profile
+}
+
+// WriteFolded writes the profile as folded stacks, the input format of flame
+// graph tools. Each line holds the call frames of a stack, outermost first and
+// labelled as address:function, followed by the opcode and the gas spent.
+func (p *GasProfiler) WriteFolded(w io.Writer) error {
+	folded := make(map[string]uint64)
+	for _, sample := range p.samples {
+		labels := make([]string, 0, len(sample.stack)+1)
+		for _, loc := range sample.stack {
+			labels = append(labels, loc.frame.label)
+		}
+		folded[strings.Join(append(labels, sample.op.String()), ";")] += sample.gas
+	}
+	stacks := make([]string, 0, len(folded))
+	for stack := range folded {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	for _, stack := range stacks {
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, folded[stack]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// WritePprof writes the profile as a gzip compressed protobuf in the pprof
+// format. Each call frame is a function named address:function, the program
+// counters of the instructions serve as line numbers. The samples hold the gas
+// spent and the number of executions, labelled with the opcode.
+func (p *GasProfiler) WritePprof(w io.Writer) error {
+	var (
+		indices = map[string]int64{"": 0}
+		table   = []string{""}
+
+		functions    = make(map[string]uint64)
+		locations    = make(map[string]map[uint64]uint64)
+		nextLocation = uint64(1)
+
+		funcs   protoBuffer
+		locs    protoBuffer
+		profile protoBuffer
+	)
+	str := func(s string) int64 {
+		if id, ok := indices[s]; ok {
+			return id
+		}
+		indices[s] = int64(len(table))
+		table = append(table, s)
+		return indices[s]
+	}
+	function := func(frame *profileFrame) uint64 {
+		if id, ok := functions[frame.label]; ok {
+			return id
+		}
+		id := uint64(len(functions) + 1)
+		functions[frame.label] = id
+
+		var fn protoBuffer
+		fn.uint64(1, id)
+		fn.int64(2, str(frame.label))
+		fn.int64(3, str(frame.label))
+		fn.int64(4, str(frame.address.Hex()))
+		funcs.message(5, &fn)
+		return id
+	}
+	location := func(loc profileLocation) uint64 {
+		if id, ok := locations[loc.frame.label][loc.pc]; ok {
+			return id
+		}
+		if locations[loc.frame.label] == nil {
+			locations[loc.frame.label] = make(map[uint64]uint64)
+		}
+		id := nextLocation
+		nextLocation++
+		locations[loc.frame.label][loc.pc] = id
+
+		var line, l protoBuffer
+		line.uint64(1, function(loc.frame))
+		line.int64(2, int64(loc.pc))
+		l.uint64(1, id)
+		l.uint64(3, loc.pc)
+		l.message(4, &line)
+		locs.message(4, &l)
+		return id
+	}
+	// Describe the sample values
+	for _, typ := range [][2]string{{"gas", "gas"}, {"executions", "count"}} {
+		var vt protoBuffer
+		vt.int64(1, str(typ[0]))
+		vt.int64(2, str(typ[1]))
+		profile.message(1, &vt)
+	}
+	// Add the samples in a deterministic order, along with their locations
+	keys := make([]string, 0, len(p.samples))
+	for key := range p.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sample := p.samples[key]
+
+		ids := make([]uint64, 0, len(sample.stack))
+		for i := len(sample.stack) - 1; i >= 0; i-- {
+			ids = append(ids, location(sample.stack[i]))
+		}
+		var s, label protoBuffer
+		s.packed(1, ids)
+		s.packed(2, []uint64{sample.gas, sample.count})
+		label.int64(1, str("op"))
+		label.int64(2, str(sample.op.String()))
+		s.message(3, &label)
+		profile.message(2, &s)
+	}
+	profile.data = append(profile.data, locs.data...)
+	profile.data = append(profile.data, funcs.data...)
+
+	// Define gas as the default sample type and finish with the string table
+	var period protoBuffer
+	period.int64(1, str("gas"))
+	period.int64(2, str("gas"))
+	profile.message(11, &period)
+	profile.int64(12, 1)
+	profile.int64(14, str("gas"))
+	for _, s := range table {
+		profile.bytes(6, []byte(s))
+	}
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.data); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protoBuffer is a minimal protocol buffer encoder, sufficient for the pprof
+// profile format.
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.data = append(b.data, byte(x)|0x80)
+		x >>= 7
+	}
+	b.data = append(b.data, byte(x))
+}
+
+func (b *protoBuffer) key(field int, wire int) {
+	b.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (b *protoBuffer) uint64(field int, x uint64) {
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64(field int, x int64) {
+	b.uint64(field, uint64(x))
+}
+
+func (b *protoBuffer) bytes(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	b.data = append(b.data, data...)
+}
+
+func (b *protoBuffer) packed(field int, xs []uint64) {
+	var inner protoBuffer
+	for _, x := range xs {
+		inner.varint(x)
+	}
+	b.bytes(field, inner.data)
+}
+
+func (b *protoBuffer) message(field int, m *protoBuffer) {
+	b.bytes(field, m.data)
+}
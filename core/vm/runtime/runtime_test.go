@@ -688,3 +688,81 @@ func TestColdAccountAccessCost(t *testing.T) {
 		}
 	}
 }
+
+// TestGasProfiler tests that the gas profile accounts all the gas used by an
+// execution, attributing the gas of calls to the callees.
+func TestGasProfiler(t *testing.T) {
+	var (
+		caller  = common.HexToAddress("0xaa")
+		callee  = common.HexToAddress("0xbb")
+		invalid = common.HexToAddress("0xcc")
+	)
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetCode(caller, []byte{
+		// Call the callee with the selector 0x12345678 and all the gas
+		byte(vm.PUSH4), 0x12, 0x34, 0x56, 0x78, byte(vm.PUSH1), 0xe0, byte(vm.SHL),
+		byte(vm.PUSH1), 0x0, byte(vm.MSTORE),
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x4, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0,
+		byte(vm.PUSH1), 0xbb, byte(vm.GAS), byte(vm.CALL), byte(vm.POP),
+		// Call the failing contract with 10000 gas, all of which it consumes
+		byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0, byte(vm.PUSH1), 0x0,
+		byte(vm.PUSH1), 0xcc, byte(vm.PUSH2), 0x27, 0x10, byte(vm.CALL), byte(vm.POP),
+		byte(vm.STOP),
+	})
+	statedb.SetCode(callee, []byte{byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x0, byte(vm.SSTORE), byte(vm.STOP)})
+	statedb.SetCode(invalid, []byte{0xfe}) // INVALID
+
+	profiler := vm.NewGasProfiler()
+	gasLimit := uint64(1000000)
+	_, leftOver, err := Call(caller, nil, &Config{
+		State:     statedb,
+		GasLimit:  gasLimit,
+		EVMConfig: vm.Config{Debug: true, Tracer: profiler},
+	})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	used := gasLimit - leftOver
+
+	profile := profiler.Profile()
+	if profile.Gas != used {
+		t.Errorf("profiled gas mismatch: have %d, want %d", profile.Gas, used)
+	}
+	// The callee pays for pushes and a cold SSTORE, the failing contract for
+	// all the gas it received, the caller for the rest
+	wantFunctions := map[string]uint64{
+		caller.Hex() + ":fallback":   used - 22106 - 10000,
+		callee.Hex() + ":0x12345678": 22106,
+		invalid.Hex() + ":fallback":  10000,
+	}
+	if len(profile.Functions) != len(wantFunctions) {
+		t.Fatalf("function count mismatch: have %d, want %d", len(profile.Functions), len(wantFunctions))
+	}
+	for _, fn := range profile.Functions {
+		if want := wantFunctions[fn.Address.Hex()+":"+fn.Function]; fn.Gas != want {
+			t.Errorf("function %x:%s gas mismatch: have %d, want %d", fn.Address, fn.Function, fn.Gas, want)
+		}
+	}
+	if profile.Contracts[0].Address != callee || profile.Contracts[0].Gas != 22106 {
+		t.Errorf("most expensive contract mismatch: have %+v", profile.Contracts[0])
+	}
+	// The folded stacks should add up to the same total
+	var folded strings.Builder
+	if err := profiler.WriteFolded(&folded); err != nil {
+		t.Fatalf("failed to write folded stacks: %v", err)
+	}
+	var total uint64
+	for _, line := range strings.Split(strings.TrimSpace(folded.String()), "\n") {
+		var gas uint64
+		if _, err := fmt.Sscanf(line[strings.LastIndex(line, " ")+1:], "%d", &gas); err != nil {
+			t.Fatalf("invalid folded stack %q: %v", line, err)
+		}
+		total += gas
+	}
+	if total != used {
+		t.Errorf("folded gas mismatch: have %d, want %d", total, used)
+	}
+	if want := caller.Hex() + ":fallback;" + callee.Hex() + ":0x12345678;SSTORE 22100"; !strings.Contains(folded.String(), want) {
+		t.Errorf("folded stacks missing %q:\n%s", want, folded.String())
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"errors"
+)
+
+// The compressed point encoding follows the ZCash serialization format which
+// is also used by the Ethereum consensus layer. The three most significant bits
+// of the encoding are flags:
+//
+//   - 0x80: the point is compressed, always set
+//   - 0x40: the point is the point at infinity
+//   - 0x20: y is the lexicographically largest of the two possible values
+const (
+	flagCompressed = 0x80
+	flagInfinity   = 0x40
+	flagLargest    = 0x20
+)
+
+// FromCompressed decodes a 48 byte compressed G1 point. The point is checked
+// to be on the curve and in the correct subgroup.
+func (g *G1) FromCompressed(in []byte) (*PointG1, error) {
+	if len(in) != 48 {
+		return nil, errors.New("compressed g1 point should be 48 bytes")
+	}
+	buf := make([]byte, 48)
+	copy(buf, in)
+	infinity, largest, err := decodeFlags(buf)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return g.Zero(), nil
+	}
+	x, err := fromBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	// y^2 = x^3 + b
+	y, y2 := new(fe), new(fe)
+	square(y2, x)
+	mul(y2, y2, x)
+	add(y2, y2, b)
+	if !sqrt(y, y2) {
+		return nil, errors.New("point is not on curve")
+	}
+	if isLexLargest(y) != largest {
+		neg(y, y)
+	}
+	p := &PointG1{*x, *y, *new(fe).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not in correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed encodes a G1 point into 48 bytes.
+func (g *G1) ToCompressed(p *PointG1) []byte {
+	out := make([]byte, 48)
+	if g.IsZero(p) {
+		out[0] = flagCompressed | flagInfinity
+		return out
+	}
+	g.Affine(p)
+	copy(out, toBytes(&p[0]))
+	out[0] |= flagCompressed
+	if isLexLargest(&p[1]) {
+		out[0] |= flagLargest
+	}
+	return out
+}
+
+// FromCompressed decodes a 96 byte compressed G2 point. The point is checked
+// to be on the curve and in the correct subgroup.
+func (g *G2) FromCompressed(in []byte) (*PointG2, error) {
+	if len(in) != 96 {
+		return nil, errors.New("compressed g2 point should be 96 bytes")
+	}
+	buf := make([]byte, 96)
+	copy(buf, in)
+	infinity, largest, err := decodeFlags(buf)
+	if err != nil {
+		return nil, err
+	}
+	if infinity {
+		return g.Zero(), nil
+	}
+	x, err := g.f.fromBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+	// y^2 = x^3 + b'
+	y, y2, check := new(fe2), new(fe2), new(fe2)
+	g.f.square(y2, x)
+	g.f.mul(y2, y2, x)
+	g.f.add(y2, y2, b2)
+	g.f.sqrt(y, y2)
+	if g.f.square(check, y); !check.equal(y2) {
+		return nil, errors.New("point is not on curve")
+	}
+	if isLexLargest2(y) != largest {
+		g.f.neg(y, y)
+	}
+	p := &PointG2{*x, *y, *new(fe2).one()}
+	if !g.InCorrectSubgroup(p) {
+		return nil, errors.New("point is not in correct subgroup")
+	}
+	return p, nil
+}
+
+// ToCompressed encodes a G2 point into 96 bytes.
+func (g *G2) ToCompressed(p *PointG2) []byte {
+	out := make([]byte, 96)
+	if g.IsZero(p) {
+		out[0] = flagCompressed | flagInfinity
+		return out
+	}
+	g.Affine(p)
+	copy(out, g.f.toBytes(&p[0]))
+	out[0] |= flagCompressed
+	if isLexLargest2(&p[1]) {
+		out[0] |= flagLargest
+	}
+	return out
+}
+
+// decodeFlags extracts and clears the flag bits of a compressed point.
+func decodeFlags(in []byte) (infinity bool, largest bool, err error) {
+	if in[0]&flagCompressed == 0 {
+		return false, false, errors.New("point is not compressed")
+	}
+	infinity, largest = in[0]&flagInfinity != 0, in[0]&flagLargest != 0
+	in[0] &^= flagCompressed | flagInfinity | flagLargest
+	if infinity {
+		if largest {
+			return false, false, errors.New("invalid flags for point at infinity")
+		}
+		for _, v := range in {
+			if v != 0 {
+				return false, false, errors.New("non-zero point at infinity")
+			}
+		}
+	}
+	return infinity, largest, nil
+}
+
+// isLexLargest reports whether e is larger than (p-1)/2.
+func isLexLargest(e *fe) bool {
+	return toBig(e).Cmp(pMinus1Over2) > 0
+}
+
+// isLexLargest2 reports whether e is the larger of e and -e, comparing the
+// c1 coefficients first.
+func isLexLargest2(e *fe2) bool {
+	if !e[1].isZero() {
+		return isLexLargest(&e[1])
+	}
+	return isLexLargest(&e[0])
+}
@@ -0,0 +1,89 @@
+package bls12381
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestG1CompressedSerialization(t *testing.T) {
+	g1 := NewG1()
+	for i := 0; i < fuz; i++ {
+		a := g1.rand()
+		buf := g1.ToCompressed(a)
+		b, err := g1.FromCompressed(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g1.Equal(a, b) {
+			t.Fatal("bad compressed serialization")
+		}
+	}
+	buf := g1.ToCompressed(g1.Zero())
+	zero, err := g1.FromCompressed(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g1.IsZero(zero) {
+		t.Fatal("bad compressed serialization of point at infinity")
+	}
+	if _, err := g1.FromCompressed(g1.ToBytes(g1.one())[:48]); err == nil {
+		t.Fatal("accepted point without compression flag")
+	}
+}
+
+func TestG2CompressedSerialization(t *testing.T) {
+	g2 := NewG2()
+	for i := 0; i < fuz; i++ {
+		a := g2.rand()
+		buf := g2.ToCompressed(a)
+		b, err := g2.FromCompressed(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !g2.Equal(a, b) {
+			t.Fatal("bad compressed serialization")
+		}
+	}
+	buf := g2.ToCompressed(g2.Zero())
+	zero, err := g2.FromCompressed(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !g2.IsZero(zero) {
+		t.Fatal("bad compressed serialization of point at infinity")
+	}
+}
+
+// TestCompressedSignature checks the encoding against a signature of the
+// Ethereum consensus layer BLS test suite.
+func TestCompressedSignature(t *testing.T) {
+	var (
+		sk, _  = new(big.Int).SetString("263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3", 16)
+		dst    = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+		expPK  = common.FromHex("a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a")
+		expSig = common.FromHex("b6ed936746e01f8ecf281f020953fbf1f01debd5657c4a383940b020b26507f6076334f91e2366c96e9ab279fb5158090352ea1c5b0c9274504f4f0e7053af24802e51e4568d164fe986834f41e55c8e850ce1f98458c0cfc9ab380b55285a55")
+	)
+	g1, g2 := NewG1(), NewG2()
+	pk := g1.MulScalar(g1.New(), g1.One(), sk)
+	if buf := g1.ToCompressed(pk); !bytes.Equal(buf, expPK) {
+		t.Fatalf("public key mismatch: have %x, want %x", buf, expPK)
+	}
+	h, err := g2.HashToCurve(make([]byte, 32), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := g2.MulScalar(g2.New(), h, sk)
+	if buf := g2.ToCompressed(sig); !bytes.Equal(buf, expSig) {
+		t.Fatalf("signature mismatch: have %x, want %x", buf, expSig)
+	}
+	decoded, err := g2.FromCompressed(expSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !NewPairingEngine().AddPair(pk, h).AddPairInv(g1.One(), decoded).Check() {
+		t.Fatal("signature verification failed")
+	}
+}
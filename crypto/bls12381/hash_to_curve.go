@@ -0,0 +1,108 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bls12381
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// HashToCurve hashes a message to a G2 point using the
+// BLS12381G2_XMD:SHA-256_SSWU_RO_ suite of the hash-to-curve specification
+// with the given domain separation tag.
+func (g *G2) HashToCurve(msg, dst []byte) (*PointG2, error) {
+	u, err := hashToFieldXMD(msg, dst, 4)
+	if err != nil {
+		return nil, err
+	}
+	x0, y0 := swuMapG2(g.f, &fe2{*u[0], *u[1]})
+	isogenyMapG2(g.f, x0, y0)
+	x1, y1 := swuMapG2(g.f, &fe2{*u[2], *u[3]})
+	isogenyMapG2(g.f, x1, y1)
+
+	q0 := &PointG2{*x0, *y0, *new(fe2).one()}
+	q1 := &PointG2{*x1, *y1, *new(fe2).one()}
+	g.Add(q0, q0, q1)
+	g.ClearCofactor(q0)
+	return g.Affine(q0), nil
+}
+
+// hashToFieldXMD derives count base field elements from the message, as
+// defined by hash_to_field with expand_message_xmd and SHA-256.
+func hashToFieldXMD(msg, dst []byte, count int) ([]*fe, error) {
+	const l = 64 // ceil((ceil(log2(p)) + k) / 8) with k = 128
+	uniform, err := expandMessageXMD(msg, dst, count*l)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*fe, count)
+	for i := range out {
+		v := new(big.Int).SetBytes(uniform[i*l : (i+1)*l])
+		v.Mod(v, modulusBig)
+		if out[i], err = fromBig(v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// modulusBig is the base field modulus as a big integer.
+var modulusBig = bigFromHex("0x1a0111ea397fe69a4b1ba7b6434bacd764774b84f38512bf6730d2a0f6b0f6241eabfffeb153ffffb9feffffffffaaab")
+
+// expandMessageXMD implements expand_message_xmd with SHA-256.
+func expandMessageXMD(msg, dst []byte, length int) ([]byte, error) {
+	const blockSize, hashSize = 64, sha256.Size
+
+	ell := (length + hashSize - 1) / hashSize
+	if ell > 255 || length > 65535 {
+		return nil, errors.New("requested length is too large")
+	}
+	if len(dst) > 255 {
+		return nil, errors.New("domain separation tag is too long")
+	}
+	dstPrime := append(append([]byte{}, dst...), byte(len(dst)))
+
+	h := sha256.New()
+	h.Write(make([]byte, blockSize))
+	h.Write(msg)
+	h.Write([]byte{byte(length >> 8), byte(length), 0})
+	h.Write(dstPrime)
+	b0 := h.Sum(nil)
+
+	h.Reset()
+	h.Write(b0)
+	h.Write([]byte{1})
+	h.Write(dstPrime)
+	bi := h.Sum(nil)
+
+	out := make([]byte, 0, ell*hashSize)
+	out = append(out, bi...)
+	for i := 2; i <= ell; i++ {
+		tmp := make([]byte, hashSize)
+		for j := range tmp {
+			tmp[j] = b0[j] ^ bi[j]
+		}
+		h.Reset()
+		h.Write(tmp)
+		h.Write([]byte{byte(i)})
+		h.Write(dstPrime)
+		bi = h.Sum(nil)
+		out = append(out, bi...)
+	}
+	return out[:length], nil
+}
@@ -0,0 +1,38 @@
+package bls12381
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestExpandMessageXMD(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-expander-SHA256-128")
+	expected := common.FromHex("68a985b87eb6b46952128911f2a4412bbc302a9d759667f87f7a21d803f07235")
+	out, err := expandMessageXMD(nil, dst, 0x20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, expected) {
+		t.Fatalf("have %x, want %x", out, expected)
+	}
+}
+
+func TestG2HashToCurve(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	expected := common.FromHex("" +
+		"05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d" +
+		"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a" +
+		"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6" +
+		"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+	)
+	g := NewG2()
+	p, err := g.HashToCurve(nil, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf := g.ToBytes(p); !bytes.Equal(buf, expected) {
+		t.Fatalf("have %x, want %x", buf, expected)
+	}
+}
@@ -311,6 +311,34 @@ func (api *PrivateDebugAPI) Preimage(ctx context.Context, hash common.Hash) (hex
 	return nil, errors.New("unknown preimage")
 }
 
+// LogIndexProgress is the backfill progress report of the log index.
+type LogIndexProgress struct {
+	SectionSize   hexutil.Uint64 `json:"sectionSize"`
+	Sections      hexutil.Uint64 `json:"sections"`
+	IndexedBlocks hexutil.Uint64 `json:"indexedBlocks"`
+	HeadBlock     hexutil.Uint64 `json:"headBlock"`
+	PendingBlocks hexutil.Uint64 `json:"pendingBlocks"`
+}
+
+// LogIndexStatus reports how far the log index used by eth_getLogs has been
+// backfilled. Blocks not yet covered by the index are filtered through the
+// bloombits index or by scanning the headers.
+func (api *PrivateDebugAPI) LogIndexStatus() LogIndexProgress {
+	progress := api.eth.logIndex.Progress()
+
+	var pending uint64
+	if progress.Head+1 > progress.Indexed {
+		pending = progress.Head + 1 - progress.Indexed
+	}
+	return LogIndexProgress{
+		SectionSize:   hexutil.Uint64(progress.SectionSize),
+		Sections:      hexutil.Uint64(progress.Sections),
+		IndexedBlocks: hexutil.Uint64(progress.Indexed),
+		HeadBlock:     hexutil.Uint64(progress.Head),
+		PendingBlocks: hexutil.Uint64(pending),
+	}
+}
+
 // BadBlockArgs represents the entries in the list returned when bad blocks are queried.
 type BadBlockArgs struct {
 	Hash  common.Hash            `json:"hash"`
@@ -28,10 +28,12 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txhistory"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/miner"
@@ -312,6 +314,23 @@ func (b *EthAPIBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, sections
 }
 
+func (b *EthAPIBackend) LogIndex() *core.LogIndex {
+	return b.eth.logIndex
+}
+
+func (b *EthAPIBackend) TxHistory(ctx context.Context, address common.Address, after string, limit int) ([]txhistory.Entry, bool, error) {
+	if b.eth.txHistory == nil {
+		return nil, false, errors.New("address transaction history not enabled (--txhistory)")
+	}
+	return txhistory.History(b.eth.chainDb, address, after, limit)
+}
+
+// TraceStore returns the persistent trace store consulted by the tracing API,
+// nil if disabled.
+func (b *EthAPIBackend) TraceStore() *tracers.Store {
+	return b.eth.traceStore
+}
+
 func (b *EthAPIBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
@@ -132,6 +132,10 @@ type Config struct {
 	NoPrefetch bool // Whether to disable prefetching and only load state on demand
 
 	TxLookupLimit uint64 `toml:",omitempty"` // The maximum number of blocks from head whose tx indices are reserved.
+	TxHistory     bool   `toml:",omitempty"` // Whether to maintain the address to transaction history index
+
+	TraceStore        bool     `toml:",omitempty"` // Whether to persist the traces of the built-in tracers
+	TraceStoreTracers []string `toml:",omitempty"` // Built-in tracers to run eagerly on every new chain head
 
 	// Whitelist of required block number -> hash values to accept
 	Whitelist map[uint64]common.Hash `toml:"-"`
@@ -25,6 +25,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		NoPruning               bool
 		NoPrefetch              bool
 		TxLookupLimit           uint64                 `toml:",omitempty"`
+		TxHistory               bool                   `toml:",omitempty"`
+		TraceStore              bool                   `toml:",omitempty"`
+		TraceStoreTracers       []string               `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               int                    `toml:",omitempty"`
 		LightIngress            int                    `toml:",omitempty"`
@@ -69,6 +72,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.NoPruning = c.NoPruning
 	enc.NoPrefetch = c.NoPrefetch
 	enc.TxLookupLimit = c.TxLookupLimit
+	enc.TxHistory = c.TxHistory
+	enc.TraceStore = c.TraceStore
+	enc.TraceStoreTracers = c.TraceStoreTracers
 	enc.Whitelist = c.Whitelist
 	enc.LightServ = c.LightServ
 	enc.LightIngress = c.LightIngress
@@ -117,6 +123,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		NoPruning               *bool
 		NoPrefetch              *bool
 		TxLookupLimit           *uint64                `toml:",omitempty"`
+		TxHistory               *bool                  `toml:",omitempty"`
+		TraceStore              *bool                  `toml:",omitempty"`
+		TraceStoreTracers       []string               `toml:",omitempty"`
 		Whitelist               map[uint64]common.Hash `toml:"-"`
 		LightServ               *int                   `toml:",omitempty"`
 		LightIngress            *int                   `toml:",omitempty"`
@@ -180,6 +189,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.TxLookupLimit != nil {
 		c.TxLookupLimit = *dec.TxLookupLimit
 	}
+	if dec.TxHistory != nil {
+		c.TxHistory = *dec.TxHistory
+	}
+	if dec.TraceStore != nil {
+		c.TraceStore = *dec.TraceStore
+	}
+	if dec.TraceStoreTracers != nil {
+		c.TraceStoreTracers = dec.TraceStoreTracers
+	}
 	if dec.Whitelist != nil {
 		c.Whitelist = dec.Whitelist
 	}
@@ -45,6 +45,10 @@ type Backend interface {
 
 	BloomStatus() (uint64, uint64)
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
+
+	// LogIndex returns the log index of the chain, or nil if the backend does
+	// not maintain one.
+	LogIndex() *core.LogIndex
 }
 
 // Filter can be used to retrieve and filter logs.
@@ -142,18 +146,40 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	if f.end == -1 {
 		end = head
 	}
-	// Gather all indexed logs, and finish with non indexed ones
-	var (
-		logs []*types.Log
-		err  error
-	)
+	// Gather all logs from the log index, continue with the bloom indexed ones
+	// and finish with non indexed ones
+	var logs []*types.Log
+
+	if index := f.backend.LogIndex(); index != nil && f.logIndexable() {
+		size, sections := index.Status()
+		if indexed := sections * size; indexed > uint64(f.begin) {
+			var (
+				found []*types.Log
+				err   error
+			)
+			if indexed > end {
+				found, err = f.logIndexLogs(ctx, index, end)
+			} else {
+				found, err = f.logIndexLogs(ctx, index, indexed-1)
+			}
+			logs = append(logs, found...)
+			if err != nil {
+				return logs, err
+			}
+		}
+	}
 	size, sections := f.backend.BloomStatus()
 	if indexed := sections * size; indexed > uint64(f.begin) {
+		var (
+			found []*types.Log
+			err   error
+		)
 		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+			found, err = f.indexedLogs(ctx, end)
 		} else {
-			logs, err = f.indexedLogs(ctx, indexed-1)
+			found, err = f.indexedLogs(ctx, indexed-1)
 		}
+		logs = append(logs, found...)
 		if err != nil {
 			return logs, err
 		}
@@ -163,6 +189,51 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 	return logs, err
 }
 
+// logIndexable reports whether the filter constrains the logs enough for the log
+// index to be of use. Without any address or topic criteria every log matches.
+func (f *Filter) logIndexable() bool {
+	if len(f.addresses) > 0 {
+		return true
+	}
+	for _, sub := range f.topics {
+		if len(sub) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// logIndexLogs returns the logs matching the filter criteria based on the log
+// index. Only the blocks the index points to are retrieved, so unlike with the
+// bloom bits there are no false positive blocks to sift through.
+func (f *Filter) logIndexLogs(ctx context.Context, index *core.LogIndex, end uint64) ([]*types.Log, error) {
+	positions, err := index.Match(ctx, uint64(f.begin), end, f.addresses, f.topics)
+	if err != nil {
+		return nil, err
+	}
+	var logs []*types.Log
+	for i, pos := range positions {
+		if i > 0 && positions[i-1].Block == pos.Block {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return logs, err
+		}
+		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(pos.Block))
+		if header == nil || err != nil {
+			return logs, err
+		}
+		found, err := f.checkMatches(ctx, header)
+		if err != nil {
+			return logs, err
+		}
+		logs = append(logs, found...)
+		f.begin = int64(pos.Block) + 1
+	}
+	f.begin = int64(end) + 1
+	return logs, nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -21,7 +21,9 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
@@ -29,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/params"
 )
 
@@ -223,3 +226,98 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// testIndexerChain is a minimal core.ChainIndexerChain feeding a chain indexer
+// with the head of a pre-written chain.
+type testIndexerChain struct {
+	head *types.Header
+	feed event.Feed
+}
+
+func (c *testIndexerChain) CurrentHeader() *types.Header { return c.head }
+
+func (c *testIndexerChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+// Tests that range filters served from the log index return the same logs as
+// the ones served by scanning the blocks.
+func TestLogIndexFilters(t *testing.T) {
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr1   = crypto.PubkeyToAddress(key1.PublicKey)
+		addr2   = common.BytesToAddress([]byte("jeff"))
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr1, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 600, func(i int, gen *core.BlockGen) {
+		var logs []*types.Log
+		if i%7 == 0 {
+			logs = append(logs, &types.Log{Address: addr1, Topics: []common.Hash{hash1}})
+		}
+		if i%11 == 0 {
+			logs = append(logs, &types.Log{Address: addr2, Topics: []common.Hash{hash2, hash1}})
+		}
+		if len(logs) > 0 {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = logs
+			gen.AddUncheckedReceipt(receipt)
+			gen.AddUncheckedTx(types.NewTransaction(uint64(i), common.HexToAddress("0x1"), big.NewInt(1), 1, big.NewInt(1), nil))
+		}
+	})
+	for i, block := range chain {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		rawdb.WriteHeadBlockHash(db, block.Hash())
+		rawdb.WriteHeadHeaderHash(db, block.Hash())
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	index := core.NewLogIndex(db, 128, 0)
+	defer index.Indexer().Close()
+
+	index.Indexer().Start(&testIndexerChain{head: chain[len(chain)-1].Header()})
+	for start := time.Now(); ; {
+		if _, sections := index.Status(); sections == 4 {
+			break
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Fatalf("log index not built in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var (
+		indexed   = &testBackend{db: db, logIndex: index}
+		unindexed = &testBackend{db: db}
+	)
+	tests := []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+	}{
+		{0, -1, []common.Address{addr1}, nil},
+		{0, -1, nil, [][]common.Hash{{hash1}}},
+		{0, -1, nil, [][]common.Hash{nil, {hash1}}},
+		{100, 300, []common.Address{addr1, addr2}, [][]common.Hash{{hash1, hash2}}},
+		{500, -1, []common.Address{addr2}, nil},
+		{0, 400, nil, [][]common.Hash{nil, nil}},
+	}
+	for i, tt := range tests {
+		want, err := NewRangeFilter(unindexed, tt.begin, tt.end, tt.addresses, tt.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: unindexed filtering failed: %v", i, err)
+		}
+		have, err := NewRangeFilter(indexed, tt.begin, tt.end, tt.addresses, tt.topics).Logs(context.Background())
+		if err != nil {
+			t.Fatalf("test %d: indexed filtering failed: %v", i, err)
+		}
+		if len(want) == 0 {
+			t.Fatalf("test %d: no logs matched", i)
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("test %d: logs mismatch: have %d logs, want %d", i, len(have), len(want))
+		}
+	}
+}
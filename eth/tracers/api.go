@@ -74,11 +74,16 @@ type Backend interface {
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
 type API struct {
 	backend Backend
+	store   *Store // Persistent trace store, nil if the backend doesn't maintain one
 }
 
 // NewAPI creates a new API definition for the tracing methods of the Ethereum service.
 func NewAPI(backend Backend) *API {
-	return &API{backend: backend}
+	api := &API{backend: backend}
+	if b, ok := backend.(storeBackend); ok {
+		api.store = b.TraceStore()
+	}
+	return api
 }
 
 type chainContext struct {
@@ -243,6 +248,41 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 	}
 	sub := notifier.CreateSubscription()
 
+	// Abort tracing when the subscription is torn down
+	localctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-notifier.Closed()
+		cancel()
+	}()
+	// Keep reading the trace results and stream the to the user
+	go func() {
+		var (
+			done = make(map[uint64]*blockTraceResult)
+			next = start.NumberU64() + 1
+		)
+		api.traceBlockRange(localctx, start, end, config, func(result *blockTraceResult) {
+			// Queue up next received result
+			done[uint64(result.Block)] = result
+
+			// Stream completed traces to the user, aborting on the first error
+			for result, ok := done[next]; ok; result, ok = done[next] {
+				if len(result.Traces) > 0 || next == end.NumberU64() {
+					notifier.Notify(sub.ID, result)
+				}
+				delete(done, next)
+				next++
+			}
+		})
+	}()
+	return sub, nil
+}
+
+// traceBlockRange traces the blocks after start up to and including end on
+// concurrent workers, passing the results of each block to the deliver callback
+// as soon as it completes, in no particular order. The callback is never called
+// concurrently. The number of blocks in flight is bounded by the number of
+// workers, regardless of the length of the range.
+func (api *API) traceBlockRange(ctx context.Context, start, end *types.Block, config *TraceConfig, deliver func(*blockTraceResult)) error {
 	// Prepare all the states for tracing. Note this procedure can take very
 	// long time. Timeout mechanism is necessary.
 	reexec := defaultTraceReexec
@@ -255,10 +295,9 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 		threads = blocks
 	}
 	var (
-		pend     = new(sync.WaitGroup)
-		tasks    = make(chan *blockTraceTask, threads)
-		results  = make(chan *blockTraceTask, threads)
-		localctx = context.Background()
+		pend    = new(sync.WaitGroup)
+		tasks   = make(chan *blockTraceTask, threads)
+		results = make(chan *blockTraceTask, threads)
 	)
 	for th := 0; th < threads; th++ {
 		pend.Add(1)
@@ -268,7 +307,7 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 			// Fetch and execute the next block trace tasks
 			for task := range tasks {
 				signer := types.MakeSigner(api.backend.ChainConfig(), task.block.Number())
-				blockCtx := core.NewEVMBlockContext(task.block.Header(), api.chainContext(localctx), nil)
+				blockCtx := core.NewEVMBlockContext(task.block.Header(), api.chainContext(ctx), nil)
 				// Trace all the transactions contained within
 				for i, tx := range task.block.Transactions() {
 					msg, _ := tx.AsMessage(signer, task.block.BaseFee())
@@ -277,7 +316,7 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 						hash:  tx.Hash(),
 						block: task.block.Hash(),
 					}
-					res, err := api.traceTx(localctx, msg, txctx, blockCtx, task.statedb, config)
+					res, err := api.traceTx(ctx, msg, txctx, blockCtx, task.statedb, config)
 					if err != nil {
 						task.results[i] = &txTraceResult{Error: err.Error()}
 						log.Warn("Tracing failed", "hash", tx.Hash(), "block", task.block.NumberU64(), "err", err)
@@ -290,21 +329,22 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 				// Stream the result back to the user or abort on teardown
 				select {
 				case results <- task:
-				case <-notifier.Closed():
+				case <-ctx.Done():
 					return
 				}
 			}
 		}()
 	}
 	// Start a goroutine to feed all the blocks into the tracers
-	begin := time.Now()
-
+	var (
+		begin  = time.Now()
+		failed error
+	)
 	go func() {
 		var (
 			logged  time.Time
 			number  uint64
 			traced  uint64
-			failed  error
 			parent  common.Hash
 			statedb *state.StateDB
 		)
@@ -327,7 +367,7 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 		for number = start.NumberU64(); number < end.NumberU64(); number++ {
 			// Stop tracing if interruption was requested
 			select {
-			case <-notifier.Closed():
+			case <-ctx.Done():
 				return
 			default:
 			}
@@ -337,14 +377,14 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 				log.Info("Tracing chain segment", "start", start.NumberU64(), "end", end.NumberU64(), "current", number, "transactions", traced, "elapsed", time.Since(begin))
 			}
 			// Retrieve the parent state to trace on top
-			block, err := api.blockByNumber(localctx, rpc.BlockNumber(number))
+			block, err := api.blockByNumber(ctx, rpc.BlockNumber(number))
 			if err != nil {
 				failed = err
 				break
 			}
 			// Prepare the statedb for tracing. Don't use the live database for
 			// tracing to avoid persisting state junks into the database.
-			statedb, err = api.backend.StateAtBlock(localctx, block, reexec, statedb, false)
+			statedb, err = api.backend.StateAtBlock(ctx, block, reexec, statedb, false)
 			if err != nil {
 				failed = err
 				break
@@ -360,7 +400,7 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 			}
 			parent = block.Root()
 
-			next, err := api.blockByNumber(localctx, rpc.BlockNumber(number+1))
+			next, err := api.blockByNumber(ctx, rpc.BlockNumber(number+1))
 			if err != nil {
 				failed = err
 				break
@@ -369,43 +409,29 @@ func (api *API) traceChain(ctx context.Context, start, end *types.Block, config
 			txs := next.Transactions()
 			select {
 			case tasks <- &blockTraceTask{statedb: statedb.Copy(), block: next, rootref: block.Root(), results: make([]*txTraceResult, len(txs))}:
-			case <-notifier.Closed():
+			case <-ctx.Done():
 				return
 			}
 			traced += uint64(len(txs))
 		}
 	}()
 
-	// Keep reading the trace results and stream the to the user
-	go func() {
-		var (
-			done = make(map[uint64]*blockTraceResult)
-			next = start.NumberU64() + 1
-		)
-		for res := range results {
-			// Queue up next received result
-			result := &blockTraceResult{
-				Block:  hexutil.Uint64(res.block.NumberU64()),
-				Hash:   res.block.Hash(),
-				Traces: res.results,
-			}
-			done[uint64(result.Block)] = result
-
-			// Dereference any parent tries held in memory by this task
-			if res.statedb.Database().TrieDB() != nil {
-				res.statedb.Database().TrieDB().Dereference(res.rootref)
-			}
-			// Stream completed traces to the user, aborting on the first error
-			for result, ok := done[next]; ok; result, ok = done[next] {
-				if len(result.Traces) > 0 || next == end.NumberU64() {
-					notifier.Notify(sub.ID, result)
-				}
-				delete(done, next)
-				next++
-			}
+	// Hand the results over as they complete
+	for res := range results {
+		// Dereference any parent tries held in memory by this task
+		if res.statedb.Database().TrieDB() != nil {
+			res.statedb.Database().TrieDB().Dereference(res.rootref)
 		}
-	}()
-	return sub, nil
+		deliver(&blockTraceResult{
+			Block:  hexutil.Uint64(res.block.NumberU64()),
+			Hash:   res.block.Hash(),
+			Traces: res.results,
+		})
+	}
+	if failed != nil {
+		return failed
+	}
+	return ctx.Err()
 }
 
 // TraceBlockByNumber returns the structured logs created during the execution of
@@ -490,6 +516,11 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
+	if api.store != nil {
+		if results := api.store.readBlock(block, config); results != nil {
+			return results, nil
+		}
+	}
 	parent, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(block.NumberU64()-1), block.ParentHash())
 	if err != nil {
 		return nil, err
@@ -563,6 +594,9 @@ func (api *API) traceBlock(ctx context.Context, block *types.Block, config *Trac
 	if failed != nil {
 		return nil, failed
 	}
+	if api.store != nil {
+		api.store.writeBlock(block, config, results)
+	}
 	return results, nil
 }
 
@@ -714,6 +748,11 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 	if err != nil {
 		return nil, err
 	}
+	if api.store != nil {
+		if result := api.store.readTx(blockHash, int(index), config); result != nil {
+			return result, nil
+		}
+	}
 	msg, vmctx, statedb, err := api.backend.StateAtTransaction(ctx, block, int(index), reexec)
 	if err != nil {
 		return nil, err
@@ -723,7 +762,14 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 		hash:  hash,
 		block: blockHash,
 	}
-	return api.traceTx(ctx, msg, txctx, vmctx, statedb, config)
+	result, err := api.traceTx(ctx, msg, txctx, vmctx, statedb, config)
+	if err != nil {
+		return nil, err
+	}
+	if api.store != nil {
+		api.store.writeTx(blockHash, int(index), config, result)
+	}
+	return result, nil
 }
 
 // TraceCall lets you trace a given eth_call. It collects the structured logs
@@ -755,11 +801,13 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 	if err != nil {
 		return nil, err
 	}
-	// Apply the customized state rules if required.
+	// Apply the customized state rules if required. The overrides are finalised
+	// so that tracers see them as the pre-state, not as changes made by the call.
 	if config != nil {
 		if err := config.StateOverrides.Apply(statedb); err != nil {
 			return nil, err
 		}
+		statedb.Finalise(api.backend.ChainConfig().IsEIP158(block.Number()))
 	}
 	// Execute the trace
 	msg := args.ToMessage(api.backend.RPCGasCap())
@@ -788,6 +836,13 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *txTrac
 		txContext = core.NewEVMTxContext(message)
 	)
 	switch {
+	case config != nil && config.Tracer != nil && *config.Tracer == stateDiffTracerName:
+		// The state diff is collected after execution, no need for a timeout
+		tracer = newStateDiffTracer(statedb, api.backend.ChainConfig().IsEIP158(vmctx.BlockNumber))
+
+	case config != nil && config.Tracer != nil && *config.Tracer == gasProfilerName:
+		tracer = vm.NewGasProfiler()
+
 	case config != nil && config.Tracer != nil:
 		// Define a meaningful timeout of a single transaction trace
 		timeout := defaultTraceTimeout
@@ -845,6 +900,12 @@ func (api *API) traceTx(ctx context.Context, message core.Message, txctx *txTrac
 	case *Tracer:
 		return tracer.GetResult()
 
+	case *stateDiffTracer:
+		return tracer.GetResult()
+
+	case *vm.GasProfiler:
+		return newGasProfileResult(tracer)
+
 	default:
 		panic(fmt.Sprintf("bad tracer type %T", tracer))
 	}
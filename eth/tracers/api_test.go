@@ -17,6 +17,7 @@
 package tracers
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/ecdsa"
@@ -24,8 +25,11 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -591,6 +595,305 @@ func TestTraceBlock(t *testing.T) {
 	}
 }
 
+func TestTraceStateDiff(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts and a contract storing 42 into slot 0
+	accounts := newAccounts(1)
+	contract := common.HexToAddress("0xc0de")
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		contract:         {Balance: new(big.Int), Code: []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP)}},
+	}}
+	signer := types.HomesteadSigner{}
+	coinbase := common.HexToAddress("0xc014ba5e")
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(coinbase)
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), contract, big.NewInt(0), 100000, big.NewInt(1), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	api := NewAPI(backend)
+
+	tracer := stateDiffTracerName
+	config := &TraceConfig{Tracer: &tracer}
+	block := backend.chain.GetBlockByNumber(1)
+	result, err := api.TraceTransaction(context.Background(), block.Transactions()[0].Hash(), config)
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(1), config)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != 1 || !bytes.Equal(results[0].Result.(json.RawMessage), result.(json.RawMessage)) {
+		t.Fatalf("block trace mismatch, want %s, get %v", result, results)
+	}
+	var diff stateDiff
+	if err := json.Unmarshal(result.(json.RawMessage), &diff); err != nil {
+		t.Fatalf("failed to decode diff: %v", err)
+	}
+	// Check the sender paying for gas and bumping its nonce
+	sender := accounts[0].addr
+	if pre := diff.Pre[sender]; pre == nil || pre.Balance.ToInt().Cmp(big.NewInt(params.Ether)) != 0 || pre.Nonce != nil {
+		t.Errorf("sender pre state mismatch: %+v", pre)
+	}
+	post := diff.Post[sender]
+	if post == nil || post.Nonce == nil || *post.Nonce != 1 || post.Code != nil || post.Storage != nil {
+		t.Fatalf("sender post state mismatch: %+v", post)
+	}
+	// Check the fee paid to the coinbase, which didn't exist before
+	if pre := diff.Pre[coinbase]; pre != nil {
+		t.Errorf("coinbase pre state mismatch: %+v", pre)
+	}
+	fees := new(big.Int).Sub(big.NewInt(params.Ether), post.Balance.ToInt())
+	if post := diff.Post[coinbase]; post == nil || post.Balance.ToInt().Cmp(fees) != 0 {
+		t.Errorf("coinbase post state mismatch, want balance %v: %+v", fees, post)
+	}
+	// Check the contract storage change, with nothing else reported
+	want := map[common.Hash]common.Hash{{}: {}}
+	if pre := diff.Pre[contract]; pre == nil || len(pre.Code) == 0 || !reflect.DeepEqual(pre.Storage, want) {
+		t.Errorf("contract pre state mismatch: %+v", pre)
+	}
+	want = map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(42))}
+	if post := diff.Post[contract]; post == nil || post.Balance != nil || post.Nonce != nil || post.Code != nil || !reflect.DeepEqual(post.Storage, want) {
+		t.Errorf("contract post state mismatch: %+v", post)
+	}
+	if len(diff.Pre) != 2 || len(diff.Post) != 3 {
+		t.Errorf("unexpected accounts in diff: %s", result)
+	}
+}
+
+func TestTraceCallStateDiffOverrides(t *testing.T) {
+	t.Parallel()
+
+	// Initialize a test account and override it and a contract storing 42 into
+	// slot 0 before tracing the call
+	accounts := newAccounts(1)
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+	}}
+	api := NewAPI(newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {}))
+
+	var (
+		contract = common.HexToAddress("0xc0de")
+		code     = []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP)}
+		balance  = new(big.Int).Mul(big.NewInt(5), big.NewInt(params.Ether))
+		tracer   = stateDiffTracerName
+		number   = rpc.LatestBlockNumber
+	)
+	config := &TraceCallConfig{
+		Tracer: &tracer,
+		StateOverrides: &ethapi.StateOverride{
+			accounts[0].addr: ethapi.OverrideAccount{Balance: newRPCBalance(balance)},
+			contract: ethapi.OverrideAccount{
+				Code:      newRPCBytes(code),
+				StateDiff: newStates([]common.Hash{{}}, []common.Hash{common.BigToHash(big.NewInt(7))}),
+			},
+		},
+	}
+	result, err := api.TraceCall(context.Background(), ethapi.TransactionArgs{From: &accounts[0].addr, To: &contract}, rpc.BlockNumberOrHash{BlockNumber: &number}, config)
+	if err != nil {
+		t.Fatalf("failed to trace call: %v", err)
+	}
+	var diff stateDiff
+	if err := json.Unmarshal(result.(json.RawMessage), &diff); err != nil {
+		t.Fatalf("failed to decode diff: %v", err)
+	}
+	// The overrides should be reported as the pre state, not as changes
+	sender := accounts[0].addr
+	if pre := diff.Pre[sender]; pre == nil || pre.Balance.ToInt().Cmp(balance) != 0 {
+		t.Errorf("sender pre state mismatch: %+v", pre)
+	}
+	if post := diff.Post[sender]; post == nil || post.Balance != nil || post.Nonce == nil || *post.Nonce != 1 {
+		t.Errorf("sender post state mismatch: %+v", post)
+	}
+	want := map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(7))}
+	if pre := diff.Pre[contract]; pre == nil || !bytes.Equal(pre.Code, code) || !reflect.DeepEqual(pre.Storage, want) {
+		t.Errorf("contract pre state mismatch: %+v", pre)
+	}
+	want = map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(42))}
+	if post := diff.Post[contract]; post == nil || post.Balance != nil || post.Code != nil || !reflect.DeepEqual(post.Storage, want) {
+		t.Errorf("contract post state mismatch: %+v", post)
+	}
+}
+
+func TestTraceGasProfile(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts and a contract storing 42 into slot 0
+	accounts := newAccounts(1)
+	contract := common.HexToAddress("0xc0de")
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		contract:         {Balance: new(big.Int), Code: []byte{byte(vm.PUSH1), 0x2a, byte(vm.PUSH1), 0x00, byte(vm.SSTORE), byte(vm.STOP)}},
+	}}
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), contract, big.NewInt(0), 100000, big.NewInt(0), []byte{0xde, 0xad, 0xbe, 0xef}), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	api := NewAPI(backend)
+
+	tracer := gasProfilerName
+	block := backend.chain.GetBlockByNumber(1)
+	result, err := api.TraceTransaction(context.Background(), block.Transactions()[0].Hash(), &TraceConfig{Tracer: &tracer})
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	var profile gasProfileResult
+	if err := json.Unmarshal(result.(json.RawMessage), &profile); err != nil {
+		t.Fatalf("failed to decode profile: %v", err)
+	}
+	// Two pushes and a cold SSTORE, all within the called function
+	if profile.Gas != 22106 {
+		t.Errorf("profiled gas mismatch: have %d, want %d", profile.Gas, 22106)
+	}
+	want := []vm.GasProfileFunction{{Address: contract, Function: "0xdeadbeef", Gas: 22106}}
+	if !reflect.DeepEqual(profile.Functions, want) {
+		t.Errorf("function profile mismatch: have %+v, want %+v", profile.Functions, want)
+	}
+	if folded := contract.Hex() + ":0xdeadbeef;SSTORE 22100\n"; !strings.Contains(profile.Folded, folded) {
+		t.Errorf("folded stacks missing %q: %s", folded, profile.Folded)
+	}
+	if len(profile.Pprof) == 0 {
+		t.Errorf("pprof profile missing")
+	}
+}
+
+func TestTraceStream(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+	}}
+	genBlocks := 10
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, genBlocks, genesis, func(i int, b *core.BlockGen) {
+		// Transfer from account[0] to account[1]
+		//    value: 1000 wei
+		//    fee:   0 wei
+		tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[1].addr, big.NewInt(1000), params.TxGas, big.NewInt(0), nil), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	srv := httptest.NewServer(NewStreamHandler(backend))
+	defer srv.Close()
+
+	// Invalid ranges should be rejected before streaming starts
+	for _, body := range []string{`{"start":"0x5","end":"0x5"}`, `{"start":"0x1","end":"0x20"}`, `{"start":`} {
+		res, err := http.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request %s failed: %v", body, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("request %s: status mismatch, want %d, get %d", body, http.StatusBadRequest, res.StatusCode)
+		}
+	}
+	// Trace a range and ensure every block is streamed exactly once
+	res, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"start":"0x2","end":"0x9"}`))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status mismatch, want %d, get %d", http.StatusOK, res.StatusCode)
+	}
+	if ctype := res.Header.Get("Content-Type"); ctype != "application/x-ndjson" {
+		t.Errorf("content type mismatch, want application/x-ndjson, get %s", ctype)
+	}
+	seen := make(map[uint64]bool)
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var result struct {
+			Block  hexutil.Uint64   `json:"block"`
+			Hash   common.Hash      `json:"hash"`
+			Traces []*txTraceResult `json:"traces"`
+			Error  string           `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			t.Fatalf("failed to decode line %q: %v", scanner.Text(), err)
+		}
+		if result.Error != "" {
+			t.Fatalf("tracing failed: %s", result.Error)
+		}
+		number := uint64(result.Block)
+		if seen[number] {
+			t.Errorf("block #%d streamed twice", number)
+		}
+		seen[number] = true
+
+		if want := backend.chain.GetBlockByNumber(number).Hash(); result.Hash != want {
+			t.Errorf("block #%d: hash mismatch, want %x, get %x", number, want, result.Hash)
+		}
+		if len(result.Traces) != 1 || result.Traces[0].Error != "" {
+			t.Errorf("block #%d: unexpected traces %v", number, result.Traces)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read stream: %v", err)
+	}
+	for number := uint64(3); number <= 9; number++ {
+		if !seen[number] {
+			t.Errorf("block #%d missing from stream", number)
+		}
+	}
+	if len(seen) != 7 {
+		t.Errorf("streamed block count mismatch, want 7, get %d", len(seen))
+	}
+	// Tracing should stop at the first failed write to a gone client. The backend
+	// stalls fetching the middle of the range until the trace is aborted.
+	var (
+		handler = NewStreamHandler(&stallingBackend{testBackend: backend, from: 3, to: 9})
+		w       = &failingResponseWriter{header: make(http.Header)}
+		done    = make(chan struct{})
+	)
+	go func() {
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"start":"0x0","end":"0xa"}`)))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("tracing not aborted after failed write")
+	}
+	if w.writes != 1 {
+		t.Errorf("write count mismatch after failure, want 1, get %d", w.writes)
+	}
+}
+
+// stallingBackend is a test backend blocking the retrieval of a range of blocks
+// until the request is cancelled.
+type stallingBackend struct {
+	*testBackend
+	from, to rpc.BlockNumber
+}
+
+func (b *stallingBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number >= b.from && number <= b.to {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return b.testBackend.BlockByNumber(ctx, number)
+}
+
+// failingResponseWriter is an http.ResponseWriter whose writes always fail, as
+// if the client disconnected.
+type failingResponseWriter struct {
+	header http.Header
+	writes int
+}
+
+func (w *failingResponseWriter) Header() http.Header { return w.header }
+func (w *failingResponseWriter) WriteHeader(int)     {}
+
+func (w *failingResponseWriter) Write([]byte) (int, error) {
+	w.writes++
+	return 0, errors.New("client gone")
+}
+
 type Account struct {
 	key  *ecdsa.PrivateKey
 	addr common.Address
@@ -0,0 +1,53 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// gasProfilerName is the name selecting the native gas profiling tracer.
+const gasProfilerName = "gasProfiler"
+
+// gasProfileResult is the JSON representation of the result of the gas
+// profiler, holding the summary of the profile along with the full profile
+// both as folded stacks and as a gzip compressed pprof protobuf.
+type gasProfileResult struct {
+	*vm.GasProfile
+	Folded string        `json:"folded"`
+	Pprof  hexutil.Bytes `json:"pprof"`
+}
+
+// newGasProfileResult assembles the result of a gas profiling trace.
+func newGasProfileResult(profiler *vm.GasProfiler) (json.RawMessage, error) {
+	var folded, pprof bytes.Buffer
+	if err := profiler.WriteFolded(&folded); err != nil {
+		return nil, err
+	}
+	if err := profiler.WritePprof(&pprof); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&gasProfileResult{
+		GasProfile: profiler.Profile(),
+		Folded:     folded.String(),
+		Pprof:      pprof.Bytes(),
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// stateDiffTracerName is the name selecting the state diff tracer, which is
+// implemented natively instead of in JavaScript.
+const stateDiffTracerName = "stateDiffTracer"
+
+// stateDiffTracer is a vm.Tracer reporting the accounts and storage slots
+// modified by a transaction, both before and after the transaction. Instead of
+// inspecting the executed opcodes, the changes are collected from the state
+// journal once the transaction is done, so that gas payments, refunds and
+// reverted calls are accounted for exactly.
+type stateDiffTracer struct {
+	statedb     *state.StateDB
+	deleteEmpty bool // Whether touched empty accounts are deleted (EIP-158)
+}
+
+// diffAccount is the JSON representation of an account in the diff. The pre
+// state of an account contains all of its fields, the post state only those
+// that changed. Only the changed storage slots are included in either.
+type diffAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   *uint64                     `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// stateDiff is the JSON representation of the result of the state diff tracer.
+type stateDiff struct {
+	Pre  map[common.Address]*diffAccount `json:"pre"`
+	Post map[common.Address]*diffAccount `json:"post"`
+}
+
+// newStateDiffTracer creates a state diff tracer for a transaction executed on
+// top of the given state.
+func newStateDiffTracer(statedb *state.StateDB, deleteEmpty bool) *stateDiffTracer {
+	return &stateDiffTracer{statedb: statedb, deleteEmpty: deleteEmpty}
+}
+
+func (t *stateDiffTracer) CaptureStart(env *vm.EVM, from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (t *stateDiffTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+}
+
+func (t *stateDiffTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *stateDiffTracer) CaptureEnd(output []byte, gasUsed uint64, d time.Duration, err error) {}
+
+// GetResult assembles the diff of the state modifications made since the state
+// was last finalised. It must be called after the transaction is applied, but
+// before the state is finalised.
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	var (
+		diff   = t.statedb.Diff(t.deleteEmpty)
+		result = &stateDiff{
+			Pre:  make(map[common.Address]*diffAccount),
+			Post: make(map[common.Address]*diffAccount),
+		}
+	)
+	for addr, pre := range diff.Pre {
+		post, ok := diff.Post[addr]
+		if !ok {
+			// Deleted account, report its full original state
+			result.Pre[addr] = newDiffAccount(pre, nil)
+			continue
+		}
+		var (
+			preAcc  = newDiffAccount(pre, post)
+			postAcc = new(diffAccount)
+			changed = len(preAcc.Storage) > 0
+		)
+		if pre.Balance.Cmp(post.Balance) != 0 {
+			postAcc.Balance, changed = (*hexutil.Big)(post.Balance), true
+		}
+		if pre.Nonce != post.Nonce {
+			nonce := post.Nonce
+			postAcc.Nonce, changed = &nonce, true
+		}
+		if string(pre.Code) != string(post.Code) {
+			postAcc.Code, changed = post.Code, true
+		}
+		if !changed {
+			continue
+		}
+		for key := range preAcc.Storage {
+			if postAcc.Storage == nil {
+				postAcc.Storage = make(map[common.Hash]common.Hash)
+			}
+			postAcc.Storage[key] = post.Storage[key]
+		}
+		result.Pre[addr], result.Post[addr] = preAcc, postAcc
+	}
+	for addr, post := range diff.Post {
+		if _, ok := diff.Pre[addr]; !ok {
+			// Created account, report its full resulting state
+			result.Post[addr] = newDiffAccount(post, nil)
+		}
+	}
+	return json.Marshal(result)
+}
+
+// newDiffAccount converts an account to its JSON representation, retaining only
+// the storage slots whose values differ from those in other. If other is nil,
+// all non-empty slots are retained.
+func newDiffAccount(account *state.DiffAccount, other *state.DiffAccount) *diffAccount {
+	nonce := account.Nonce
+	acc := &diffAccount{
+		Balance: (*hexutil.Big)(account.Balance),
+		Nonce:   &nonce,
+		Code:    account.Code,
+	}
+	if nonce == 0 {
+		acc.Nonce = nil
+	}
+	for key, value := range account.Storage {
+		if other != nil && other.Storage[key] == value {
+			continue
+		}
+		if other == nil && value == (common.Hash{}) {
+			continue
+		}
+		if acc.Storage == nil {
+			acc.Storage = make(map[common.Hash]common.Hash)
+		}
+		acc.Storage[key] = value
+	}
+	return acc
+}
@@ -0,0 +1,290 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// storeEagerDepth is the maximum number of blocks below the chain head that
+// are traced eagerly when the head moves, bounding the work done after long
+// absences such as during sync.
+const storeEagerDepth = 64
+
+// StoreChain is the blockchain whose traces are persisted by a Store.
+type StoreChain interface {
+	// CurrentBlock retrieves the current head block of the canonical chain.
+	CurrentBlock() *types.Block
+
+	// SubscribeChainHeadEvent subscribes to new head notifications.
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+
+	// SubscribeChainSideEvent subscribes to notifications about blocks leaving
+	// or never joining the canonical chain.
+	SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription
+}
+
+// storeBackend is implemented by backends maintaining a trace store, which is
+// then consulted transparently by the tracing API.
+type storeBackend interface {
+	TraceStore() *Store
+}
+
+// Store is a persistent cache of the transaction traces produced by the
+// built-in tracers, keyed by block hash, tracer name and tracer configuration.
+// Traces are stored on demand when requested through the API, or eagerly for
+// a configured set of tracers as new blocks become the chain head. The traces
+// of blocks reorged out of the canonical chain are deleted.
+type Store struct {
+	db      ethdb.Database
+	chain   StoreChain
+	api     *API
+	tracers []string // Tracers to run eagerly on new chain heads
+
+	wake chan struct{} // Notification channel that new heads should be traced
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewStore creates a trace store persisting into the given database. The
+// traces of the given built-in tracers are produced eagerly for every new
+// chain head once the store is started.
+func NewStore(db ethdb.Database, backend Backend, chain StoreChain, tracers []string) (*Store, error) {
+	for _, name := range tracers {
+		if !IsBuiltin(name) {
+			return nil, fmt.Errorf("unknown tracer %q", name)
+		}
+	}
+	store := &Store{
+		db:      db,
+		chain:   chain,
+		tracers: tracers,
+		wake:    make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+	store.api = &API{backend: backend, store: store}
+	return store, nil
+}
+
+// Start launches the invalidation of reorged blocks and the eager tracing of
+// new chain heads.
+func (s *Store) Start() {
+	var (
+		heads = make(chan core.ChainHeadEvent, 10)
+		sides = make(chan core.ChainSideEvent, 10)
+	)
+	headSub := s.chain.SubscribeChainHeadEvent(heads)
+	sideSub := s.chain.SubscribeChainSideEvent(sides)
+
+	s.wg.Add(2)
+	go s.eventLoop(heads, headSub, sides, sideSub)
+	go s.traceLoop()
+}
+
+// Stop terminates all background goroutines.
+func (s *Store) Stop() {
+	close(s.quit)
+	s.wg.Wait()
+}
+
+// eventLoop deletes the traces of blocks leaving the canonical chain and wakes
+// the trace loop on new heads if any tracers are run eagerly. Tracing a block
+// is slow, so a wake-up which is already pending absorbs subsequent heads.
+func (s *Store) eventLoop(heads chan core.ChainHeadEvent, headSub event.Subscription, sides chan core.ChainSideEvent, sideSub event.Subscription) {
+	defer s.wg.Done()
+	defer headSub.Unsubscribe()
+	defer sideSub.Unsubscribe()
+
+	for {
+		select {
+		case <-heads:
+			if len(s.tracers) == 0 {
+				continue
+			}
+			select {
+			case s.wake <- struct{}{}:
+			default:
+			}
+		case ev := <-sides:
+			rawdb.DeleteTraceResults(s.db, ev.Block.Hash())
+		case <-headSub.Err():
+			return
+		case <-sideSub.Err():
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// traceLoop runs the eager tracers on the canonical chain each time it is woken
+// up, remembering the recently traced blocks across runs.
+func (s *Store) traceLoop() {
+	defer s.wg.Done()
+
+	recent := make(map[uint64]common.Hash)
+	for {
+		select {
+		case <-s.wake:
+			s.update(recent)
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// update traces the canonical blocks leading up to the current chain head
+// with the eager tracers, skipping those already traced as tracked by recent.
+func (s *Store) update(recent map[uint64]common.Hash) {
+	// Collect the untraced blocks from the head backwards
+	var (
+		head   = s.chain.CurrentBlock()
+		blocks []*types.Block
+	)
+	for block := head; block != nil && block.NumberU64() > 0; {
+		if recent[block.NumberU64()] == block.Hash() || head.NumberU64()-block.NumberU64() >= storeEagerDepth {
+			break
+		}
+		blocks = append(blocks, block)
+		block = rawdb.ReadBlock(s.db, block.ParentHash(), block.NumberU64()-1)
+	}
+	// Trace them from oldest to newest, as the older states go away first
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+		for _, name := range s.tracers {
+			select {
+			case <-s.quit:
+				return
+			default:
+			}
+			name := name
+			if _, err := s.api.traceBlock(context.Background(), block, &TraceConfig{Tracer: &name}); err != nil {
+				log.Debug("Failed to trace block eagerly", "number", block.NumberU64(), "hash", block.Hash(), "tracer", name, "err", err)
+			}
+		}
+		// The block might have been reorged out while being traced
+		if rawdb.ReadCanonicalHash(s.db, block.NumberU64()) != block.Hash() {
+			rawdb.DeleteTraceResults(s.db, block.Hash())
+			continue
+		}
+		recent[block.NumberU64()] = block.Hash()
+	}
+	for number := range recent {
+		if number+storeEagerDepth <= head.NumberU64() {
+			delete(recent, number)
+		}
+	}
+}
+
+// tracerHash returns the key identifying the traces produced with the given
+// config, or false if the traces of the config are not stored. Only built-in
+// tracers are eligible, as the output of the struct logger is too large and
+// custom tracers are one-off by nature.
+func tracerHash(config *TraceConfig) (common.Hash, bool) {
+	if config == nil || config.Tracer == nil || !IsBuiltin(*config.Tracer) {
+		return common.Hash{}, false
+	}
+	blob, err := json.Marshal(struct {
+		Tracer string
+		Config *vm.LogConfig
+	}{*config.Tracer, config.LogConfig})
+	if err != nil {
+		return common.Hash{}, false
+	}
+	return crypto.Keccak256Hash(blob), true
+}
+
+// readTx retrieves the stored trace of a single transaction, or nil if it is
+// unavailable.
+func (s *Store) readTx(block common.Hash, index int, config *TraceConfig) json.RawMessage {
+	tracer, ok := tracerHash(config)
+	if !ok {
+		return nil
+	}
+	if blob := rawdb.ReadTraceResult(s.db, block, tracer, uint32(index)); len(blob) > 0 {
+		return json.RawMessage(blob)
+	}
+	return nil
+}
+
+// writeTx stores the trace of a single transaction.
+func (s *Store) writeTx(block common.Hash, index int, config *TraceConfig, result interface{}) {
+	tracer, ok := tracerHash(config)
+	if !ok {
+		return
+	}
+	blob, err := json.Marshal(result)
+	if err != nil {
+		log.Warn("Failed to encode trace result", "block", block, "index", index, "err", err)
+		return
+	}
+	rawdb.WriteTraceResult(s.db, block, tracer, uint32(index), blob)
+}
+
+// readBlock retrieves the stored traces of all transactions in a block, or nil
+// if any of them is unavailable.
+func (s *Store) readBlock(block *types.Block, config *TraceConfig) []*txTraceResult {
+	tracer, ok := tracerHash(config)
+	if !ok {
+		return nil
+	}
+	blobs := rawdb.ReadTraceResults(s.db, block.Hash(), tracer, len(block.Transactions()))
+	if blobs == nil {
+		return nil
+	}
+	results := make([]*txTraceResult, len(blobs))
+	for i, blob := range blobs {
+		results[i] = &txTraceResult{Result: json.RawMessage(blob)}
+	}
+	return results
+}
+
+// writeBlock stores the successful traces of the transactions in a block.
+func (s *Store) writeBlock(block *types.Block, config *TraceConfig, results []*txTraceResult) {
+	tracer, ok := tracerHash(config)
+	if !ok {
+		return
+	}
+	batch := s.db.NewBatch()
+	for i, result := range results {
+		if result == nil || result.Error != "" {
+			continue
+		}
+		blob, err := json.Marshal(result.Result)
+		if err != nil {
+			log.Warn("Failed to encode trace result", "block", block.Hash(), "index", i, "err", err)
+			return
+		}
+		rawdb.WriteTraceResult(batch, block.Hash(), tracer, uint32(i), blob)
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to store trace results", "block", block.Hash(), "err", err)
+	}
+}
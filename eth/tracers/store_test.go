@@ -0,0 +1,134 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// storeTestBackend is a test backend maintaining a trace store.
+type storeTestBackend struct {
+	*testBackend
+	store *Store
+}
+
+func (b *storeTestBackend) TraceStore() *Store {
+	return b.store
+}
+
+func TestTraceStore(t *testing.T) {
+	t.Parallel()
+
+	// Initialize test accounts
+	accounts := newAccounts(3)
+	genesis := &core.Genesis{Alloc: core.GenesisAlloc{
+		accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		accounts[1].addr: {Balance: big.NewInt(params.Ether)},
+		accounts[2].addr: {Balance: big.NewInt(params.Ether)},
+	}}
+	signer := types.HomesteadSigner{}
+	transfer := func(to int) func(i int, b *core.BlockGen) {
+		return func(i int, b *core.BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(uint64(i), accounts[to].addr, big.NewInt(1000), params.TxGas, big.NewInt(0), nil), signer, accounts[0].key)
+			b.AddTx(tx)
+		}
+	}
+	backend := newTestBackend(t, 10, genesis, transfer(1))
+
+	tracer := "callTracer"
+	store, err := NewStore(backend.chaindb, backend, backend.chain, []string{tracer})
+	if err != nil {
+		t.Fatalf("failed to create trace store: %v", err)
+	}
+	store.Start()
+	defer store.Stop()
+
+	api := NewAPI(&storeTestBackend{backend, store})
+	config := &TraceConfig{Tracer: &tracer}
+	key, _ := tracerHash(config)
+
+	// Trace a block and ensure the results are stored and served afterwards
+	block := backend.chain.GetBlockByNumber(5)
+	if _, err := api.TraceBlockByHash(context.Background(), block.Hash(), config); err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if rawdb.ReadTraceResults(backend.chaindb, block.Hash(), key, 1) == nil {
+		t.Fatalf("block traces not stored")
+	}
+	sentinel := json.RawMessage(`{"stored":true}`)
+	rawdb.WriteTraceResult(backend.chaindb, block.Hash(), key, 0, sentinel)
+
+	results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(5), config)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != 1 || string(results[0].Result.(json.RawMessage)) != string(sentinel) {
+		t.Errorf("block traces not served from the store: %v", results)
+	}
+	result, err := api.TraceTransaction(context.Background(), block.Transactions()[0].Hash(), config)
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if string(result.(json.RawMessage)) != string(sentinel) {
+		t.Errorf("transaction trace not served from the store: %s", result)
+	}
+	// Trace a transaction and ensure it is stored
+	block = backend.chain.GetBlockByNumber(6)
+	if _, err := api.TraceTransaction(context.Background(), block.Transactions()[0].Hash(), config); err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if rawdb.ReadTraceResult(backend.chaindb, block.Hash(), key, 0) == nil {
+		t.Errorf("transaction trace not stored")
+	}
+	// Ensure only the traces of built-in tracers are stored
+	custom := "{data: [], step: function() {}, fault: function() {}, result: function() { return this.data; }}"
+	for _, config := range []*TraceConfig{nil, {}, {Tracer: &custom}} {
+		if _, ok := tracerHash(config); ok {
+			t.Errorf("traces of config %v stored", config)
+		}
+	}
+	// Reorg the chain and ensure the old traces are dropped and the new head
+	// is traced eagerly
+	old := backend.chain.GetBlockByNumber(5).Hash()
+	db := rawdb.NewMemoryDatabase()
+	fork, _ := core.GenerateChain(backend.chainConfig, genesis.MustCommit(db), backend.engine, db, 12, transfer(2))
+	if _, err := backend.chain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	head := fork[len(fork)-1]
+	for i := 0; ; i++ {
+		dropped := rawdb.ReadTraceResult(backend.chaindb, old, key, 0) == nil
+		traced := rawdb.ReadTraceResults(backend.chaindb, head.Hash(), key, 1) != nil
+		if dropped && traced {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("store not updated after reorg: dropped %v, traced %v", dropped, traced)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxStreamRequestSize is the maximum accepted size of a trace stream request
+// body. It is generous to leave room for custom JavaScript tracers.
+const maxStreamRequestSize = 5 * 1024 * 1024
+
+// StreamRequest is the body of a request to the trace stream endpoint. Like
+// debug_traceChain, all the blocks between Start (exclusive) and End (inclusive)
+// are traced.
+type StreamRequest struct {
+	Start  rpc.BlockNumber `json:"start"`
+	End    rpc.BlockNumber `json:"end"`
+	Config *TraceConfig    `json:"config"`
+}
+
+// streamError is the trailing line written if tracing fails mid-stream.
+type streamError struct {
+	Error string `json:"error"`
+}
+
+// StreamHandler serves the traces of a block range over plain HTTP, writing
+// one JSON object per block as newline-delimited JSON as soon as each block
+// is traced. Blocks are traced concurrently, so the lines are not necessarily
+// ordered by block number. Only a bounded number of blocks is held in memory
+// at any time, making the endpoint suitable for arbitrarily long ranges.
+//
+// Note, the HTTP server's write timeout applies to the whole response, so it
+// has to be raised for long ranges (--http.writetimeout).
+type StreamHandler struct {
+	api *API
+}
+
+// NewStreamHandler creates a handler serving block range traces from the given
+// backend.
+func NewStreamHandler(backend Backend) *StreamHandler {
+	return &StreamHandler{api: NewAPI(backend)}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *StreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req StreamRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxStreamRequestSize)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	// Fetch the block interval that we want to trace. Tracing is aborted as soon
+	// as the client can't be written to anymore.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	from, err := h.api.blockByNumber(ctx, req.Start)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := h.api.blockByNumber(ctx, req.End)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if from.Number().Cmp(to.Number()) >= 0 {
+		http.Error(w, fmt.Sprintf("end block (#%d) needs to come after start block (#%d)", req.End, req.Start), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// Write out the traces as they complete, flushing each so that the client
+	// receives them without waiting for the whole range
+	var (
+		enc        = json.NewEncoder(w)
+		flusher, _ = w.(http.Flusher)
+	)
+	err = h.api.traceBlockRange(ctx, from, to, req.Config, func(result *blockTraceResult) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := enc.Encode(result); err != nil {
+			log.Debug("Failed to write trace stream", "err", err)
+			cancel()
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	})
+	if err != nil && ctx.Err() == nil {
+		enc.Encode(&streamError{Error: err.Error()})
+	}
+}
@@ -51,3 +51,13 @@ func tracer(name string) (string, bool) {
 	}
 	return "", false
 }
+
+// IsBuiltin reports whether name is one of the built in tracers, either one
+// of the JavaScript ones or the native state diff and gas profiling tracers.
+func IsBuiltin(name string) bool {
+	if name == stateDiffTracerName || name == gasProfilerName {
+		return true
+	}
+	_, ok := tracer(name)
+	return ok
+}
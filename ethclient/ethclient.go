@@ -481,6 +481,34 @@ func (ec *Client) CallContract(ctx context.Context, msg ethereum.CallMsg, blockN
 	return hex, nil
 }
 
+// BatchCallContract executes multiple message calls in a single batch request. The
+// returned slices hold the output and the failure of each call, the error is only
+// set if the request itself failed.
+func (ec *Client) BatchCallContract(ctx context.Context, msgs []ethereum.CallMsg, blockNumber *big.Int) ([][]byte, []error, error) {
+	var (
+		results = make([]hexutil.Bytes, len(msgs))
+		reqs    = make([]rpc.BatchElem, len(msgs))
+	)
+	for i, msg := range msgs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_call",
+			Args:   []interface{}{toCallArg(msg), toBlockNumArg(blockNumber)},
+			Result: &results[i],
+		}
+	}
+	if err := ec.c.BatchCallContext(ctx, reqs); err != nil {
+		return nil, nil, err
+	}
+	var (
+		outputs = make([][]byte, len(msgs))
+		errs    = make([]error, len(msgs))
+	)
+	for i := range reqs {
+		outputs[i], errs[i] = results[i], reqs[i].Error
+	}
+	return outputs, errs, nil
+}
+
 // PendingCallContract executes a message call transaction using the EVM.
 // The state seen by the contract call is the pending state.
 func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
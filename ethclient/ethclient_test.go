@@ -488,6 +488,22 @@ func testCallContract(t *testing.T, client *rpc.Client) {
 	if _, err := ec.PendingCallContract(context.Background(), msg); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	// BatchCallContract
+	failing := msg
+	failing.Value = new(big.Int).Lsh(big.NewInt(1), 128)
+	outputs, errs, err := ec.BatchCallContract(context.Background(), []ethereum.CallMsg{msg, failing}, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(outputs) != 2 || len(errs) != 2 {
+		t.Fatalf("result count mismatch: have %d outputs, %d errors, want 2", len(outputs), len(errs))
+	}
+	if errs[0] != nil {
+		t.Fatalf("unexpected call error: %v", errs[0])
+	}
+	if errs[1] == nil {
+		t.Fatalf("expected call to fail with insufficient funds")
+	}
 }
 
 func testAtFunctions(t *testing.T, client *rpc.Client) {
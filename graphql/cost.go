@@ -0,0 +1,708 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/introspection"
+)
+
+// Every field resolved costs one, plus the extra cost below for the fields
+// doing more work than reading a value. Fields returning lists multiply the
+// cost of their selection by the number of items they are assumed to return,
+// unless the size is bounded by their arguments.
+var (
+	fieldCosts = map[string]uint64{
+		"Query.logs":          100,
+		"Query.call":          100,
+		"Query.estimateGas":   100,
+		"Block.logs":          10,
+		"Block.call":          100,
+		"Block.estimateGas":   100,
+		"Pending.call":        100,
+		"Pending.estimateGas": 100,
+		"Transaction.trace":   1000,
+	}
+	listSizes = map[string]uint64{
+		"Query.logs":                  1000,
+		"Block.ommers":                2,
+		"Block.transactions":          300,
+		"Block.logs":                  300,
+		"Pending.transactions":        300,
+		"Transaction.logs":            20,
+		"TransactionConnection.edges": 1, // sized by Account.transactions
+	}
+	defaultListSize = uint64(10)
+)
+
+// costType describes the type a field resolves to.
+type costType struct {
+	name string // name of the underlying named type
+	list bool   // whether the field returns a list of it
+}
+
+// costSchema holds the field types of a GraphQL schema, needed to estimate the
+// cost of queries against it.
+type costSchema struct {
+	fields map[string]map[string]costType // field types by object type and field name
+	roots  map[string]string              // root type names by operation type
+}
+
+// newCostSchema collects the field types of the given schema.
+func newCostSchema(s *graphql.Schema) *costSchema {
+	var (
+		inspect = s.Inspect()
+		cs      = &costSchema{
+			fields: make(map[string]map[string]costType),
+			roots:  make(map[string]string),
+		}
+	)
+	for _, t := range inspect.Types() {
+		if t.Kind() != "OBJECT" {
+			continue
+		}
+		fields := make(map[string]costType)
+		for _, f := range *t.Fields(&struct{ IncludeDeprecated bool }{true}) {
+			fields[f.Name()] = unwrapType(f.Type())
+		}
+		cs.fields[*t.Name()] = fields
+	}
+	for op, t := range map[string]*introspection.Type{
+		"query":        inspect.QueryType(),
+		"mutation":     inspect.MutationType(),
+		"subscription": inspect.SubscriptionType(),
+	} {
+		if t != nil {
+			cs.roots[op] = *t.Name()
+		}
+	}
+	return cs
+}
+
+// unwrapType strips the list and non-null wrappers off a type.
+func unwrapType(t *introspection.Type) costType {
+	var ct costType
+	for t.OfType() != nil {
+		if t.Kind() == "LIST" {
+			ct.list = true
+		}
+		t = t.OfType()
+	}
+	ct.name = *t.Name()
+	return ct
+}
+
+// metaFields are the introspection fields available on the query root, and
+// __typename available on every type.
+var metaFields = map[string]costType{
+	"__schema":   {name: "__Schema"},
+	"__type":     {name: "__Type"},
+	"__typename": {name: "String"},
+}
+
+// estimator computes the cost of a single operation.
+type estimator struct {
+	schema    *costSchema
+	fragments map[string]*queryFragment
+	variables map[string]interface{}
+	head      uint64 // current block number, bounding open block ranges
+	visiting  map[string]bool
+}
+
+// queryCost estimates the cost of executing the given operation of a query.
+// The head block number bounds the ranges left open by the query.
+func (cs *costSchema) queryCost(query string, operationName string, variables map[string]interface{}, head uint64) (uint64, error) {
+	doc, err := parseQuery(query)
+	if err != nil {
+		return 0, err
+	}
+	var op *queryOperation
+	for _, o := range doc.operations {
+		if operationName == "" || o.name == operationName {
+			if op != nil {
+				return 0, errors.New("more than one operation in query document and no operation name given")
+			}
+			op = o
+		}
+	}
+	if op == nil {
+		return 0, fmt.Errorf("no operation with name %q", operationName)
+	}
+	root, ok := cs.roots[op.typ]
+	if !ok {
+		return 0, fmt.Errorf("no %s operations are offered by the schema", op.typ)
+	}
+	// Variables not given fall back to their defaults
+	vars := make(map[string]interface{})
+	for name, value := range op.defaults {
+		vars[name] = value
+	}
+	for name, value := range variables {
+		vars[name] = value
+	}
+	e := &estimator{
+		schema:    cs,
+		fragments: doc.fragments,
+		variables: vars,
+		head:      head,
+		visiting:  make(map[string]bool),
+	}
+	return e.selectionCost(root, op.selections)
+}
+
+// selectionCost returns the cost of resolving the selections once on an object
+// of the given type.
+func (e *estimator) selectionCost(typ string, selections []*querySelection) (uint64, error) {
+	var total uint64
+	for _, sel := range selections {
+		var (
+			cost uint64
+			err  error
+		)
+		switch {
+		case sel.field != nil:
+			cost, err = e.fieldCost(typ, sel.field)
+		case sel.spread != "":
+			frag, ok := e.fragments[sel.spread]
+			if !ok {
+				return 0, fmt.Errorf("unknown fragment %q", sel.spread)
+			}
+			if e.visiting[sel.spread] {
+				return 0, fmt.Errorf("fragment %q is self-referencing", sel.spread)
+			}
+			e.visiting[sel.spread] = true
+			cost, err = e.selectionCost(frag.typ, frag.selections)
+			delete(e.visiting, sel.spread)
+		default:
+			fragType := sel.inline.typ
+			if fragType == "" {
+				fragType = typ
+			}
+			cost, err = e.selectionCost(fragType, sel.inline.selections)
+		}
+		if err != nil {
+			return 0, err
+		}
+		total = addCost(total, cost)
+	}
+	return total, nil
+}
+
+// fieldCost returns the cost of resolving a field, including its selections,
+// on an object of the given type.
+func (e *estimator) fieldCost(typ string, field *queryField) (uint64, error) {
+	key := typ + "." + field.name
+	cost := 1 + fieldCosts[key]
+
+	ft, ok := e.schema.fields[typ][field.name]
+	if !ok {
+		ft, ok = metaFields[field.name]
+	}
+	if !ok {
+		// Unknown fields are rejected by validation
+		return cost, nil
+	}
+	if len(field.selections) > 0 {
+		sub, err := e.selectionCost(ft.name, field.selections)
+		if err != nil {
+			return 0, err
+		}
+		cost = addCost(cost, sub)
+	}
+	size, err := e.fieldSize(key, ft, field.args)
+	if err != nil {
+		return 0, err
+	}
+	return mulCost(cost, size), nil
+}
+
+// fieldSize returns the number of items a field is assumed to resolve to.
+func (e *estimator) fieldSize(key string, ft costType, args map[string]interface{}) (uint64, error) {
+	switch key {
+	case "Query.blocks":
+		from, err := e.longArg(args, "from", 0)
+		if err != nil {
+			return 0, err
+		}
+		to, err := e.longArg(args, "to", e.head)
+		if err != nil {
+			return 0, err
+		}
+		if to < from {
+			return 0, nil
+		}
+		return to - from + 1, nil
+
+	case "Account.transactions":
+		return e.intArg(args, "first", 10)
+	}
+	if !ft.list {
+		return 1, nil
+	}
+	if size, ok := listSizes[key]; ok {
+		return size, nil
+	}
+	return defaultListSize, nil
+}
+
+// arg returns the value of an argument, resolving variables.
+func (e *estimator) arg(args map[string]interface{}, name string) (interface{}, bool) {
+	value, ok := args[name]
+	if v, isVar := value.(queryVariable); isVar {
+		value, ok = e.variables[string(v)]
+	}
+	return value, ok && value != nil
+}
+
+// longArg returns the value of a Long argument, decoded the same way as for
+// the resolvers so the estimate can't disagree with the execution. Negative
+// values are rejected.
+func (e *estimator) longArg(args map[string]interface{}, name string, fallback uint64) (uint64, error) {
+	value, ok := e.arg(args, name)
+	if !ok {
+		return fallback, nil
+	}
+	var n Long
+	if err := n.UnmarshalGraphQL(value); err != nil {
+		return 0, fmt.Errorf("invalid %s argument: %v", name, err)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("negative %s argument %d", name, n)
+	}
+	return uint64(n), nil
+}
+
+// intArg returns the value of an Int argument, negative values counting as 0.
+func (e *estimator) intArg(args map[string]interface{}, name string, fallback uint64) (uint64, error) {
+	value, ok := e.arg(args, name)
+	if !ok {
+		return fallback, nil
+	}
+	switch v := value.(type) {
+	case int64:
+		if v < 0 {
+			return 0, nil
+		}
+		return uint64(v), nil
+	case float64:
+		if v < 0 {
+			return 0, nil
+		}
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("invalid %s argument %v", name, value)
+}
+
+// addCost adds two costs, saturating instead of overflowing.
+func addCost(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
+
+// mulCost multiplies two costs, saturating instead of overflowing.
+func mulCost(a, b uint64) uint64 {
+	if a != 0 && b > math.MaxUint64/a {
+		return math.MaxUint64
+	}
+	return a * b
+}
+
+// The types below are the parts of a GraphQL query document that matter for
+// estimating its cost. Directives and variable types are skipped, and nothing
+// is validated: queries are validated against the schema before execution.
+type (
+	queryDocument struct {
+		operations []*queryOperation
+		fragments  map[string]*queryFragment
+	}
+	queryOperation struct {
+		typ        string // query, mutation or subscription
+		name       string
+		defaults   map[string]interface{} // variable default values
+		selections []*querySelection
+	}
+	queryFragment struct {
+		typ        string // type condition
+		selections []*querySelection
+	}
+	querySelection struct {
+		field  *queryField
+		spread string         // name of a spread fragment
+		inline *queryFragment // inline fragment, its type condition may be empty
+	}
+	queryField struct {
+		name       string
+		args       map[string]interface{}
+		selections []*querySelection
+	}
+	// queryVariable is an argument value referring to a variable.
+	queryVariable string
+)
+
+// queryParser is a recursive descent parser for GraphQL query documents.
+type queryParser struct {
+	lex *queryLexer
+	tok queryToken
+}
+
+// parseQuery parses a GraphQL query document.
+func parseQuery(query string) (doc *queryDocument, err error) {
+	p := &queryParser{lex: &queryLexer{input: query}}
+	defer func() {
+		if r := recover(); r != nil {
+			perr, ok := r.(queryParseError)
+			if !ok {
+				panic(r)
+			}
+			doc, err = nil, perr
+		}
+	}()
+	p.next()
+
+	doc = &queryDocument{fragments: make(map[string]*queryFragment)}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peek(tokPunct, "{"):
+			doc.operations = append(doc.operations, &queryOperation{typ: "query", selections: p.parseSelectionSet()})
+		case p.peek(tokName, "fragment"):
+			p.next()
+			name := p.expect(tokName, "")
+			p.expect(tokName, "on")
+			frag := &queryFragment{typ: p.expect(tokName, "")}
+			p.skipDirectives()
+			frag.selections = p.parseSelectionSet()
+			doc.fragments[name] = frag
+		case p.peek(tokName, "query"), p.peek(tokName, "mutation"), p.peek(tokName, "subscription"):
+			op := &queryOperation{typ: p.next().value, defaults: make(map[string]interface{})}
+			if p.tok.kind == tokName {
+				op.name = p.next().value
+			}
+			if p.accept(tokPunct, "(") {
+				for !p.accept(tokPunct, ")") {
+					p.expect(tokPunct, "$")
+					name := p.expect(tokName, "")
+					p.expect(tokPunct, ":")
+					p.skipType()
+					if p.accept(tokPunct, "=") {
+						op.defaults[name] = p.parseValue()
+					}
+					p.skipDirectives()
+				}
+			}
+			p.skipDirectives()
+			op.selections = p.parseSelectionSet()
+			doc.operations = append(doc.operations, op)
+		default:
+			p.fail("unexpected %q", p.tok.value)
+		}
+	}
+	return doc, nil
+}
+
+func (p *queryParser) parseSelectionSet() []*querySelection {
+	var selections []*querySelection
+	p.expect(tokPunct, "{")
+	for !p.accept(tokPunct, "}") {
+		if p.accept(tokPunct, "...") {
+			if p.tok.kind == tokName && p.tok.value != "on" {
+				selections = append(selections, &querySelection{spread: p.next().value})
+				p.skipDirectives()
+				continue
+			}
+			frag := new(queryFragment)
+			if p.accept(tokName, "on") {
+				frag.typ = p.expect(tokName, "")
+			}
+			p.skipDirectives()
+			frag.selections = p.parseSelectionSet()
+			selections = append(selections, &querySelection{inline: frag})
+			continue
+		}
+		field := &queryField{name: p.expect(tokName, "")}
+		if p.accept(tokPunct, ":") {
+			field.name = p.expect(tokName, "") // the first name was an alias
+		}
+		if p.accept(tokPunct, "(") {
+			field.args = make(map[string]interface{})
+			for !p.accept(tokPunct, ")") {
+				name := p.expect(tokName, "")
+				p.expect(tokPunct, ":")
+				field.args[name] = p.parseValue()
+			}
+		}
+		p.skipDirectives()
+		if p.peek(tokPunct, "{") {
+			field.selections = p.parseSelectionSet()
+		}
+		selections = append(selections, &querySelection{field: field})
+	}
+	return selections
+}
+
+func (p *queryParser) parseValue() interface{} {
+	tok := p.next()
+	switch tok.kind {
+	case tokInt:
+		n, err := strconv.ParseInt(tok.value, 10, 64)
+		if err != nil {
+			return tok.value // out of range, let validation complain
+		}
+		return n
+	case tokFloat:
+		f, _ := strconv.ParseFloat(tok.value, 64)
+		return f
+	case tokString:
+		return tok.value
+	case tokName:
+		switch tok.value {
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return tok.value // enum value
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			return queryVariable(p.expect(tokName, ""))
+		case "[":
+			list := []interface{}{}
+			for !p.accept(tokPunct, "]") {
+				list = append(list, p.parseValue())
+			}
+			return list
+		case "{":
+			obj := make(map[string]interface{})
+			for !p.accept(tokPunct, "}") {
+				name := p.expect(tokName, "")
+				p.expect(tokPunct, ":")
+				obj[name] = p.parseValue()
+			}
+			return obj
+		}
+	}
+	p.fail("unexpected %q", tok.value)
+	return nil
+}
+
+// skipType skips a variable type.
+func (p *queryParser) skipType() {
+	if p.accept(tokPunct, "[") {
+		p.skipType()
+		p.expect(tokPunct, "]")
+	} else {
+		p.expect(tokName, "")
+	}
+	p.accept(tokPunct, "!")
+}
+
+// skipDirectives skips any directives, which don't change the estimated cost.
+func (p *queryParser) skipDirectives() {
+	for p.accept(tokPunct, "@") {
+		p.expect(tokName, "")
+		if p.accept(tokPunct, "(") {
+			for !p.accept(tokPunct, ")") {
+				p.expect(tokName, "")
+				p.expect(tokPunct, ":")
+				p.parseValue()
+			}
+		}
+	}
+}
+
+// next advances to the next token, returning the current one.
+func (p *queryParser) next() queryToken {
+	tok := p.tok
+	p.tok = p.lex.next()
+	if p.tok.kind == tokError {
+		p.fail("%s", p.tok.value)
+	}
+	return tok
+}
+
+// peek reports whether the current token matches. An empty value matches any
+// token of the kind.
+func (p *queryParser) peek(kind queryTokenKind, value string) bool {
+	return p.tok.kind == kind && (value == "" || p.tok.value == value)
+}
+
+// accept advances past the current token if it matches.
+func (p *queryParser) accept(kind queryTokenKind, value string) bool {
+	if !p.peek(kind, value) {
+		return false
+	}
+	p.next()
+	return true
+}
+
+// expect advances past the current token, failing if it doesn't match.
+func (p *queryParser) expect(kind queryTokenKind, value string) string {
+	if !p.peek(kind, value) {
+		if p.tok.kind == tokEOF {
+			p.fail("unexpected end of query")
+		}
+		p.fail("unexpected %q", p.tok.value)
+	}
+	return p.next().value
+}
+
+func (p *queryParser) fail(format string, args ...interface{}) {
+	panic(queryParseError{fmt.Sprintf(format, args...), p.lex.line})
+}
+
+// queryParseError is raised by the parser on malformed query documents.
+type queryParseError struct {
+	msg  string
+	line int
+}
+
+func (err queryParseError) Error() string {
+	return fmt.Sprintf("syntax error on line %d: %s", err.line+1, err.msg)
+}
+
+type queryTokenKind int
+
+const (
+	tokEOF queryTokenKind = iota
+	tokError
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+type queryToken struct {
+	kind  queryTokenKind
+	value string
+}
+
+// queryLexer splits a GraphQL query document into tokens.
+type queryLexer struct {
+	input string
+	pos   int
+	line  int
+}
+
+func (l *queryLexer) next() queryToken {
+	// Skip ignored tokens: whitespace, commas, comments and byte order marks
+	for l.pos < len(l.input) {
+		c := l.input[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.input[l.pos:], "\ufeff"):
+			l.pos += len("\ufeff")
+		default:
+			return l.token()
+		}
+	}
+	return queryToken{kind: tokEOF}
+}
+
+// token lexes the token starting at the current position.
+func (l *queryLexer) token() queryToken {
+	start, c := l.pos, l.input[l.pos]
+	switch {
+	case strings.HasPrefix(l.input[l.pos:], "..."):
+		l.pos += 3
+		return queryToken{tokPunct, "..."}
+
+	case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+		l.pos++
+		return queryToken{tokPunct, string(c)}
+
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.input) && (l.input[l.pos] == '_' || isLetter(l.input[l.pos]) || isDigit(l.input[l.pos])) {
+			l.pos++
+		}
+		return queryToken{tokName, l.input[start:l.pos]}
+
+	case c == '-' || isDigit(c):
+		kind := tokInt
+		l.pos++
+		for l.pos < len(l.input) {
+			c := l.input[l.pos]
+			if c == '.' || c == 'e' || c == 'E' {
+				kind = tokFloat
+			} else if !isDigit(c) && !(kind == tokFloat && (c == '+' || c == '-')) {
+				break
+			}
+			l.pos++
+		}
+		return queryToken{kind, l.input[start:l.pos]}
+
+	case strings.HasPrefix(l.input[l.pos:], `"""`):
+		// Block strings end at the first unescaped triple quote
+		l.pos += 3
+		for !strings.HasPrefix(l.input[l.pos:], `"""`) {
+			if l.pos >= len(l.input) {
+				return queryToken{tokError, "unterminated block string"}
+			}
+			if strings.HasPrefix(l.input[l.pos:], `\"""`) {
+				l.pos += 3
+			}
+			if l.input[l.pos] == '\n' {
+				l.line++
+			}
+			l.pos++
+		}
+		l.pos += 3
+		value := l.input[start+3 : l.pos-3]
+		return queryToken{tokString, strings.ReplaceAll(value, `\"""`, `"""`)}
+
+	case c == '"':
+		l.pos++
+		for l.pos < len(l.input) && l.input[l.pos] != '"' && l.input[l.pos] != '\n' {
+			if l.input[l.pos] == '\\' {
+				l.pos++
+			}
+			l.pos++
+		}
+		if l.pos >= len(l.input) || l.input[l.pos] != '"' {
+			return queryToken{tokError, "unterminated string"}
+		}
+		l.pos++
+		value, err := strconv.Unquote(l.input[start:l.pos])
+		if err != nil {
+			// GraphQL escapes are a subset of Go's, but keep going regardless
+			value = l.input[start+1 : l.pos-1]
+		}
+		return queryToken{tokString, value}
+	}
+	return queryToken{tokError, fmt.Sprintf("unexpected character %q", c)}
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
@@ -0,0 +1,191 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/graph-gophers/graphql-go"
+)
+
+func TestQueryCost(t *testing.T) {
+	s, err := graphql.ParseSchema(schema, &Resolver{})
+	if err != nil {
+		t.Fatalf("could not parse schema: %v", err)
+	}
+	costs := newCostSchema(s)
+
+	for i, tt := range []struct {
+		query     string
+		operation string
+		variables map[string]interface{}
+		cost      uint64
+		err       string
+	}{
+		{query: `{block{number}}`, cost: 2},
+		{query: `{ b: block(number: 1) { number hash } }`, cost: 3},
+		{query: `query { block { transactions { hash } } }`, cost: 1 + 300*2},
+		{query: `{blocks(from: 10, to: 19) {number}}`, cost: 10 * 2},
+		{query: `{blocks(from: 90) {number}}`, cost: 11 * 2},
+		{query: `{blocks(from: 20, to: 10) {number}}`, cost: 0},
+		{query: `query Q($from: Long!, $to: Long = 4) {blocks(from: $from, to: $to) {number}}`, variables: map[string]interface{}{"from": "3"}, cost: 2 * 2},
+		{query: `query Q($from: Long!) {blocks(from: $from, to: 5) {hash}}`, variables: map[string]interface{}{"from": 3.0}, err: "invalid from argument: unexpected type float64 for Long"},
+		{query: `query Q($from: Long!) {blocks(from: $from, to: 5) {hash}}`, variables: map[string]interface{}{"from": "0x3"}, err: `invalid from argument: strconv.ParseInt: parsing "0x3": invalid syntax`},
+		{query: `query Q($from: Long!) {blocks(from: $from, to: 5) {hash}}`, variables: map[string]interface{}{"from": "-1000000000"}, err: "negative from argument -1000000000"},
+		{query: `{blocks(from: -1000000000, to: 5) {hash}}`, err: "negative from argument -1000000000"},
+		{query: `{blocks(from: 0, to: -1) {hash}}`, err: "negative to argument -1"},
+		{query: `query Q($from: Long!, $to: Long = 4) {blocks(from: $from, to: $to) {number}}`, variables: map[string]interface{}{"from": "1", "to": "4"}, cost: 4 * 2},
+		{query: `{blocks(from: 0, to: 9) {transactions {logs {data}}}}`, cost: 10 * (1 + 300*(1+20*2))},
+		{query: `{block {account(address: "0x00") {transactions(first: 5) {edges {node {hash}}}}}}`, cost: 1 + 1 + 5*(1+1*(1+2))},
+		{query: `{block {transactionAt(index: 0) {trace(tracer: "callTracer")}}}`, cost: 1 + 1 + 1001},
+		{query: `{block {...fields}} fragment fields on Block {number ... on Block {hash}}`, cost: 3},
+		{query: `query A {block {number}} query B {pending {transactionCount}}`, operation: "B", cost: 2},
+		{query: `mutation {sendRawTransaction(data: "0x")}`, cost: 1},
+		{query: "# comment\n{block {number @include(if: true)}}", cost: 2},
+		{query: `{__typename __schema {types {name}}}`, cost: 1 + 1 + 10*2},
+		{query: `{block {number}`, err: "syntax error on line 1: unexpected end of query"},
+		{query: `{block {...missing}}`, err: `unknown fragment "missing"`},
+		{query: `{block {...a}} fragment a on Block {...a}`, err: `fragment "a" is self-referencing`},
+		{query: `query A {block {number}} query B {block {number}}`, err: "more than one operation in query document and no operation name given"},
+		{query: `subscription {newBlocks {number}}`, err: "no subscription operations are offered by the schema"},
+	} {
+		cost, err := costs.queryCost(tt.query, tt.operation, tt.variables, 100)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("test %d: wrong error: have %v, want %s", i, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to estimate cost: %v", i, err)
+			continue
+		}
+		if cost != tt.cost {
+			t.Errorf("test %d: wrong cost: have %d, want %d", i, cost, tt.cost)
+		}
+	}
+}
+
+// Tests that the introspection query of GraphiQL stays within the default limit.
+func TestIntrospectionQueryCost(t *testing.T) {
+	s, err := graphql.ParseSchema(schema, &Resolver{})
+	if err != nil {
+		t.Fatalf("could not parse schema: %v", err)
+	}
+	cost, err := newCostSchema(s).queryCost(introspectionQuery, "IntrospectionQuery", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to estimate cost: %v", err)
+	}
+	if cost > node.DefaultConfig.GraphQLMaxCost {
+		t.Errorf("introspection query too expensive: %d", cost)
+	}
+}
+
+const introspectionQuery = `
+  query IntrospectionQuery {
+    __schema {
+      queryType { name }
+      mutationType { name }
+      subscriptionType { name }
+      types {
+        ...FullType
+      }
+      directives {
+        name
+        description
+        locations
+        args {
+          ...InputValue
+        }
+      }
+    }
+  }
+
+  fragment FullType on __Type {
+    kind
+    name
+    description
+    fields(includeDeprecated: true) {
+      name
+      description
+      args {
+        ...InputValue
+      }
+      type {
+        ...TypeRef
+      }
+      isDeprecated
+      deprecationReason
+    }
+    inputFields {
+      ...InputValue
+    }
+    interfaces {
+      ...TypeRef
+    }
+    enumValues(includeDeprecated: true) {
+      name
+      description
+      isDeprecated
+      deprecationReason
+    }
+    possibleTypes {
+      ...TypeRef
+    }
+  }
+
+  fragment InputValue on __InputValue {
+    name
+    description
+    type { ...TypeRef }
+    defaultValue
+  }
+
+  fragment TypeRef on __Type {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+                ofType {
+                  kind
+                  name
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+`
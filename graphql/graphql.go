@@ -19,8 +19,10 @@ package graphql
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strconv"
 	"time"
 
@@ -29,8 +31,10 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txhistory"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/rpc"
 )
@@ -70,6 +74,22 @@ func (b *Long) UnmarshalGraphQL(input interface{}) error {
 	return err
 }
 
+// JSON is an arbitrary JSON value, only ever returned by resolvers.
+type JSON json.RawMessage
+
+// ImplementsGraphQLType returns true if JSON implements the provided GraphQL type.
+func (j JSON) ImplementsGraphQLType(name string) bool { return name == "JSON" }
+
+// UnmarshalGraphQL unmarshals the provided GraphQL query data.
+func (j *JSON) UnmarshalGraphQL(input interface{}) error {
+	return errors.New("JSON is not accepted as input")
+}
+
+// MarshalJSON returns the value as is.
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(j).MarshalJSON()
+}
+
 // Account represents an Ethereum account at a particular block.
 type Account struct {
 	backend       ethapi.Backend
@@ -119,6 +139,120 @@ func (a *Account) Storage(ctx context.Context, args struct{ Slot common.Hash })
 	return state.GetState(a.address, args.Slot), nil
 }
 
+// TransactionsArgs are the pagination arguments of an account's transactions.
+type TransactionsArgs struct {
+	First *int32
+	After *string
+}
+
+func (a *Account) Transactions(ctx context.Context, args TransactionsArgs) (*TransactionConnection, error) {
+	limit := 10
+	if args.First != nil {
+		if *args.First <= 0 || *args.First > 1000 {
+			return nil, errors.New("first must be between 1 and 1000")
+		}
+		limit = int(*args.First)
+	}
+	var after string
+	if args.After != nil {
+		after = *args.After
+	}
+	entries, more, err := a.backend.TxHistory(ctx, a.address, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		conn   = &TransactionConnection{edges: make([]*TransactionEdge, 0, len(entries)), more: more}
+		blocks = make(map[common.Hash]*Block)
+	)
+	for _, entry := range entries {
+		block, ok := blocks[entry.BlockHash]
+		if !ok {
+			numberOrHash := rpc.BlockNumberOrHashWithHash(entry.BlockHash, false)
+			block = &Block{
+				backend:      a.backend,
+				numberOrHash: &numberOrHash,
+				hash:         entry.BlockHash,
+			}
+			blocks[entry.BlockHash] = block
+		}
+		b, err := block.resolve(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if b == nil || entry.TxIndex >= uint(len(b.Transactions())) {
+			return nil, fmt.Errorf("transaction %d of block %#x not found", entry.TxIndex, entry.BlockHash)
+		}
+		tx := b.Transactions()[entry.TxIndex]
+		conn.edges = append(conn.edges, &TransactionEdge{
+			entry: entry,
+			node: &Transaction{
+				backend: a.backend,
+				hash:    tx.Hash(),
+				tx:      tx,
+				block:   block,
+				index:   uint64(entry.TxIndex),
+			},
+		})
+	}
+	return conn, nil
+}
+
+// TransactionConnection is a page of the transaction history of an account.
+type TransactionConnection struct {
+	edges []*TransactionEdge
+	more  bool
+}
+
+func (c *TransactionConnection) Edges(ctx context.Context) []*TransactionEdge {
+	return c.edges
+}
+
+func (c *TransactionConnection) PageInfo(ctx context.Context) *PageInfo {
+	info := &PageInfo{hasNextPage: c.more}
+	if len(c.edges) > 0 {
+		cursor := c.edges[len(c.edges)-1].entry.Cursor()
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+// TransactionEdge is a transaction in the history of an account.
+type TransactionEdge struct {
+	entry txhistory.Entry
+	node  *Transaction
+}
+
+func (e *TransactionEdge) Cursor(ctx context.Context) string {
+	return e.entry.Cursor()
+}
+
+func (e *TransactionEdge) Node(ctx context.Context) *Transaction {
+	return e.node
+}
+
+func (e *TransactionEdge) Roles(ctx context.Context) []string {
+	return txhistory.RoleNames(e.entry.Roles)
+}
+
+func (e *TransactionEdge) Traced(ctx context.Context) bool {
+	return e.entry.Traced
+}
+
+// PageInfo describes the position of a page within a paginated list.
+type PageInfo struct {
+	hasNextPage bool
+	endCursor   *string
+}
+
+func (p *PageInfo) HasNextPage(ctx context.Context) bool {
+	return p.hasNextPage
+}
+
+func (p *PageInfo) EndCursor(ctx context.Context) *string {
+	return p.endCursor
+}
+
 // Log represents an individual log message. All arguments are mandatory.
 type Log struct {
 	backend     ethapi.Backend
@@ -150,6 +284,10 @@ func (l *Log) Data(ctx context.Context) hexutil.Bytes {
 	return l.log.Data
 }
 
+func (l *Log) Removed(ctx context.Context) bool {
+	return l.log.Removed
+}
+
 // AccessTuple represents EIP-2930
 type AccessTuple struct {
 	address     common.Address
@@ -380,6 +518,61 @@ func (t *Transaction) AccessList(ctx context.Context) (*[]*AccessTuple, error) {
 	return &ret, nil
 }
 
+func (t *Transaction) MaxFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.Type() != types.DynamicFeeTxType {
+		return nil, err
+	}
+	return (*hexutil.Big)(tx.FeeCap()), nil
+}
+
+func (t *Transaction) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || tx.Type() != types.DynamicFeeTxType {
+		return nil, err
+	}
+	return (*hexutil.Big)(tx.Tip()), nil
+}
+
+func (t *Transaction) EffectiveGasPrice(ctx context.Context) (*hexutil.Big, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	header, err := t.block.resolveHeader(ctx)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return (*hexutil.Big)(tx.GasPrice()), nil
+	}
+	price := new(big.Int).Add(header.BaseFee, tx.EffectiveTipValue(header.BaseFee))
+	return (*hexutil.Big)(price), nil
+}
+
+func (t *Transaction) Trace(ctx context.Context, args struct{ Tracer string }) (*JSON, error) {
+	tx, err := t.resolve(ctx)
+	if err != nil || tx == nil || t.block == nil {
+		return nil, err
+	}
+	if !tracers.IsBuiltin(args.Tracer) {
+		return nil, fmt.Errorf("unknown tracer %q", args.Tracer)
+	}
+	backend, ok := t.backend.(tracers.Backend)
+	if !ok {
+		return nil, errors.New("tracing not supported by the backend")
+	}
+	result, err := tracers.NewAPI(backend).TraceTransaction(ctx, t.hash, &tracers.TraceConfig{Tracer: &args.Tracer})
+	if err != nil {
+		return nil, err
+	}
+	output, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	return (*JSON)(&output), nil
+}
+
 func (t *Transaction) R(ctx context.Context) (hexutil.Big, error) {
 	tx, err := t.resolve(ctx)
 	if err != nil || tx == nil {
@@ -517,6 +710,14 @@ func (b *Block) GasUsed(ctx context.Context) (Long, error) {
 	return Long(header.GasUsed), nil
 }
 
+func (b *Block) BaseFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	header, err := b.resolveHeader(ctx)
+	if err != nil || header == nil || header.BaseFee == nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(header.BaseFee), nil
+}
+
 func (b *Block) Parent(ctx context.Context) (*Block, error) {
 	// If the block header hasn't been fetched, and we'll need it, fetch it.
 	if b.numberOrHash == nil && b.header == nil {
@@ -1012,6 +1213,9 @@ func (r *Resolver) Blocks(ctx context.Context, args struct {
 	From *Long
 	To   *Long
 }) ([]*Block, error) {
+	if *args.From < 0 || (args.To != nil && *args.To < 0) {
+		return nil, errors.New("negative block number")
+	}
 	from := rpc.BlockNumber(*args.From)
 
 	var to rpc.BlockNumber
@@ -17,6 +17,7 @@
 package graphql
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -36,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/node"
 	"github.com/ethereum/go-ethereum/params"
 
+	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -52,7 +54,7 @@ func TestBuildSchema(t *testing.T) {
 		t.Fatalf("could not create new node: %v", err)
 	}
 	// Make sure the schema can be parsed and matched up to the object model.
-	if err := newHandler(stack, nil, []string{}, []string{}); err != nil {
+	if err := newHandler(stack, nil, []string{}, []string{}, Limits{}); err != nil {
 		t.Errorf("Could not construct GraphQL handler: %v", err)
 	}
 }
@@ -73,74 +75,74 @@ func TestGraphQLBlockSerialization(t *testing.T) {
 	}{
 		{ // Should return latest block
 			body: `{"query": "{block{number}}","variables": null}`,
-			want: `{"data":{"block":{"number":10}}}`,
+			want: `{"data":{"block":{"number":10}},"extensions":{"cost":2}}`,
 			code: 200,
 		},
 		{ // Should return info about latest block
 			body: `{"query": "{block{number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":{"number":10,"gasUsed":0,"gasLimit":11500000}}}`,
+			want: `{"data":{"block":{"number":10,"gasUsed":0,"gasLimit":11500000}},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:0){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":{"number":0,"gasUsed":0,"gasLimit":11500000}}}`,
+			want: `{"data":{"block":{"number":0,"gasUsed":0,"gasLimit":11500000}},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:-1){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":null}}`,
+			want: `{"data":{"block":null},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:-500){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":null}}`,
+			want: `{"data":{"block":null},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:\"0\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":{"number":0,"gasUsed":0,"gasLimit":11500000}}}`,
+			want: `{"data":{"block":{"number":0,"gasUsed":0,"gasLimit":11500000}},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:\"-33\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":null}}`,
+			want: `{"data":{"block":null},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:\"1337\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"data":{"block":null}}`,
+			want: `{"data":{"block":null},"extensions":{"cost":4}}`,
 			code: 200,
 		},
 		{
 			body: `{"query": "{block(number:\"0xbad\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"0xbad\": invalid syntax"}],"data":{}}`,
+			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"0xbad\": invalid syntax"}],"data":{},"extensions":{"cost":4}}`,
 			code: 400,
 		},
 		{ // hex strings are currently not supported. If that's added to the spec, this test will need to change
 			body: `{"query": "{block(number:\"0x0\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"0x0\": invalid syntax"}],"data":{}}`,
+			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"0x0\": invalid syntax"}],"data":{},"extensions":{"cost":4}}`,
 			code: 400,
 		},
 		{
 			body: `{"query": "{block(number:\"a\"){number,gasUsed,gasLimit}}","variables": null}`,
-			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"a\": invalid syntax"}],"data":{}}`,
+			want: `{"errors":[{"message":"strconv.ParseInt: parsing \"a\": invalid syntax"}],"data":{},"extensions":{"cost":4}}`,
 			code: 400,
 		},
 		{
 			body: `{"query": "{bleh{number}}","variables": null}"`,
-			want: `{"errors":[{"message":"Cannot query field \"bleh\" on type \"Query\".","locations":[{"line":1,"column":2}]}]}`,
+			want: `{"errors":[{"message":"Cannot query field \"bleh\" on type \"Query\".","locations":[{"line":1,"column":2}]}],"extensions":{"cost":1}}`,
 			code: 400,
 		},
 		// should return `estimateGas` as decimal
 		{
 			body: `{"query": "{block{ estimateGas(data:{}) }}"}`,
-			want: `{"data":{"block":{"estimateGas":53000}}}`,
+			want: `{"data":{"block":{"estimateGas":53000}},"extensions":{"cost":102}}`,
 			code: 200,
 		},
 		// should return `status` as decimal
 		{
 			body: `{"query": "{block {number call (data : {from : \"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b\", to: \"0x6295ee1b4f6dd65047762f924ecd367c17eabf8f\", data :\"0x12a7b914\"}){data status}}}"}`,
-			want: `{"data":{"block":{"number":10,"call":{"data":"0x","status":1}}}}`,
+			want: `{"data":{"block":{"number":10,"call":{"data":"0x","status":1}}},"extensions":{"cost":105}}`,
 			code: 200,
 		},
 	} {
@@ -176,7 +178,7 @@ func TestGraphQLBlockSerializationEIP2718(t *testing.T) {
 	}{
 		{
 			body: `{"query": "{block {number transactions { from { address } to { address } value hash type accessList { address storageKeys } index}}}"}`,
-			want: `{"data":{"block":{"number":1,"transactions":[{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x64","hash":"0x4f7b8d718145233dcf7f29e34a969c63dd4de8715c054ea2af022b66c4f4633e","type":0,"accessList":[],"index":0},{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x32","hash":"0x9c6c2c045b618fe87add0e49ba3ca00659076ecae00fd51de3ba5d4ccf9dbf40","type":1,"accessList":[{"address":"0x0000000000000000000000000000000000000dad","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000000"]}],"index":1}]}}}`,
+			want: `{"data":{"block":{"number":1,"transactions":[{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x64","hash":"0x4f7b8d718145233dcf7f29e34a969c63dd4de8715c054ea2af022b66c4f4633e","type":0,"accessList":[],"index":0},{"from":{"address":"0x71562b71999873db5b286df957af199ec94617f7"},"to":{"address":"0x0000000000000000000000000000000000000dad"},"value":"0x32","hash":"0x9c6c2c045b618fe87add0e49ba3ca00659076ecae00fd51de3ba5d4ccf9dbf40","type":1,"accessList":[{"address":"0x0000000000000000000000000000000000000dad","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000000"]}],"index":1}]}},"extensions":{"cost":38702}}`,
 			code: 200,
 		},
 	} {
@@ -197,6 +199,241 @@ func TestGraphQLBlockSerializationEIP2718(t *testing.T) {
 	}
 }
 
+// Tests that the fee fields and tracer output of transactions are served.
+func TestGraphQLTransactionFeesAndTrace(t *testing.T) {
+	stack := createNode(t, true, true)
+	defer stack.Close()
+	// start node
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+
+	for i, tt := range []struct {
+		body string
+		want string
+		code int
+	}{
+		{
+			body: `{"query": "{block {baseFeePerGas transactions {maxFeePerGas maxPriorityFeePerGas effectiveGasPrice}}}"}`,
+			want: `{"data":{"block":{"baseFeePerGas":null,"transactions":[{"maxFeePerGas":null,"maxPriorityFeePerGas":null,"effectiveGasPrice":"0x1"},{"maxFeePerGas":null,"maxPriorityFeePerGas":null,"effectiveGasPrice":"0x1"}]}},"extensions":{"cost":1202}}`,
+			code: 200,
+		},
+		{
+			body: `{"query": "{block {transactionAt(index: 0) {trace(tracer: \"prestateTracer\")}}}"}`,
+			want: `{"data":{"block":{"transactionAt":{"trace":{"0x0000000000000000000000000000000000000dad":{"balance":"0x0","nonce":0,"code":"0x58585454","storage":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000000","0x0000000000000000000000000000000000000000000000000000000000000000":"0x0000000000000000000000000000000000000000000000000000000000000000"}},"0x71562b71999873db5b286df957af199ec94617f7":{"balance":"0x3b9aca00","nonce":0,"code":"0x","storage":{}}}}}},"extensions":{"cost":1003}}`,
+			code: 200,
+		},
+		{
+			body: `{"query": "{block {transactionAt(index: 0) {trace(tracer: \"{result: function() {}}\")}}}"}`,
+			want: `{"errors":[{"message":"unknown tracer \"{result: function() {}}\"","path":["block","transactionAt","trace"]}],"data":{"block":{"transactionAt":{"trace":null}}},"extensions":{"cost":1003}}`,
+			code: 400,
+		},
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("could not post: %v", err)
+		}
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("could not read from response body: %v", err)
+		}
+		if have := string(bodyBytes); have != tt.want {
+			t.Errorf("testcase %d %s,\nhave:\n%v\nwant:\n%v", i, tt.body, have, tt.want)
+		}
+		if tt.code != resp.StatusCode {
+			t.Errorf("testcase %d %s,\nwrong statuscode, have: %v, want: %v", i, tt.body, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+// Tests that the transaction history of an account can be paged through.
+func TestGraphQLAccountTransactions(t *testing.T) {
+	stack := createNode(t, true, true)
+	defer stack.Close()
+	// start node
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	query := func(body string) string {
+		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("could not post: %v", err)
+		}
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("could not read from response body: %v", err)
+		}
+		return string(bodyBytes)
+	}
+	for i, tt := range []struct {
+		body string
+		want string
+	}{
+		{
+			body: `{"query": "{block {account(address: \"0x71562b71999873db5b286df957af199ec94617f7\") {transactions(first: 1) {edges {cursor roles node {index}} pageInfo {hasNextPage endCursor}}}}}"}`,
+			want: `{"data":{"block":{"account":{"transactions":{"edges":[{"cursor":"0x000000000000000100000001","roles":["from"],"node":{"index":1}}],"pageInfo":{"hasNextPage":true,"endCursor":"0x000000000000000100000001"}}}}},"extensions":{"cost":20}}`,
+		},
+		{
+			body: `{"query": "{block {account(address: \"0x71562b71999873db5b286df957af199ec94617f7\") {transactions(after: \"0x000000000000000100000001\") {edges {cursor roles node {index}} pageInfo {hasNextPage endCursor}}}}}"}`,
+			want: `{"data":{"block":{"account":{"transactions":{"edges":[{"cursor":"0x000000000000000100000000","roles":["from"],"node":{"index":0}}],"pageInfo":{"hasNextPage":false,"endCursor":"0x000000000000000100000000"}}}}},"extensions":{"cost":182}}`,
+		},
+		{
+			body: `{"query": "{block {account(address: \"0x0000000000000000000000000000000000000dad\") {transactions {edges {roles node {index}}}}}}"}`,
+			want: `{"data":{"block":{"account":{"transactions":{"edges":[{"roles":["to"],"node":{"index":1}},{"roles":["to"],"node":{"index":0}}]}}}},"extensions":{"cost":142}}`,
+		},
+	} {
+		// The history is backfilled in the background after startup
+		var have string
+		for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+			if have = query(tt.body); have == tt.want {
+				break
+			}
+		}
+		if have != tt.want {
+			t.Errorf("testcase %d %s,\nhave:\n%v\nwant:\n%v", i, tt.body, have, tt.want)
+		}
+	}
+}
+
+// Tests that subscriptions are served over websocket using the graphql-ws protocol.
+func TestGraphQLSubscription(t *testing.T) {
+	stack := createNode(t, false, false)
+	defer stack.Close()
+	ethBackend := createGQLService(t, stack, Limits{})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(stack.HTTPEndpoint(), "http") + "/graphql"
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	read := func(want string) wsMessage {
+		for {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("could not read %q message: %v", want, err)
+			}
+			if msg.Type == want {
+				return msg
+			}
+			if msg.Type != wsKeepAliveMsg {
+				t.Fatalf("unexpected message: have %q, want %q (payload %s)", msg.Type, want, msg.Payload)
+			}
+		}
+	}
+	conn.WriteJSON(wsMessage{Type: wsConnectionInit})
+	read(wsConnectionAck)
+
+	conn.WriteJSON(wsMessage{ID: "1", Type: wsStart, Payload: json.RawMessage(`{"query": "subscription {newBlocks {number}}"}`)})
+	// Give the subscription time to be installed before importing a new block
+	time.Sleep(100 * time.Millisecond)
+	chain, _ := core.GenerateChain(params.AllEthashProtocolChanges, ethBackend.BlockChain().CurrentBlock(),
+		ethash.NewFaker(), ethBackend.ChainDb(), 1, func(i int, gen *core.BlockGen) {})
+	if _, err := ethBackend.BlockChain().InsertChain(chain); err != nil {
+		t.Fatalf("could not import block: %v", err)
+	}
+	msg := read(wsData)
+	if have, want := string(msg.Payload), `{"data":{"newBlocks":{"number":11}}}`; msg.ID != "1" || have != want {
+		t.Errorf("wrong data for operation %s: have %s, want %s", msg.ID, have, want)
+	}
+	conn.WriteJSON(wsMessage{ID: "1", Type: wsStop})
+	if msg := read(wsComplete); msg.ID != "1" {
+		t.Errorf("wrong operation completed: have %s, want 1", msg.ID)
+	}
+}
+
+// Tests that websocket upgrades are subject to the configured virtual hosts.
+func TestGraphQLSubscriptionVHosts(t *testing.T) {
+	stack := createNode(t, false, false)
+	defer stack.Close()
+	createGQLService(t, stack, Limits{})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	url := "ws" + strings.TrimPrefix(stack.HTTPEndpoint(), "http") + "/graphql"
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-ws"}}
+
+	conn, resp, err := dialer.Dial(url, http.Header{"Host": {"evil.example.com"}})
+	if err == nil {
+		conn.Close()
+		t.Fatal("upgrade with disallowed host succeeded")
+	}
+	if resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("wrong response for disallowed host: %v", err)
+	}
+	conn, _, err = dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("could not dial: %v", err)
+	}
+	conn.Close()
+}
+
+// Tests that queries exceeding the configured limits are rejected.
+func TestGraphQLLimits(t *testing.T) {
+	stack := createNode(t, false, false)
+	defer stack.Close()
+	createGQLService(t, stack, Limits{MaxCost: 20, MaxDepth: 3})
+	if err := stack.Start(); err != nil {
+		t.Fatalf("could not start node: %v", err)
+	}
+	for i, tt := range []struct {
+		body string
+		want string
+		code int
+	}{
+		{
+			body: `{"query": "{blocks(from: 1, to: 10) {number}}"}`,
+			want: `{"data":{"blocks":[{"number":1},{"number":2},{"number":3},{"number":4},{"number":5},{"number":6},{"number":7},{"number":8},{"number":9},{"number":10}]},"extensions":{"cost":20}}`,
+			code: 200,
+		},
+		{
+			body: `{"query": "{blocks(from: 0) {number}}"}`,
+			want: `{"errors":[{"message":"query cost 22 exceeds the limit of 20"}],"extensions":{"cost":22}}`,
+			code: 400,
+		},
+		{
+			body: `{"query": "{blocks(from: -1000000000, to: 5) {number}}"}`,
+			want: `{"errors":[{"message":"negative from argument -1000000000"}],"extensions":{"cost":0}}`,
+			code: 400,
+		},
+		{
+			body: `{"query": "query Q($from: Long!) {blocks(from: $from, to: 5) {number}}", "variables": {"from": "-2"}}`,
+			want: `{"errors":[{"message":"negative from argument -2"}],"extensions":{"cost":0}}`,
+			code: 400,
+		},
+		{
+			body: `{"query": "{block {parent {parent {number}}}}"}`,
+			want: `{"errors":[{"message":"Field \"number\" has depth 4 that exceeds max depth 3","locations":[{"line":1,"column":25}]}],"extensions":{"cost":4}}`,
+			code: 400,
+		},
+		{
+			body: `{"query": "{block {number}"}`,
+			want: `{"errors":[{"message":"syntax error: unexpected \"\", expecting Ident","locations":[{"line":1,"column":16}]}],"extensions":{"cost":0}}`,
+			code: 400,
+		},
+	} {
+		resp, err := http.Post(fmt.Sprintf("%s/graphql", stack.HTTPEndpoint()), "application/json", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("could not post: %v", err)
+		}
+		bodyBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("could not read from response body: %v", err)
+		}
+		if have := string(bodyBytes); have != tt.want {
+			t.Errorf("testcase %d %s,\nhave:\n%v\nwant:\n%v", i, tt.body, have, tt.want)
+		}
+		if tt.code != resp.StatusCode {
+			t.Errorf("testcase %d %s,\nwrong statuscode, have: %v, want: %v", i, tt.body, resp.StatusCode, tt.code)
+		}
+	}
+}
+
 // Tests that a graphQL request is not handled successfully when graphql is not enabled on the specified endpoint
 func TestGraphQLHTTPOnSamePort_GQLRequest_Unsuccessful(t *testing.T) {
 	stack := createNode(t, false, false)
@@ -227,14 +464,14 @@ func createNode(t *testing.T, gqlEnabled bool, txEnabled bool) *node.Node {
 		return stack
 	}
 	if !txEnabled {
-		createGQLService(t, stack)
+		createGQLService(t, stack, Limits{})
 	} else {
 		createGQLServiceWithTransactions(t, stack)
 	}
 	return stack
 }
 
-func createGQLService(t *testing.T, stack *node.Node) {
+func createGQLService(t *testing.T, stack *node.Node, limits Limits) *eth.Ethereum {
 	// create backend
 	ethConf := &ethconfig.Config{
 		Genesis: &core.Genesis{
@@ -265,10 +502,11 @@ func createGQLService(t *testing.T, stack *node.Node) {
 		t.Fatalf("could not create import blocks: %v", err)
 	}
 	// create gql service
-	err = New(stack, ethBackend.APIBackend, []string{}, []string{})
+	err = New(stack, ethBackend.APIBackend, []string{}, []string{}, limits)
 	if err != nil {
 		t.Fatalf("could not create graphql service: %v", err)
 	}
+	return ethBackend
 }
 
 func createGQLServiceWithTransactions(t *testing.T, stack *node.Node) {
@@ -308,6 +546,7 @@ func createGQLServiceWithTransactions(t *testing.T, stack *node.Node) {
 		TrieDirtyCache:          5,
 		TrieTimeout:             60 * time.Minute,
 		SnapshotCache:           5,
+		TxHistory:               true,
 	}
 
 	ethBackend, err := eth.New(stack, ethConf)
@@ -349,7 +588,7 @@ func createGQLServiceWithTransactions(t *testing.T, stack *node.Node) {
 		t.Fatalf("could not create import blocks: %v", err)
 	}
 	// create gql service
-	err = New(stack, ethBackend.APIBackend, []string{}, []string{})
+	err = New(stack, ethBackend.APIBackend, []string{}, []string{}, Limits{})
 	if err != nil {
 		t.Fatalf("could not create graphql service: %v", err)
 	}
@@ -16,7 +16,44 @@
 
 package graphql
 
+// schema is the schema served to queries and mutations over HTTP.
 const schema string = `
+    schema {
+        query: Query
+        mutation: Mutation
+    }
+` + schemaTypes
+
+// subscriptionSchema is the schema served to subscriptions over websocket. It
+// has its own root query type since graphql-go resolves every root operation
+// type on the same object, so the logs subscription and the logs query cannot
+// both be resolved by Resolver.
+const subscriptionSchema string = `
+    schema {
+        query: SubscriptionQuery
+        subscription: Subscription
+    }
+
+    # SubscriptionQuery is the query root of the websocket endpoint, which only
+    # serves subscriptions. Queries and mutations are served over HTTP.
+    type SubscriptionQuery {
+        # ChainID returns the current chain ID for transaction replay protection.
+        chainID: BigInt!
+    }
+
+    type Subscription {
+        # NewBlocks emits every block imported into the canonical chain.
+        newBlocks: Block!
+        # PendingTransactions emits every transaction entering the transaction pool.
+        pendingTransactions: Transaction!
+        # Logs emits the logs matching the filter as blocks are imported. Logs
+        # of blocks dropped in a reorg are emitted again with removed set.
+        logs(filter: FilterCriteria!): Log!
+    }
+` + schemaTypes
+
+// schemaTypes holds the types shared by schema and subscriptionSchema.
+const schemaTypes string = `
     # Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
     scalar Bytes32
     # Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
@@ -30,11 +67,8 @@ const schema string = `
     scalar BigInt
     # Long is a 64 bit unsigned integer.
     scalar Long
-
-    schema {
-        query: Query
-        mutation: Mutation
-    }
+    # JSON is an arbitrary JSON value, such as the output of a tracer.
+    scalar JSON
 
     # Account is an Ethereum account at a particular block.
     type Account {
@@ -52,6 +86,42 @@ const schema string = `
         # Storage provides access to the storage of a contract account, indexed
         # by its 32 byte slot identifier.
         storage(slot: Bytes32!): Bytes32!
+        # Transactions lists the transactions sent from or to this account,
+        # including the ones moving value to or from it in internal calls,
+        # newest first. It requires the node to maintain the address transaction
+        # history (--txhistory).
+        transactions(first: Int, after: String): TransactionConnection!
+    }
+
+    # TransactionConnection is a page of the transaction history of an account.
+    type TransactionConnection {
+        # Edges are the transactions on this page.
+        edges: [TransactionEdge!]!
+        # PageInfo describes how to continue with the next page.
+        pageInfo: PageInfo!
+    }
+
+    # TransactionEdge is a transaction in the history of an account.
+    type TransactionEdge {
+        # Cursor identifies the position of the transaction in the history.
+        cursor: String!
+        # Node is the transaction itself.
+        node: Transaction!
+        # Roles lists how the account took part in the transaction: "from",
+        # "to" and "internal".
+        roles: [String!]!
+        # Traced is false if the internal calls of the transaction could not
+        # be traced, in which case "internal" roles may be missing.
+        traced: Boolean!
+    }
+
+    # PageInfo describes the position of a page within a paginated list.
+    type PageInfo {
+        # HasNextPage is true if further items follow this page.
+        hasNextPage: Boolean!
+        # EndCursor is the cursor of the last item on this page, to be passed
+        # as the 'after' argument when requesting the next page.
+        endCursor: String
     }
 
     # Log is an Ethereum event log.
@@ -67,6 +137,9 @@ const schema string = `
         data: Bytes!
         # Transaction is the transaction that generated this log entry.
         transaction: Transaction!
+        # Removed is true if the log was reverted by a chain reorganisation.
+        # It is only ever set on logs emitted by the logs subscription.
+        removed: Boolean!
     }
 
     #EIP-2718 
@@ -127,6 +200,19 @@ const schema string = `
         #Envelope transaction support
         type: Int
         accessList: [AccessTuple!]
+        # MaxFeePerGas is the maximum price per gas the sender is willing to pay,
+        # in wei. It is null for transactions predating EIP-1559.
+        maxFeePerGas: BigInt
+        # MaxPriorityFeePerGas is the maximum tip per gas the sender is willing
+        # to pay the miner, in wei. It is null for transactions predating EIP-1559.
+        maxPriorityFeePerGas: BigInt
+        # EffectiveGasPrice is the price per gas actually paid, in wei. If the
+        # transaction has not yet been mined, this field will be null.
+        effectiveGasPrice: BigInt
+        # Trace is the result of running the named built-in tracer, such as
+        # callTracer, over the transaction. If the transaction has not yet been
+        # mined, this field will be null.
+        trace(tracer: String!): JSON
     }
 
     # BlockFilterCriteria encapsulates log filter criteria for a filter applied
@@ -176,6 +262,9 @@ const schema string = `
         gasLimit: Long!
         # GasUsed is the amount of gas that was used executing transactions in this block.
         gasUsed: Long!
+        # BaseFeePerGas is the protocol fee per gas burned by transactions in this
+        # block, in wei. It is null for blocks predating EIP-1559.
+        baseFeePerGas: BigInt
         # Timestamp is the unix timestamp at which this block was mined.
         timestamp: Long!
         # LogsBloom is a bloom filter that can be used to check if a block may
@@ -17,16 +17,67 @@
 package graphql
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/gorilla/websocket"
 	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
 )
 
+// Limits bounds the work a single GraphQL request may cause.
+type Limits struct {
+	MaxCost  uint64        // Maximum estimated cost of a query (0 = unlimited)
+	MaxDepth int           // Maximum selection nesting depth of a query (0 = unlimited)
+	Timeout  time.Duration // Maximum execution time of a request (0 = unlimited)
+}
+
+// costLimiter estimates the cost of queries and rejects the ones exceeding the
+// configured maximum.
+type costLimiter struct {
+	schema  *graphql.Schema
+	costs   *costSchema
+	backend ethapi.Backend
+	maxCost uint64
+}
+
+func newCostLimiter(schema *graphql.Schema, backend ethapi.Backend, maxCost uint64) *costLimiter {
+	return &costLimiter{
+		schema:  schema,
+		costs:   newCostSchema(schema),
+		backend: backend,
+		maxCost: maxCost,
+	}
+}
+
+// check returns the estimated cost of a query, or the errors to reject it with.
+func (l *costLimiter) check(query string, operationName string, variables map[string]interface{}) (uint64, []*errors.QueryError) {
+	var head uint64
+	if header := l.backend.CurrentHeader(); header != nil {
+		head = header.Number.Uint64()
+	}
+	cost, err := l.costs.queryCost(query, operationName, variables, head)
+	if err != nil {
+		// Prefer the errors graphql-go reports for invalid queries
+		if errs := l.schema.ValidateWithVariables(query, variables); len(errs) > 0 {
+			return 0, errs
+		}
+		return 0, []*errors.QueryError{errors.Errorf("%v", err)}
+	}
+	if l.maxCost > 0 && cost > l.maxCost {
+		return cost, []*errors.QueryError{errors.Errorf("query cost %d exceeds the limit of %d", cost, l.maxCost)}
+	}
+	return cost, nil
+}
+
 type handler struct {
-	Schema *graphql.Schema
+	Schema  *graphql.Schema
+	limiter *costLimiter
+	timeout time.Duration
 }
 
 func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -39,8 +90,21 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	ctx := r.Context()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	var response *graphql.Response
+	cost, errs := h.limiter.check(params.Query, params.OperationName, params.Variables)
+	if len(errs) > 0 {
+		response = &graphql.Response{Errors: errs}
+	} else {
+		response = h.Schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+	}
+	response.Extensions = map[string]interface{}{"cost": cost}
 
-	response := h.Schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
 	responseJSON, err := json.Marshal(response)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -56,25 +120,50 @@ func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // New constructs a new GraphQL service instance.
-func New(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error {
+func New(stack *node.Node, backend ethapi.Backend, cors, vhosts []string, limits Limits) error {
 	if backend == nil {
 		panic("missing backend")
 	}
 	// check if http server with given endpoint exists and enable graphQL on it
-	return newHandler(stack, backend, cors, vhosts)
+	return newHandler(stack, backend, cors, vhosts, limits)
 }
 
 // newHandler returns a new `http.Handler` that will answer GraphQL queries.
+// Websocket upgrades are served subscriptions using the graphql-ws protocol.
 // It additionally exports an interactive query browser on the / endpoint.
-func newHandler(stack *node.Node, backend ethapi.Backend, cors, vhosts []string) error {
+func newHandler(stack *node.Node, backend ethapi.Backend, cors, vhosts []string, limits Limits) error {
 	q := Resolver{backend}
 
-	s, err := graphql.ParseSchema(schema, &q)
+	var opts []graphql.SchemaOpt
+	if limits.MaxDepth > 0 {
+		opts = append(opts, graphql.MaxDepth(limits.MaxDepth))
+	}
+	s, err := graphql.ParseSchema(schema, &q, opts...)
 	if err != nil {
 		return err
 	}
-	h := handler{Schema: s}
-	handler := node.NewHTTPHandlerStack(h, cors, vhosts)
+	ss, err := graphql.ParseSchema(subscriptionSchema, &SubscriptionResolver{backend: backend}, opts...)
+	if err != nil {
+		return err
+	}
+	h := handler{
+		Schema:  s,
+		limiter: newCostLimiter(s, backend, limits.MaxCost),
+		timeout: limits.Timeout,
+	}
+	var (
+		httpHandler = node.NewHTTPHandlerStack(h, cors, vhosts)
+		wsHandler   = node.NewWSHandlerStack(newWSHandler(ss, newCostLimiter(ss, backend, limits.MaxCost), cors), vhosts)
+	)
+	// Websocket upgrades are served by their own handler stack, the gzip writer
+	// of the HTTP one can't be hijacked.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			wsHandler.ServeHTTP(w, r)
+			return
+		}
+		httpHandler.ServeHTTP(w, r)
+	})
 
 	stack.RegisterHandler("GraphQL UI", "/graphql/ui", GraphiQL{})
 	stack.RegisterHandler("GraphQL", "/graphql", handler)
@@ -0,0 +1,290 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+)
+
+const (
+	wsProtocol         = "graphql-ws"
+	wsReadBuffer       = 1024
+	wsWriteBuffer      = 1024
+	wsMessageSizeLimit = 1024 * 1024
+	wsWriteTimeout     = 10 * time.Second
+	wsKeepAlive        = 30 * time.Second
+)
+
+// Message types of the graphql-ws protocol, as implemented by Apollo's
+// subscriptions-transport-ws.
+const (
+	wsConnectionInit      = "connection_init"      // client -> server
+	wsConnectionTerminate = "connection_terminate" // client -> server
+	wsStart               = "start"                // client -> server
+	wsStop                = "stop"                 // client -> server
+	wsConnectionAck       = "connection_ack"       // server -> client
+	wsConnectionError     = "connection_error"     // server -> client
+	wsKeepAliveMsg        = "ka"                   // server -> client
+	wsData                = "data"                 // server -> client
+	wsError               = "error"                // server -> client
+	wsComplete            = "complete"             // server -> client
+)
+
+// wsMessage is the envelope of every graphql-ws message.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// wsHandler serves subscriptions over websocket using the graphql-ws protocol.
+type wsHandler struct {
+	schema   *graphql.Schema
+	limiter  *costLimiter
+	upgrader websocket.Upgrader
+}
+
+func newWSHandler(schema *graphql.Schema, limiter *costLimiter, allowedOrigins []string) *wsHandler {
+	return &wsHandler{
+		schema:  schema,
+		limiter: limiter,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  wsReadBuffer,
+			WriteBufferSize: wsWriteBuffer,
+			Subprotocols:    []string{wsProtocol},
+			CheckOrigin:     wsOriginValidator(allowedOrigins),
+		},
+	}
+}
+
+// wsOriginValidator returns the origin check of the websocket upgrade. Browsers
+// are held to the same origins as the CORS configuration of the HTTP endpoint;
+// requests without an Origin header don't come from a browser and are allowed.
+func wsOriginValidator(allowedOrigins []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		log.Warn("Rejected GraphQL websocket connection", "origin", origin)
+		return false
+	}
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("GraphQL websocket upgrade failed", "err", err)
+		return
+	}
+	if conn.Subprotocol() != wsProtocol {
+		msg := websocket.FormatCloseMessage(websocket.CloseProtocolError, "unsupported subprotocol, expected "+wsProtocol)
+		conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+		conn.Close()
+		return
+	}
+	newWSConn(h.schema, h.limiter, conn).run()
+}
+
+// wsConn is a single graphql-ws connection, multiplexing any number of
+// operations started by the client.
+type wsConn struct {
+	schema  *graphql.Schema
+	limiter *costLimiter
+	conn    *websocket.Conn
+
+	writeLock sync.Mutex // serialises writes to conn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	opLock sync.Mutex
+	ops    map[string]context.CancelFunc // running operations by client id
+	wg     sync.WaitGroup
+}
+
+func newWSConn(schema *graphql.Schema, limiter *costLimiter, conn *websocket.Conn) *wsConn {
+	ctx, cancel := context.WithCancel(context.Background())
+	conn.SetReadLimit(wsMessageSizeLimit)
+	return &wsConn{
+		schema:  schema,
+		limiter: limiter,
+		conn:    conn,
+		ctx:     ctx,
+		cancel:  cancel,
+		ops:     make(map[string]context.CancelFunc),
+	}
+}
+
+// run reads and handles client messages until the connection is terminated,
+// then stops all operations still running.
+func (c *wsConn) run() {
+	defer func() {
+		c.cancel()
+		c.wg.Wait()
+		c.conn.Close()
+	}()
+	var initialised bool
+	for {
+		var msg wsMessage
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			log.Debug("GraphQL websocket read failed", "err", err)
+			return
+		}
+		switch msg.Type {
+		case wsConnectionInit:
+			if initialised {
+				continue
+			}
+			initialised = true
+			c.write(wsMessage{Type: wsConnectionAck})
+			c.write(wsMessage{Type: wsKeepAliveMsg})
+			c.wg.Add(1)
+			go c.keepAlive()
+
+		case wsStart:
+			if !initialised {
+				c.writeError(wsConnectionError, "", errors.New("connection not initialised"))
+				return
+			}
+			c.start(msg.ID, msg.Payload)
+
+		case wsStop:
+			c.opLock.Lock()
+			if cancel, ok := c.ops[msg.ID]; ok {
+				cancel()
+			}
+			c.opLock.Unlock()
+
+		case wsConnectionTerminate:
+			return
+
+		default:
+			c.writeError(wsError, msg.ID, errors.New("unknown message type "+msg.Type))
+		}
+	}
+}
+
+// start runs the operation of a start message, streaming its responses to the
+// client until it completes or is stopped.
+func (c *wsConn) start(id string, payload json.RawMessage) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	if err := json.Unmarshal(payload, &params); err != nil {
+		c.writeError(wsError, id, err)
+		return
+	}
+	if _, errs := c.limiter.check(params.Query, params.OperationName, params.Variables); len(errs) > 0 {
+		// Rejected like invalid queries, with a single response
+		payload, _ := json.Marshal(&graphql.Response{Errors: errs})
+		c.write(wsMessage{ID: id, Type: wsData, Payload: payload})
+		c.write(wsMessage{ID: id, Type: wsComplete})
+		return
+	}
+	c.opLock.Lock()
+	defer c.opLock.Unlock()
+
+	if _, ok := c.ops[id]; ok {
+		c.writeError(wsError, id, errors.New("duplicate operation id "+id))
+		return
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	responses, err := c.schema.Subscribe(ctx, params.Query, params.OperationName, params.Variables)
+	if err != nil {
+		cancel()
+		c.writeError(wsError, id, err)
+		return
+	}
+	c.ops[id] = cancel
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		for response := range responses {
+			data, err := json.Marshal(response)
+			if err != nil {
+				log.Warn("Failed to encode GraphQL subscription response", "err", err)
+				continue
+			}
+			c.write(wsMessage{ID: id, Type: wsData, Payload: data})
+		}
+		c.opLock.Lock()
+		delete(c.ops, id)
+		c.opLock.Unlock()
+		cancel()
+
+		// Let the client know the operation is done, unless the whole
+		// connection is going away.
+		if c.ctx.Err() == nil {
+			c.write(wsMessage{ID: id, Type: wsComplete})
+		}
+	}()
+}
+
+// keepAlive periodically sends keep-alive messages until the connection is
+// closed.
+func (c *wsConn) keepAlive() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(wsKeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.write(wsMessage{Type: wsKeepAliveMsg})
+		case <-c.ctx.Done():
+			return
+		}
+	}
+}
+
+// writeError sends an error message of the given type to the client.
+func (c *wsConn) writeError(typ string, id string, err error) {
+	payload, _ := json.Marshal(map[string]string{"message": err.Error()})
+	c.write(wsMessage{ID: id, Type: typ, Payload: payload})
+}
+
+// write sends a message to the client. Failures are only logged, the read loop
+// notices broken connections.
+func (c *wsConn) write(msg wsMessage) {
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := c.conn.WriteJSON(msg); err != nil {
+		log.Debug("GraphQL websocket write failed", "err", err)
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txhistory"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -1478,6 +1479,73 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByHash(ctx context.Context,
 	return tx.MarshalBinary()
 }
 
+const (
+	defaultTxHistoryLimit = 100  // Page size of the address transaction history if none is requested
+	maxTxHistoryLimit     = 1000 // Maximum page size of the address transaction history
+)
+
+// AddressTransaction is a transaction in the history of an address, along with
+// the roles the address played in it.
+type AddressTransaction struct {
+	*RPCTransaction
+	Roles  []string `json:"roles"`
+	Traced bool     `json:"traced"`
+	Cursor string   `json:"cursor"`
+}
+
+// AddressTransactionsPage is a page of the transaction history of an address.
+type AddressTransactionsPage struct {
+	Transactions []*AddressTransaction `json:"transactions"`
+	Next         *string               `json:"next"`
+}
+
+// GetTransactionsByAddress returns the transactions sent from or to the given
+// address, including the ones moving value to or from it in internal calls,
+// newest first. Pages are continued by passing the returned next cursor. Internal
+// transfers are missing from transactions which are not marked as traced.
+func (s *PublicTransactionPoolAPI) GetTransactionsByAddress(ctx context.Context, address common.Address, after *string, limit *hexutil.Uint64) (*AddressTransactionsPage, error) {
+	size := defaultTxHistoryLimit
+	if limit != nil {
+		if *limit == 0 || *limit > maxTxHistoryLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", maxTxHistoryLimit)
+		}
+		size = int(*limit)
+	}
+	var cursor string
+	if after != nil {
+		cursor = *after
+	}
+	entries, more, err := s.b.TxHistory(ctx, address, cursor, size)
+	if err != nil {
+		return nil, err
+	}
+	page := &AddressTransactionsPage{Transactions: make([]*AddressTransaction, 0, len(entries))}
+	blocks := make(map[common.Hash]*types.Block)
+	for _, entry := range entries {
+		block, ok := blocks[entry.BlockHash]
+		if !ok {
+			if block, err = s.b.BlockByHash(ctx, entry.BlockHash); err != nil {
+				return nil, err
+			}
+			if block == nil {
+				return nil, fmt.Errorf("block %#x not found", entry.BlockHash)
+			}
+			blocks[entry.BlockHash] = block
+		}
+		page.Transactions = append(page.Transactions, &AddressTransaction{
+			RPCTransaction: newRPCTransactionFromBlockIndex(block, uint64(entry.TxIndex)),
+			Roles:          txhistory.RoleNames(entry.Roles),
+			Traced:         entry.Traced,
+			Cursor:         entry.Cursor(),
+		})
+	}
+	if more {
+		next := entries[len(entries)-1].Cursor()
+		page.Next = &next
+	}
+	return page, nil
+}
+
 // GetTransactionReceipt returns the transaction receipt for the given transaction hash.
 func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, hash common.Hash) (map[string]interface{}, error) {
 	tx, blockHash, blockNumber, index, err := s.b.GetTransaction(ctx, hash)
@@ -0,0 +1,130 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package testproof provides an untrusted eth namespace serving Merkle proofs of
+// a state, for testing the clients verifying them.
+package testproof
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChainID is the chain id reported by eth_chainId.
+const ChainID = 1337
+
+// API serves eth_getProof and eth_getCode from a state, optionally tampering
+// with the results. It is meant to be registered in the eth namespace.
+type API struct {
+	State  *state.StateDB
+	Block  common.Hash // If set, requests for other blocks are rejected
+	Tamper bool        // Whether to report wrong balances, storage values and code
+}
+
+// AccountResult is the result of eth_getProof.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the proof of a storage slot in the result of eth_getProof.
+type StorageResult struct {
+	Key   string          `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+func toBytes(nodes [][]byte) []hexutil.Bytes {
+	out := make([]hexutil.Bytes, len(nodes))
+	for i, n := range nodes {
+		out[i] = n
+	}
+	return out
+}
+
+// ChainId implements eth_chainId.
+func (api *API) ChainId() hexutil.Uint64 {
+	return ChainID
+}
+
+// GetProof implements eth_getProof.
+func (api *API) GetProof(addr common.Address, keys []string, block common.Hash) (*AccountResult, error) {
+	if api.Block != (common.Hash{}) && block != api.Block {
+		return nil, errors.New("unknown block")
+	}
+	proof, err := api.State.GetProof(addr)
+	if err != nil {
+		return nil, err
+	}
+	result := &AccountResult{
+		Address:      addr,
+		AccountProof: toBytes(proof),
+		Balance:      (*hexutil.Big)(api.State.GetBalance(addr)),
+		CodeHash:     api.State.GetCodeHash(addr),
+		Nonce:        hexutil.Uint64(api.State.GetNonce(addr)),
+		StorageHash:  types.EmptyRootHash,
+	}
+	// Mirror eth_getProof, which reports the empty code hash and storage
+	// for accounts which don't exist.
+	tr := api.State.StorageTrie(addr)
+	if tr != nil {
+		result.StorageHash = tr.Hash()
+	} else {
+		result.CodeHash = crypto.Keccak256Hash(nil)
+	}
+	for _, key := range keys {
+		slot := common.HexToHash(key)
+		if tr == nil {
+			result.StorageProof = append(result.StorageProof, StorageResult{Key: key, Value: new(hexutil.Big)})
+			continue
+		}
+		proof, err := api.State.GetStorageProof(addr, slot)
+		if err != nil {
+			return nil, err
+		}
+		result.StorageProof = append(result.StorageProof, StorageResult{
+			Key:   key,
+			Value: (*hexutil.Big)(api.State.GetState(addr, slot).Big()),
+			Proof: toBytes(proof),
+		})
+	}
+	if api.Tamper {
+		result.Balance = (*hexutil.Big)(big.NewInt(1))
+		for i := range result.StorageProof {
+			result.StorageProof[i].Value = (*hexutil.Big)(big.NewInt(1))
+		}
+	}
+	return result, nil
+}
+
+// GetCode implements eth_getCode.
+func (api *API) GetCode(addr common.Address, block common.Hash) hexutil.Bytes {
+	if api.Tamper {
+		return []byte{0x00}
+	}
+	return api.State.GetCode(addr)
+}
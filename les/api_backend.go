@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/txhistory"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/eth/downloader"
@@ -287,6 +288,15 @@ func (b *LesApiBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocksClient, sections
 }
 
+// LogIndex returns nil as light clients don't maintain a log index.
+func (b *LesApiBackend) LogIndex() *core.LogIndex {
+	return nil
+}
+
+func (b *LesApiBackend) TxHistory(ctx context.Context, address common.Address, after string, limit int) ([]txhistory.Entry, bool, error) {
+	return nil, false, errors.New("address transaction history not available in light mode")
+}
+
 func (b *LesApiBackend) ServiceFilter(ctx context.Context, session *bloombits.MatcherSession) {
 	for i := 0; i < bloomFilterThreads; i++ {
 		go session.Multiplex(bloomRetrievalBatch, bloomRetrievalWait, b.eth.bloomRequests)
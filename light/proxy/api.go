@@ -0,0 +1,205 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	beacon "github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// verifiedMethods are the methods answered by the proxy itself. All other
+// methods are forwarded to the upstream node unchanged.
+var verifiedMethods = map[string]bool{
+	"eth_getBalance":   true,
+	"eth_getStorageAt": true,
+	"eth_getCode":      true,
+	"eth_call":         true,
+}
+
+// ethAPI implements the verified methods of the eth namespace.
+type ethAPI struct {
+	p *Proxy
+}
+
+// header resolves a block reference to a trusted header.
+func (api *ethAPI) header(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) (*Header, error) {
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		return api.p.headers.HeaderByHash(ctx, hash)
+	}
+	if number, ok := blockNrOrHash.Number(); ok {
+		return api.p.headers.HeaderByNumber(ctx, number)
+	}
+	return nil, errors.New("invalid arguments; neither block nor hash specified")
+}
+
+// proof fetches a verified proof of an account and storage slots.
+func (api *ethAPI) proof(ctx context.Context, address common.Address, keys []common.Hash, blockNrOrHash rpc.BlockNumberOrHash) (*beacon.AccountProof, *Header, error) {
+	header, err := api.header(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, _, err := beacon.FetchProof(ctx, api.p.upstream, header.Hash, header.Root, address, keys)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unverifiable result from upstream: %v", err)
+	}
+	return proof, header, nil
+}
+
+// GetBalance returns the proven balance of an account.
+func (api *ethAPI) GetBalance(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (*hexutil.Big, error) {
+	proof, _, err := api.proof(ctx, address, nil, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Balance, nil
+}
+
+// GetStorageAt returns the proven value of a storage slot.
+func (api *ethAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	slot := common.HexToHash(key)
+	proof, _, err := api.proof(ctx, address, []common.Hash{slot}, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	return common.BigToHash(proof.StorageProof[0].Value.ToInt()).Bytes(), nil
+}
+
+// GetCode returns the code of an account, checked against its proven code hash.
+func (api *ethAPI) GetCode(ctx context.Context, address common.Address, blockNrOrHash rpc.BlockNumberOrHash) (hexutil.Bytes, error) {
+	proof, header, err := api.proof(ctx, address, nil, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	var code hexutil.Bytes
+	if err := api.p.upstream.CallContext(ctx, &code, "eth_getCode", address, header.Hash); err != nil {
+		return nil, err
+	}
+	if hash := crypto.Keccak256Hash(code); hash != proof.CodeHash {
+		return nil, fmt.Errorf("unverifiable result from upstream: code hash mismatch: have %x, proven %x", hash, proof.CodeHash)
+	}
+	return code, nil
+}
+
+// Call executes a message call locally. All state accessed by the call is
+// fetched from the upstream node and verified.
+func (api *ethAPI) Call(ctx context.Context, args ethapi.TransactionArgs, blockNrOrHash rpc.BlockNumberOrHash, overrides *ethapi.StateOverride) (hexutil.Bytes, error) {
+	header, err := api.header(ctx, blockNrOrHash)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, api.p.config.CallTimeout)
+	defer cancel()
+
+	db := newProofDatabase(ctx, api.p.upstream, header)
+	statedb, err := state.New(header.Root, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := overrides.Apply(statedb); err != nil {
+		return nil, err
+	}
+	msg := args.ToMessage(api.p.config.GasCap)
+	blockCtx := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     api.getHashFn(ctx, header),
+		Coinbase:    header.Coinbase,
+		BlockNumber: new(big.Int).SetUint64(header.Number),
+		Time:        new(big.Int).SetUint64(header.Time),
+		Difficulty:  new(big.Int).Set(header.Difficulty),
+		GasLimit:    header.GasLimit,
+	}
+	if header.BaseFee != nil {
+		blockCtx.BaseFee = new(big.Int).Set(header.BaseFee)
+	}
+	evm := vm.NewEVM(blockCtx, core.NewEVMTxContext(msg), statedb, api.p.config.ChainConfig, vm.Config{})
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+
+	result, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if dbErr := db.Error(); dbErr != nil {
+		return nil, fmt.Errorf("unverifiable state access: %v", dbErr)
+	}
+	if evm.Cancelled() {
+		return nil, fmt.Errorf("execution aborted (timeout = %v)", api.p.config.CallTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("err: %w (supplied gas %d)", err, msg.Gas())
+	}
+	if len(result.Revert()) > 0 {
+		return nil, newRevertError(result)
+	}
+	return result.Return(), result.Err
+}
+
+// getHashFn returns the BLOCKHASH implementation of calls. Only the hashes of
+// trusted headers are available, all other hashes are zero.
+func (api *ethAPI) getHashFn(ctx context.Context, header *Header) vm.GetHashFunc {
+	return func(n uint64) common.Hash {
+		if n+1 == header.Number {
+			return header.ParentHash
+		}
+		if h, err := api.p.headers.HeaderByNumber(ctx, rpc.BlockNumber(n)); err == nil {
+			return h.Hash
+		}
+		return common.Hash{}
+	}
+}
+
+// revertError is the error of a reverted call, see ethapi.
+type revertError struct {
+	error
+	reason string // revert reason hex encoded
+}
+
+func newRevertError(result *core.ExecutionResult) *revertError {
+	reason, errUnpack := abi.UnpackRevert(result.Revert())
+	err := errors.New("execution reverted")
+	if errUnpack == nil {
+		err = fmt.Errorf("execution reverted: %v", reason)
+	}
+	return &revertError{
+		error:  err,
+		reason: hexutil.Encode(result.Revert()),
+	}
+}
+
+// ErrorCode returns the JSON error code for a revertal.
+func (e *revertError) ErrorCode() int {
+	return 3
+}
+
+// ErrorData returns the hex encoded revert reason.
+func (e *revertError) ErrorData() interface{} {
+	return e.reason
+}
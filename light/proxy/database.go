@@ -0,0 +1,240 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	beacon "github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	errReadOnly       = errors.New("proof-backed state is read-only")
+	errNotSupported   = errors.New("not supported by proof-backed state")
+	emptyCodeHash     = crypto.Keccak256Hash(nil)
+	errUnknownAccount = errors.New("storage trie of unknown account")
+)
+
+// proofDatabase is a state.Database which retrieves accounts, storage slots
+// and code of a single block from an untrusted node. Accounts and storage are
+// fetched with eth_getProof and verified against the trusted state root, code
+// is checked against the proven code hash.
+//
+// The state tries are only accessed by key, so the database only supports
+// reading single values and can't iterate the state.
+type proofDatabase struct {
+	ctx    context.Context
+	client *rpc.Client
+	header *Header
+
+	lock      sync.Mutex
+	addresses map[common.Hash]common.Address // preimages of accessed account keys
+	err       error                          // first retrieval error
+}
+
+func newProofDatabase(ctx context.Context, client *rpc.Client, header *Header) *proofDatabase {
+	return &proofDatabase{
+		ctx:       ctx,
+		client:    client,
+		header:    header,
+		addresses: make(map[common.Hash]common.Address),
+	}
+}
+
+// proof fetches and verifies the proof of an account and storage slots.
+func (db *proofDatabase) proof(address common.Address, keys []common.Hash) (*beacon.AccountProof, *state.Account, error) {
+	db.lock.Lock()
+	db.addresses[crypto.Keccak256Hash(address[:])] = address
+	db.lock.Unlock()
+
+	proof, account, err := beacon.FetchProof(db.ctx, db.client, db.header.Hash, db.header.Root, address, keys)
+	return proof, account, db.setError(err)
+}
+
+// setError records the first error of the database and returns err. Errors
+// of state reads are not passed through by state.StateDB, so they have to be
+// checked with Error once the state is no longer used.
+func (db *proofDatabase) setError(err error) error {
+	if err != nil {
+		db.lock.Lock()
+		if db.err == nil {
+			db.err = err
+		}
+		db.lock.Unlock()
+	}
+	return err
+}
+
+// Error returns the first error which occurred while retrieving state.
+func (db *proofDatabase) Error() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	return db.err
+}
+
+// address returns the address of a previously accessed account.
+func (db *proofDatabase) address(addrHash common.Hash) (common.Address, error) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	address, ok := db.addresses[addrHash]
+	if !ok {
+		if db.err == nil {
+			db.err = errUnknownAccount
+		}
+		return common.Address{}, errUnknownAccount
+	}
+	return address, nil
+}
+
+// OpenTrie implements state.Database.
+func (db *proofDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	if root != db.header.Root {
+		return nil, fmt.Errorf("state root %x doesn't match trusted root %x", root, db.header.Root)
+	}
+	return &proofTrie{db: db, root: root}, nil
+}
+
+// OpenStorageTrie implements state.Database.
+func (db *proofDatabase) OpenStorageTrie(addrHash, root common.Hash) (state.Trie, error) {
+	address, err := db.address(addrHash)
+	if err != nil {
+		return nil, err
+	}
+	return &proofTrie{db: db, root: root, account: &address}, nil
+}
+
+// CopyTrie implements state.Database. Proof tries are immutable, so they
+// don't need to be copied.
+func (db *proofDatabase) CopyTrie(t state.Trie) state.Trie {
+	return t
+}
+
+// ContractCode implements state.Database.
+func (db *proofDatabase) ContractCode(addrHash, codeHash common.Hash) ([]byte, error) {
+	if codeHash == emptyCodeHash {
+		return nil, nil
+	}
+	address, err := db.address(addrHash)
+	if err != nil {
+		return nil, err
+	}
+	var code hexutil.Bytes
+	if err := db.client.CallContext(db.ctx, &code, "eth_getCode", address, db.header.Hash); err != nil {
+		return nil, db.setError(err)
+	}
+	if hash := crypto.Keccak256Hash(code); hash != codeHash {
+		return nil, db.setError(fmt.Errorf("code hash mismatch for %x: have %x, proven %x", address, hash, codeHash))
+	}
+	return code, nil
+}
+
+// ContractCodeSize implements state.Database.
+func (db *proofDatabase) ContractCodeSize(addrHash, codeHash common.Hash) (int, error) {
+	code, err := db.ContractCode(addrHash, codeHash)
+	return len(code), err
+}
+
+// TrieDB implements state.Database.
+func (db *proofDatabase) TrieDB() *trie.Database {
+	return nil
+}
+
+// proofTrie is the account trie or a storage trie of a proofDatabase.
+type proofTrie struct {
+	db      *proofDatabase
+	root    common.Hash
+	account *common.Address // nil for the account trie
+}
+
+// TryGet implements state.Trie. The key is an address for the account trie
+// and a storage slot for storage tries.
+func (t *proofTrie) TryGet(key []byte) ([]byte, error) {
+	if t.account == nil {
+		_, account, err := t.db.proof(common.BytesToAddress(key), nil)
+		if err != nil || account == nil {
+			return nil, err
+		}
+		return rlp.EncodeToBytes(account)
+	}
+	if t.root == types.EmptyRootHash {
+		return nil, nil
+	}
+	slot := common.BytesToHash(key)
+	proof, account, err := t.db.proof(*t.account, []common.Hash{slot})
+	if err != nil {
+		return nil, err
+	}
+	if account == nil || account.Root != t.root {
+		return nil, t.db.setError(fmt.Errorf("storage root of %x changed", *t.account))
+	}
+	value := proof.StorageProof[0].Value.ToInt()
+	if value.Sign() == 0 {
+		return nil, nil
+	}
+	return rlp.EncodeToBytes(value.Bytes())
+}
+
+// TryUpdate implements state.Trie.
+func (t *proofTrie) TryUpdate(key, value []byte) error {
+	return errReadOnly
+}
+
+// TryDelete implements state.Trie.
+func (t *proofTrie) TryDelete(key []byte) error {
+	return errReadOnly
+}
+
+// Hash implements state.Trie.
+func (t *proofTrie) Hash() common.Hash {
+	return t.root
+}
+
+// Commit implements state.Trie.
+func (t *proofTrie) Commit(onleaf trie.LeafCallback) (common.Hash, error) {
+	return t.root, nil
+}
+
+// NodeIterator implements state.Trie. Proof tries can't be iterated, the
+// returned iterator is empty.
+func (t *proofTrie) NodeIterator(startKey []byte) trie.NodeIterator {
+	empty, _ := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	return empty.NodeIterator(startKey)
+}
+
+// GetKey implements state.Trie.
+func (t *proofTrie) GetKey(sha []byte) []byte {
+	return nil
+}
+
+// Prove implements state.Trie.
+func (t *proofTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.KeyValueWriter) error {
+	return errNotSupported
+}
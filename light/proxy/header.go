@@ -0,0 +1,206 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	beacon "github.com/ethereum/go-ethereum/beacon/light"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// errUnknownBlock is returned by header sources for blocks they can't vouch for.
+var errUnknownBlock = errors.New("block not available from trusted header source")
+
+// Header contains the fields of an execution block header the proxy needs to
+// verify state and execute calls.
+type Header struct {
+	Number     uint64
+	Hash       common.Hash
+	ParentHash common.Hash
+	Root       common.Hash
+	Coinbase   common.Address
+	Time       uint64
+	GasLimit   uint64
+	BaseFee    *big.Int // nil before London
+	Difficulty *big.Int // the RANDAO mix after the merge
+}
+
+// HeaderSource provides trusted block headers. All results of the proxy are
+// verified against the state roots of these headers.
+type HeaderSource interface {
+	// HeaderByNumber returns the header of the given block. The latest and
+	// pending block numbers both refer to the latest trusted header.
+	HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*Header, error)
+
+	// HeaderByHash returns the header of the block with the given hash.
+	HeaderByHash(ctx context.Context, hash common.Hash) (*Header, error)
+}
+
+// BeaconHeaderSource provides the execution headers proven by a beacon chain
+// light client. Only the latest and the latest finalized block are available.
+type BeaconHeaderSource struct {
+	client *beacon.Client
+}
+
+// NewBeaconHeaderSource creates a header source backed by a beacon light client.
+func NewBeaconHeaderSource(client *beacon.Client) *BeaconHeaderSource {
+	return &BeaconHeaderSource{client: client}
+}
+
+func (s *BeaconHeaderSource) headers() []*beacon.ExecutionHeader {
+	var headers []*beacon.ExecutionHeader
+	for _, h := range []*beacon.ExecutionHeader{s.client.Head(), s.client.Finalized()} {
+		if h != nil {
+			headers = append(headers, h)
+		}
+	}
+	return headers
+}
+
+// HeaderByNumber implements HeaderSource.
+func (s *BeaconHeaderSource) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*Header, error) {
+	headers := s.headers()
+	if len(headers) == 0 {
+		return nil, errUnknownBlock
+	}
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return beaconHeader(headers[0]), nil
+	}
+	for _, h := range headers {
+		if number >= 0 && h.BlockNumber == uint64(number) {
+			return beaconHeader(h), nil
+		}
+	}
+	return nil, errUnknownBlock
+}
+
+// HeaderByHash implements HeaderSource.
+func (s *BeaconHeaderSource) HeaderByHash(ctx context.Context, hash common.Hash) (*Header, error) {
+	for _, h := range s.headers() {
+		if h.BlockHash == hash {
+			return beaconHeader(h), nil
+		}
+	}
+	return nil, errUnknownBlock
+}
+
+func beaconHeader(h *beacon.ExecutionHeader) *Header {
+	return &Header{
+		Number:     h.BlockNumber,
+		Hash:       h.BlockHash,
+		ParentHash: h.ParentHash,
+		Root:       h.StateRoot,
+		Coinbase:   h.FeeRecipient,
+		Time:       h.Timestamp,
+		GasLimit:   h.GasLimit,
+		BaseFee:    h.BaseFeePerGas,
+		Difficulty: new(big.Int).SetBytes(h.PrevRandao[:]),
+	}
+}
+
+// RPCHeaderSource fetches headers from a trusted node, for example a local
+// node whose state is pruned or which doesn't expose its RPC API publicly.
+type RPCHeaderSource struct {
+	client *rpc.Client
+}
+
+// NewRPCHeaderSource creates a header source using a trusted RPC endpoint.
+func NewRPCHeaderSource(client *rpc.Client) *RPCHeaderSource {
+	return &RPCHeaderSource{client: client}
+}
+
+// rpcHeader is the subset of the block fields returned by eth_getBlockBy*.
+type rpcHeader struct {
+	Number     hexutil.Uint64 `json:"number"`
+	Hash       common.Hash    `json:"hash"`
+	ParentHash common.Hash    `json:"parentHash"`
+	Root       common.Hash    `json:"stateRoot"`
+	Coinbase   common.Address `json:"miner"`
+	Time       hexutil.Uint64 `json:"timestamp"`
+	GasLimit   hexutil.Uint64 `json:"gasLimit"`
+	BaseFee    *hexutil.Big   `json:"baseFeePerGas"`
+	Difficulty *hexutil.Big   `json:"difficulty"`
+	MixDigest  common.Hash    `json:"mixHash"`
+}
+
+func (s *RPCHeaderSource) fetch(ctx context.Context, method string, arg interface{}) (*Header, error) {
+	var h *rpcHeader
+	if err := s.client.CallContext(ctx, &h, method, arg, false); err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, errUnknownBlock
+	}
+	header := &Header{
+		Number:     uint64(h.Number),
+		Hash:       h.Hash,
+		ParentHash: h.ParentHash,
+		Root:       h.Root,
+		Coinbase:   h.Coinbase,
+		Time:       uint64(h.Time),
+		GasLimit:   uint64(h.GasLimit),
+		BaseFee:    (*big.Int)(h.BaseFee),
+		Difficulty: new(big.Int),
+	}
+	if h.Difficulty != nil {
+		header.Difficulty.Set(h.Difficulty.ToInt())
+	}
+	if header.Difficulty.Sign() == 0 {
+		// Proof-of-stake blocks carry the RANDAO mix in the mix digest.
+		header.Difficulty.SetBytes(h.MixDigest[:])
+	}
+	return header, nil
+}
+
+// HeaderByNumber implements HeaderSource.
+func (s *RPCHeaderSource) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*Header, error) {
+	if number == rpc.PendingBlockNumber {
+		number = rpc.LatestBlockNumber
+	}
+	arg := "latest"
+	if number >= 0 {
+		arg = hexutil.EncodeUint64(uint64(number))
+	} else if number != rpc.LatestBlockNumber {
+		return nil, errUnknownBlock
+	}
+	header, err := s.fetch(ctx, "eth_getBlockByNumber", arg)
+	if err != nil {
+		return nil, err
+	}
+	if number >= 0 && header.Number != uint64(number) {
+		return nil, fmt.Errorf("trusted node returned block %d, want %d", header.Number, number)
+	}
+	return header, nil
+}
+
+// HeaderByHash implements HeaderSource.
+func (s *RPCHeaderSource) HeaderByHash(ctx context.Context, hash common.Hash) (*Header, error) {
+	header, err := s.fetch(ctx, "eth_getBlockByHash", hash)
+	if err != nil {
+		return nil, err
+	}
+	if header.Hash != hash {
+		return nil, fmt.Errorf("trusted node returned block %x, want %x", header.Hash, hash)
+	}
+	return header, nil
+}
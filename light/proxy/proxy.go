@@ -0,0 +1,209 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package proxy implements a JSON-RPC proxy which verifies the results of an
+// untrusted node.
+//
+// The proxy serves the eth namespace of the upstream node. State queries
+// (eth_getBalance, eth_getStorageAt and eth_getCode) are answered from Merkle
+// proofs retrieved with eth_getProof, and eth_call is executed locally on a
+// state which fetches and verifies every accessed account and storage slot.
+// All proofs are checked against the state roots of headers provided by a
+// trusted HeaderSource. Other eth methods are forwarded without verification,
+// while the methods of all other namespaces are rejected.
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const maxRequestContentLength = 1024 * 1024 * 5
+
+// Config contains the settings of the proxy.
+type Config struct {
+	// ChainConfig determines the rules calls are executed with.
+	ChainConfig *params.ChainConfig
+
+	// GasCap is the gas limit of calls. It defaults to 25M.
+	GasCap uint64
+
+	// CallTimeout is the time calls may run, including the retrieval of
+	// proofs. It defaults to 30 seconds.
+	CallTimeout time.Duration
+}
+
+// Proxy is a verifying JSON-RPC proxy. It is served over HTTP.
+type Proxy struct {
+	config   Config
+	headers  HeaderSource
+	upstream *rpc.Client
+	server   *rpc.Server
+	local    *rpc.Client // in-process client for the verified methods
+}
+
+// New creates a proxy for the given upstream node.
+func New(config Config, headers HeaderSource, upstream *rpc.Client) *Proxy {
+	if config.ChainConfig == nil {
+		config.ChainConfig = params.MainnetChainConfig
+	}
+	if config.GasCap == 0 {
+		config.GasCap = 25000000
+	}
+	if config.CallTimeout == 0 {
+		config.CallTimeout = 30 * time.Second
+	}
+	p := &Proxy{
+		config:   config,
+		headers:  headers,
+		upstream: upstream,
+		server:   rpc.NewServer(),
+	}
+	if err := p.server.RegisterName("eth", &ethAPI{p}); err != nil {
+		panic(err)
+	}
+	p.local = rpc.DialInProc(p.server)
+	return p
+}
+
+// Close stops the proxy. It doesn't close the upstream client.
+func (p *Proxy) Close() {
+	p.local.Close()
+	p.server.Stop()
+}
+
+// jsonrpcMessage is a JSON-RPC request or response.
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Error   *jsonError      `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+}
+
+type jsonError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestContentLength+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(body) > maxRequestContentLength {
+		http.Error(w, "content length too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var batch []*jsonrpcMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			json.NewEncoder(w).Encode(errorMessage(nil, -32700, err.Error()))
+			return
+		}
+		responses := make([]*jsonrpcMessage, 0, len(batch))
+		for _, msg := range batch {
+			if resp := p.handle(r.Context(), msg); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		json.NewEncoder(w).Encode(responses)
+		return
+	}
+	var msg jsonrpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		json.NewEncoder(w).Encode(errorMessage(nil, -32700, err.Error()))
+		return
+	}
+	if resp := p.handle(r.Context(), &msg); resp != nil {
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handle answers a single request, returning nil for notifications.
+func (p *Proxy) handle(ctx context.Context, msg *jsonrpcMessage) *jsonrpcMessage {
+	if msg.Method == "" {
+		return errorMessage(msg.ID, -32600, "invalid request")
+	}
+	// Don't expose the administrative namespaces of the upstream node
+	if !strings.HasPrefix(msg.Method, "eth_") {
+		if msg.ID == nil {
+			return nil
+		}
+		return errorMessage(msg.ID, -32601, fmt.Sprintf("the method %s does not exist/is not available", msg.Method))
+	}
+	var params []json.RawMessage
+	if len(msg.Params) > 0 {
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return errorMessage(msg.ID, -32602, "non-array args")
+		}
+	}
+	args := make([]interface{}, len(params))
+	for i := range params {
+		args[i] = params[i]
+	}
+	client := p.upstream
+	if verifiedMethods[msg.Method] {
+		client = p.local
+	}
+	var result json.RawMessage
+	err := client.CallContext(ctx, &result, msg.Method, args...)
+	if msg.ID == nil {
+		return nil
+	}
+	if err != nil {
+		resp := errorMessage(msg.ID, -32000, err.Error())
+		if e, ok := err.(rpc.Error); ok {
+			resp.Error.Code = e.ErrorCode()
+		}
+		if e, ok := err.(rpc.DataError); ok {
+			resp.Error.Data = e.ErrorData()
+		}
+		return resp
+	}
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return &jsonrpcMessage{Version: "2.0", ID: msg.ID, Result: result}
+}
+
+func errorMessage(id json.RawMessage, code int, message string) *jsonrpcMessage {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonrpcMessage{Version: "2.0", ID: id, Error: &jsonError{Code: code, Message: message}}
+}
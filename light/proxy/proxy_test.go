@@ -0,0 +1,162 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/internal/testproof"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	testBlockHash = common.HexToHash("0xb10c")
+	testAccount   = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testContract  = common.HexToAddress("0x2000000000000000000000000000000000000002")
+
+	// testCode returns the value of storage slot zero.
+	testCode = common.FromHex("0x60005460005260206000f3")
+)
+
+// staticHeaders is a header source with a single header.
+type staticHeaders struct {
+	header *Header
+}
+
+func (s *staticHeaders) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*Header, error) {
+	if number < 0 || uint64(number) == s.header.Number {
+		return s.header, nil
+	}
+	return nil, errUnknownBlock
+}
+
+func (s *staticHeaders) HeaderByHash(ctx context.Context, hash common.Hash) (*Header, error) {
+	if hash == s.header.Hash {
+		return s.header, nil
+	}
+	return nil, errUnknownBlock
+}
+
+// adminAPI is an administrative namespace of the upstream node.
+type adminAPI struct{}
+
+func (adminAPI) NodeInfo() string {
+	return "upstream"
+}
+
+func newTestProxy(t *testing.T, tamper bool) *rpc.Client {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	statedb.SetBalance(testAccount, big.NewInt(1000))
+	statedb.SetCode(testContract, testCode)
+	statedb.SetState(testContract, common.Hash{}, common.HexToHash("0x2a"))
+	root, err := statedb.Commit(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upstream := rpc.NewServer()
+	if err := upstream.RegisterName("eth", &testproof.API{State: statedb, Block: testBlockHash, Tamper: tamper}); err != nil {
+		t.Fatal(err)
+	}
+	if err := upstream.RegisterName("admin", adminAPI{}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(upstream.Stop)
+
+	headers := &staticHeaders{&Header{
+		Number:     1,
+		Hash:       testBlockHash,
+		Root:       root,
+		GasLimit:   30000000,
+		Difficulty: new(big.Int),
+	}}
+	proxy := New(Config{ChainConfig: params.TestChainConfig}, headers, rpc.DialInProc(upstream))
+	t.Cleanup(proxy.Close)
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+
+	client, err := rpc.Dial(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(client.Close)
+	return client
+}
+
+func TestProxy(t *testing.T) {
+	client := ethclient.NewClient(newTestProxy(t, false))
+	ctx := context.Background()
+
+	if balance, err := client.BalanceAt(ctx, testAccount, nil); err != nil || balance.Int64() != 1000 {
+		t.Errorf("BalanceAt: %v, %v", balance, err)
+	}
+	if balance, err := client.BalanceAt(ctx, common.HexToAddress("0x03"), nil); err != nil || balance.Sign() != 0 {
+		t.Errorf("BalanceAt of missing account: %v, %v", balance, err)
+	}
+	if value, err := client.StorageAt(ctx, testContract, common.Hash{}, nil); err != nil || common.BytesToHash(value) != common.HexToHash("0x2a") {
+		t.Errorf("StorageAt: %x, %v", value, err)
+	}
+	if code, err := client.CodeAt(ctx, testContract, nil); err != nil || !bytes.Equal(code, testCode) {
+		t.Errorf("CodeAt: %x, %v", code, err)
+	}
+	result, err := client.CallContract(ctx, ethereum.CallMsg{To: &testContract}, nil)
+	if err != nil || common.BytesToHash(result) != common.HexToHash("0x2a") {
+		t.Errorf("CallContract: %x, %v", result, err)
+	}
+	if _, err := client.BalanceAt(ctx, testAccount, big.NewInt(2)); err == nil {
+		t.Error("BalanceAt of untrusted block succeeded")
+	}
+	// Other methods are forwarded.
+	if id, err := client.ChainID(ctx); err != nil || id.Uint64() != testproof.ChainID {
+		t.Errorf("ChainID: %v, %v", id, err)
+	}
+}
+
+func TestProxyNamespaces(t *testing.T) {
+	client := newTestProxy(t, false)
+
+	var info string
+	err := client.Call(&info, "admin_nodeInfo")
+	if err == nil {
+		t.Fatalf("admin_nodeInfo forwarded to upstream: %q", info)
+	}
+	if e, ok := err.(rpc.Error); !ok || e.ErrorCode() != -32601 {
+		t.Errorf("wrong error: %v", err)
+	}
+}
+
+func TestProxyTamperedUpstream(t *testing.T) {
+	client := ethclient.NewClient(newTestProxy(t, true))
+	ctx := context.Background()
+
+	if _, err := client.StorageAt(ctx, testContract, common.Hash{}, nil); err == nil {
+		t.Error("tampered storage accepted")
+	}
+	if _, err := client.CallContract(ctx, ethereum.CallMsg{To: &testContract}, nil); err == nil {
+		t.Error("call on tampered storage succeeded")
+	}
+}
@@ -19,7 +19,10 @@
 package geth
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -123,11 +126,61 @@ func (opts *TransactOpts) SetGasPrice(price *BigInt)   { opts.opts.GasPrice = pr
 func (opts *TransactOpts) SetGasLimit(limit int64)     { opts.opts.GasLimit = uint64(limit) }
 func (opts *TransactOpts) SetContext(context *Context) { opts.opts.Context = context.context }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	opts bind.FilterOpts
+}
+
+// NewFilterOpts creates a new option set for event filtering.
+func NewFilterOpts() *FilterOpts {
+	return new(FilterOpts)
+}
+
+func (opts *FilterOpts) GetStart() int64 { return int64(opts.opts.Start) }
+func (opts *FilterOpts) GetEnd() int64 {
+	if opts.opts.End == nil {
+		return -1
+	}
+	return int64(*opts.opts.End)
+}
+
+func (opts *FilterOpts) SetStart(start int64) { opts.opts.Start = uint64(start) }
+
+// SetEnd sets the last block to filter, a negative number meaning the latest one.
+func (opts *FilterOpts) SetEnd(end int64) {
+	if end < 0 {
+		opts.opts.End = nil
+		return
+	}
+	last := uint64(end)
+	opts.opts.End = &last
+}
+func (opts *FilterOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	opts bind.WatchOpts
+}
+
+// NewWatchOpts creates a new option set for event subscriptions.
+func NewWatchOpts() *WatchOpts {
+	return new(WatchOpts)
+}
+
+func (opts *WatchOpts) SetStart(start int64) {
+	first := uint64(start)
+	opts.opts.Start = &first
+}
+func (opts *WatchOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Ethereum network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
 type BoundContract struct {
 	contract *bind.BoundContract
+	abi      abi.ABI
 	address  common.Address
 	deployer *types.Transaction
 }
@@ -140,12 +193,17 @@ func DeployContract(opts *TransactOpts, abiJSON string, bytecode []byte, client
 	if err != nil {
 		return nil, err
 	}
-	addr, tx, bound, err := bind.DeployContract(&opts.opts, parsed, common.CopyBytes(bytecode), client.client, args.objects...)
+	params, err := packArgs(parsed.Constructor.Inputs, args.objects)
+	if err != nil {
+		return nil, err
+	}
+	addr, tx, bound, err := bind.DeployContract(&opts.opts, parsed, common.CopyBytes(bytecode), client.client, params...)
 	if err != nil {
 		return nil, err
 	}
 	return &BoundContract{
 		contract: bound,
+		abi:      parsed,
 		address:  addr,
 		deployer: tx,
 	}, nil
@@ -160,6 +218,7 @@ func BindContract(address *Address, abiJSON string, client *EthereumClient) (con
 	}
 	return &BoundContract{
 		contract: bind.NewBoundContract(address.address, parsed, client.client, client.client, client.client),
+		abi:      parsed,
 		address:  address.address,
 	}, nil
 }
@@ -175,18 +234,40 @@ func (c *BoundContract) GetDeployer() *Transaction {
 // Call invokes the (constant) contract method with params as input values and
 // sets the output to result.
 func (c *BoundContract) Call(opts *CallOpts, out *Interfaces, method string, args *Interfaces) error {
-	results := make([]interface{}, len(out.objects))
-	copy(results, out.objects)
-	if err := c.contract.Call(&opts.opts, &results, method, args.objects...); err != nil {
+	params, err := packArgs(c.abi.Methods[method].Inputs, args.objects)
+	if err != nil {
 		return err
 	}
-	copy(out.objects, results)
+	if !hasTuples(out.objects) {
+		results := make([]interface{}, len(out.objects))
+		copy(results, out.objects)
+		if err := c.contract.Call(&opts.opts, &results, method, params...); err != nil {
+			return err
+		}
+		copy(out.objects, results)
+		return nil
+	}
+	// Tuples can't be unpacked in place, convert the decoded results instead
+	var results []interface{}
+	if err := c.contract.Call(&opts.opts, &results, method, params...); err != nil {
+		return err
+	}
+	if len(results) != len(out.objects) {
+		return fmt.Errorf("output count mismatch: have %d, want %d", len(out.objects), len(results))
+	}
+	for i, result := range results {
+		out.objects[i] = fromGoValue(reflect.ValueOf(result))
+	}
 	return nil
 }
 
 // Transact invokes the (paid) contract method with params as input values.
 func (c *BoundContract) Transact(opts *TransactOpts, method string, args *Interfaces) (tx *Transaction, _ error) {
-	rawTx, err := c.contract.Transact(&opts.opts, method, args.objects...)
+	params, err := packArgs(c.abi.Methods[method].Inputs, args.objects)
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := c.contract.Transact(&opts.opts, method, params...)
 	if err != nil {
 		return nil, err
 	}
@@ -211,3 +292,279 @@ func (c *BoundContract) Transfer(opts *TransactOpts) (tx *Transaction, _ error)
 	}
 	return &Transaction{rawTx}, nil
 }
+
+// FilterLogs retrieves the past logs of an event. The query holds the list of
+// accepted values of each indexed event argument in declaration order, an unset
+// entry matching any value.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, name string, query *Interfaces) (logs *Logs, _ error) {
+	rules, err := c.topicRules(name, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, sub, err := c.contract.FilterLogs(&opts.opts, name, rules...)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	var res []*types.Log
+	for {
+		select {
+		case log := <-ch:
+			res = append(res, &log)
+		case err := <-sub.Err():
+			if err != nil {
+				return nil, err
+			}
+			// Filtering finished, collect any logs still queued up
+			for len(ch) > 0 {
+				log := <-ch
+				res = append(res, &log)
+			}
+			return &Logs{res}, nil
+		}
+	}
+}
+
+// WatchLogs subscribes to the future logs of an event, invoking the handler for
+// each of them. The query is the same as for FilterLogs.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, name string, query *Interfaces, handler FilterLogsHandler) (sub *Subscription, _ error) {
+	rules, err := c.topicRules(name, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, rawSub, err := c.contract.WatchLogs(&opts.opts, name, rules...)
+	if err != nil {
+		return nil, err
+	}
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case log := <-ch:
+				handler.OnFilterLogs(&Log{&log})
+
+			case err := <-rawSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}, nil
+}
+
+// UnpackLog unpacks the arguments of an event from a retrieved log into out,
+// one entry per event argument in declaration order. Indexed arguments which
+// aren't stored directly in a topic (dynamic types, fixed bytes, arrays and
+// structs) are returned as their raw topic hashes.
+func (c *BoundContract) UnpackLog(out *Interfaces, name string, log *Log) error {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return fmt.Errorf("event '%s' not found", name)
+	}
+	if len(out.objects) != len(event.Inputs) {
+		return fmt.Errorf("output count mismatch: have %d, want %d", len(out.objects), len(event.Inputs))
+	}
+	if len(log.log.Topics) == 0 || log.log.Topics[0] != event.ID {
+		return errors.New("event signature mismatch")
+	}
+	var values []interface{}
+	if len(log.log.Data) > 0 {
+		var err error
+		if values, err = event.Inputs.Unpack(log.log.Data); err != nil {
+			return err
+		}
+	}
+	if len(values) != len(event.Inputs.NonIndexed()) {
+		return errors.New("event data mismatch")
+	}
+	topics := log.log.Topics[1:]
+	for i, input := range event.Inputs {
+		if !input.Indexed {
+			out.objects[i] = fromGoValue(reflect.ValueOf(values[0]))
+			values = values[1:]
+			continue
+		}
+		if len(topics) == 0 {
+			return errors.New("event topics mismatch")
+		}
+		topic := topics[0]
+		topics = topics[1:]
+
+		switch input.Type.T {
+		case abi.StringTy, abi.BytesTy, abi.FixedBytesTy, abi.TupleTy, abi.ArrayTy, abi.SliceTy:
+			// Values not stored directly in the topic are exposed as the raw topic
+			out.objects[i] = &topic
+		default:
+			parsed := make(map[string]interface{})
+			if err := abi.ParseTopicsIntoMap(parsed, abi.Arguments{input}, []common.Hash{topic}); err != nil {
+				return err
+			}
+			out.objects[i] = fromGoValue(reflect.ValueOf(parsed[input.Name]))
+		}
+	}
+	return nil
+}
+
+// topicRules converts the accepted values of the indexed arguments of an event
+// into the filter rules of the contract bindings.
+func (c *BoundContract) topicRules(name string, query *Interfaces) ([][]interface{}, error) {
+	event, ok := c.abi.Events[name]
+	if !ok {
+		return nil, fmt.Errorf("event '%s' not found", name)
+	}
+	if query == nil {
+		return nil, nil
+	}
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(query.objects) > len(indexed) {
+		return nil, fmt.Errorf("too many topic filters: have %d, want at most %d", len(query.objects), len(indexed))
+	}
+	rules := make([][]interface{}, len(query.objects))
+	for i, object := range query.objects {
+		if object == nil {
+			continue
+		}
+		values := reflect.ValueOf(object).Elem()
+		if values.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("topic filter %d is not a list", i)
+		}
+		for j := 0; j < values.Len(); j++ {
+			value := values.Index(j)
+			if indexed[i].Type.T == abi.FixedBytesTy && value.Kind() == reflect.Slice {
+				// Fixed bytes are topics on their own, not hashed like dynamic ones
+				array := reflect.New(indexed[i].Type.GetType()).Elem()
+				reflect.Copy(array, value)
+				value = array
+			}
+			rules[i] = append(rules[i], value.Interface())
+		}
+	}
+	return rules, nil
+}
+
+// hasTuples reports whether any of the objects is a tuple placeholder.
+func hasTuples(objects []interface{}) bool {
+	for _, object := range objects {
+		switch object.(type) {
+		case *tuple, *tuples:
+			return true
+		}
+	}
+	return false
+}
+
+// packArgs converts the tuples among the arguments of a contract method into
+// the Go structs expected by the ABI encoder, leaving everything else as is.
+func packArgs(args abi.Arguments, objects []interface{}) ([]interface{}, error) {
+	if !hasTuples(objects) || len(args) != len(objects) {
+		return objects, nil
+	}
+	params := make([]interface{}, len(objects))
+	for i, object := range objects {
+		value, err := toGoValue(args[i].Type, object)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %v", i, err)
+		}
+		params[i] = value.Interface()
+	}
+	return params, nil
+}
+
+// toGoValue converts an object set through an Interface into the Go type used
+// by the ABI encoder for the given Solidity type.
+func toGoValue(kind abi.Type, object interface{}) (reflect.Value, error) {
+	switch object := object.(type) {
+	case nil:
+		return reflect.Value{}, fmt.Errorf("missing value for %s", kind)
+
+	case *tuple:
+		if kind.T != abi.TupleTy || len(object.fields) != len(kind.TupleElems) {
+			return reflect.Value{}, fmt.Errorf("tuple incompatible with %s", kind)
+		}
+		value := reflect.New(kind.GetType()).Elem()
+		for i, elem := range kind.TupleElems {
+			field, err := toGoValue(*elem, object.fields[i])
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if !field.Type().AssignableTo(value.Field(i).Type()) {
+				return reflect.Value{}, fmt.Errorf("field %d: %v incompatible with %s", i, field.Type(), elem)
+			}
+			value.Field(i).Set(field)
+		}
+		return value, nil
+
+	case *tuples:
+		var value reflect.Value
+		switch kind.T {
+		case abi.SliceTy:
+			value = reflect.MakeSlice(kind.GetType(), len(object.items), len(object.items))
+		case abi.ArrayTy:
+			if len(object.items) != kind.Size {
+				return reflect.Value{}, fmt.Errorf("tuple count mismatch: have %d, want %d", len(object.items), kind.Size)
+			}
+			value = reflect.New(kind.GetType()).Elem()
+		default:
+			return reflect.Value{}, fmt.Errorf("tuple list incompatible with %s", kind)
+		}
+		for i, item := range object.items {
+			elem, err := toGoValue(*kind.Elem, item)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			value.Index(i).Set(elem)
+		}
+		return value, nil
+
+	default:
+		// Plain values are stored behind a pointer by the setters. Lists are always
+		// slices, so convert them into arrays if fixed size ones are needed.
+		value := reflect.ValueOf(object).Elem()
+		if typ := kind.GetType(); typ.Kind() == reflect.Array && value.Kind() == reflect.Slice {
+			if value.Len() != typ.Len() {
+				return reflect.Value{}, fmt.Errorf("length mismatch for %s: have %d", kind, value.Len())
+			}
+			array := reflect.New(typ).Elem()
+			reflect.Copy(array, value)
+			value = array
+		}
+		return value, nil
+	}
+}
+
+// fromGoValue converts a value decoded by the ABI decoder into an object which
+// can be retrieved through an Interface.
+func fromGoValue(value reflect.Value) interface{} {
+	switch {
+	case value.Kind() == reflect.Struct:
+		fields := make([]interface{}, value.NumField())
+		for i := range fields {
+			fields[i] = fromGoValue(value.Field(i))
+		}
+		return &tuple{fields}
+
+	case (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Type().Elem().Kind() == reflect.Struct:
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = fromGoValue(value.Index(i))
+		}
+		return &tuples{items}
+
+	case value.Kind() == reflect.Array && value.Type() != reflect.TypeOf(common.Address{}) && value.Type() != reflect.TypeOf(common.Hash{}):
+		// Fixed size arrays and bytes are exposed as lists and binary blobs
+		slice := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), value.Len(), value.Len())
+		reflect.Copy(slice, value)
+		value = slice
+	}
+	object := reflect.New(value.Type())
+	object.Elem().Set(value)
+	return object.Interface()
+}
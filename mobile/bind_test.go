@@ -0,0 +1,145 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package geth
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const tupleTestABI = `[
+	{"name":"set","type":"function","inputs":[{"name":"p","type":"tuple","components":[{"name":"owner","type":"address"},{"name":"values","type":"uint256[2]"},{"name":"inner","type":"tuple[]","components":[{"name":"flag","type":"bool"}]}]}],"outputs":[]},
+	{"name":"Moved","type":"event","inputs":[{"name":"from","type":"address","indexed":true},{"name":"tag","type":"string","indexed":true},{"name":"amount","type":"uint8","indexed":false}]}
+]`
+
+// Tests that tuples set through interfaces are converted to the Go structs of
+// the ABI encoder and back.
+func TestTupleConversion(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	// Assemble the tuple argument from individual interfaces
+	newIface := func(set func(*Interface)) *Interface {
+		iface := NewInterface()
+		set(iface)
+		return iface
+	}
+	inner := NewInterfaces(2)
+	for i, flag := range []bool{true, false} {
+		fields := NewInterfaces(1)
+		fields.Set(0, newIface(func(iface *Interface) { iface.SetBool(flag) }))
+		inner.Set(i, newIface(func(iface *Interface) { iface.SetTuple(fields) }))
+	}
+	values := NewBigInts(2)
+	values.Set(0, NewBigInt(1))
+	values.Set(1, NewBigInt(2))
+
+	fields := NewInterfaces(3)
+	fields.Set(0, newIface(func(iface *Interface) { iface.SetAddress(&Address{common.Address{0xaa}}) }))
+	fields.Set(1, newIface(func(iface *Interface) { iface.SetBigInts(values) }))
+	fields.Set(2, newIface(func(iface *Interface) { iface.SetTuples(inner) }))
+
+	args := NewInterfaces(1)
+	args.Set(0, newIface(func(iface *Interface) { iface.SetTuple(fields) }))
+
+	params, err := packArgs(parsed.Methods["set"].Inputs, args.objects)
+	if err != nil {
+		t.Fatalf("failed to convert arguments: %v", err)
+	}
+	input, err := parsed.Pack("set", params...)
+	if err != nil {
+		t.Fatalf("failed to pack arguments: %v", err)
+	}
+	// Decode the call data and ensure the tuple can be read back
+	unpacked, err := parsed.Methods["set"].Inputs.Unpack(input[4:])
+	if err != nil {
+		t.Fatalf("failed to unpack arguments: %v", err)
+	}
+	res := &Interface{fromGoValue(reflect.ValueOf(unpacked[0]))}
+
+	decoded := res.GetTuple()
+	if iface, _ := decoded.Get(0); iface.GetAddress().address != (common.Address{0xaa}) {
+		t.Errorf("owner mismatch: have %x", iface.GetAddress().address)
+	}
+	if iface, _ := decoded.Get(1); !reflect.DeepEqual(iface.GetBigInts().bigints, []*big.Int{big.NewInt(1), big.NewInt(2)}) {
+		t.Errorf("values mismatch: have %v", iface.GetBigInts().bigints)
+	}
+	iface, _ := decoded.Get(2)
+	items := iface.GetTuples()
+	if items.Size() != 2 {
+		t.Fatalf("inner tuple count mismatch: have %d, want 2", items.Size())
+	}
+	for i, want := range []bool{true, false} {
+		item, _ := items.Get(i)
+		flag, _ := item.GetTuple().Get(0)
+		if flag.GetBool() != want {
+			t.Errorf("inner tuple %d: flag mismatch: have %v, want %v", i, flag.GetBool(), want)
+		}
+	}
+}
+
+// Tests that event logs are unpacked into interfaces in argument order.
+func TestUnpackLog(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(tupleTestABI))
+	if err != nil {
+		t.Fatalf("failed to parse ABI: %v", err)
+	}
+	contract := &BoundContract{abi: parsed}
+
+	event := parsed.Events["Moved"]
+	data, _ := event.Inputs.NonIndexed().Pack(uint8(42))
+	tag := common.BytesToHash([]byte("tag"))
+	log := &Log{&types.Log{
+		Topics: []common.Hash{event.ID, common.BytesToHash(common.Address{0xbb}.Bytes()), tag},
+		Data:   data,
+	}}
+	out := NewInterfaces(3)
+	if err := contract.UnpackLog(out, "Moved", log); err != nil {
+		t.Fatalf("failed to unpack log: %v", err)
+	}
+	if iface, _ := out.Get(0); iface.GetAddress().address != (common.Address{0xbb}) {
+		t.Errorf("from mismatch: have %x", iface.GetAddress().address)
+	}
+	if iface, _ := out.Get(1); iface.GetHash().hash != tag {
+		t.Errorf("tag mismatch: have %x, want %x", iface.GetHash().hash, tag)
+	}
+	if iface, _ := out.Get(2); iface.GetUint8().bigint.Uint64() != 42 {
+		t.Errorf("amount mismatch: have %v, want 42", iface.GetUint8())
+	}
+	// Ensure topic filters are converted to the accepted values
+	query := NewInterfaces(1)
+	addrs := NewAddressesEmpty()
+	addrs.Append(&Address{common.Address{0xbb}})
+	iface := NewInterface()
+	iface.SetAddresses(addrs)
+	query.Set(0, iface)
+
+	rules, err := contract.topicRules("Moved", query)
+	if err != nil {
+		t.Fatalf("failed to convert topic filters: %v", err)
+	}
+	if want := [][]interface{}{{common.Address{0xbb}}}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("topic rules mismatch: have %v, want %v", rules, want)
+	}
+}
@@ -118,6 +118,8 @@ func (i *Interface) SetUint64s(bigints *BigInts) {
 }
 func (i *Interface) SetBigInt(bigint *BigInt)    { i.object = &bigint.bigint }
 func (i *Interface) SetBigInts(bigints *BigInts) { i.object = &bigints.bigints }
+func (i *Interface) SetTuple(fields *Interfaces) { i.object = &tuple{fields.objects} }
+func (i *Interface) SetTuples(items *Interfaces) { i.object = &tuples{items.objects} }
 
 func (i *Interface) SetDefaultBool()      { i.object = new(bool) }
 func (i *Interface) SetDefaultBools()     { i.object = new([]bool) }
@@ -147,6 +149,8 @@ func (i *Interface) SetDefaultUint64()    { i.object = new(uint64) }
 func (i *Interface) SetDefaultUint64s()   { i.object = new([]uint64) }
 func (i *Interface) SetDefaultBigInt()    { i.object = new(*big.Int) }
 func (i *Interface) SetDefaultBigInts()   { i.object = new([]*big.Int) }
+func (i *Interface) SetDefaultTuple()     { i.object = new(tuple) }
+func (i *Interface) SetDefaultTuples()    { i.object = new(tuples) }
 
 func (i *Interface) GetBool() bool            { return *i.object.(*bool) }
 func (i *Interface) GetBools() *Bools         { return &Bools{*i.object.(*[]bool)} }
@@ -238,8 +242,23 @@ func (i *Interface) GetUint64s() *BigInts {
 	}
 	return bigints
 }
-func (i *Interface) GetBigInt() *BigInt   { return &BigInt{*i.object.(**big.Int)} }
-func (i *Interface) GetBigInts() *BigInts { return &BigInts{*i.object.(*[]*big.Int)} }
+func (i *Interface) GetBigInt() *BigInt     { return &BigInt{*i.object.(**big.Int)} }
+func (i *Interface) GetBigInts() *BigInts   { return &BigInts{*i.object.(*[]*big.Int)} }
+func (i *Interface) GetTuple() *Interfaces  { return &Interfaces{i.object.(*tuple).fields} }
+func (i *Interface) GetTuples() *Interfaces { return &Interfaces{i.object.(*tuples).items} }
+
+// tuple is the representation of a Solidity tuple crossing the language boundary
+// as the list of its fields. It is converted to and from the matching Go struct
+// by the contract bindings.
+type tuple struct {
+	fields []interface{}
+}
+
+// tuples is the representation of a list of Solidity tuples, each item holding
+// a tuple.
+type tuples struct {
+	items []interface{}
+}
 
 // Interfaces is a slices of wrapped generic objects.
 type Interfaces struct {
@@ -38,6 +38,7 @@ func (l *Log) GetTxHash() *Hash      { return &Hash{l.log.TxHash} }
 func (l *Log) GetTxIndex() int       { return int(l.log.TxIndex) }
 func (l *Log) GetBlockHash() *Hash   { return &Hash{l.log.BlockHash} }
 func (l *Log) GetIndex() int         { return int(l.log.Index) }
+func (l *Log) GetRemoved() bool      { return l.log.Removed }
 
 // Logs represents a slice of VM logs.
 type Logs struct{ logs []*types.Log }
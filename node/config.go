@@ -25,9 +25,11 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/hdwallet"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
@@ -185,6 +187,18 @@ type Config struct {
 	// Requests using ip address directly are not affected
 	GraphQLVirtualHosts []string `toml:",omitempty"`
 
+	// GraphQLMaxCost is the maximum estimated cost of a GraphQL query. Queries
+	// costing more are rejected before being executed. Zero means no limit.
+	GraphQLMaxCost uint64 `toml:",omitempty"`
+
+	// GraphQLMaxDepth is the maximum selection nesting depth of a GraphQL query.
+	// Zero means no limit.
+	GraphQLMaxDepth int `toml:",omitempty"`
+
+	// GraphQLTimeout is the maximum execution time of a GraphQL request. Zero
+	// means no limit.
+	GraphQLTimeout time.Duration `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
 
@@ -488,6 +502,7 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		// we can have both, but it's very confusing for the user to see the same
 		// accounts in both externally and locally, plus very racey.
 		backends = append(backends, keystore.NewKeyStore(keydir, scryptN, scryptP))
+		backends = append(backends, hdwallet.NewStore(keydir, scryptN, scryptP))
 		if conf.USB {
 			// Start a USB hub for Ledger hardware wallets
 			if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
@@ -21,6 +21,7 @@ import (
 	"os/user"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/nat"
@@ -46,6 +47,9 @@ var DefaultConfig = Config{
 	WSPort:              DefaultWSPort,
 	WSModules:           []string{"net", "web3"},
 	GraphQLVirtualHosts: []string{"localhost"},
+	GraphQLMaxCost:      100000,
+	GraphQLMaxDepth:     20,
+	GraphQLTimeout:      20 * time.Second,
 	P2P: p2p.Config{
 		ListenAddr: ":30303",
 		MaxPeers:   50,
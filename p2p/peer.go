@@ -107,6 +107,7 @@ type Peer struct {
 	rw      *conn
 	running map[string]*protoRW
 	log     log.Logger
+	clock   mclock.Clock
 	created mclock.AbsTime
 
 	wg       sync.WaitGroup
@@ -124,7 +125,7 @@ func NewPeer(id enode.ID, name string, caps []Cap) *Peer {
 	pipe, _ := net.Pipe()
 	node := enode.SignNull(new(enr.Record), id)
 	conn := &conn{fd: pipe, transport: nil, node: node, caps: caps, name: name}
-	peer := newPeer(log.Root(), conn, nil)
+	peer := newPeer(log.Root(), conn, nil, mclock.System{})
 	close(peer.closed) // ensures Disconnect doesn't block
 	return peer
 }
@@ -216,12 +217,13 @@ func (p *Peer) Inbound() bool {
 	return p.rw.is(inboundConn)
 }
 
-func newPeer(log log.Logger, conn *conn, protocols []Protocol) *Peer {
+func newPeer(log log.Logger, conn *conn, protocols []Protocol, clock mclock.Clock) *Peer {
 	protomap := matchProtocols(protocols, conn.caps, conn)
 	p := &Peer{
 		rw:       conn,
 		running:  protomap,
-		created:  mclock.Now(),
+		clock:    clock,
+		created:  clock.Now(),
 		disc:     make(chan DiscReason),
 		protoErr: make(chan error, len(protomap)+1), // protocols + pingLoop
 		closed:   make(chan struct{}),
@@ -285,12 +287,12 @@ loop:
 }
 
 func (p *Peer) pingLoop() {
-	ping := time.NewTimer(pingInterval)
+	ping := p.clock.NewTimer(pingInterval)
 	defer p.wg.Done()
 	defer ping.Stop()
 	for {
 		select {
-		case <-ping.C:
+		case <-ping.C():
 			if err := SendItems(p.rw, pingMsg); err != nil {
 				p.protoErr <- err
 				return
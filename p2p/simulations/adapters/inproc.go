@@ -17,6 +17,7 @@
 package adapters
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -24,6 +25,7 @@ import (
 	"net"
 	"sync"
 
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/node"
@@ -38,8 +40,10 @@ import (
 // connects them using net.Pipe
 type SimAdapter struct {
 	pipe       func() (net.Conn, net.Conn, error)
+	clock      mclock.Clock
 	mtx        sync.RWMutex
 	nodes      map[enode.ID]*SimNode
+	links      map[[2]enode.ID]*pipes.Link
 	lifecycles LifecycleConstructors
 }
 
@@ -48,9 +52,20 @@ type SimAdapter struct {
 // particular node are passed to the NewNode function in the NodeConfig)
 // the adapter uses a net.Pipe for in-memory simulated network connections
 func NewSimAdapter(services LifecycleConstructors) *SimAdapter {
+	return NewSimAdapterWithClock(services, mclock.System{})
+}
+
+// NewSimAdapterWithClock is like NewSimAdapter, but runs the p2p stack of all
+// nodes (dialer, discovery and peer timers) on the given clock. Passing an
+// *mclock.Simulated makes the simulation run on virtual time, which is only
+// advanced when the caller runs the clock. The RLPx handshake and frame
+// timeouts are deadlines on the connections and still expire in wall-clock time.
+func NewSimAdapterWithClock(services LifecycleConstructors, clock mclock.Clock) *SimAdapter {
 	return &SimAdapter{
 		pipe:       pipes.NetPipe,
+		clock:      clock,
 		nodes:      make(map[enode.ID]*SimNode),
+		links:      make(map[[2]enode.ID]*pipes.Link),
 		lifecycles: services,
 	}
 }
@@ -60,6 +75,11 @@ func (s *SimAdapter) Name() string {
 	return "sim-adapter"
 }
 
+// Clock returns the clock used by the nodes of the adapter
+func (s *SimAdapter) Clock() mclock.Clock {
+	return s.clock
+}
+
 // NewNode returns a new SimNode using the given config
 func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 	s.mtx.Lock()
@@ -96,8 +116,9 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 			PrivateKey:      config.PrivateKey,
 			MaxPeers:        math.MaxInt32,
 			NoDiscovery:     true,
-			Dialer:          s,
+			Dialer:          &simNodeDialer{adapter: s, id: id},
 			EnableMsgEvents: config.EnableMsgEvents,
+			Clock:           s.clock,
 		},
 		ExternalSigner: config.ExternalSigner,
 		Logger:         log.New("node.id", id.String()),
@@ -120,6 +141,12 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 // Dial implements the p2p.NodeDialer interface by connecting to the node using
 // an in-memory net.Pipe
 func (s *SimAdapter) Dial(ctx context.Context, dest *enode.Node) (conn net.Conn, err error) {
+	return s.dial(nil, dest)
+}
+
+// dial connects to the destination node. If the source node is known, the
+// connection is shaped by the link between the two nodes.
+func (s *SimAdapter) dial(src *enode.ID, dest *enode.Node) (conn net.Conn, err error) {
 	node, ok := s.GetNode(dest.ID())
 	if !ok {
 		return nil, fmt.Errorf("unknown node: %s", dest.ID())
@@ -129,10 +156,20 @@ func (s *SimAdapter) Dial(ctx context.Context, dest *enode.Node) (conn net.Conn,
 		return nil, fmt.Errorf("node not running: %s", dest.ID())
 	}
 	// SimAdapter.pipe is net.Pipe (NewSimAdapter)
+	var link *pipes.Link
+	if src != nil {
+		link = s.link(*src, dest.ID())
+		if link.Config().Partitioned {
+			return nil, pipes.ErrPartitioned
+		}
+	}
 	pipe1, pipe2, err := s.pipe()
 	if err != nil {
 		return nil, err
 	}
+	if link != nil {
+		pipe1, pipe2 = link.Wrap(pipe1, pipe2)
+	}
 	// this is simulated 'listening'
 	// asynchronously call the dialed destination node's p2p server
 	// to set up connection on the 'listening' side
@@ -140,6 +177,46 @@ func (s *SimAdapter) Dial(ctx context.Context, dest *enode.Node) (conn net.Conn,
 	return pipe2, nil
 }
 
+// LinkConfig returns the configuration of the simulated link between two nodes
+func (s *SimAdapter) LinkConfig(one, other enode.ID) pipes.LinkConfig {
+	return s.link(one, other).Config()
+}
+
+// SetLinkConfig changes the configuration of the simulated link between two
+// nodes. The change also applies to connections which are already established.
+func (s *SimAdapter) SetLinkConfig(one, other enode.ID, config pipes.LinkConfig) {
+	s.link(one, other).SetConfig(config)
+}
+
+// link returns the link between two nodes, creating it if it doesn't exist
+func (s *SimAdapter) link(one, other enode.ID) *pipes.Link {
+	key := [2]enode.ID{one, other}
+	if bytes.Compare(one[:], other[:]) > 0 {
+		key = [2]enode.ID{other, one}
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	link, ok := s.links[key]
+	if !ok {
+		link = pipes.NewLink(s.clock)
+		s.links[key] = link
+	}
+	return link
+}
+
+// simNodeDialer dials on behalf of a single node of the adapter, which makes
+// the source of the connection known for link shaping.
+type simNodeDialer struct {
+	adapter *SimAdapter
+	id      enode.ID
+}
+
+// Dial implements the p2p.NodeDialer interface
+func (d *simNodeDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	return d.adapter.dial(&d.id, dest)
+}
+
 // DialRPC implements the RPCDialer interface by creating an in-memory RPC
 // client of the given node
 func (s *SimAdapter) DialRPC(id enode.ID) (*rpc.Client, error) {
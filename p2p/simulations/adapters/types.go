@@ -32,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/simulations/pipes"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gorilla/websocket"
 )
@@ -76,6 +77,16 @@ type NodeAdapter interface {
 	NewNode(config *NodeConfig) (Node, error)
 }
 
+// LinkShaper is implemented by NodeAdapters which can change the properties
+// of the simulated network links between their nodes
+type LinkShaper interface {
+	// LinkConfig returns the configuration of the link between two nodes
+	LinkConfig(one, other enode.ID) pipes.LinkConfig
+
+	// SetLinkConfig changes the configuration of the link between two nodes
+	SetLinkConfig(one, other enode.ID, config pipes.LinkConfig)
+}
+
 // NodeConfig is the configuration used to start a node in a simulation
 // network
 type NodeConfig struct {
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/p2p/simulations/pipes"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
@@ -205,6 +206,27 @@ func (c *Client) DisconnectNode(nodeID, peerID string) error {
 	return c.Delete(fmt.Sprintf("/nodes/%s/conn/%s", nodeID, peerID))
 }
 
+// GetLink returns the configuration of the simulated link between two nodes
+func (c *Client) GetLink(nodeID, peerID string) (*pipes.LinkConfig, error) {
+	config := &pipes.LinkConfig{}
+	return config, c.Get(fmt.Sprintf("/nodes/%s/link/%s", nodeID, peerID), config)
+}
+
+// SetLink changes the configuration of the simulated link between two nodes
+func (c *Client) SetLink(nodeID, peerID string, config *pipes.LinkConfig) error {
+	return c.Post(fmt.Sprintf("/nodes/%s/link/%s", nodeID, peerID), config, nil)
+}
+
+// Partition splits the network into the given groups of nodes
+func (c *Client) Partition(groups [][]string) error {
+	return c.Post("/partition", &PartitionRequest{Groups: groups}, nil)
+}
+
+// Heal removes all network partitions
+func (c *Client) Heal() error {
+	return c.Delete("/partition")
+}
+
 // RPCClient returns an RPC client connected to a node
 func (c *Client) RPCClient(ctx context.Context, nodeID string) (*rpc.Client, error) {
 	baseURL := strings.Replace(c.URL, "http", "ws", 1)
@@ -296,6 +318,10 @@ func NewServer(network *Network) *Server {
 	s.POST("/nodes/:nodeid/conn/:peerid", s.ConnectNode)
 	s.DELETE("/nodes/:nodeid/conn/:peerid", s.DisconnectNode)
 	s.GET("/nodes/:nodeid/rpc", s.NodeRPC)
+	s.GET("/nodes/:nodeid/link/:peerid", s.GetLink)
+	s.POST("/nodes/:nodeid/link/:peerid", s.SetLink)
+	s.POST("/partition", s.Partition)
+	s.DELETE("/partition", s.Heal)
 
 	return s
 }
@@ -642,6 +668,76 @@ func (s *Server) DisconnectNode(w http.ResponseWriter, req *http.Request) {
 	s.JSON(w, http.StatusOK, node.NodeInfo())
 }
 
+// GetLink returns the configuration of the simulated link between two nodes
+func (s *Server) GetLink(w http.ResponseWriter, req *http.Request) {
+	node := req.Context().Value("node").(*Node)
+	peer := req.Context().Value("peer").(*Node)
+
+	config, err := s.network.LinkConfig(node.ID(), peer.ID())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.JSON(w, http.StatusOK, config)
+}
+
+// SetLink changes the configuration of the simulated link between two nodes
+func (s *Server) SetLink(w http.ResponseWriter, req *http.Request) {
+	node := req.Context().Value("node").(*Node)
+	peer := req.Context().Value("peer").(*Node)
+
+	var config pipes.LinkConfig
+	if err := json.NewDecoder(req.Body).Decode(&config); err != nil && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.network.SetLinkConfig(node.ID(), peer.ID(), config); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.JSON(w, http.StatusOK, config)
+}
+
+// PartitionRequest is the request body of the partition API, the groups
+// contain node IDs or names
+type PartitionRequest struct {
+	Groups [][]string `json:"groups"`
+}
+
+// Partition splits the network into the requested groups of nodes
+func (s *Server) Partition(w http.ResponseWriter, req *http.Request) {
+	var request PartitionRequest
+	if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	groups := make([][]enode.ID, len(request.Groups))
+	for i, ids := range request.Groups {
+		for _, id := range ids {
+			node := s.lookupNode(id)
+			if node == nil {
+				http.Error(w, fmt.Sprintf("unknown node %q", id), http.StatusNotFound)
+				return
+			}
+			groups[i] = append(groups[i], node.ID())
+		}
+	}
+	if err := s.network.Partition(groups...); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.JSON(w, http.StatusOK, request)
+}
+
+// Heal removes all network partitions
+func (s *Server) Heal(w http.ResponseWriter, req *http.Request) {
+	s.network.Heal()
+	w.WriteHeader(http.StatusOK)
+}
+
 // Options responds to the OPTIONS HTTP method by returning a 200 OK response
 // with the "Access-Control-Allow-Headers" header set to "Content-Type"
 func (s *Server) Options(w http.ResponseWriter, req *http.Request) {
@@ -708,13 +804,7 @@ func (s *Server) wrapHandler(handler http.HandlerFunc) httprouter.Handle {
 		ctx := req.Context()
 
 		if id := params.ByName("nodeid"); id != "" {
-			var nodeID enode.ID
-			var node *Node
-			if nodeID.UnmarshalText([]byte(id)) == nil {
-				node = s.network.GetNode(nodeID)
-			} else {
-				node = s.network.GetNodeByName(id)
-			}
+			node := s.lookupNode(id)
 			if node == nil {
 				http.NotFound(w, req)
 				return
@@ -723,13 +813,7 @@ func (s *Server) wrapHandler(handler http.HandlerFunc) httprouter.Handle {
 		}
 
 		if id := params.ByName("peerid"); id != "" {
-			var peerID enode.ID
-			var peer *Node
-			if peerID.UnmarshalText([]byte(id)) == nil {
-				peer = s.network.GetNode(peerID)
-			} else {
-				peer = s.network.GetNodeByName(id)
-			}
+			peer := s.lookupNode(id)
 			if peer == nil {
 				http.NotFound(w, req)
 				return
@@ -740,3 +824,12 @@ func (s *Server) wrapHandler(handler http.HandlerFunc) httprouter.Handle {
 		handler(w, req.WithContext(ctx))
 	}
 }
+
+// lookupNode returns the node with the given ID or name
+func (s *Server) lookupNode(id string) *Node {
+	var nodeID enode.ID
+	if nodeID.UnmarshalText([]byte(id)) == nil {
+		return s.network.GetNode(nodeID)
+	}
+	return s.network.GetNodeByName(id)
+}
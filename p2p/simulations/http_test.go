@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/p2p/simulations/pipes"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/mattn/go-colorable"
 )
@@ -360,6 +361,65 @@ func TestHTTPNetwork(t *testing.T) {
 	)
 }
 
+// TestHTTPLinks tests changing the simulated links between nodes and
+// partitioning the network using the HTTP API
+func TestHTTPLinks(t *testing.T) {
+	network, s := testHTTPServer(t)
+	defer s.Close()
+	defer network.Shutdown()
+
+	client := NewClient(s.URL)
+	nodeIDs := startTestNetwork(t, client)
+
+	config := &pipes.LinkConfig{Latency: 50 * time.Millisecond, Bandwidth: 1 << 20, Loss: 0.1}
+	if err := client.SetLink(nodeIDs[0], nodeIDs[1], config); err != nil {
+		t.Fatalf("error setting link: %s", err)
+	}
+	got, err := client.GetLink(nodeIDs[1], nodeIDs[0])
+	if err != nil {
+		t.Fatalf("error getting link: %s", err)
+	}
+	if *got != *config {
+		t.Fatalf("wrong link config: got %+v, want %+v", got, config)
+	}
+	if err := client.SetLink(nodeIDs[0], nodeIDs[1], &pipes.LinkConfig{Loss: 2}); err == nil {
+		t.Fatal("expected error for invalid loss probability")
+	}
+
+	// partitioning marks the link as partitioned, healing restores it
+	if err := client.Partition([][]string{{nodeIDs[0]}, {nodeIDs[1]}}); err != nil {
+		t.Fatalf("error partitioning network: %s", err)
+	}
+	if got, _ := client.GetLink(nodeIDs[0], nodeIDs[1]); !got.Partitioned {
+		t.Fatal("link not partitioned")
+	}
+	if err := client.ConnectNode(nodeIDs[0], nodeIDs[1]); err == nil {
+		t.Fatal("expected error connecting partitioned nodes")
+	}
+	if err := client.Heal(); err != nil {
+		t.Fatalf("error healing network: %s", err)
+	}
+	if got, _ := client.GetLink(nodeIDs[0], nodeIDs[1]); got.Partitioned || *got != *config {
+		t.Fatalf("wrong link config after heal: %+v", got)
+	}
+
+	// healing keeps links partitioned explicitly
+	partitioned := *config
+	partitioned.Partitioned = true
+	if err := client.SetLink(nodeIDs[0], nodeIDs[1], &partitioned); err != nil {
+		t.Fatalf("error setting link: %s", err)
+	}
+	if err := client.Partition([][]string{{nodeIDs[0]}, {nodeIDs[1]}}); err != nil {
+		t.Fatalf("error partitioning network: %s", err)
+	}
+	if err := client.Heal(); err != nil {
+		t.Fatalf("error healing network: %s", err)
+	}
+	if got, _ := client.GetLink(nodeIDs[0], nodeIDs[1]); *got != partitioned {
+		t.Fatalf("wrong link config after heal: %+v", got)
+	}
+}
+
 func startTestNetwork(t *testing.T, client *Client) []string {
 	// create two nodes
 	nodeCount := 2
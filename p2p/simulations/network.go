@@ -27,11 +27,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/p2p/simulations/pipes"
 )
 
 var DialBanTimeout = 200 * time.Millisecond
@@ -62,21 +64,38 @@ type Network struct {
 	Conns   []*Conn `json:"conns"`
 	connMap map[string]int
 
+	// Connection labels which may not be initiated because the two nodes
+	// are on different sides of a partition
+	partitioned map[string][2]enode.ID
+
+	// Links partitioned by Partition, which are restored by Heal
+	partitionedLinks map[string][2]enode.ID
+
 	nodeAdapter adapters.NodeAdapter
+	clock       mclock.Clock
 	events      event.Feed
 	lock        sync.RWMutex
 	quitc       chan struct{}
 }
 
-// NewNetwork returns a Network which uses the given NodeAdapter and NetworkConfig
+// NewNetwork returns a Network which uses the given NodeAdapter and NetworkConfig.
+// If the adapter runs its nodes on a custom clock (see
+// adapters.NewSimAdapterWithClock), the network uses the same clock.
 func NewNetwork(nodeAdapter adapters.NodeAdapter, conf *NetworkConfig) *Network {
+	var clock mclock.Clock = mclock.System{}
+	if a, ok := nodeAdapter.(interface{ Clock() mclock.Clock }); ok {
+		clock = a.Clock()
+	}
 	return &Network{
-		NetworkConfig: *conf,
-		nodeAdapter:   nodeAdapter,
-		nodeMap:       make(map[enode.ID]int),
-		propertyMap:   make(map[string][]int),
-		connMap:       make(map[string]int),
-		quitc:         make(chan struct{}),
+		NetworkConfig:    *conf,
+		nodeAdapter:      nodeAdapter,
+		clock:            clock,
+		nodeMap:          make(map[enode.ID]int),
+		propertyMap:      make(map[string][]int),
+		connMap:          make(map[string]int),
+		partitioned:      make(map[string][2]enode.ID),
+		partitionedLinks: make(map[string][2]enode.ID),
+		quitc:            make(chan struct{}),
 	}
 }
 
@@ -342,6 +361,121 @@ func (net *Network) Disconnect(oneID, otherID enode.ID) error {
 	return client.Call(nil, "admin_removePeer", string(conn.other.Addr()))
 }
 
+// Partition splits the network into the given groups of nodes. Active
+// connections between nodes of different groups are disconnected and new
+// connections between them are refused until Heal is called. If the node
+// adapter supports link shaping, the links between the groups are partitioned
+// as well. Nodes which are not part of any group are not affected.
+func (net *Network) Partition(groups ...[]enode.ID) error {
+	net.lock.Lock()
+	group := make(map[enode.ID]int)
+	for i, ids := range groups {
+		for _, id := range ids {
+			if net.getNode(id) == nil {
+				net.lock.Unlock()
+				return fmt.Errorf("node %v does not exist", id)
+			}
+			group[id] = i
+		}
+	}
+	shaper, _ := net.nodeAdapter.(adapters.LinkShaper)
+	for one, i := range group {
+		for other, j := range group {
+			if i == j {
+				continue
+			}
+			label := ConnLabel(one, other)
+			net.partitioned[label] = [2]enode.ID{one, other}
+			if shaper != nil {
+				if config := shaper.LinkConfig(one, other); !config.Partitioned {
+					config.Partitioned = true
+					shaper.SetLinkConfig(one, other, config)
+					net.partitionedLinks[label] = [2]enode.ID{one, other}
+				}
+			}
+		}
+	}
+	var drop []*Conn
+	for _, conn := range net.Conns {
+		if _, ok := net.partitioned[ConnLabel(conn.One, conn.Other)]; ok && conn.Up {
+			drop = append(drop, conn)
+		}
+	}
+	net.lock.Unlock()
+
+	// Disconnect must be called without net.lock held. The connection may
+	// have dropped in the meantime, which is fine.
+	for _, conn := range drop {
+		if err := net.Disconnect(conn.One, conn.Other); err != nil && net.connUp(conn.One, conn.Other) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Heal removes all partitions created by Partition. Nodes which were
+// disconnected by the partition are not reconnected automatically. Links which
+// were already partitioned through SetLinkConfig stay partitioned.
+func (net *Network) Heal() {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+
+	if shaper, ok := net.nodeAdapter.(adapters.LinkShaper); ok {
+		for _, pair := range net.partitionedLinks {
+			config := shaper.LinkConfig(pair[0], pair[1])
+			config.Partitioned = false
+			shaper.SetLinkConfig(pair[0], pair[1], config)
+		}
+	}
+	net.partitioned = make(map[string][2]enode.ID)
+	net.partitionedLinks = make(map[string][2]enode.ID)
+}
+
+// LinkConfig returns the configuration of the simulated link between two
+// nodes. It fails if the node adapter doesn't support link shaping.
+func (net *Network) LinkConfig(oneID, otherID enode.ID) (pipes.LinkConfig, error) {
+	shaper, err := net.linkShaper(oneID, otherID)
+	if err != nil {
+		return pipes.LinkConfig{}, err
+	}
+	return shaper.LinkConfig(oneID, otherID), nil
+}
+
+// SetLinkConfig changes the latency, bandwidth, loss and partitioning of the
+// simulated link between two nodes. It fails if the node adapter doesn't
+// support link shaping.
+func (net *Network) SetLinkConfig(oneID, otherID enode.ID, config pipes.LinkConfig) error {
+	shaper, err := net.linkShaper(oneID, otherID)
+	if err != nil {
+		return err
+	}
+	if config.Loss < 0 || config.Loss > 1 {
+		return fmt.Errorf("invalid loss probability %v", config.Loss)
+	}
+	if config.Latency < 0 || config.Bandwidth < 0 {
+		return errors.New("negative latency or bandwidth")
+	}
+	shaper.SetLinkConfig(oneID, otherID, config)
+	return nil
+}
+
+func (net *Network) linkShaper(oneID, otherID enode.ID) (adapters.LinkShaper, error) {
+	shaper, ok := net.nodeAdapter.(adapters.LinkShaper)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support link shaping", net.nodeAdapter.Name())
+	}
+	if oneID == otherID {
+		return nil, fmt.Errorf("no link from %v to itself", oneID)
+	}
+	if net.GetNode(oneID) == nil {
+		return nil, fmt.Errorf("node %v does not exist", oneID)
+	}
+	if net.GetNode(otherID) == nil {
+		return nil, fmt.Errorf("node %v does not exist", otherID)
+	}
+	return shaper, nil
+}
+
 // DidConnect tracks the fact that the "one" node connected to the "other" node
 func (net *Network) DidConnect(one, other enode.ID) error {
 	net.lock.Lock()
@@ -371,7 +505,7 @@ func (net *Network) DidDisconnect(one, other enode.ID) error {
 		return fmt.Errorf("%v and %v already disconnected", one, other)
 	}
 	conn.Up = false
-	conn.initiated = time.Now().Add(-DialBanTimeout)
+	conn.dialled = false // allow reconnecting right away
 	net.events.Send(NewEvent(conn))
 	return nil
 }
@@ -649,6 +783,15 @@ func (net *Network) getConn(oneID, otherID enode.ID) *Conn {
 	return net.Conns[i]
 }
 
+// connUp reports whether the connection between the two nodes is up
+func (net *Network) connUp(oneID, otherID enode.ID) bool {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+
+	conn := net.getConn(oneID, otherID)
+	return conn != nil && conn.Up
+}
+
 // InitConn(one, other) retrieves the connection model for the connection between
 // peers one and other, or creates a new one if it does not exist
 // the order of nodes does not matter, i.e., Conn(i,j) == Conn(j, i)
@@ -674,7 +817,10 @@ func (net *Network) initConn(oneID, otherID enode.ID) (*Conn, error) {
 	if conn.Up {
 		return nil, fmt.Errorf("%v and %v already connected", oneID, otherID)
 	}
-	if time.Since(conn.initiated) < DialBanTimeout {
+	if _, ok := net.partitioned[ConnLabel(oneID, otherID)]; ok {
+		return nil, fmt.Errorf("%v and %v are partitioned", oneID, otherID)
+	}
+	if conn.dialled && net.clock.Now().Sub(conn.initiated) < DialBanTimeout {
 		return nil, fmt.Errorf("connection between %v and %v recently attempted", oneID, otherID)
 	}
 
@@ -684,7 +830,7 @@ func (net *Network) initConn(oneID, otherID enode.ID) (*Conn, error) {
 		return nil, fmt.Errorf("nodes not up: %v", err)
 	}
 	log.Debug("Connection initiated", "id", oneID, "other", otherID)
-	conn.initiated = time.Now()
+	conn.initiated, conn.dialled = net.clock.Now(), true
 	return conn, nil
 }
 
@@ -715,6 +861,8 @@ func (net *Network) Reset() {
 	net.connMap = make(map[string]int)
 	net.nodeMap = make(map[enode.ID]int)
 	net.propertyMap = make(map[string][]int)
+	net.partitioned = make(map[string][2]enode.ID)
+	net.partitionedLinks = make(map[string][2]enode.ID)
 
 	net.Nodes = nil
 	net.Conns = nil
@@ -818,7 +966,8 @@ type Conn struct {
 	// Up tracks whether or not the connection is active
 	Up bool `json:"up"`
 	// Registers when the connection was grabbed to dial
-	initiated time.Time
+	initiated mclock.AbsTime
+	dialled   bool // Whether initiated is set, zero is a valid simulated time
 
 	one   *Node
 	other *Node
@@ -0,0 +1,293 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pipes
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+// RetransmitTimeout is the additional delay of a write which is lost on a
+// lossy link before it is retransmitted.
+var RetransmitTimeout = 200 * time.Millisecond
+
+// ErrPartitioned is returned when creating a connection over a partitioned link.
+var ErrPartitioned = errors.New("link is partitioned")
+
+// LinkConfig describes the properties of a simulated network link. The zero
+// value is a perfect link.
+type LinkConfig struct {
+	// Latency is the one-way delay of all data sent over the link
+	Latency time.Duration `json:"latency,omitempty"`
+
+	// Bandwidth is the capacity of each direction of the link in bytes per
+	// second. Zero means unlimited.
+	Bandwidth int `json:"bandwidth,omitempty"`
+
+	// Loss is the probability in the range [0, 1] that a write is lost and
+	// has to be retransmitted, which delays it (and all data sent after it)
+	// by RetransmitTimeout.
+	Loss float64 `json:"loss,omitempty"`
+
+	// Partitioned blocks all traffic over the link. Data written to existing
+	// connections is held back until the partition is removed, new
+	// connections can't be established.
+	Partitioned bool `json:"partitioned,omitempty"`
+}
+
+// Link is a simulated network link between two hosts. All connections
+// wrapped by the link are shaped according to its current configuration,
+// which can be changed at any time.
+type Link struct {
+	clock mclock.Clock
+
+	mu      sync.Mutex
+	config  LinkConfig
+	rand    *rand.Rand
+	changed chan struct{} // closed when the config changes
+}
+
+// NewLink creates a perfect link which measures time using the given clock.
+func NewLink(clock mclock.Clock) *Link {
+	return &Link{
+		clock:   clock,
+		rand:    rand.New(rand.NewSource(1)),
+		changed: make(chan struct{}),
+	}
+}
+
+// Config returns the current configuration of the link.
+func (l *Link) Config() LinkConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config
+}
+
+// SetConfig updates the configuration of the link. The new configuration
+// applies to all data written after the call.
+func (l *Link) SetConfig(config LinkConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.config = config
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Wrap returns the two ends of a connected pipe, with the traffic flowing
+// through them shaped by the link.
+func (l *Link) Wrap(c1, c2 net.Conn) (net.Conn, net.Conn) {
+	return newShapedConn(c1, l), newShapedConn(c2, l)
+}
+
+// state returns the current config and a channel which is closed when it changes
+func (l *Link) state() (LinkConfig, <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.config, l.changed
+}
+
+// lost decides whether a write is lost on the link
+func (l *Link) lost(loss float64) bool {
+	if loss <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rand.Float64() < loss
+}
+
+// chunk is a piece of written data waiting to be delivered
+type chunk struct {
+	data []byte
+	at   mclock.AbsTime
+}
+
+// shapedConn wraps the writing side of a connection. Written data is queued
+// and delivered to the underlying connection at the time it would arrive on
+// the simulated link. Like a socket send buffer, the queue only blocks writers
+// when it is full. Data which hasn't been delivered when the connection is
+// closed is discarded.
+type shapedConn struct {
+	net.Conn
+	link  *Link
+	queue chan *chunk
+
+	writeMu sync.Mutex
+	busy    mclock.AbsTime // time at which the outgoing direction becomes idle
+
+	deadlineMu    sync.Mutex
+	writeDeadline time.Time
+
+	errMu sync.Mutex
+	err   error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newShapedConn(conn net.Conn, link *Link) *shapedConn {
+	c := &shapedConn{
+		Conn:   conn,
+		link:   link,
+		queue:  make(chan *chunk, 64),
+		closed: make(chan struct{}),
+	}
+	go c.deliverLoop()
+	return c
+}
+
+// Write implements net.Conn.
+func (c *shapedConn) Write(b []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := c.error(); err != nil {
+		return 0, err
+	}
+	deadline, stop := c.deadline()
+	defer stop()
+
+	// Compute the time the data leaves the sender and when it arrives.
+	config, _ := c.link.state()
+	now := c.link.clock.Now()
+	start := now
+	if c.busy > start {
+		start = c.busy
+	}
+	c.busy = start
+	if config.Bandwidth > 0 {
+		c.busy = start.Add(time.Duration(len(b)) * time.Second / time.Duration(config.Bandwidth))
+	}
+	at := c.busy.Add(config.Latency)
+	if c.link.lost(config.Loss) {
+		at = at.Add(RetransmitTimeout)
+	}
+
+	// Block the writer until the data is on the wire, so that a bandwidth
+	// limited link applies back pressure.
+	if wait := c.busy.Sub(now); wait > 0 {
+		select {
+		case <-c.link.clock.After(wait):
+		case <-deadline:
+			return 0, os.ErrDeadlineExceeded
+		case <-c.closed:
+			return 0, io.ErrClosedPipe
+		}
+	}
+	data := make([]byte, len(b))
+	copy(data, b)
+	select {
+	case c.queue <- &chunk{data: data, at: at}:
+		return len(b), nil
+	case <-deadline:
+		return 0, os.ErrDeadlineExceeded
+	case <-c.closed:
+		return 0, io.ErrClosedPipe
+	}
+}
+
+// deliverLoop writes queued data to the underlying connection once it is due.
+func (c *shapedConn) deliverLoop() {
+	for {
+		select {
+		case ch := <-c.queue:
+			// Hold the data back while the link is partitioned.
+			config, changed := c.link.state()
+			for config.Partitioned {
+				select {
+				case <-changed:
+					config, changed = c.link.state()
+				case <-c.closed:
+					return
+				}
+			}
+			if wait := ch.at.Sub(c.link.clock.Now()); wait > 0 {
+				select {
+				case <-c.link.clock.After(wait):
+				case <-c.closed:
+					return
+				}
+			}
+			if _, err := c.Conn.Write(ch.data); err != nil {
+				c.setError(err)
+				return
+			}
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// Close implements net.Conn.
+func (c *shapedConn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		err = c.Conn.Close()
+	})
+	return err
+}
+
+// SetDeadline implements net.Conn.
+func (c *shapedConn) SetDeadline(t time.Time) error {
+	c.SetWriteDeadline(t)
+	return c.Conn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline implements net.Conn. The deadline is not passed on to
+// the underlying connection because writes to it happen asynchronously.
+func (c *shapedConn) SetWriteDeadline(t time.Time) error {
+	c.deadlineMu.Lock()
+	defer c.deadlineMu.Unlock()
+	c.writeDeadline = t
+	return nil
+}
+
+// deadline returns a channel which fires when the write deadline expires.
+func (c *shapedConn) deadline() (<-chan time.Time, func()) {
+	c.deadlineMu.Lock()
+	t := c.writeDeadline
+	c.deadlineMu.Unlock()
+
+	if t.IsZero() {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(time.Until(t))
+	return timer.C, func() { timer.Stop() }
+}
+
+func (c *shapedConn) setError(err error) {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	if c.err == nil {
+		c.err = err
+	}
+}
+
+func (c *shapedConn) error() error {
+	c.errMu.Lock()
+	defer c.errMu.Unlock()
+	return c.err
+}
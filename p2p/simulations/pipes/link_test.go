@@ -0,0 +1,142 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pipes
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+)
+
+func newTestLink(config LinkConfig) (*mclock.Simulated, *Link, net.Conn, net.Conn) {
+	clock := new(mclock.Simulated)
+	link := NewLink(clock)
+	link.SetConfig(config)
+	p1, p2 := net.Pipe()
+	c1, c2 := link.Wrap(p1, p2)
+	return clock, link, c1, c2
+}
+
+// readAsync reads n bytes from the connection in the background.
+func readAsync(c net.Conn, n int) <-chan []byte {
+	ch := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c, buf); err == nil {
+			ch <- buf
+		}
+	}()
+	return ch
+}
+
+func TestLinkLatency(t *testing.T) {
+	clock, _, c1, c2 := newTestLink(LinkConfig{Latency: time.Second})
+	defer c1.Close()
+	defer c2.Close()
+
+	received := readAsync(c2, 5)
+	if _, err := c1.Write([]byte("hello")); err != nil {
+		t.Fatal("write failed:", err)
+	}
+	clock.WaitForTimers(1)
+	clock.Run(999 * time.Millisecond)
+	select {
+	case <-received:
+		t.Fatal("data delivered before latency elapsed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Run(time.Millisecond)
+	select {
+	case data := <-received:
+		if string(data) != "hello" {
+			t.Fatalf("wrong data %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("data not delivered")
+	}
+}
+
+func TestLinkBandwidth(t *testing.T) {
+	clock, _, c1, c2 := newTestLink(LinkConfig{Bandwidth: 10})
+	defer c1.Close()
+	defer c2.Close()
+
+	received := readAsync(c2, 20)
+	written := make(chan error, 1)
+	go func() {
+		_, err := c1.Write(make([]byte, 20))
+		written <- err
+	}()
+	// Sending 20 bytes at 10 bytes/s takes two seconds.
+	clock.WaitForTimers(1)
+	clock.Run(time.Second)
+	select {
+	case <-written:
+		t.Fatal("write returned before data was sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Run(time.Second)
+	if err := <-written; err != nil {
+		t.Fatal("write failed:", err)
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("data not delivered")
+	}
+}
+
+func TestLinkPartition(t *testing.T) {
+	_, link, c1, c2 := newTestLink(LinkConfig{Partitioned: true})
+	defer c1.Close()
+	defer c2.Close()
+
+	// Writes are buffered, but not delivered while the link is partitioned.
+	received := readAsync(c2, 5)
+	if _, err := c1.Write([]byte("hello")); err != nil {
+		t.Fatal("write failed:", err)
+	}
+	select {
+	case <-received:
+		t.Fatal("data delivered over partitioned link")
+	case <-time.After(50 * time.Millisecond):
+	}
+	link.SetConfig(LinkConfig{})
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("data not delivered after partition was removed")
+	}
+
+	// Once the buffer is full, writes time out.
+	link.SetConfig(LinkConfig{Partitioned: true})
+	c1.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	for i := 0; ; i++ {
+		_, err := c1.Write([]byte("hello"))
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			break
+		}
+		if err != nil || i > 1000 {
+			t.Fatalf("write %d: unexpected result %v", i, err)
+		}
+	}
+}
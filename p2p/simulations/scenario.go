@@ -0,0 +1,335 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// ScenarioEventType is the type of a scripted scenario event
+type ScenarioEventType string
+
+const (
+	// ScenarioConnect connects the two given nodes
+	ScenarioConnect ScenarioEventType = "connect"
+
+	// ScenarioDisconnect disconnects the two given nodes
+	ScenarioDisconnect ScenarioEventType = "disconnect"
+
+	// ScenarioStart starts the given nodes
+	ScenarioStart ScenarioEventType = "start"
+
+	// ScenarioStop stops the given nodes
+	ScenarioStop ScenarioEventType = "stop"
+
+	// ScenarioPartition splits the network into the given groups
+	ScenarioPartition ScenarioEventType = "partition"
+
+	// ScenarioHeal removes all partitions
+	ScenarioHeal ScenarioEventType = "heal"
+)
+
+// ScenarioEvent is a single event of a scenario. Nodes are referred to by
+// name (see adapters.NodeConfig) so that scenarios can be written down
+// independently of the generated node IDs.
+type ScenarioEvent struct {
+	// At is the virtual time, relative to the start of the scenario, at
+	// which the event is applied. Events must be sorted by At.
+	At time.Duration `json:"at"`
+
+	// Type is the type of the event
+	Type ScenarioEventType `json:"type"`
+
+	// Nodes are the nodes the event applies to. Connect and disconnect
+	// events take exactly two nodes, start and stop events one or more.
+	Nodes []string `json:"nodes,omitempty"`
+
+	// Groups are the node groups of a partition event
+	Groups [][]string `json:"groups,omitempty"`
+}
+
+// Scenario is a scripted sequence of network events along with the peer
+// graph expected once all events have been applied.
+type Scenario struct {
+	Events []ScenarioEvent `json:"events"`
+
+	// Expect is the set of connections which must be up at the end of the
+	// scenario. A nil Expect skips the check.
+	Expect PeerGraph `json:"expect,omitempty"`
+}
+
+// PeerGraph is a set of connections between nodes, given as pairs of node
+// names. The canonical form, as returned by Network.PeerGraph, has the names
+// of each pair and the pairs themselves sorted.
+type PeerGraph [][2]string
+
+// canonical returns a sorted copy of the graph
+func (g PeerGraph) canonical() PeerGraph {
+	out := make(PeerGraph, 0, len(g))
+	for _, pair := range g {
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		out = append(out, pair)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i][0] != out[j][0] {
+			return out[i][0] < out[j][0]
+		}
+		return out[i][1] < out[j][1]
+	})
+	return out
+}
+
+// Equal reports whether both graphs contain the same connections
+func (g PeerGraph) Equal(other PeerGraph) bool {
+	return reflect.DeepEqual(g.canonical(), other.canonical())
+}
+
+// PeerGraph returns the connections which are currently up in the network
+func (net *Network) PeerGraph() PeerGraph {
+	net.lock.RLock()
+	defer net.lock.RUnlock()
+
+	var graph PeerGraph
+	for _, conn := range net.Conns {
+		if conn.Up {
+			graph = append(graph, [2]string{conn.one.Config.Name, conn.other.Config.Name})
+		}
+	}
+	return graph.canonical()
+}
+
+// ScenarioRunner replays scenarios against a network whose nodes run on a
+// simulated clock. Virtual time is advanced between events and every event is
+// applied only after the network has settled from the previous one, which
+// makes the outcome of a scenario independent of goroutine scheduling.
+type ScenarioRunner struct {
+	network *Network
+	clock   *mclock.Simulated
+	start   mclock.AbsTime
+
+	// Timeout is the real time allowed for the network to settle after each
+	// event. It defaults to ten seconds.
+	Timeout time.Duration
+}
+
+// NewScenarioRunner creates a runner for the given network. The clock must be
+// the one the network's adapter was created with, see
+// adapters.NewSimAdapterWithClock.
+func NewScenarioRunner(network *Network, clock *mclock.Simulated) *ScenarioRunner {
+	return &ScenarioRunner{
+		network: network,
+		clock:   clock,
+		start:   clock.Now(),
+		Timeout: 10 * time.Second,
+	}
+}
+
+// Run applies all events of the scenario in order and checks the resulting
+// peer graph against the expected one. It returns the resulting peer graph.
+func (r *ScenarioRunner) Run(ctx context.Context, s *Scenario) (PeerGraph, error) {
+	for i, ev := range s.Events {
+		if elapsed := r.clock.Now().Sub(r.start); ev.At > elapsed {
+			r.clock.Run(ev.At - elapsed)
+		} else if ev.At < elapsed {
+			return nil, fmt.Errorf("event %d (%s) is scheduled before the previous event", i, ev.Type)
+		}
+		log.Debug("Applying scenario event", "index", i, "type", ev.Type, "at", ev.At)
+		if err := r.apply(ctx, ev); err != nil {
+			return nil, fmt.Errorf("event %d (%s at %v): %v", i, ev.Type, ev.At, err)
+		}
+	}
+	graph := r.network.PeerGraph()
+	if s.Expect != nil && !graph.Equal(s.Expect) {
+		return graph, fmt.Errorf("unexpected peer graph: have %v, want %v", graph, s.Expect.canonical())
+	}
+	return graph, nil
+}
+
+// apply executes a single event and waits until its effect is visible in
+// the network.
+func (r *ScenarioRunner) apply(ctx context.Context, ev ScenarioEvent) error {
+	ids, err := r.resolve(ev.Nodes)
+	if err != nil {
+		return err
+	}
+	switch ev.Type {
+	case ScenarioConnect, ScenarioDisconnect:
+		if len(ids) != 2 {
+			return fmt.Errorf("need exactly two nodes, have %d", len(ids))
+		}
+		up := ev.Type == ScenarioConnect
+		return r.waitFor(ctx, func() error {
+			if up {
+				return r.network.Connect(ids[0], ids[1])
+			}
+			return r.network.Disconnect(ids[0], ids[1])
+		}, func() bool {
+			return r.network.connUp(ids[0], ids[1]) == up
+		})
+
+	case ScenarioStart, ScenarioStop:
+		if len(ids) == 0 {
+			return fmt.Errorf("no nodes given")
+		}
+		up := ev.Type == ScenarioStart
+		return r.waitFor(ctx, func() error {
+			for _, id := range ids {
+				var err error
+				if up {
+					err = r.network.Start(id)
+				} else {
+					err = r.network.Stop(id)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}, func() bool {
+			for _, id := range ids {
+				if r.network.GetNode(id).Up() != up {
+					return false
+				}
+			}
+			// Stopped nodes must have dropped all of their connections
+			return up || !r.hasConns(ids)
+		})
+
+	case ScenarioPartition:
+		groups := make([][]enode.ID, len(ev.Groups))
+		for i, names := range ev.Groups {
+			if groups[i], err = r.resolve(names); err != nil {
+				return err
+			}
+		}
+		return r.waitFor(ctx, func() error {
+			return r.network.Partition(groups...)
+		}, func() bool {
+			for _, conn := range r.network.PeerGraph() {
+				if crossesPartition(ev.Groups, conn) {
+					return false
+				}
+			}
+			return true
+		})
+
+	case ScenarioHeal:
+		r.network.Heal()
+		return nil
+
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+}
+
+// waitFor performs the given action and then waits until the network
+// satisfies the given condition.
+func (r *ScenarioRunner) waitFor(ctx context.Context, action func() error, cond func() bool) error {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	// Network events are sent while holding the network lock, so they
+	// have to be drained on a separate goroutine for cond to make progress.
+	var (
+		events  = make(chan *Event)
+		changed = make(chan struct{}, 1)
+		sub     = r.network.Events().Subscribe(events)
+	)
+	defer sub.Unsubscribe()
+	go func() {
+		for {
+			select {
+			case <-events:
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+
+	if err := action(); err != nil {
+		return err
+	}
+	for !cond() {
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return fmt.Errorf("network did not settle: %v", ctx.Err())
+		}
+	}
+	return nil
+}
+
+// resolve maps node names to IDs
+func (r *ScenarioRunner) resolve(names []string) ([]enode.ID, error) {
+	ids := make([]enode.ID, len(names))
+	for i, name := range names {
+		node := r.network.GetNodeByName(name)
+		if node == nil {
+			return nil, fmt.Errorf("unknown node %q", name)
+		}
+		ids[i] = node.ID()
+	}
+	return ids, nil
+}
+
+// hasConns reports whether any of the given nodes has a connection which is up
+func (r *ScenarioRunner) hasConns(ids []enode.ID) bool {
+	r.network.lock.RLock()
+	defer r.network.lock.RUnlock()
+
+	for _, conn := range r.network.Conns {
+		if !conn.Up {
+			continue
+		}
+		for _, id := range ids {
+			if conn.One == id || conn.Other == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// crossesPartition reports whether the connection links two different groups
+func crossesPartition(groups [][]string, conn [2]string) bool {
+	group := func(name string) int {
+		for i, names := range groups {
+			for _, n := range names {
+				if n == name {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+	one, other := group(conn[0]), group(conn[1])
+	return one >= 0 && other >= 0 && one != other
+}
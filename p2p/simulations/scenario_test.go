@@ -0,0 +1,117 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+)
+
+func newScenarioNetwork(t *testing.T, clock *mclock.Simulated, nodeCount int) *Network {
+	adapter := adapters.NewSimAdapterWithClock(adapters.LifecycleConstructors{
+		"noopwoop": func(ctx *adapters.ServiceContext, stack *node.Node) (node.Lifecycle, error) {
+			return NewNoopService(nil), nil
+		},
+	}, clock)
+	network := NewNetwork(adapter, &NetworkConfig{DefaultService: "noopwoop"})
+	for i := 0; i < nodeCount; i++ {
+		conf := adapters.RandomNodeConfig()
+		conf.Name = fmt.Sprintf("n%d", i)
+		if _, err := network.NewNodeWithConfig(conf); err != nil {
+			t.Fatalf("error creating node: %v", err)
+		}
+	}
+	if err := network.StartAll(); err != nil {
+		t.Fatalf("error starting nodes: %v", err)
+	}
+	return network
+}
+
+func TestScenarioRunner(t *testing.T) {
+	scenario := &Scenario{
+		Events: []ScenarioEvent{
+			{At: 0, Type: ScenarioConnect, Nodes: []string{"n0", "n1"}},
+			{At: 0, Type: ScenarioConnect, Nodes: []string{"n1", "n2"}},
+			{At: time.Second, Type: ScenarioConnect, Nodes: []string{"n2", "n3"}},
+			{At: time.Second, Type: ScenarioConnect, Nodes: []string{"n3", "n0"}},
+			{At: time.Minute, Type: ScenarioPartition, Groups: [][]string{{"n0", "n1"}, {"n2", "n3"}}},
+			{At: 2 * time.Minute, Type: ScenarioHeal},
+			{At: 3 * time.Minute, Type: ScenarioConnect, Nodes: []string{"n1", "n2"}},
+			{At: 3 * time.Minute, Type: ScenarioStop, Nodes: []string{"n3"}},
+		},
+		Expect: PeerGraph{{"n1", "n0"}, {"n2", "n1"}},
+	}
+	// Replaying the scenario must yield the same result every time.
+	for i := 0; i < 3; i++ {
+		clock := new(mclock.Simulated)
+		network := newScenarioNetwork(t, clock, 4)
+		runner := NewScenarioRunner(network, clock)
+		graph, err := runner.Run(context.Background(), scenario)
+		network.Shutdown()
+		if err != nil {
+			t.Fatalf("run %d: %v", i, err)
+		}
+		if clock.Now() != mclock.AbsTime(3*time.Minute) {
+			t.Fatalf("run %d: wrong virtual time %v", i, time.Duration(clock.Now()))
+		}
+		if !graph.Equal(scenario.Expect) {
+			t.Fatalf("run %d: wrong peer graph %v", i, graph)
+		}
+	}
+}
+
+func TestScenarioPartitionRefusesConnect(t *testing.T) {
+	clock := new(mclock.Simulated)
+	network := newScenarioNetwork(t, clock, 2)
+	defer network.Shutdown()
+
+	runner := NewScenarioRunner(network, clock)
+	_, err := runner.Run(context.Background(), &Scenario{
+		Events: []ScenarioEvent{
+			{Type: ScenarioPartition, Groups: [][]string{{"n0"}, {"n1"}}},
+			{Type: ScenarioConnect, Nodes: []string{"n0", "n1"}},
+		},
+	})
+	if err == nil {
+		t.Fatal("expected error connecting partitioned nodes")
+	}
+}
+
+// Tests that dials at virtual time zero are subject to the dial ban.
+func TestInitConnAtTimeZero(t *testing.T) {
+	clock := new(mclock.Simulated)
+	network := newScenarioNetwork(t, clock, 2)
+	defer network.Shutdown()
+
+	one, other := network.Nodes[0].ID(), network.Nodes[1].ID()
+	if _, err := network.InitConn(one, other); err != nil {
+		t.Fatalf("first dial failed: %v", err)
+	}
+	if _, err := network.InitConn(one, other); err == nil {
+		t.Fatal("repeated dial at time zero not refused")
+	}
+	clock.Run(DialBanTimeout)
+	if _, err := network.InitConn(one, other); err != nil {
+		t.Fatalf("dial after ban timeout failed: %v", err)
+	}
+}
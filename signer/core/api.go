@@ -26,6 +26,7 @@ import (
 	"reflect"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/hdwallet"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/accounts/scwallet"
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
@@ -40,9 +41,9 @@ const (
 	// numberOfAccountsToDerive For hardware wallets, the number of accounts to derive
 	numberOfAccountsToDerive = 10
 	// ExternalAPIVersion -- see extapi_changelog.md
-	ExternalAPIVersion = "6.1.0"
+	ExternalAPIVersion = "6.2.0"
 	// InternalAPIVersion -- see intapi_changelog.md
-	InternalAPIVersion = "7.0.1"
+	InternalAPIVersion = "7.1.0"
 )
 
 // ExternalAPI defines the external API through which signing requests are made.
@@ -137,6 +138,7 @@ func StartClefAccountManager(ksLocation string, nousb, lightKDF bool, scpath str
 	// support password based accounts
 	if len(ksLocation) > 0 {
 		backends = append(backends, keystore.NewKeyStore(ksLocation, n, p))
+		backends = append(backends, hdwallet.NewStore(ksLocation, n, p))
 	}
 	if !nousb {
 		// Start a USB hub for Ledger hardware wallets
@@ -473,6 +475,14 @@ func logDiff(original *SignTxRequest, new *SignTxResponse) bool {
 		modified = true
 		log.Info("GasPrice changed by UI", "was", g0, "is", g1)
 	}
+	if g0, g1 := original.Transaction.MaxFeePerGas, new.Transaction.MaxFeePerGas; !reflect.DeepEqual(g0, g1) {
+		modified = true
+		log.Info("MaxFeePerGas changed by UI", "was", g0, "is", g1)
+	}
+	if g0, g1 := original.Transaction.MaxPriorityFeePerGas, new.Transaction.MaxPriorityFeePerGas; !reflect.DeepEqual(g0, g1) {
+		modified = true
+		log.Info("MaxPriorityFeePerGas changed by UI", "was", g0, "is", g1)
+	}
 	if v0, v1 := big.Int(original.Transaction.Value), big.Int(new.Transaction.Value); v0.Cmp(&v1) != 0 {
 		modified = true
 		log.Info("Value changed by UI", "was", v0, "is", v1)
@@ -534,6 +544,9 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 			return nil, err
 		}
 	}
+	if err := args.validateFees(); err != nil {
+		return nil, err
+	}
 	if args.ChainID != nil {
 		requestedChainId := (*big.Int)(args.ChainID)
 		if api.chainID.Cmp(requestedChainId) != 0 {
@@ -567,7 +580,10 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 		return nil, err
 	}
 	// Convert fields into a real transaction
-	var unsignedTx = result.Transaction.toTransaction()
+	unsignedTx, err := result.Transaction.toTransaction()
+	if err != nil {
+		return nil, err
+	}
 	// Get the password for the transaction
 	pw, err := api.lookupOrQueryPassword(acc.Address, "Account password",
 		fmt.Sprintf("Please enter the password for account %s", acc.Address.String()))
@@ -24,6 +24,7 @@ import (
 	"math/big"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -323,3 +324,59 @@ func TestSignTx(t *testing.T) {
 	}
 
 }
+
+func TestSignDynamicFeeTx(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := mkTestTx(common.NewMixedcaseAddress(list[0]))
+	tx.MaxFeePerGas = (*hexutil.Big)(big.NewInt(3000000000))
+	tx.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(1000000000))
+
+	control.approveCh <- "Y"
+	control.inputCh <- "a_long_password"
+	res, err := api.SignTransaction(context.Background(), tx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedTx := new(types.Transaction)
+	if err := parsedTx.UnmarshalBinary(res.Raw); err != nil {
+		t.Fatal(err)
+	}
+	if parsedTx.Type() != types.DynamicFeeTxType {
+		t.Fatalf("Expected dynamic fee transaction, got type %d", parsedTx.Type())
+	}
+	if parsedTx.FeeCap().Cmp(tx.MaxFeePerGas.ToInt()) != 0 || parsedTx.Tip().Cmp(tx.MaxPriorityFeePerGas.ToInt()) != 0 {
+		t.Errorf("Fee mismatch: have %v/%v, want %v/%v", parsedTx.FeeCap(), parsedTx.Tip(), tx.MaxFeePerGas, tx.MaxPriorityFeePerGas)
+	}
+	sender, err := types.Sender(types.NewLondonSigner(parsedTx.ChainId()), parsedTx)
+	if err != nil || sender != list[0] {
+		t.Errorf("Sender mismatch: have %x (%v), want %x", sender, err, list[0])
+	}
+}
+
+func TestSignDynamicFeeTxMissingField(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control.approveCh <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, fee := range []string{"maxFeePerGas", "maxPriorityFeePerGas"} {
+		tx := mkTestTx(common.NewMixedcaseAddress(list[0]))
+		if fee == "maxFeePerGas" {
+			tx.MaxFeePerGas = (*hexutil.Big)(big.NewInt(3000000000))
+		} else {
+			tx.MaxPriorityFeePerGas = (*hexutil.Big)(big.NewInt(1000000000))
+		}
+		// The request must be rejected before being shown to the user
+		if _, err := api.SignTransaction(context.Background(), tx, nil); err == nil || !strings.Contains(err.Error(), "must be set together") {
+			t.Errorf("test %d: only %s set: have error %v", i, fee, err)
+		}
+	}
+}
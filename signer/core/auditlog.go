@@ -112,6 +112,12 @@ func (l *AuditLogger) Version(ctx context.Context) (string, error) {
 
 }
 
+// AuditApproval implements ApprovalAuditor, recording the events of the M-of-N
+// transaction approval flow.
+func (l *AuditLogger) AuditApproval(id uint64, event string, ctx ...interface{}) {
+	l.log.Info("Approval", append([]interface{}{"type", event, "id", id}, ctx...)...)
+}
+
 func NewAuditLogger(path string, api ExternalAPI) (*AuditLogger, error) {
 	l := log.New("api", "signer")
 	handler, err := log.FileHandler(path, log.LogfmtFormat())
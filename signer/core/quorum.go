@@ -0,0 +1,339 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+var (
+	// ErrUnknownReviewer is returned if a reviewer token doesn't match any of
+	// the configured reviewers.
+	ErrUnknownReviewer = errors.New("unknown reviewer")
+
+	// ErrUnknownApproval is returned if a vote is cast on a request which is not
+	// pending (anymore).
+	ErrUnknownApproval = errors.New("unknown or already decided request")
+
+	// ErrAlreadyVoted is returned if a reviewer attempts to vote twice on the
+	// same request.
+	ErrAlreadyVoted = errors.New("reviewer already voted")
+)
+
+// QuorumReviewer is a party allowed to vote on pending transaction requests.
+type QuorumReviewer struct {
+	Name      string      `json:"name"`
+	TokenHash common.Hash `json:"tokenHash"` // sha256 of the reviewer's access token
+}
+
+// QuorumConfig is the configuration of the M-of-N transaction approval flow.
+type QuorumConfig struct {
+	Threshold int              `json:"threshold"` // Number of approvals required
+	Timeout   string           `json:"timeout"`   // Duration after which pending requests are rejected
+	Reviewers []QuorumReviewer `json:"reviewers"`
+}
+
+// ApprovalAuditor is notified of every event of the approval flow, i.e. new
+// pending requests, individual votes and final outcomes.
+type ApprovalAuditor interface {
+	AuditApproval(id uint64, event string, ctx ...interface{})
+}
+
+// PendingApproval is the reviewer facing representation of a transaction request
+// waiting for a quorum.
+type PendingApproval struct {
+	ID         uint64         `json:"id"`
+	Request    *SignTxRequest `json:"request"`
+	Created    time.Time      `json:"created"`
+	Deadline   time.Time      `json:"deadline"`
+	Approvals  []string       `json:"approvals"`
+	Rejections []string       `json:"rejections"`
+}
+
+// pendingApproval is a transaction request held until its quorum is reached.
+type pendingApproval struct {
+	PendingApproval
+	votes   map[string]bool // Votes cast so far, true for approvals
+	decided bool            // Whether the outcome was already delivered
+	done    chan bool       // Channel to deliver the outcome on
+}
+
+// QuorumUI is an implementation of UIClientAPI which requires transaction
+// requests to be approved by a threshold of reviewers before signing. Reviewers
+// vote via QuorumAPI, each of them authenticated by their own access token.
+// All other requests are forwarded to the next UI.
+type QuorumUI struct {
+	next      UIClientAPI
+	threshold int
+	timeout   time.Duration
+	reviewers map[common.Hash]string // Reviewer names keyed by token hash
+	auditor   ApprovalAuditor
+
+	pending map[uint64]*pendingApproval
+	nextID  uint64
+	lock    sync.Mutex
+}
+
+// NewQuorumUI creates an M-of-N approval UI from the given configuration.
+func NewQuorumUI(next UIClientAPI, config *QuorumConfig) (*QuorumUI, error) {
+	timeout, err := time.ParseDuration(config.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %v", err)
+	}
+	if timeout <= 0 {
+		return nil, errors.New("timeout must be positive")
+	}
+	reviewers := make(map[common.Hash]string)
+	names := make(map[string]bool)
+	for _, reviewer := range config.Reviewers {
+		if reviewer.Name == "" {
+			return nil, errors.New("reviewer without name")
+		}
+		if names[reviewer.Name] {
+			return nil, fmt.Errorf("duplicate reviewer %q", reviewer.Name)
+		}
+		if _, ok := reviewers[reviewer.TokenHash]; ok {
+			return nil, fmt.Errorf("reviewer %q shares token with %q", reviewer.Name, reviewers[reviewer.TokenHash])
+		}
+		names[reviewer.Name] = true
+		reviewers[reviewer.TokenHash] = reviewer.Name
+	}
+	if config.Threshold < 1 || config.Threshold > len(reviewers) {
+		return nil, fmt.Errorf("invalid threshold %d for %d reviewers", config.Threshold, len(reviewers))
+	}
+	return &QuorumUI{
+		next:      next,
+		threshold: config.Threshold,
+		timeout:   timeout,
+		reviewers: reviewers,
+		pending:   make(map[uint64]*pendingApproval),
+	}, nil
+}
+
+// SetAuditor sets the auditor to record approval events with.
+func (q *QuorumUI) SetAuditor(auditor ApprovalAuditor) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.auditor = auditor
+}
+
+// Timeout returns the duration after which pending requests are rejected.
+func (q *QuorumUI) Timeout() time.Duration {
+	return q.timeout
+}
+
+// audit records an approval event in the log and with the auditor, if any. The
+// caller must hold the lock.
+func (q *QuorumUI) audit(id uint64, event string, ctx ...interface{}) {
+	log.Info("Approval "+event, append([]interface{}{"id", id}, ctx...)...)
+	if q.auditor != nil {
+		q.auditor.AuditApproval(id, event, ctx...)
+	}
+}
+
+// reviewer authenticates a reviewer by its access token.
+func (q *QuorumUI) reviewer(token string) (string, error) {
+	name, ok := q.reviewers[sha256.Sum256([]byte(token))]
+	if !ok {
+		return "", ErrUnknownReviewer
+	}
+	return name, nil
+}
+
+// Pending returns the requests waiting for votes, ordered by id.
+func (q *QuorumUI) Pending() []*PendingApproval {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	pending := make([]*PendingApproval, 0, len(q.pending))
+	for _, p := range q.pending {
+		approval := p.PendingApproval
+		approval.Approvals = append([]string(nil), p.Approvals...)
+		approval.Rejections = append([]string(nil), p.Rejections...)
+		pending = append(pending, &approval)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].ID < pending[j].ID })
+	return pending
+}
+
+// vote casts a reviewer's vote on a pending request, delivering the outcome if
+// the vote decides it.
+func (q *QuorumUI) vote(token string, id uint64, approve bool, reason string) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	name, err := q.reviewer(token)
+	if err != nil {
+		return err
+	}
+	p, ok := q.pending[id]
+	if !ok {
+		return ErrUnknownApproval
+	}
+	if _, ok := p.votes[name]; ok {
+		return ErrAlreadyVoted
+	}
+	p.votes[name] = approve
+	if approve {
+		p.Approvals = append(p.Approvals, name)
+		q.audit(id, "vote", "reviewer", name, "approved", true)
+	} else {
+		p.Rejections = append(p.Rejections, name)
+		q.audit(id, "vote", "reviewer", name, "approved", false, "reason", reason)
+	}
+	switch {
+	case len(p.Approvals) >= q.threshold:
+		q.decide(p, true, "quorum reached")
+	case len(p.Rejections) > len(q.reviewers)-q.threshold:
+		q.decide(p, false, "quorum unreachable")
+	}
+	return nil
+}
+
+// decide delivers the outcome of a pending request. The caller must hold the lock.
+func (q *QuorumUI) decide(p *pendingApproval, approved bool, reason string) {
+	p.decided = true
+	delete(q.pending, p.ID)
+
+	q.audit(p.ID, "outcome", "approved", approved, "reason", reason,
+		"approvals", p.Approvals, "rejections", p.Rejections)
+	p.done <- approved
+}
+
+// ApproveTx holds the transaction request until enough reviewers approve it,
+// rejecting it once the quorum becomes unreachable or the timeout passes.
+func (q *QuorumUI) ApproveTx(request *SignTxRequest) (SignTxResponse, error) {
+	q.lock.Lock()
+	q.nextID++
+	now := time.Now()
+	p := &pendingApproval{
+		PendingApproval: PendingApproval{
+			ID:       q.nextID,
+			Request:  request,
+			Created:  now,
+			Deadline: now.Add(q.timeout),
+		},
+		votes: make(map[string]bool),
+		done:  make(chan bool, 1),
+	}
+	q.pending[p.ID] = p
+	q.audit(p.ID, "request", "metadata", request.Meta.String(), "tx", request.Transaction.String(),
+		"threshold", q.threshold, "reviewers", len(q.reviewers))
+	q.lock.Unlock()
+
+	q.next.ShowInfo(fmt.Sprintf("Transaction request #%d awaiting approval by %d of %d reviewers", p.ID, q.threshold, len(q.reviewers)))
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	var approved bool
+	select {
+	case approved = <-p.done:
+	case <-timer.C:
+		q.lock.Lock()
+		if !p.decided {
+			q.decide(p, false, "timeout")
+		}
+		q.lock.Unlock()
+		approved = <-p.done
+	}
+	if !approved {
+		return SignTxResponse{Approved: false}, nil
+	}
+	return SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+}
+
+func (q *QuorumUI) ApproveSignData(request *SignDataRequest) (SignDataResponse, error) {
+	return q.next.ApproveSignData(request)
+}
+
+func (q *QuorumUI) ApproveListing(request *ListRequest) (ListResponse, error) {
+	return q.next.ApproveListing(request)
+}
+
+func (q *QuorumUI) ApproveNewAccount(request *NewAccountRequest) (NewAccountResponse, error) {
+	return q.next.ApproveNewAccount(request)
+}
+
+func (q *QuorumUI) ShowError(message string) {
+	q.next.ShowError(message)
+}
+
+func (q *QuorumUI) ShowInfo(message string) {
+	q.next.ShowInfo(message)
+}
+
+func (q *QuorumUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	q.next.OnApprovedTx(tx)
+}
+
+func (q *QuorumUI) OnSignerStartup(info StartupInfo) {
+	q.next.OnSignerStartup(info)
+}
+
+func (q *QuorumUI) OnInputRequired(info UserInputRequest) (UserInputResponse, error) {
+	return q.next.OnInputRequired(info)
+}
+
+func (q *QuorumUI) RegisterUIServer(api *UIServerAPI) {
+	q.next.RegisterUIServer(api)
+}
+
+// QuorumAPI is the reviewer facing API of the approval flow. Every method is
+// authenticated by the access token of the calling reviewer.
+type QuorumAPI struct {
+	ui *QuorumUI
+}
+
+// NewQuorumAPI creates the reviewer API for the given approval UI.
+func NewQuorumAPI(ui *QuorumUI) *QuorumAPI {
+	return &QuorumAPI{ui: ui}
+}
+
+// Pending lists the transaction requests waiting for votes.
+// Example call
+// {"jsonrpc":"2.0","method":"quorum_pending","params":["<token>"], "id":6}
+func (api *QuorumAPI) Pending(token string) ([]*PendingApproval, error) {
+	if _, err := api.ui.reviewer(token); err != nil {
+		return nil, err
+	}
+	return api.ui.Pending(), nil
+}
+
+// Approve votes in favour of a pending transaction request.
+// Example call
+// {"jsonrpc":"2.0","method":"quorum_approve","params":["<token>", 1], "id":6}
+func (api *QuorumAPI) Approve(token string, id uint64) error {
+	return api.ui.vote(token, id, true, "")
+}
+
+// Reject votes against a pending transaction request.
+// Example call
+// {"jsonrpc":"2.0","method":"quorum_reject","params":["<token>", 1, "unknown recipient"], "id":6}
+func (api *QuorumAPI) Reject(token string, id uint64, reason string) error {
+	return api.ui.vote(token, id, false, reason)
+}
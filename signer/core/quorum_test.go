@@ -0,0 +1,181 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/signer/core"
+)
+
+// recordingAuditor collects the approval events reported by the quorum UI.
+type recordingAuditor struct {
+	events []string
+	lock   sync.Mutex
+}
+
+func (a *recordingAuditor) AuditApproval(id uint64, event string, ctx ...interface{}) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.events = append(a.events, fmt.Sprintf("%d:%s", id, event))
+}
+
+func newTestQuorum(t *testing.T, threshold int, timeout string) (*core.QuorumUI, *core.QuorumAPI, *recordingAuditor) {
+	config := &core.QuorumConfig{Threshold: threshold, Timeout: timeout}
+	for _, name := range []string{"alice", "bob", "carol"} {
+		config.Reviewers = append(config.Reviewers, core.QuorumReviewer{
+			Name:      name,
+			TokenHash: sha256.Sum256([]byte(name + "-token")),
+		})
+	}
+	ui, err := core.NewQuorumUI(&headlessUi{}, config)
+	if err != nil {
+		t.Fatalf("failed to create quorum ui: %v", err)
+	}
+	auditor := new(recordingAuditor)
+	ui.SetAuditor(auditor)
+	return ui, core.NewQuorumAPI(ui), auditor
+}
+
+// requestApproval submits a transaction for approval, returning a channel with
+// the outcome once the request became visible to reviewers.
+func requestApproval(t *testing.T, ui *core.QuorumUI, api *core.QuorumAPI) (uint64, chan bool) {
+	before := len(ui.Pending())
+	result := make(chan bool, 1)
+	go func() {
+		request := &core.SignTxRequest{Transaction: mkTestTx(common.NewMixedcaseAddress(common.Address{0x01}))}
+		resp, err := ui.ApproveTx(request)
+		if err != nil {
+			t.Errorf("approval failed: %v", err)
+		}
+		result <- resp.Approved
+	}()
+	for i := 0; i < 100; i++ {
+		pending, err := api.Pending("alice-token")
+		if err != nil {
+			t.Fatalf("failed to list pending requests: %v", err)
+		}
+		if len(pending) > before {
+			return pending[len(pending)-1].ID, result
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("request never became pending")
+	return 0, nil
+}
+
+func TestQuorumConfig(t *testing.T) {
+	tests := []*core.QuorumConfig{
+		{Threshold: 1, Timeout: "soon", Reviewers: []core.QuorumReviewer{{Name: "a", TokenHash: common.Hash{1}}}},
+		{Threshold: 2, Timeout: "1m", Reviewers: []core.QuorumReviewer{{Name: "a", TokenHash: common.Hash{1}}}},
+		{Threshold: 0, Timeout: "1m", Reviewers: []core.QuorumReviewer{{Name: "a", TokenHash: common.Hash{1}}}},
+		{Threshold: 1, Timeout: "1m", Reviewers: []core.QuorumReviewer{{Name: "a", TokenHash: common.Hash{1}}, {Name: "a", TokenHash: common.Hash{2}}}},
+		{Threshold: 1, Timeout: "1m", Reviewers: []core.QuorumReviewer{{Name: "a", TokenHash: common.Hash{1}}, {Name: "b", TokenHash: common.Hash{1}}}},
+	}
+	for i, config := range tests {
+		if _, err := core.NewQuorumUI(&headlessUi{}, config); err == nil {
+			t.Errorf("test %d: invalid config accepted", i)
+		}
+	}
+}
+
+func TestQuorumApproval(t *testing.T) {
+	ui, api, auditor := newTestQuorum(t, 2, "1m")
+
+	id, result := requestApproval(t, ui, api)
+	if err := api.Approve("mallory-token", id); err != core.ErrUnknownReviewer {
+		t.Fatalf("unauthenticated vote error mismatch: have %v, want %v", err, core.ErrUnknownReviewer)
+	}
+	if _, err := api.Pending("mallory-token"); err != core.ErrUnknownReviewer {
+		t.Fatalf("unauthenticated listing error mismatch: have %v, want %v", err, core.ErrUnknownReviewer)
+	}
+	if err := api.Approve("alice-token", id); err != nil {
+		t.Fatalf("failed to approve: %v", err)
+	}
+	if err := api.Approve("alice-token", id); err != core.ErrAlreadyVoted {
+		t.Fatalf("double vote error mismatch: have %v, want %v", err, core.ErrAlreadyVoted)
+	}
+	select {
+	case <-result:
+		t.Fatalf("request decided before quorum")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := api.Reject("bob-token", id, "not sure"); err != nil {
+		t.Fatalf("failed to reject: %v", err)
+	}
+	if err := api.Approve("carol-token", id); err != nil {
+		t.Fatalf("failed to approve: %v", err)
+	}
+	if approved := <-result; !approved {
+		t.Fatalf("request rejected despite quorum")
+	}
+	if err := api.Approve("bob-token", id); err != core.ErrUnknownApproval {
+		t.Fatalf("vote on decided request error mismatch: have %v, want %v", err, core.ErrUnknownApproval)
+	}
+	want := []string{"1:request", "1:vote", "1:vote", "1:vote", "1:outcome"}
+	if fmt.Sprint(auditor.events) != fmt.Sprint(want) {
+		t.Errorf("audit events mismatch: have %v, want %v", auditor.events, want)
+	}
+}
+
+func TestQuorumRejection(t *testing.T) {
+	ui, api, _ := newTestQuorum(t, 2, "1m")
+
+	// Two rejections out of three reviewers make the quorum unreachable
+	id, result := requestApproval(t, ui, api)
+	if err := api.Reject("alice-token", id, "no"); err != nil {
+		t.Fatalf("failed to reject: %v", err)
+	}
+	if err := api.Reject("bob-token", id, "no"); err != nil {
+		t.Fatalf("failed to reject: %v", err)
+	}
+	if approved := <-result; approved {
+		t.Fatalf("request approved without quorum")
+	}
+	if pending := ui.Pending(); len(pending) != 0 {
+		t.Fatalf("decided request still pending: %v", pending)
+	}
+}
+
+func TestQuorumTimeout(t *testing.T) {
+	ui, api, auditor := newTestQuorum(t, 2, "100ms")
+
+	id, result := requestApproval(t, ui, api)
+	if err := api.Approve("alice-token", id); err != nil {
+		t.Fatalf("failed to approve: %v", err)
+	}
+	select {
+	case approved := <-result:
+		if approved {
+			t.Fatalf("request approved without quorum")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("request not rejected after timeout")
+	}
+	if err := api.Approve("bob-token", id); err != core.ErrUnknownApproval {
+		t.Fatalf("vote on timed out request error mismatch: have %v, want %v", err, core.ErrUnknownApproval)
+	}
+	want := []string{"1:request", "1:vote", "1:outcome"}
+	if fmt.Sprint(auditor.events) != fmt.Sprint(want) {
+		t.Errorf("audit events mismatch: have %v, want %v", auditor.events, want)
+	}
+}
@@ -18,6 +18,7 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -80,6 +81,10 @@ type SendTxArgs struct {
 	// For non-legacy transactions
 	AccessList *types.AccessList `json:"accessList,omitempty"`
 	ChainID    *hexutil.Big      `json:"chainId,omitempty"`
+
+	// For EIP-1559 transactions
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas,omitempty"`
 }
 
 func (args SendTxArgs) String() string {
@@ -90,7 +95,22 @@ func (args SendTxArgs) String() string {
 	return err.Error()
 }
 
-func (args *SendTxArgs) toTransaction() *types.Transaction {
+// errMissingFeeField is returned if only one of the EIP-1559 fee fields is set.
+var errMissingFeeField = errors.New("maxFeePerGas and maxPriorityFeePerGas must be set together")
+
+// validateFees checks that the request either sets both EIP-1559 fee fields
+// or neither of them.
+func (args *SendTxArgs) validateFees() error {
+	if (args.MaxFeePerGas == nil) != (args.MaxPriorityFeePerGas == nil) {
+		return errMissingFeeField
+	}
+	return nil
+}
+
+func (args *SendTxArgs) toTransaction() (*types.Transaction, error) {
+	if err := args.validateFees(); err != nil {
+		return nil, err
+	}
 	var input []byte
 	if args.Data != nil {
 		input = *args.Data
@@ -103,7 +123,24 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 		to = &_to
 	}
 	var data types.TxData
-	if args.AccessList == nil {
+	switch {
+	case args.MaxFeePerGas != nil:
+		var al types.AccessList
+		if args.AccessList != nil {
+			al = *args.AccessList
+		}
+		data = &types.DynamicFeeTx{
+			To:         to,
+			ChainID:    (*big.Int)(args.ChainID),
+			Nonce:      uint64(args.Nonce),
+			Gas:        uint64(args.Gas),
+			FeeCap:     (*big.Int)(args.MaxFeePerGas),
+			Tip:        (*big.Int)(args.MaxPriorityFeePerGas),
+			Value:      (*big.Int)(&args.Value),
+			Data:       input,
+			AccessList: al,
+		}
+	case args.AccessList == nil:
 		data = &types.LegacyTx{
 			To:       to,
 			Nonce:    uint64(args.Nonce),
@@ -112,7 +149,7 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 			Value:    (*big.Int)(&args.Value),
 			Data:     input,
 		}
-	} else {
+	default:
 		data = &types.AccessListTx{
 			To:         to,
 			ChainID:    (*big.Int)(args.ChainID),
@@ -124,5 +161,5 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 			AccessList: *args.AccessList,
 		}
 	}
-	return types.NewTx(data)
+	return types.NewTx(data), nil
 }
@@ -25,6 +25,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/hdwallet"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/math"
@@ -130,6 +131,60 @@ func (s *UIServerAPI) ImportRawKey(privkey string, password string) (accounts.Ac
 	return fetchKeystore(s.am).ImportECDSA(key, password)
 }
 
+// fetchHDStore retrieves the software HD wallet store from the account manager.
+func fetchHDStore(am *accounts.Manager) (*hdwallet.Store, error) {
+	backends := am.Backends(hdwallet.StoreType)
+	if len(backends) == 0 {
+		return nil, errors.New("hd wallets not supported")
+	}
+	return backends[0].(*hdwallet.Store), nil
+}
+
+// NewHDWalletResult is the response of a HD wallet creation, containing the
+// freshly generated mnemonic the user must back up.
+type NewHDWalletResult struct {
+	URL      string `json:"url"`
+	Mnemonic string `json:"mnemonic"`
+}
+
+// NewHDWallet generates a new mnemonic backed HD wallet, encrypting its seed with
+// the passphrase. The mnemonic is returned once and never stored in plain text.
+// Example call
+// {"jsonrpc":"2.0","method":"clef_newHDWallet","params":["password123"], "id":6}
+func (s *UIServerAPI) NewHDWallet(password string) (*NewHDWalletResult, error) {
+	if err := ValidatePasswordFormat(password); err != nil {
+		return nil, fmt.Errorf("password requirements not met: %v", err)
+	}
+	store, err := fetchHDStore(s.am)
+	if err != nil {
+		return nil, err
+	}
+	wallet, mnemonic, err := store.NewWallet(password)
+	if err != nil {
+		return nil, err
+	}
+	return &NewHDWalletResult{URL: wallet.URL().String(), Mnemonic: mnemonic}, nil
+}
+
+// ImportMnemonic stores the given BIP-39 mnemonic as a HD wallet, encrypting its
+// seed with the passphrase, and returns the URL of the new wallet.
+// Example call
+// {"jsonrpc":"2.0","method":"clef_importMnemonic","params":["abandon abandon ... about","password123"], "id":6}
+func (s *UIServerAPI) ImportMnemonic(mnemonic string, password string) (string, error) {
+	if err := ValidatePasswordFormat(password); err != nil {
+		return "", fmt.Errorf("password requirements not met: %v", err)
+	}
+	store, err := fetchHDStore(s.am)
+	if err != nil {
+		return "", err
+	}
+	wallet, err := store.Import(mnemonic, password)
+	if err != nil {
+		return "", err
+	}
+	return wallet.URL().String(), nil
+}
+
 // OpenWallet initiates a hardware wallet opening procedure, establishing a USB
 // connection and attempting to authenticate via the provided passphrase. Note,
 // the method may return an extra challenge requiring a second open (e.g. the
@@ -0,0 +1,460 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+// PolicyAction is the outcome of a policy evaluation.
+type PolicyAction string
+
+const (
+	ActionManual  PolicyAction = "manual"  // Forward the request to the next UI
+	ActionApprove PolicyAction = "approve" // Approve the request without user interaction
+	ActionReject  PolicyAction = "reject"  // Reject the request without user interaction
+)
+
+// Policy is a declarative alternative to javascript rulesets. Transactions are
+// approved if any of the rules allows them, other requests are handled according
+// to the static actions configured for them.
+type Policy struct {
+	Listing  PolicyAction `json:"listing,omitempty"`  // Action for account listings, manual by default
+	SignData PolicyAction `json:"signData,omitempty"` // Action for data signing requests, manual by default
+	Fallback PolicyAction `json:"fallback,omitempty"` // Action for transactions no rule approved, manual by default
+	Rules    []PolicyRule `json:"rules"`
+}
+
+// PolicyRule is a set of constraints a transaction must satisfy in full to be
+// approved by the rule. Unset constraints are not checked.
+type PolicyRule struct {
+	Name        string                `json:"name"`                  // Unique name, used in explanations
+	From        []common.Address      `json:"from,omitempty"`        // Senders the rule applies to, any if empty
+	To          []common.Address      `json:"to,omitempty"`          // Allowed recipients, any if empty
+	Create      bool                  `json:"create,omitempty"`      // Whether contract creations are allowed
+	Selectors   []hexutil.Bytes       `json:"selectors,omitempty"`   // Allowed 4 byte method selectors, any if empty
+	MaxValue    *math.HexOrDecimal256 `json:"maxValue,omitempty"`    // Maximum value of a single transaction
+	DailyLimit  *math.HexOrDecimal256 `json:"dailyLimit,omitempty"`  // Maximum value per sender per UTC day, across all rules
+	MaxGasPrice *math.HexOrDecimal256 `json:"maxGasPrice,omitempty"` // Maximum gas price or fee cap
+	Window      *TimeWindow           `json:"window,omitempty"`      // Time of the week the rule is active in
+}
+
+// TimeWindow is a recurring period of time in UTC. If From is after To, the
+// window wraps around midnight.
+type TimeWindow struct {
+	Days []string `json:"days,omitempty"` // Weekdays as "mon".."sun", all if empty
+	From string   `json:"from"`           // Start of the window as "15:04"
+	To   string   `json:"to"`             // End of the window (exclusive) as "15:04"
+
+	days     map[time.Weekday]bool
+	from, to int // Minutes since midnight
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// parse validates the time window and caches its parsed representation.
+func (w *TimeWindow) parse() error {
+	w.days = make(map[time.Weekday]bool)
+	for _, day := range w.Days {
+		weekday, ok := weekdays[strings.ToLower(day)]
+		if !ok {
+			return fmt.Errorf("invalid weekday %q", day)
+		}
+		w.days[weekday] = true
+	}
+	var err error
+	if w.from, err = parseClock(w.From); err != nil {
+		return err
+	}
+	if w.to, err = parseClock(w.To); err != nil {
+		return err
+	}
+	return nil
+}
+
+// contains reports whether the given time falls into the window.
+func (w *TimeWindow) contains(now time.Time) bool {
+	now = now.UTC()
+	minute := now.Hour()*60 + now.Minute()
+
+	// Minutes after midnight of a wrapping window belong to the previous day
+	day := now.Weekday()
+	if w.from > w.to && minute < w.to {
+		day = (day + 6) % 7
+	}
+	if len(w.days) > 0 && !w.days[day] {
+		return false
+	}
+	if w.from <= w.to {
+		return minute >= w.from && minute < w.to
+	}
+	return minute >= w.from || minute < w.to
+}
+
+// parseClock converts a "15:04" formatted time of day into minutes since midnight.
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// PolicyDecision is the outcome of evaluating a request against a policy, along
+// with an explanation of why it was reached.
+type PolicyDecision struct {
+	Action PolicyAction `json:"action"`
+	Rule   string       `json:"rule,omitempty"` // Rule which approved the request, if any
+	Reason string       `json:"reason"`
+}
+
+// String implements fmt.Stringer.
+func (d PolicyDecision) String() string {
+	if d.Rule != "" {
+		return fmt.Sprintf("%s by rule %q: %s", d.Action, d.Rule, d.Reason)
+	}
+	return fmt.Sprintf("%s: %s", d.Action, d.Reason)
+}
+
+// spendRecord is the value tracked per rule and sender in the policy storage.
+type spendRecord struct {
+	Day   string                `json:"day"`
+	Spent *math.HexOrDecimal256 `json:"spent"`
+}
+
+// policyUI provides an implementation of UIClientAPI that evaluates requests
+// against a declarative policy, forwarding undecided ones to the next UI.
+type policyUI struct {
+	next    core.UIClientAPI // The next handler, for manual processing
+	storage storage.Storage  // Storage for spend accounting
+	explain bool             // Whether to report decisions to the next UI
+	policy  *Policy
+	now     func() time.Time
+	lock    sync.Mutex // Serializes spend accounting
+}
+
+// NewPolicyEvaluator creates a policy based UI wrapper. Daily spends are tracked
+// in the given storage. If explain is set, every decision is also reported to
+// the next UI, detailing which rule approved or rejected a request.
+func NewPolicyEvaluator(next core.UIClientAPI, backend storage.Storage, explain bool) (*policyUI, error) {
+	return &policyUI{
+		next:    next,
+		storage: backend,
+		explain: explain,
+		policy:  new(Policy),
+		now:     time.Now,
+	}, nil
+}
+
+// Init parses and validates the policy to evaluate requests against. Unknown
+// fields are rejected to avoid silently ignoring misspelled constraints.
+func (p *policyUI) Init(policyJSON []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(policyJSON))
+	dec.DisallowUnknownFields()
+
+	policy := new(Policy)
+	if err := dec.Decode(policy); err != nil {
+		return fmt.Errorf("invalid policy: %v", err)
+	}
+	for _, action := range []*PolicyAction{&policy.Listing, &policy.SignData, &policy.Fallback} {
+		switch *action {
+		case "":
+			*action = ActionManual
+		case ActionManual, ActionApprove, ActionReject:
+		default:
+			return fmt.Errorf("invalid policy action %q", *action)
+		}
+	}
+	if policy.Fallback == ActionApprove {
+		return errors.New("fallback action cannot be approve")
+	}
+	names := make(map[string]bool)
+	for i, rule := range policy.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule #%d: missing name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("rule %q: duplicate name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		for _, selector := range rule.Selectors {
+			if len(selector) != 4 {
+				return fmt.Errorf("rule %q: invalid selector %v", rule.Name, selector)
+			}
+		}
+		if rule.Window != nil {
+			if err := rule.Window.parse(); err != nil {
+				return fmt.Errorf("rule %q: %v", rule.Name, err)
+			}
+		}
+	}
+	p.policy = policy
+	return nil
+}
+
+// Explain evaluates a transaction against the policy without accounting for
+// its value, reporting the decision that would be made.
+func (p *policyUI) Explain(args *core.SendTxArgs) PolicyDecision {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.evaluate(args, p.now())
+}
+
+// evaluate checks a transaction against all the rules of the policy, returning
+// the decision. The caller must hold the lock.
+func (p *policyUI) evaluate(args *core.SendTxArgs, now time.Time) PolicyDecision {
+	var reasons []string
+	for _, rule := range p.policy.Rules {
+		err := p.check(&rule, args, now)
+		if err == nil {
+			return PolicyDecision{Action: ActionApprove, Rule: rule.Name, Reason: "all constraints satisfied"}
+		}
+		reasons = append(reasons, fmt.Sprintf("rule %q: %v", rule.Name, err))
+	}
+	reason := "no rules configured"
+	if len(reasons) > 0 {
+		reason = strings.Join(reasons, "; ")
+	}
+	return PolicyDecision{Action: p.policy.Fallback, Reason: reason}
+}
+
+// spendKey returns the storage key the daily spend of a sender is accounted
+// under. It is shared by all rules, so that a sender matching several of them
+// still has a single daily budget.
+func spendKey(from common.Address) string {
+	return "policy:spent:" + from.Hex()
+}
+
+// check verifies a transaction against a single rule, returning the first
+// violated constraint.
+func (p *policyUI) check(rule *PolicyRule, args *core.SendTxArgs, now time.Time) error {
+	from := args.From.Address()
+	if len(rule.From) > 0 && !containsAddress(rule.From, from) {
+		return fmt.Errorf("sender %v not allowed", from)
+	}
+	if args.To == nil {
+		if !rule.Create {
+			return errors.New("contract creation not allowed")
+		}
+	} else if len(rule.To) > 0 && !containsAddress(rule.To, args.To.Address()) {
+		return fmt.Errorf("recipient %v not allowed", args.To.Address())
+	}
+	if len(rule.Selectors) > 0 {
+		var data []byte
+		if args.Data != nil {
+			data = *args.Data
+		} else if args.Input != nil {
+			data = *args.Input
+		}
+		if len(data) < 4 {
+			return errors.New("method call required")
+		}
+		allowed := false
+		for _, selector := range rule.Selectors {
+			if bytes.Equal(selector, data[:4]) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("method selector %#x not allowed", data[:4])
+		}
+	}
+	value := args.Value.ToInt()
+	if rule.MaxValue != nil && value.Cmp((*big.Int)(rule.MaxValue)) > 0 {
+		return fmt.Errorf("value %v above maximum %v", value, (*big.Int)(rule.MaxValue))
+	}
+	if rule.MaxGasPrice != nil {
+		price := args.GasPrice.ToInt()
+		if args.MaxFeePerGas != nil {
+			price = args.MaxFeePerGas.ToInt()
+		}
+		if price.Cmp((*big.Int)(rule.MaxGasPrice)) > 0 {
+			return fmt.Errorf("gas price %v above maximum %v", price, (*big.Int)(rule.MaxGasPrice))
+		}
+	}
+	if rule.Window != nil && !rule.Window.contains(now) {
+		return fmt.Errorf("outside of time window %s-%s", rule.Window.From, rule.Window.To)
+	}
+	if rule.DailyLimit != nil {
+		spent, err := p.spent(spendKey(from), now)
+		if err != nil {
+			return err
+		}
+		total := new(big.Int).Add(spent, value)
+		if total.Cmp((*big.Int)(rule.DailyLimit)) > 0 {
+			return fmt.Errorf("daily limit %v exceeded, %v already spent", (*big.Int)(rule.DailyLimit), spent)
+		}
+	}
+	return nil
+}
+
+// spent retrieves the value already spent today under the given key.
+func (p *policyUI) spent(key string, now time.Time) (*big.Int, error) {
+	blob, err := p.storage.Get(key)
+	if err == storage.ErrNotFound {
+		return new(big.Int), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record spendRecord
+	if err := json.Unmarshal([]byte(blob), &record); err != nil || record.Spent == nil {
+		return nil, fmt.Errorf("corrupt spend record: %v", err)
+	}
+	if record.Day != now.UTC().Format("2006-01-02") {
+		return new(big.Int), nil
+	}
+	return (*big.Int)(record.Spent), nil
+}
+
+// account adds the value of an approved transaction to today's spend.
+func (p *policyUI) account(key string, value *big.Int, now time.Time) error {
+	spent, err := p.spent(key, now)
+	if err != nil {
+		return err
+	}
+	blob, err := json.Marshal(&spendRecord{
+		Day:   now.UTC().Format("2006-01-02"),
+		Spent: (*math.HexOrDecimal256)(new(big.Int).Add(spent, value)),
+	})
+	if err != nil {
+		return err
+	}
+	p.storage.Put(key, string(blob))
+	return nil
+}
+
+// report logs a policy decision, also forwarding it to the next UI in explain mode.
+func (p *policyUI) report(kind string, decision PolicyDecision) {
+	log.Info("Policy decision", "request", kind, "action", decision.Action, "rule", decision.Rule, "reason", decision.Reason)
+	if p.explain {
+		p.next.ShowInfo(fmt.Sprintf("Policy %s %s", kind, decision))
+	}
+}
+
+func (p *policyUI) RegisterUIServer(api *core.UIServerAPI) {
+	p.next.RegisterUIServer(api)
+}
+
+// ApproveTx approves a transaction if any policy rule allows it. The value of
+// approved transactions is accounted against the sender's daily spend right
+// away, so requests failing to sign afterwards still consume allowance.
+func (p *policyUI) ApproveTx(request *core.SignTxRequest) (core.SignTxResponse, error) {
+	p.lock.Lock()
+	now := p.now()
+	decision := p.evaluate(&request.Transaction, now)
+	if decision.Action == ActionApprove {
+		key := spendKey(request.Transaction.From.Address())
+		if err := p.account(key, request.Transaction.Value.ToInt(), now); err != nil {
+			decision = PolicyDecision{Action: p.policy.Fallback, Reason: fmt.Sprintf("spend accounting failed: %v", err)}
+		}
+	}
+	p.lock.Unlock()
+
+	p.report("transaction", decision)
+	switch decision.Action {
+	case ActionApprove:
+		return core.SignTxResponse{Transaction: request.Transaction, Approved: true}, nil
+	case ActionReject:
+		return core.SignTxResponse{Approved: false}, nil
+	default:
+		return p.next.ApproveTx(request)
+	}
+}
+
+func (p *policyUI) ApproveSignData(request *core.SignDataRequest) (core.SignDataResponse, error) {
+	p.report("data signing", PolicyDecision{Action: p.policy.SignData, Reason: "static data signing action"})
+	switch p.policy.SignData {
+	case ActionApprove:
+		return core.SignDataResponse{Approved: true}, nil
+	case ActionReject:
+		return core.SignDataResponse{Approved: false}, nil
+	default:
+		return p.next.ApproveSignData(request)
+	}
+}
+
+func (p *policyUI) ApproveListing(request *core.ListRequest) (core.ListResponse, error) {
+	p.report("listing", PolicyDecision{Action: p.policy.Listing, Reason: "static listing action"})
+	switch p.policy.Listing {
+	case ActionApprove:
+		return core.ListResponse{Accounts: request.Accounts}, nil
+	case ActionReject:
+		return core.ListResponse{}, nil
+	default:
+		return p.next.ApproveListing(request)
+	}
+}
+
+func (p *policyUI) ApproveNewAccount(request *core.NewAccountRequest) (core.NewAccountResponse, error) {
+	// This cannot be handled by policies, requires setting a password
+	return p.next.ApproveNewAccount(request)
+}
+
+// OnInputRequired not handled by policies
+func (p *policyUI) OnInputRequired(info core.UserInputRequest) (core.UserInputResponse, error) {
+	return p.next.OnInputRequired(info)
+}
+
+func (p *policyUI) ShowError(message string) {
+	log.Error(message)
+	p.next.ShowError(message)
+}
+
+func (p *policyUI) ShowInfo(message string) {
+	log.Info(message)
+	p.next.ShowInfo(message)
+}
+
+func (p *policyUI) OnSignerStartup(info core.StartupInfo) {
+	p.next.OnSignerStartup(info)
+}
+
+func (p *policyUI) OnApprovedTx(tx ethapi.SignTransactionResult) {
+	p.next.OnApprovedTx(tx)
+}
+
+// containsAddress reports whether the address is in the list.
+func containsAddress(list []common.Address, addr common.Address) bool {
+	for _, a := range list {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rules
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/signer/core"
+	"github.com/ethereum/go-ethereum/signer/storage"
+)
+
+const testPolicy = `{
+	"listing": "approve",
+	"signData": "reject",
+	"fallback": "reject",
+	"rules": [
+		{
+			"name": "token-transfers",
+			"to": ["0x000000000000000000000000000000000000dead"],
+			"selectors": ["0xa9059cbb"],
+			"maxValue": "0"
+		},
+		{
+			"name": "office-hours",
+			"from": ["0x000000000000000000000000000000000000dead"],
+			"maxValue": "1000",
+			"dailyLimit": "2500",
+			"maxGasPrice": "0x1e8480",
+			"window": {"days": ["mon", "tue", "wed", "thu", "fri"], "from": "09:00", "to": "17:00"}
+		}
+	]
+}`
+
+// monday is a point in time within the office hours of the test policy.
+var monday = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func initPolicyEngine(t *testing.T, policy string, backend storage.Storage, next core.UIClientAPI) *policyUI {
+	p, err := NewPolicyEvaluator(next, backend, true)
+	if err != nil {
+		t.Fatalf("failed to create evaluator: %v", err)
+	}
+	if err := p.Init([]byte(policy)); err != nil {
+		t.Fatalf("failed to load policy: %v", err)
+	}
+	p.now = func() time.Time { return monday }
+	return p
+}
+
+func TestPolicyValidation(t *testing.T) {
+	tests := []struct {
+		policy string
+		err    string
+	}{
+		{`{"rules": [{"name": "a", "maxValu": "1"}]}`, "unknown field"},
+		{`{"rules": [{"maxValue": "1"}]}`, "missing name"},
+		{`{"rules": [{"name": "a"}, {"name": "a"}]}`, "duplicate name"},
+		{`{"rules": [{"name": "a", "selectors": ["0xa9059c"]}]}`, "invalid selector"},
+		{`{"rules": [{"name": "a", "window": {"days": ["someday"], "from": "09:00", "to": "17:00"}}]}`, "invalid weekday"},
+		{`{"rules": [{"name": "a", "window": {"from": "9am", "to": "17:00"}}]}`, "invalid time of day"},
+		{`{"listing": "maybe"}`, "invalid policy action"},
+		{`{"fallback": "approve"}`, "fallback action"},
+	}
+	for i, tt := range tests {
+		p, _ := NewPolicyEvaluator(&dummyUI{}, storage.NewEphemeralStorage(), false)
+		if err := p.Init([]byte(tt.policy)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %q", i, err, tt.err)
+		}
+	}
+}
+
+func TestPolicyStaticActions(t *testing.T) {
+	next := &dummyUI{}
+	p := initPolicyEngine(t, testPolicy, storage.NewEphemeralStorage(), next)
+
+	list, err := p.ApproveListing(&core.ListRequest{Accounts: make([]accounts.Account, 2)})
+	if err != nil || len(list.Accounts) != 2 {
+		t.Errorf("listing not approved: %v %v", list, err)
+	}
+	sign, err := p.ApproveSignData(&core.SignDataRequest{})
+	if err != nil || sign.Approved {
+		t.Errorf("data signing not rejected: %v %v", sign, err)
+	}
+	// Static actions should not reach the next UI, apart from explanations
+	for _, call := range next.calls {
+		if call != "ShowInfo" {
+			t.Errorf("unexpected forwarded call %s", call)
+		}
+	}
+}
+
+func TestPolicyTransactions(t *testing.T) {
+	p := initPolicyEngine(t, testPolicy, storage.NewEphemeralStorage(), &dummyUI{})
+
+	transfer := dummyTxWithV(0)
+	data := hexutil.Bytes(append(hexutil.MustDecode("0xa9059cbb"), make([]byte, 64)...))
+	transfer.Transaction.Data = &data
+
+	create := dummyTxWithV(1)
+	create.Transaction.To = nil
+
+	call := dummyTxWithV(0)
+	calldata := hexutil.Bytes(hexutil.MustDecode("0xdeadbeef"))
+	call.Transaction.Data = &calldata
+
+	expensive := dummyTxWithV(1)
+	expensive.Transaction.MaxFeePerGas = (*hexutil.Big)(big.NewInt(3000000))
+
+	tests := []struct {
+		request *core.SignTxRequest
+		now     time.Time
+		action  PolicyAction
+		rule    string
+		reason  string
+	}{
+		{transfer, monday, ActionApprove, "token-transfers", ""},
+		{dummyTxWithV(1000), monday, ActionApprove, "office-hours", ""},
+		{dummyTxWithV(1001), monday, ActionReject, "", "value 1001 above maximum 1000"},
+		{create, monday, ActionReject, "", "contract creation not allowed"},
+		{expensive, monday, ActionReject, "", "gas price 3000000 above maximum 2000000"},
+		{dummyTxWithV(1), monday.Add(6 * time.Hour), ActionReject, "", "outside of time window"},
+		{dummyTxWithV(1), monday.Add(-24 * time.Hour), ActionReject, "", "outside of time window"},
+		{call, monday.Add(6 * time.Hour), ActionReject, "", `rule "token-transfers": method selector 0xdeadbeef not allowed`},
+	}
+	for i, tt := range tests {
+		p.now = func() time.Time { return tt.now }
+		decision := p.Explain(&tt.request.Transaction)
+		if decision.Action != tt.action || decision.Rule != tt.rule || !strings.Contains(decision.Reason, tt.reason) {
+			t.Errorf("test %d: decision mismatch: have %v, want %s by %q (%s)", i, decision, tt.action, tt.rule, tt.reason)
+		}
+		resp, err := p.ApproveTx(tt.request)
+		if err != nil {
+			t.Fatalf("test %d: approval failed: %v", i, err)
+		}
+		if resp.Approved != (tt.action == ActionApprove) {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, resp.Approved, tt.action == ActionApprove)
+		}
+	}
+}
+
+func TestPolicyDailyLimit(t *testing.T) {
+	db := storage.NewEphemeralStorage()
+	p := initPolicyEngine(t, testPolicy, db, &dummyUI{})
+
+	// Spend 2000 out of the 2500 daily limit, leaving room for 500 more
+	for i := 0; i < 2; i++ {
+		if resp, _ := p.ApproveTx(dummyTxWithV(1000)); !resp.Approved {
+			t.Fatalf("transaction %d not approved", i)
+		}
+	}
+	if resp, _ := p.ApproveTx(dummyTxWithV(501)); resp.Approved {
+		t.Fatalf("transaction above daily limit approved")
+	}
+	// Spend accounting must be persistent across evaluator instances
+	p = initPolicyEngine(t, testPolicy, db, &dummyUI{})
+	if decision := p.Explain(&dummyTxWithV(501).Transaction); !strings.Contains(decision.Reason, "2000 already spent") {
+		t.Fatalf("spend not persisted: %v", decision)
+	}
+	if resp, _ := p.ApproveTx(dummyTxWithV(500)); !resp.Approved {
+		t.Fatalf("transaction within daily limit not approved")
+	}
+	// The limit should reset on the next day
+	p.now = func() time.Time { return monday.Add(24 * time.Hour) }
+	if resp, _ := p.ApproveTx(dummyTxWithV(1000)); !resp.Approved {
+		t.Fatalf("transaction not approved after daily reset")
+	}
+}
+
+// Tests that a sender matched by several rules has a single daily budget.
+func TestPolicyDailyLimitAcrossRules(t *testing.T) {
+	policy := `{"rules": [
+		{"name": "small", "maxValue": "1000", "dailyLimit": "1000"},
+		{"name": "large", "maxValue": "2000", "dailyLimit": "2500"}
+	]}`
+	p := initPolicyEngine(t, policy, storage.NewEphemeralStorage(), &dummyUI{})
+
+	// Exhaust the small budget, then spend the rest under the larger one
+	for i, rule := range []string{"small", "large"} {
+		if decision := p.Explain(&dummyTxWithV(1000).Transaction); decision.Rule != rule {
+			t.Fatalf("transaction %d: approving rule mismatch: have %q, want %q", i, decision.Rule, rule)
+		}
+		if resp, _ := p.ApproveTx(dummyTxWithV(1000)); !resp.Approved {
+			t.Fatalf("transaction %d not approved", i)
+		}
+	}
+	// Spends approved by the small rule count against the large one too
+	if resp, _ := p.ApproveTx(dummyTxWithV(600)); resp.Approved {
+		t.Fatalf("transaction above the sender's daily budget approved")
+	}
+	if resp, _ := p.ApproveTx(dummyTxWithV(500)); !resp.Approved {
+		t.Fatalf("transaction within the sender's daily budget not approved")
+	}
+}
+
+func TestPolicyFallback(t *testing.T) {
+	next := &dummyUI{}
+	p := initPolicyEngine(t, `{"rules": [{"name": "small", "maxValue": "10"}]}`, storage.NewEphemeralStorage(), next)
+
+	if resp, _ := p.ApproveTx(dummyTxWithV(10)); !resp.Approved {
+		t.Fatalf("transaction not approved")
+	}
+	// Undecided requests should be forwarded to manual processing
+	if _, err := p.ApproveTx(dummyTxWithV(11)); err != core.ErrRequestDenied {
+		t.Fatalf("transaction not forwarded: %v", err)
+	}
+	p.ApproveListing(&core.ListRequest{})
+	p.ApproveSignData(&core.SignDataRequest{})
+
+	var forwarded []string
+	for _, call := range next.calls {
+		if call != "ShowInfo" {
+			forwarded = append(forwarded, call)
+		}
+	}
+	if want := "ApproveTx,ApproveListing,ApproveSignData"; strings.Join(forwarded, ",") != want {
+		t.Errorf("forwarded calls mismatch: have %v, want %v", forwarded, want)
+	}
+}
+
+func TestTimeWindowWrap(t *testing.T) {
+	w := &TimeWindow{Days: []string{"fri"}, From: "22:00", To: "02:00"}
+	if err := w.parse(); err != nil {
+		t.Fatal(err)
+	}
+	friday := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		offset time.Duration
+		inside bool
+	}{
+		{21 * time.Hour, false},
+		{22 * time.Hour, true},
+		{25 * time.Hour, true}, // Saturday night belongs to Friday's window
+		{26 * time.Hour, false},
+		{time.Hour, false}, // Friday early morning belongs to Thursday's window
+	}
+	for _, tt := range tests {
+		if have := w.contains(friday.Add(tt.offset)); have != tt.inside {
+			t.Errorf("%v: containment mismatch: have %v, want %v", friday.Add(tt.offset), have, tt.inside)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// forkOrder lists the forks of Forks which succeed each other on mainnet, in
+// activation order. Fork ranges in filler networks are resolved against it.
+var forkOrder = []string{
+	"Frontier", "Homestead", "EIP150", "EIP158", "Byzantium", "Constantinople",
+	"ConstantinopleFix", "Istanbul", "Berlin", "London",
+}
+
+// StateFiller is a state test template, consisting of a pre-state, a transaction
+// with variations and predicates on the post-state. Filling it executes all the
+// variations across the fork matrix and produces a fully populated StateTest.
+type StateFiller struct {
+	json stFillerJSON
+}
+
+func (f *StateFiller) UnmarshalJSON(in []byte) error {
+	return json.Unmarshal(in, &f.json)
+}
+
+type stFillerJSON struct {
+	Env    stEnv                      `json:"env"`
+	Pre    map[string]stFillerAccount `json:"pre"`
+	Tx     stTransaction              `json:"transaction"`
+	Expect []stExpectSection          `json:"expect"`
+}
+
+// stFillerAccount is a pre-state account, with code given as hex or assembly.
+type stFillerAccount struct {
+	Balance *math.HexOrDecimal256 `json:"balance"`
+	Nonce   math.HexOrDecimal64   `json:"nonce"`
+	Code    string                `json:"code"`
+	Storage map[string]string     `json:"storage"`
+}
+
+// stExpectSection is a set of post-state predicates, applying to the variations
+// matching its indexes on the forks matching its network.
+type stExpectSection struct {
+	Indexes struct {
+		Data  stIndexSet `json:"data"`
+		Gas   stIndexSet `json:"gas"`
+		Value stIndexSet `json:"value"`
+	} `json:"indexes"`
+	Network []string                   `json:"network"`
+	Result  map[string]stExpectAccount `json:"result"`
+}
+
+// stExpectAccount are the predicates on a post-state account, unset fields are
+// not checked.
+type stExpectAccount struct {
+	Balance        *math.HexOrDecimal256 `json:"balance"`
+	Nonce          *math.HexOrDecimal64  `json:"nonce"`
+	Code           *string               `json:"code"`
+	Storage        map[string]string     `json:"storage"`
+	ShouldNotExist bool                  `json:"shouldnotexist"`
+}
+
+// stIndexSet is a set of transaction variation indexes, given as a single index
+// or a list of them. An index of -1 (or an absent set) matches all variations.
+type stIndexSet []int
+
+func (s *stIndexSet) UnmarshalJSON(in []byte) error {
+	var list []json.RawMessage
+	if err := json.Unmarshal(in, &list); err != nil {
+		list = []json.RawMessage{in}
+	}
+	*s = (*s)[:0]
+	for _, item := range list {
+		var str string
+		if err := json.Unmarshal(item, &str); err != nil {
+			str = string(item)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return fmt.Errorf("invalid index %s", item)
+		}
+		*s = append(*s, index)
+	}
+	return nil
+}
+
+// matches reports whether the index is part of the set.
+func (s stIndexSet) matches(index int) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, i := range s {
+		if i == -1 || i == index {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNetwork reports whether a fork is selected by a network specification,
+// which is either a fork name or a range like ">=Byzantium" or "<London".
+func matchesNetwork(spec, fork string) (bool, error) {
+	var op string
+	for _, prefix := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(spec, prefix) {
+			op, spec = prefix, strings.TrimPrefix(spec, prefix)
+			break
+		}
+	}
+	if _, ok := Forks[spec]; !ok {
+		return false, UnsupportedForkError{spec}
+	}
+	if op == "" {
+		return spec == fork, nil
+	}
+	pivot, current := forkIndex(spec), forkIndex(fork)
+	if pivot < 0 {
+		return false, fmt.Errorf("fork %s cannot be used in ranges", spec)
+	}
+	if current < 0 {
+		return false, nil
+	}
+	switch op {
+	case ">=":
+		return current >= pivot, nil
+	case "<=":
+		return current <= pivot, nil
+	case ">":
+		return current > pivot, nil
+	default:
+		return current < pivot, nil
+	}
+}
+
+// forkIndex returns the position of the fork in the mainnet order, or -1.
+func forkIndex(fork string) int {
+	for i, name := range forkOrder {
+		if name == fork {
+			return i
+		}
+	}
+	return -1
+}
+
+// fillerForks returns all the forks to fill tests for; the mainnet forks in
+// activation order, followed by the remaining ones alphabetically.
+func fillerForks() []string {
+	forks := append([]string{}, forkOrder...)
+
+	var rest []string
+	for fork := range Forks {
+		if forkIndex(fork) < 0 {
+			rest = append(rest, fork)
+		}
+	}
+	sort.Strings(rest)
+	return append(forks, rest...)
+}
+
+// compileFillerCode converts filler code into bytecode. Code may be given as plain
+// hex, as hex prefixed with ":raw" or as EVM assembly prefixed with ":asm".
+func compileFillerCode(code string) ([]byte, error) {
+	code = strings.TrimSpace(code)
+	switch {
+	case code == "":
+		return nil, nil
+
+	case strings.HasPrefix(code, ":raw"):
+		code = strings.TrimSpace(strings.TrimPrefix(code, ":raw"))
+
+	case strings.HasPrefix(code, ":asm"):
+		compiler := asm.NewCompiler(false)
+		compiler.Feed(asm.Lex([]byte(strings.TrimPrefix(code, ":asm")+"\n"), false))
+		bin, errs := compiler.Compile()
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("invalid assembly: %v", errs[0])
+		}
+		code = bin
+
+	case strings.HasPrefix(code, ":"):
+		return nil, fmt.Errorf("unsupported code format %q", strings.Fields(code)[0])
+	}
+	bin, err := hex.DecodeString(strings.TrimPrefix(code, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid code hex: %v", err)
+	}
+	return bin, nil
+}
+
+// parseFillerAddress parses an address given as hex, with or without 0x prefix.
+func parseFillerAddress(s string) (common.Address, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	bin, err := hex.DecodeString(s)
+	if err != nil || len(bin) != common.AddressLength {
+		return common.Address{}, fmt.Errorf("invalid address %q", s)
+	}
+	return common.BytesToAddress(bin), nil
+}
+
+// parseFillerSlot parses a storage key or value given as decimal or hex number.
+func parseFillerSlot(s string) (common.Hash, error) {
+	v, ok := math.ParseBig256(strings.TrimSpace(s))
+	if !ok {
+		return common.Hash{}, fmt.Errorf("invalid storage slot %q", s)
+	}
+	return common.BigToHash(v), nil
+}
+
+// prestate assembles the genesis allocation of the filler.
+func (f *StateFiller) prestate() (core.GenesisAlloc, error) {
+	alloc := make(core.GenesisAlloc)
+	for hexaddr, account := range f.json.Pre {
+		addr, err := parseFillerAddress(hexaddr)
+		if err != nil {
+			return nil, fmt.Errorf("pre-state: %v", err)
+		}
+		code, err := compileFillerCode(account.Code)
+		if err != nil {
+			return nil, fmt.Errorf("pre-state account %x: %v", addr, err)
+		}
+		balance := new(big.Int)
+		if account.Balance != nil {
+			balance = (*big.Int)(account.Balance)
+		}
+		storage := make(map[common.Hash]common.Hash)
+		for k, v := range account.Storage {
+			key, err := parseFillerSlot(k)
+			if err != nil {
+				return nil, fmt.Errorf("pre-state account %x: %v", addr, err)
+			}
+			val, err := parseFillerSlot(v)
+			if err != nil {
+				return nil, fmt.Errorf("pre-state account %x: %v", addr, err)
+			}
+			storage[key] = val
+		}
+		alloc[addr] = core.GenesisAccount{
+			Code:    code,
+			Storage: storage,
+			Balance: balance,
+			Nonce:   uint64(account.Nonce),
+		}
+	}
+	return alloc, nil
+}
+
+// Fill executes every transaction variation on every fork selected by at least
+// one expect section, verifies the post-state predicates and returns the state
+// test populated with the resulting post-state roots and log hashes.
+func (f *StateFiller) Fill(vmconfig vm.Config) (*StateTest, error) {
+	pre, err := f.prestate()
+	if err != nil {
+		return nil, err
+	}
+	tx := f.json.Tx
+	if tx.To != "" {
+		to, err := parseFillerAddress(tx.To)
+		if err != nil {
+			return nil, fmt.Errorf("transaction recipient: %v", err)
+		}
+		tx.To = "0x" + hex.EncodeToString(to[:])
+	}
+	tx.Data = make([]string, len(f.json.Tx.Data))
+	for i, data := range f.json.Tx.Data {
+		bin, err := compileFillerCode(data)
+		if err != nil {
+			return nil, fmt.Errorf("transaction data %d: %v", i, err)
+		}
+		tx.Data[i] = "0x" + hex.EncodeToString(bin)
+	}
+	test := &StateTest{json: stJSON{
+		Env:  f.json.Env,
+		Pre:  pre,
+		Tx:   tx,
+		Post: make(map[string][]stPostState),
+	}}
+	var failures []string
+	for _, fork := range fillerForks() {
+		for d := range tx.Data {
+			for g := range tx.GasLimit {
+				for v := range tx.Value {
+					sections, err := f.sections(fork, d, g, v)
+					if err != nil {
+						return nil, err
+					}
+					if len(sections) == 0 {
+						continue
+					}
+					var post stPostState
+					post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value = d, g, v
+
+					// Run the variation in isolation to obtain its post-state
+					variation := &StateTest{json: test.json}
+					variation.json.Post = map[string][]stPostState{fork: {post}}
+					_, statedb, root, err := variation.RunNoVerify(StateSubtest{Fork: fork}, vmconfig, false)
+					if err != nil {
+						return nil, fmt.Errorf("%s/d%dg%dv%d: %v", fork, d, g, v, err)
+					}
+					post.Root = common.UnprefixedHash(root)
+					post.Logs = common.UnprefixedHash(rlpHash(statedb.Logs()))
+					test.json.Post[fork] = append(test.json.Post[fork], post)
+
+					for _, section := range sections {
+						for _, err := range section.verify(statedb) {
+							failures = append(failures, fmt.Sprintf("%s/d%dg%dv%d: %v", fork, d, g, v, err))
+						}
+					}
+				}
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("expectations failed:\n%s", strings.Join(failures, "\n"))
+	}
+	if len(test.json.Post) == 0 {
+		return nil, errors.New("no expect section matches any fork")
+	}
+	return test, nil
+}
+
+// sections returns the expect sections applying to a variation on a fork.
+func (f *StateFiller) sections(fork string, d, g, v int) ([]*stExpectSection, error) {
+	var matches []*stExpectSection
+	for i := range f.json.Expect {
+		section := &f.json.Expect[i]
+		if !section.Indexes.Data.matches(d) || !section.Indexes.Gas.matches(g) || !section.Indexes.Value.matches(v) {
+			continue
+		}
+		for _, spec := range section.Network {
+			ok, err := matchesNetwork(spec, fork)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				matches = append(matches, section)
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// verify checks the post-state predicates of the section, returning all the
+// violated ones.
+func (s *stExpectSection) verify(statedb *state.StateDB) []error {
+	var errs []error
+	for hexaddr, want := range s.Result {
+		addr, err := parseFillerAddress(hexaddr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if want.ShouldNotExist {
+			if statedb.Exist(addr) {
+				errs = append(errs, fmt.Errorf("account %x: exists, expected not to", addr))
+			}
+			continue
+		}
+		if !statedb.Exist(addr) {
+			errs = append(errs, fmt.Errorf("account %x: missing", addr))
+			continue
+		}
+		if want.Balance != nil {
+			if have := statedb.GetBalance(addr); have.Cmp((*big.Int)(want.Balance)) != 0 {
+				errs = append(errs, fmt.Errorf("account %x: balance %v, want %v", addr, have, (*big.Int)(want.Balance)))
+			}
+		}
+		if want.Nonce != nil {
+			if have := statedb.GetNonce(addr); have != uint64(*want.Nonce) {
+				errs = append(errs, fmt.Errorf("account %x: nonce %d, want %d", addr, have, uint64(*want.Nonce)))
+			}
+		}
+		if want.Code != nil {
+			code, err := compileFillerCode(*want.Code)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %x: %v", addr, err))
+			} else if have := statedb.GetCode(addr); !bytes.Equal(have, code) {
+				errs = append(errs, fmt.Errorf("account %x: code %x, want %x", addr, have, code))
+			}
+		}
+		for k, v := range want.Storage {
+			key, err := parseFillerSlot(k)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %x: %v", addr, err))
+				continue
+			}
+			val, err := parseFillerSlot(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("account %x: %v", addr, err))
+				continue
+			}
+			if have := statedb.GetState(addr, key); have != val {
+				errs = append(errs, fmt.Errorf("account %x: storage %x = %x, want %x", addr, key, have, val))
+			}
+		}
+	}
+	return errs
+}
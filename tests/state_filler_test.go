@@ -0,0 +1,153 @@
+// Copyright 2021 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const testFiller = `{
+	"env": {
+		"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+		"currentDifficulty": "0x20000",
+		"currentGasLimit": "10000000",
+		"currentNumber": "1",
+		"currentTimestamp": "1000"
+	},
+	"pre": {
+		"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+			"balance": "1000000000000000000",
+			"nonce": "0"
+		},
+		"0x095e7baea6a6c7c4c2dfeb977efac326af552d87": {
+			"balance": "0",
+			"code": ":asm CALLVALUE\nPUSH 0\nSSTORE",
+			"storage": {"0x01": "0x2a"}
+		}
+	},
+	"transaction": {
+		"data": ["", ":raw 0x00"],
+		"gasLimit": ["400000"],
+		"gasPrice": "1000000000",
+		"nonce": "0",
+		"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+		"to": "0x095e7baea6a6c7c4c2dfeb977efac326af552d87",
+		"value": ["0", "1"]
+	},
+	"expect": [
+		{
+			"indexes": {"data": -1, "gas": -1, "value": 0},
+			"network": [">=Istanbul"],
+			"result": {
+				"0x095e7baea6a6c7c4c2dfeb977efac326af552d87": {
+					"code": "0x34600055",
+					"storage": {"0x00": "0", "0x01": "42"}
+				},
+				"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"nonce": "1"}
+			}
+		},
+		{
+			"indexes": {"data": [0, 1], "gas": "0", "value": "1"},
+			"network": ["Berlin"],
+			"result": {
+				"0x095e7baea6a6c7c4c2dfeb977efac326af552d87": {"balance": "1", "storage": {"0x00": "1"}},
+				"0x0000000000000000000000000000000000000001": {"shouldnotexist": true}
+			}
+		}
+	]
+}`
+
+func TestStateFiller(t *testing.T) {
+	var filler StateFiller
+	if err := json.Unmarshal([]byte(testFiller), &filler); err != nil {
+		t.Fatalf("failed to parse filler: %v", err)
+	}
+	filled, err := filler.Fill(vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to fill test: %v", err)
+	}
+	// Check that the expected fork and variation matrix was filled
+	want := map[string]int{"Istanbul": 2, "Berlin": 4, "London": 2}
+	for fork, posts := range filled.json.Post {
+		if len(posts) != want[fork] {
+			t.Errorf("fork %s: post state count mismatch: have %d, want %d", fork, len(posts), want[fork])
+		}
+	}
+	if len(filled.json.Post) != len(want) {
+		t.Errorf("filled fork count mismatch: have %d, want %d", len(filled.json.Post), len(want))
+	}
+	// The filled test must round-trip through JSON and pass
+	blob, err := json.Marshal(filled)
+	if err != nil {
+		t.Fatalf("failed to encode filled test: %v", err)
+	}
+	var test StateTest
+	if err := json.Unmarshal(blob, &test); err != nil {
+		t.Fatalf("failed to decode filled test: %v", err)
+	}
+	for _, subtest := range test.Subtests() {
+		if _, _, err := test.Run(subtest, vm.Config{}, false); err != nil {
+			t.Errorf("%s/%d: filled test failed: %v", subtest.Fork, subtest.Index, err)
+		}
+	}
+}
+
+func TestStateFillerFailure(t *testing.T) {
+	var filler StateFiller
+	if err := json.Unmarshal([]byte(strings.Replace(testFiller, `{"0x00": "1"}`, `{"0x00": "2"}`, 1)), &filler); err != nil {
+		t.Fatalf("failed to parse filler: %v", err)
+	}
+	_, err := filler.Fill(vm.Config{})
+	if err == nil {
+		t.Fatalf("failing expectation not detected")
+	}
+	if !strings.Contains(err.Error(), "Berlin/d1g0v1") || !strings.Contains(err.Error(), "want 0000000000000000000000000000000000000000000000000000000000000002") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMatchesNetwork(t *testing.T) {
+	tests := []struct {
+		spec, fork string
+		match      bool
+	}{
+		{"Berlin", "Berlin", true},
+		{"Berlin", "London", false},
+		{">=Berlin", "London", true},
+		{">=Berlin", "Istanbul", false},
+		{">Berlin", "Berlin", false},
+		{"<Byzantium", "EIP158", true},
+		{"<=Byzantium", "Byzantium", true},
+		{">=Frontier", "FrontierToHomesteadAt5", false},
+	}
+	for _, tt := range tests {
+		match, err := matchesNetwork(tt.spec, tt.fork)
+		if err != nil {
+			t.Fatalf("%s on %s: %v", tt.spec, tt.fork, err)
+		}
+		if match != tt.match {
+			t.Errorf("%s on %s: match mismatch: have %v, want %v", tt.spec, tt.fork, match, tt.match)
+		}
+	}
+	if _, err := matchesNetwork(">=Dummy", "Berlin"); err == nil {
+		t.Errorf("unknown fork accepted")
+	}
+}